@@ -0,0 +1,135 @@
+package backup
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// uploadTimeout bounds an upload, so an endpoint that stops answering can't
+// hold up the backups scheduled after it.
+const uploadTimeout = 5 * time.Minute
+
+var client = &http.Client{Timeout: uploadTimeout}
+
+// S3Config locates a bucket on S3 or an S3-compatible store such as MinIO.
+// Objects are addressed path-style, Endpoint/Bucket/key.
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3ConfigFromEnv reads POKER_S3_ENDPOINT, POKER_S3_REGION, POKER_S3_BUCKET,
+// POKER_S3_ACCESS_KEY and POKER_S3_SECRET_KEY. It returns nil when no bucket
+// is configured.
+func S3ConfigFromEnv() *S3Config {
+	config := &S3Config{
+		Endpoint:  os.Getenv("POKER_S3_ENDPOINT"),
+		Region:    os.Getenv("POKER_S3_REGION"),
+		Bucket:    os.Getenv("POKER_S3_BUCKET"),
+		AccessKey: os.Getenv("POKER_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("POKER_S3_SECRET_KEY"),
+	}
+	if config.Bucket == "" {
+		return nil
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3.amazonaws.com"
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return config
+}
+
+// Upload PUTs the file at path to the bucket under key, signed with AWS
+// Signature Version 4.
+func (c *S3Config) Upload(key, path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(c.Endpoint, "/"))
+	if err != nil {
+		return fmt.Errorf("invalid S3 endpoint: %v", err)
+	}
+	endpoint.Path += "/" + c.Bucket + "/" + strings.TrimPrefix(key, "/")
+
+	request, err := http.NewRequest(http.MethodPut, endpoint.String(), strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	c.sign(request, body, time.Now().UTC())
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return fmt.Errorf("upload to %s failed: %s: %s", endpoint, response.Status, message)
+	}
+	return nil
+}
+
+func (c *S3Config) sign(request *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	request.Header.Set("Host", request.URL.Host)
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + c.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), day)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
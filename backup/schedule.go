@@ -0,0 +1,84 @@
+// Package backup snapshots the bot's database on a schedule and can copy the
+// snapshots to S3-compatible storage.
+package backup
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"poker-bot/db"
+)
+
+// Snapshots hold every account's bankroll and token, so only the bot's
+// user can read them.
+const (
+	dirMode  = 0o700
+	fileMode = 0o600
+)
+
+// Snapshot backs up the bot's database into dir under a timestamped name,
+// uploads it when s3 is set, and returns its path.
+func Snapshot(dir string, s3 *S3Config) (string, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(dir, dirMode); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("poker-%s.db", time.Now().UTC().Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	if err := db.Backup(path); err != nil {
+		return "", fmt.Errorf("backup failed: %v", err)
+	}
+	if err := os.Chmod(path, fileMode); err != nil {
+		return path, err
+	}
+	if s3 != nil {
+		if err := s3.Upload(name, path); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// Prune deletes all but the newest keep snapshots in dir. Copies uploaded
+// to S3 are left to the bucket's lifecycle rules.
+func Prune(dir string, keep int) error {
+	snapshots, err := filepath.Glob(filepath.Join(dir, "poker-*.db"))
+	if err != nil {
+		return err
+	}
+	// The timestamped names sort oldest first.
+	sort.Strings(snapshots)
+	for len(snapshots) > keep {
+		if err := os.Remove(snapshots[0]); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+// Schedule takes a Snapshot every interval until the process exits, keeping
+// the newest keep of them, or all of them when keep is 0.
+func Schedule(interval time.Duration, dir string, keep int, s3 *S3Config) {
+	go func() {
+		for range time.Tick(interval) {
+			path, err := Snapshot(dir, s3)
+			if err != nil {
+				log.Printf("Scheduled backup: %v", err)
+				continue
+			}
+			log.Printf("Scheduled backup written to %s", path)
+			if keep > 0 {
+				if err := Prune(dir, keep); err != nil {
+					log.Printf("Pruning old backups: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,40 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"poker-20260101-000000.db",
+		"poker-20260102-000000.db",
+		"poker-20251231-230000.db",
+		"poker-20260103-000000.db",
+		"notes.txt",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Prune(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, entry := range entries {
+		left = append(left, entry.Name())
+	}
+	want := []string{"notes.txt", "poker-20260102-000000.db", "poker-20260103-000000.db"}
+	if !reflect.DeepEqual(left, want) {
+		t.Errorf("left %v, want %v", left, want)
+	}
+}
@@ -0,0 +1,296 @@
+// Command pokerctl is the operator's tool for the poker bot.
+//
+//	pokerctl backup [-db poker.db] [-upload] <snapshot>
+//	pokerctl restore [-db poker.db] <snapshot>
+//	pokerctl suspicious [-db poker.db] [-days 30]
+//	pokerctl export [-db poker.db] [-format json|csv] <nick>
+//	pokerctl players [-db poker.db] [-offset 0] [-limit 50]
+//	pokerctl audit [-db poker.db] [-days 30] [-actor nick] [-action name] [-limit 100]
+//	pokerctl [-socket poker.sock] tables
+//	pokerctl [-socket poker.sock] end <channel>
+//	pokerctl [-socket poker.sock] chips <nick> <amount> [channel]
+//	pokerctl [-socket poker.sock] broadcast <message>
+//	pokerctl [-socket poker.sock] reload
+//	pokerctl [-socket poker.sock] ratelimit
+//
+// backup uses SQLite's online backup API, so it is safe to run while the bot
+// is up. restore overwrites the database and must only be run with the bot
+// stopped. -upload also copies the snapshot to the bucket configured by the
+// POKER_S3_* environment variables.
+//
+// suspicious runs the collusion report over the hands of the last days, the
+// same report bot admins get from $admin suspicious.
+//
+// export writes a player's hands, sessions and transactions to stdout, the
+// same file players download with $export stats.
+//
+// players lists bankrolls by economy and nick, a page at a time.
+//
+// audit shows the audit log of operator actions, newest first: bans, chip
+// adjustments, voided hands, cancelled games and the like, with who did
+// them. Actions from this tool's control commands are logged as pokerctl.
+//
+// tables, end, chips, broadcast, reload and ratelimit talk to the running
+// bot over its control socket. end voids the hand in play and gives everyone
+// their chips back; chips adds to (or, with a negative amount, takes from) a
+// player's bankroll in the channel's economy and their stack if they're
+// seated there. reload rereads the config file, paytable and flavor packs,
+// like sending the bot SIGHUP. ratelimit shows how many commands the flood
+// limiter has let through and throttled since the bot started.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"poker-bot/backup"
+	"poker-bot/collusion"
+	"poker-bot/db"
+	"poker-bot/stats"
+)
+
+func main() {
+	socket := flag.String("socket", "poker.sock", "the bot's control socket")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+	}
+	args := flag.Args()
+
+	var err error
+	switch args[0] {
+	case "backup":
+		err = runBackup(args[1:])
+	case "restore":
+		err = runRestore(args[1:])
+	case "suspicious":
+		err = runSuspicious(args[1:])
+	case "export":
+		err = runExport(args[1:])
+	case "players":
+		err = runPlayers(args[1:])
+	case "audit":
+		err = runAudit(args[1:])
+	case "tables", "end", "chips", "broadcast", "reload", "ratelimit":
+		err = runControl(*socket, args)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pokerctl %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pokerctl backup [-db poker.db] [-upload] <snapshot>")
+	fmt.Fprintln(os.Stderr, "       pokerctl restore [-db poker.db] <snapshot>")
+	fmt.Fprintln(os.Stderr, "       pokerctl suspicious [-db poker.db] [-days 30]")
+	fmt.Fprintln(os.Stderr, "       pokerctl export [-db poker.db] [-format json|csv] <nick>")
+	fmt.Fprintln(os.Stderr, "       pokerctl players [-db poker.db] [-offset 0] [-limit 50]")
+	fmt.Fprintln(os.Stderr, "       pokerctl audit [-db poker.db] [-days 30] [-actor nick] [-action name] [-limit 100]")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] tables")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] end <channel>")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] chips <nick> <amount> [channel]")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] broadcast <message>")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] reload")
+	fmt.Fprintln(os.Stderr, "       pokerctl [-socket poker.sock] ratelimit")
+	os.Exit(2)
+}
+
+// runControl sends one command to the bot's control socket and prints the
+// reply.
+func runControl(socket string, args []string) error {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("is the bot running? %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprintln(conn, strings.Join(args, " ")); err != nil {
+		return err
+	}
+	reply, err := io.ReadAll(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	if message, failed := strings.CutPrefix(string(reply), "error: "); failed {
+		return fmt.Errorf("%s", strings.TrimSpace(message))
+	}
+	fmt.Print(string(reply))
+	return nil
+}
+
+func runBackup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to back up")
+	upload := flags.Bool("upload", false, "upload the snapshot to the POKER_S3_* bucket")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	snapshot := flags.Arg(0)
+
+	if err := db.BackupFile(*dbPath, snapshot); err != nil {
+		return err
+	}
+	if *upload {
+		s3 := backup.S3ConfigFromEnv()
+		if s3 == nil {
+			return fmt.Errorf("-upload needs POKER_S3_BUCKET")
+		}
+		if err := s3.Upload(filepath.Base(snapshot), snapshot); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Backed up %s to %s\n", *dbPath, snapshot)
+	return nil
+}
+
+func runRestore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to restore into")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	snapshot := flags.Arg(0)
+
+	if _, err := os.Stat(snapshot); err != nil {
+		return err
+	}
+	if err := db.RestoreFile(snapshot, *dbPath); err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s from %s\n", *dbPath, snapshot)
+	return nil
+}
+
+func runSuspicious(args []string) error {
+	flags := flag.NewFlagSet("suspicious", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to analyze")
+	days := flags.Int("days", 30, "how many days of hands to look at")
+	flags.Parse(args)
+
+	if err := db.Initialize(*dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+	report, err := collusion.Report(time.Now().AddDate(0, 0, -*days))
+	if err != nil {
+		return err
+	}
+	if len(report) == 0 {
+		fmt.Printf("Nothing suspicious in the last %d days.\n", *days)
+	}
+	for _, flag := range report {
+		fmt.Println(flag)
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to export from")
+	format := flags.String("format", "json", "json or csv")
+	flags.Parse(args)
+	if flags.NArg() != 1 || (*format != "json" && *format != "csv") {
+		usage()
+	}
+
+	if err := db.Initialize(*dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+	export, err := stats.Build(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if *format == "csv" {
+		data, err = export.CSV()
+	} else {
+		data, err = export.JSON()
+	}
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func runPlayers(args []string) error {
+	flags := flag.NewFlagSet("players", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to list players from")
+	offset := flags.Int("offset", 0, "how many players to skip")
+	limit := flags.Int("limit", 50, "how many players to list")
+	flags.Parse(args)
+	if flags.NArg() != 0 || *offset < 0 || *limit < 1 {
+		usage()
+	}
+
+	if err := db.Initialize(*dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+	players, err := db.ListPlayers(context.Background(), *offset, *limit)
+	if err != nil {
+		return err
+	}
+	for _, player := range players {
+		economy := player.Economy
+		if economy == db.SharedEconomy {
+			economy = "(shared)"
+		}
+		fmt.Printf("%-12s %-20s %8d chips %6d hands won\n", economy, player.Nick, player.Money, player.HandsWon)
+	}
+	return nil
+}
+
+func runAudit(args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	dbPath := flags.String("db", "poker.db", "database to read the audit log from")
+	days := flags.Int("days", 30, "how many days of the log to show")
+	actor := flags.String("actor", "", "only actions by this nick or nick!user@host")
+	action := flags.String("action", "", "only this action, such as ban or chips")
+	limit := flags.Int("limit", 100, "how many entries to show at most")
+	flags.Parse(args)
+	if flags.NArg() != 0 || *limit < 1 {
+		usage()
+	}
+
+	if err := db.Initialize(*dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+	entries, err := db.AuditLog(time.Now().AddDate(0, 0, -*days), *actor, *action, *limit)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Printf("Nothing in the audit log in the last %d days.\n", *days)
+	}
+	for _, e := range entries {
+		line := fmt.Sprintf("%s %s %s", e.At.Format("2006-01-02 15:04:05"), e.Actor, e.Action)
+		if e.Target != "" {
+			line += " " + e.Target
+		}
+		if e.Channel != "" {
+			line += " at " + e.Channel
+		}
+		if e.Detail != "" {
+			line += ": " + e.Detail
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package collusion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// dumpChips is how many chips one player must lose to another before
+	// the flow between them is looked at.
+	dumpChips = 2000
+	// dumpRatio is how lopsided the flow must be: the loser must have lost
+	// this many times what they won back.
+	dumpRatio = 4
+	// softPlayFaced is how often a player must have faced another's bets
+	// before their folds to that player are judged.
+	softPlayFaced = 8
+	// softPlayFolds is the share of those bets folded to that is
+	// suspicious, when the player folds to everyone else at most
+	// softPlayElse of the time.
+	softPlayFolds = 0.9
+	softPlayElse  = 0.5
+)
+
+// Flag is one suspicious pattern.
+type Flag struct {
+	Kind   string // "chip dumping", "soft play" or "shared host"
+	Nicks  []string
+	Detail string
+}
+
+func (f Flag) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Kind, strings.Join(f.Nicks, ", "), f.Detail)
+}
+
+type pair struct{ from, to string }
+
+// Analyze looks for collusion in hands and in the hosts each nick has
+// played from, given as host -> nicks.
+func Analyze(hands []Hand, hosts map[string][]string) []Flag {
+	var flags []Flag
+	flags = append(flags, chipDumping(hands)...)
+	flags = append(flags, softPlay(hands)...)
+	flags = append(flags, sharedHosts(hosts)...)
+	return flags
+}
+
+// chipDumping flags a player who loses far more to one other player than
+// they win back, mostly by betting and then folding.
+func chipDumping(hands []Hand) []Flag {
+	lost := make(map[pair]int)
+	played := make(map[pair]int)
+	thrown := make(map[pair]int) // hands the loser bet or raised, then folded
+	for _, hand := range hands {
+		if hand.Winner == "" {
+			continue
+		}
+		for nick, invested := range hand.Invested {
+			if nick == hand.Winner || invested == 0 {
+				continue
+			}
+			p := pair{nick, hand.Winner}
+			lost[p] += invested
+			played[p]++
+			if _, folded := hand.FoldedTo[nick]; folded && hand.Aggressed[nick] {
+				thrown[p]++
+			}
+		}
+	}
+
+	var flags []Flag
+	for p, chips := range lost {
+		back := lost[pair{p.to, p.from}]
+		if chips < dumpChips || chips < dumpRatio*back || thrown[p]*2 < played[p] {
+			continue
+		}
+		flags = append(flags, Flag{
+			Kind:   "chip dumping",
+			Nicks:  []string{p.from, p.to},
+			Detail: fmt.Sprintf("%s lost %d to %s and won %d back; bet and folded in %d of %d hands", p.from, chips, p.to, back, thrown[p], played[p]),
+		})
+	}
+	sortFlags(flags)
+	return flags
+}
+
+// softPlay flags a player who almost always folds to one player's bets but
+// plays on against everyone else's.
+func softPlay(hands []Hand) []Flag {
+	faced := make(map[pair]int)
+	folded := make(map[pair]int)
+	facedAll := make(map[string]int)
+	foldedAll := make(map[string]int)
+	for _, hand := range hands {
+		for nick, aggressors := range hand.Faced {
+			for _, aggressor := range aggressors {
+				faced[pair{nick, aggressor}]++
+				facedAll[nick]++
+			}
+		}
+		for nick, aggressor := range hand.FoldedTo {
+			folded[pair{nick, aggressor}]++
+			foldedAll[nick]++
+		}
+	}
+
+	var flags []Flag
+	for p, n := range faced {
+		if n < softPlayFaced {
+			continue
+		}
+		rate := float64(folded[p]) / float64(n)
+		othersFaced := facedAll[p.from] - n
+		if rate < softPlayFolds || othersFaced == 0 {
+			continue
+		}
+		othersRate := float64(foldedAll[p.from]-folded[p]) / float64(othersFaced)
+		if othersRate > softPlayElse {
+			continue
+		}
+		flags = append(flags, Flag{
+			Kind:   "soft play",
+			Nicks:  []string{p.from, p.to},
+			Detail: fmt.Sprintf("%s folded to %s's bets %.0f%% of %d times, and to everyone else's %.0f%% of the time", p.from, p.to, rate*100, n, othersRate*100),
+		})
+	}
+	sortFlags(flags)
+	return flags
+}
+
+func sharedHosts(hosts map[string][]string) []Flag {
+	var flags []Flag
+	for host, nicks := range hosts {
+		if len(nicks) < 2 {
+			continue
+		}
+		sorted := append([]string{}, nicks...)
+		sort.Strings(sorted)
+		flags = append(flags, Flag{Kind: "shared host", Nicks: sorted, Detail: host})
+	}
+	sortFlags(flags)
+	return flags
+}
+
+func sortFlags(flags []Flag) {
+	sort.Slice(flags, func(i, j int) bool {
+		return strings.Join(flags[i].Nicks, ",") < strings.Join(flags[j].Nicks, ",")
+	})
+}
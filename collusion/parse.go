@@ -0,0 +1,149 @@
+// Package collusion looks through hand histories for signs of players
+// working together: chips dumped from one player to another, players who
+// never play back at a friend, and several nicks on one host.
+package collusion
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hand is what the analysis needs from one hand history: who put how many
+// chips in, who folded to whom and who won.
+type Hand struct {
+	Channel string
+	// Invested is what each player put in the pot, counting bets, calls
+	// and raises. Blinds and antes aren't in the history, so they aren't
+	// counted.
+	Invested map[string]int
+	// Aggressed is who bet or raised during the hand.
+	Aggressed map[string]bool
+	// FoldedTo records, for each player who folded facing a bet, who made
+	// that bet.
+	FoldedTo map[string]string
+	// Faced records, for each player, whose bets they had to act on.
+	Faced map[string][]string
+	// Positions is each player's position, such as BTN or UTG, in games
+	// with a button.
+	Positions map[string]string
+	// Voluntary is who put chips in before the flop by calling, betting
+	// or raising, rather than only posting a blind.
+	Voluntary map[string]bool
+	// Won is what each winner took of the pot, more than one when it was
+	// split. Winner is whoever took the most, and Pot is the whole pot.
+	Won    map[string]int
+	Winner string
+	Pot    int
+}
+
+var (
+	betLine   = regexp.MustCompile(`^(\S+) bets (\d+)$`)
+	raiseLine = regexp.MustCompile(`^(\S+) raises to (\d+)$`)
+	callLine  = regexp.MustCompile(`^(\S+) calls$`)
+	shortCall = regexp.MustCompile(`^(\S+) calls all in for (\d+)$`)
+	foldLine  = regexp.MustCompile(`^(\S+) folds$`)
+	winLine   = regexp.MustCompile(`^Round over! (\S+ wins \d+(, \S+ wins \d+)*)$`)
+	winEntry  = regexp.MustCompile(`^(\S+) wins (\d+)$`)
+	boardLine = regexp.MustCompile(`^Board: `)
+	seatsLine = regexp.MustCompile(`^Positions: (.+)$`)
+	seatEntry = regexp.MustCompile(`^(\S+) (\S+)$`)
+)
+
+// ParseHand reads a hand from the lines of its history.
+func ParseHand(channel string, lines []string) Hand {
+	hand := Hand{
+		Channel:   channel,
+		Invested:  make(map[string]int),
+		Aggressed: make(map[string]bool),
+		FoldedTo:  make(map[string]string),
+		Faced:     make(map[string][]string),
+		Positions: make(map[string]string),
+		Voluntary: make(map[string]bool),
+		Won:       make(map[string]int),
+	}
+	preflop := true
+
+	// The betting on the current street: each player's total, the amount
+	// to call and whose bet it is.
+	street := make(map[string]int)
+	level, aggressor := 0, ""
+	actOn := func(nick string) {
+		if aggressor != "" && aggressor != nick {
+			hand.Faced[nick] = append(hand.Faced[nick], aggressor)
+		}
+	}
+
+	for _, line := range lines {
+		switch {
+		case seatsLine.MatchString(line):
+			for _, seat := range strings.Split(seatsLine.FindStringSubmatch(line)[1], ", ") {
+				if m := seatEntry.FindStringSubmatch(seat); m != nil {
+					hand.Positions[m[2]] = m[1]
+				}
+			}
+		case boardLine.MatchString(line):
+			street = make(map[string]int)
+			level, aggressor = 0, ""
+			preflop = false
+		case betLine.MatchString(line):
+			m := betLine.FindStringSubmatch(line)
+			amount, _ := strconv.Atoi(m[2])
+			actOn(m[1])
+			if preflop {
+				hand.Voluntary[m[1]] = true
+			}
+			street[m[1]] += amount
+			hand.Invested[m[1]] += amount
+			if street[m[1]] > level {
+				level, aggressor = street[m[1]], m[1]
+				hand.Aggressed[m[1]] = true
+			}
+		case raiseLine.MatchString(line):
+			m := raiseLine.FindStringSubmatch(line)
+			to, _ := strconv.Atoi(m[2])
+			actOn(m[1])
+			if preflop {
+				hand.Voluntary[m[1]] = true
+			}
+			hand.Invested[m[1]] += to - street[m[1]]
+			street[m[1]] = to
+			level, aggressor = to, m[1]
+			hand.Aggressed[m[1]] = true
+		case callLine.MatchString(line):
+			nick := callLine.FindStringSubmatch(line)[1]
+			actOn(nick)
+			if preflop {
+				hand.Voluntary[nick] = true
+			}
+			hand.Invested[nick] += level - street[nick]
+			street[nick] = level
+		case shortCall.MatchString(line):
+			m := shortCall.FindStringSubmatch(line)
+			amount, _ := strconv.Atoi(m[2])
+			actOn(m[1])
+			if preflop {
+				hand.Voluntary[m[1]] = true
+			}
+			hand.Invested[m[1]] += amount
+			street[m[1]] += amount
+		case foldLine.MatchString(line):
+			nick := foldLine.FindStringSubmatch(line)[1]
+			actOn(nick)
+			if aggressor != "" && aggressor != nick {
+				hand.FoldedTo[nick] = aggressor
+			}
+		case winLine.MatchString(line):
+			for _, win := range strings.Split(winLine.FindStringSubmatch(line)[1], ", ") {
+				m := winEntry.FindStringSubmatch(win)
+				amount, _ := strconv.Atoi(m[2])
+				hand.Won[m[1]] += amount
+				hand.Pot += amount
+				if hand.Winner == "" || hand.Won[m[1]] > hand.Won[hand.Winner] {
+					hand.Winner = m[1]
+				}
+			}
+		}
+	}
+	return hand
+}
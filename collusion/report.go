@@ -0,0 +1,25 @@
+package collusion
+
+import (
+	"time"
+
+	"poker-bot/db"
+)
+
+// Report analyzes the hands played since the given time and every host
+// recorded in the database.
+func Report(since time.Time) ([]Flag, error) {
+	histories, err := db.HandHistories(since)
+	if err != nil {
+		return nil, err
+	}
+	hands := make([]Hand, len(histories))
+	for i, history := range histories {
+		hands[i] = ParseHand(history.Channel, history.Lines)
+	}
+	hosts, err := db.SharedHosts()
+	if err != nil {
+		return nil, err
+	}
+	return Analyze(hands, hosts), nil
+}
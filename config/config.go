@@ -0,0 +1,280 @@
+// Package config holds the settings an operator can change without
+// restarting the bot. They are read from a JSON file at startup and again on
+// SIGHUP or `pokerctl reload`; new values apply from the next hand.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Duration is a time.Duration written in JSON as a string such as "15s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("durations are strings such as \"15s\"")
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+type Config struct {
+	// TurnTimeout is how long a player has to act before they are folded,
+	// and SlowRollTimeout the shorter clock given to repeat slow-rollers.
+	TurnTimeout     Duration `json:"turn_timeout"`
+	SlowRollTimeout Duration `json:"slow_roll_timeout"`
+
+	// RunoutDelay is the pause between streets when the board is run out
+	// with everyone all in. Zero deals them all at once.
+	RunoutDelay Duration `json:"runout_delay"`
+
+	// The stakes of cash games. Tournaments use their blind levels.
+	SmallBlind int `json:"small_blind"`
+	BigBlind   int `json:"big_blind"`
+	Ante       int `json:"ante"`
+
+	// EquityWorkers is how many goroutines share the boards dealt to work
+	// out each player's chance of winning when everyone is all in. Zero
+	// uses one per CPU.
+	EquityWorkers int `json:"equity_workers"`
+
+	// LobbyTimeout is how long a new table waits for enough players to
+	// deal the first hand before it is closed. Zero keeps it open until
+	// the host cancels it.
+	LobbyTimeout Duration `json:"lobby_timeout"`
+
+	// RebuyWait is how long a game left short of players with chips waits
+	// for a busted player to $rebuy before it ends, while rebuys are open.
+	// Zero ends it straight away.
+	RebuyWait Duration `json:"rebuy_wait"`
+
+	// ForceEndRefund is who gets the pot when a game ends with a hand
+	// still in play: "contributions" gives every player back what they
+	// put in, and "live" shares it between the players who hadn't folded,
+	// in proportion to what they put in. Empty means "contributions".
+	ForceEndRefund string `json:"force_end_refund"`
+
+	// SeasonLength is how long a season runs. When one ends, its standings
+	// are kept, the top finishers, one for each of SeasonBonuses, get a
+	// trophy and that bonus, and the season leaderboard starts over. Zero
+	// turns seasons off.
+	SeasonLength  Duration `json:"season_length"`
+	SeasonBonuses []int    `json:"season_bonuses"`
+
+	// ConfirmLargeBets asks a player to $confirm a bet or raise of more
+	// than half their stack that doesn't put them all in, in case of a
+	// typo.
+	ConfirmLargeBets bool `json:"confirm_large_bets"`
+
+	// CheatsPerHand is how many times a player may $cheat in one hand, and
+	// CheatCooldown how many hands they must then play before they may
+	// cheat again. Zero CheatsPerHand turns $cheat off.
+	CheatsPerHand int `json:"cheats_per_hand"`
+	CheatCooldown int `json:"cheat_cooldown"`
+
+	// CommandInterval is how often one nick may use a command in a channel.
+	CommandInterval Duration `json:"command_interval"`
+
+	// Admins are the hostmasks, such as "*!*@staff.example.net", allowed
+	// to use $admin. * and ? are wildcards that match any character,
+	// including the / of cloaked hosts.
+	Admins []string `json:"admins"`
+
+	// AdminChannel is where the bot warns admins about suspicious seating,
+	// such as two nicks from one host at a table. Empty to only log it.
+	AdminChannel string `json:"admin_channel"`
+	// BlockSharedHosts refuses a seat to a nick whose host already has a
+	// nick at the table.
+	BlockSharedHosts bool `json:"block_shared_hosts"`
+
+	// Tone is how the bot speaks in its penalty messages and table talk:
+	// "family-friendly" keeps it clean, and "classic" is its old, saltier
+	// voice. ChannelTones picks a different tone for some channels. Empty
+	// means "family-friendly".
+	Tone         string            `json:"tone"`
+	ChannelTones map[string]string `json:"channel_tones"`
+
+	// AltNicks are the nicks the bot tries, in order, when its own is in
+	// use as it connects. NickServPassword identifies its own nick to
+	// services, and lets it ghost whoever holds the nick. Empty to not
+	// identify.
+	AltNicks         []string `json:"alt_nicks"`
+	NickServPassword string   `json:"nickserv_password"`
+
+	// Colors shows players' nicks in table announcements in the color they
+	// picked with $setprofile. Leave it off where formatting codes are
+	// stripped or unwelcome.
+	Colors bool `json:"colors"`
+
+	// WeeklyDigest is when the bot posts the week's summary to each channel
+	// that saw play, as a day and local time such as "Sun 20:00". Empty to
+	// turn the digest off.
+	WeeklyDigest string `json:"weekly_digest"`
+
+	// TopicChannels are the channels whose topic the bot keeps up to date
+	// with the table's status, after whatever else the topic says. The bot
+	// needs ops in them, unless the topic isn't locked.
+	TopicChannels []string `json:"topic_channels"`
+
+	// TableLogDir is where the bot writes a plaintext log of each
+	// channel's games, a file a day with every line timestamped, for
+	// operators to audit without the database. Empty to write none.
+	// TableLogRetention is how long the files are kept, or zero for ever.
+	TableLogDir       string   `json:"table_log_dir"`
+	TableLogRetention Duration `json:"table_log_retention"`
+
+	// WebURL is where players reach the web dashboard served with -http,
+	// such as "https://poker.example.net", for links the bot sends them.
+	WebURL string `json:"web_url"`
+
+	// How the bot reaches the IRC server, for networks that restrict
+	// outgoing connections. BindAddress is the local IP to connect from,
+	// PreferIPv6 tries the server's IPv6 addresses before its IPv4 ones,
+	// and Proxy is a SOCKS5 proxy such as "socks5://127.0.0.1:9050" for
+	// Tor. They apply from the next reconnect.
+	BindAddress string `json:"bind_address"`
+	PreferIPv6  bool   `json:"prefer_ipv6"`
+	Proxy       string `json:"proxy"`
+}
+
+// Default is the configuration used when there is no config file, and the
+// base any file is applied on top of.
+var Default = Config{
+	TurnTimeout:     Duration(15 * time.Second),
+	SlowRollTimeout: Duration(8 * time.Second),
+	RunoutDelay:     Duration(3 * time.Second),
+	SmallBlind:      5,
+	BigBlind:        10,
+	CommandInterval: Duration(3 * time.Second),
+	LobbyTimeout:    Duration(15 * time.Minute),
+	RebuyWait:       Duration(2 * time.Minute),
+	CheatsPerHand:   1,
+	CheatCooldown:   3,
+	SeasonBonuses:   []int{1000, 500, 250},
+	WeeklyDigest:    "Sun 20:00",
+	Tone:            "family-friendly",
+}
+
+// Load reads the config file at path. Settings the file leaves out keep
+// their defaults.
+func Load(path string) (Config, error) {
+	config := Default
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("invalid config %s: %v", path, err)
+	}
+	return config, nil
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.TurnTimeout < Duration(5*time.Second):
+		return fmt.Errorf("turn_timeout must be at least 5s")
+	case c.SlowRollTimeout <= 0 || c.SlowRollTimeout > c.TurnTimeout:
+		return fmt.Errorf("slow_roll_timeout must be positive and no longer than turn_timeout")
+	case c.SmallBlind <= 0 || c.BigBlind < c.SmallBlind:
+		return fmt.Errorf("blinds must be positive, with the big blind at least the small blind")
+	case c.RunoutDelay < 0 || c.RunoutDelay > Duration(30*time.Second):
+		return fmt.Errorf("runout_delay must be between 0s and 30s")
+	case c.Ante < 0:
+		return fmt.Errorf("ante can't be negative")
+	case c.CommandInterval < 0:
+		return fmt.Errorf("command_interval can't be negative")
+	case c.EquityWorkers < 0:
+		return fmt.Errorf("equity_workers can't be negative")
+	case c.TableLogRetention < 0:
+		return fmt.Errorf("table_log_retention can't be negative")
+	case c.LobbyTimeout < 0:
+		return fmt.Errorf("lobby_timeout can't be negative")
+	case c.RebuyWait < 0:
+		return fmt.Errorf("rebuy_wait can't be negative")
+	case c.SeasonLength < 0:
+		return fmt.Errorf("season_length can't be negative")
+	case c.CheatsPerHand < 0 || c.CheatCooldown < 0:
+		return fmt.Errorf("cheats_per_hand and cheat_cooldown can't be negative")
+	case c.ForceEndRefund != "" && c.ForceEndRefund != "contributions" && c.ForceEndRefund != "live":
+		return fmt.Errorf("force_end_refund must be \"contributions\" or \"live\"")
+	}
+	for channel, tone := range c.ChannelTones {
+		if !validTone(tone) {
+			return fmt.Errorf("the tone of %s must be \"family-friendly\" or \"classic\"", channel)
+		}
+	}
+	if !validTone(c.Tone) {
+		return fmt.Errorf("tone must be \"family-friendly\" or \"classic\"")
+	}
+	for _, bonus := range c.SeasonBonuses {
+		if bonus < 0 {
+			return fmt.Errorf("season_bonuses can't be negative")
+		}
+	}
+	if c.WeeklyDigest != "" {
+		if _, _, err := ParseDigest(c.WeeklyDigest); err != nil {
+			return err
+		}
+	}
+	if c.BindAddress != "" && net.ParseIP(c.BindAddress) == nil {
+		return fmt.Errorf("bind_address must be an IP address")
+	}
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || (u.Scheme != "socks5" && u.Scheme != "socks5h") || u.Host == "" {
+			return fmt.Errorf("proxy must be a URL such as \"socks5://127.0.0.1:9050\"")
+		}
+	}
+	for _, nick := range c.AltNicks {
+		if nick == "" || strings.ContainsAny(nick, " ,:!@#") {
+			return fmt.Errorf("invalid alt nick %q", nick)
+		}
+	}
+	for _, mask := range c.Admins {
+		if mask == "" || strings.ContainsAny(mask, " ,") {
+			return fmt.Errorf("invalid admin hostmask %q", mask)
+		}
+	}
+	return nil
+}
+
+// validTone reports whether tone is one the bot speaks in. Empty means
+// "family-friendly".
+func validTone(tone string) bool {
+	return tone == "" || tone == "family-friendly" || tone == "classic"
+}
+
+// ParseDigest reads a WeeklyDigest setting such as "Sun 20:00" into the
+// day and the time of day.
+func ParseDigest(when string) (time.Weekday, time.Duration, error) {
+	day, clock, _ := strings.Cut(when, " ")
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		if !strings.EqualFold(day, weekday.String()[:3]) {
+			continue
+		}
+		at, err := time.Parse("15:04", clock)
+		if err != nil {
+			break
+		}
+		return weekday, time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+	}
+	return 0, 0, fmt.Errorf("weekly_digest must be a day and time such as \"Sun 20:00\"")
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+)
+
+// Bankroll is a player's standing in one economy.
+type Bankroll struct {
+	Economy  string `json:"economy"`
+	Money    int    `json:"money"`
+	HandsWon int    `json:"hands_won"`
+}
+
+func createAccountTables() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS accounts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			nick TEXT UNIQUE,
+			token TEXT UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// LinkAccount links nick to a web account, creating the account the first
+// time, and returns a new token for it. Tokens from earlier links stop
+// working.
+func LinkAccount(nick string) (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	_, err := exec(`
+		INSERT INTO accounts (nick, token) VALUES (?, ?)
+		ON CONFLICT (nick) DO UPDATE SET token = excluded.token
+	`, nick, token)
+	return token, err
+}
+
+// AccountNick returns the nick linked to an account token, or "" if the
+// token belongs to no account.
+func AccountNick(token string) (string, error) {
+	var nick string
+	err := queryRow("SELECT nick FROM accounts WHERE token = ?", token).Scan(&nick)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return nick, err
+}
+
+// GetBankrolls returns nick's bankroll in every economy they've played in.
+func GetBankrolls(nick string) ([]Bankroll, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	rows, err := query("SELECT economy, money, hands_won FROM players WHERE nick = ? ORDER BY economy", nick)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	bankrolls := []Bankroll{}
+	for rows.Next() {
+		var b Bankroll
+		if err := rows.Scan(&b.Economy, &b.Money, &b.HandsWon); err != nil {
+			return nil, err
+		}
+		if p := cache.players[playerKey{b.Economy, nick}]; p != nil {
+			b.Money, b.HandsWon = p.money, p.handsWon
+		}
+		bankrolls = append(bankrolls, b)
+	}
+	return bankrolls, rows.Err()
+}
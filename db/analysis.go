@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HandHistory is one stored hand history.
+type HandHistory struct {
+	ID        int64
+	Channel   string
+	StartedAt time.Time
+	Lines     []string
+	// Game is the game type and Stakes the blinds and ante the hand was
+	// dealt at, such as "holdem" and "5/10". Both are empty for hands
+	// saved before they were kept.
+	Game   string
+	Stakes string
+}
+
+// HandHistories returns the hands started since the given time, oldest
+// first.
+func HandHistories(since time.Time) ([]HandHistory, error) {
+	return handHistories("started_at >= ?", since)
+}
+
+// HandFilter picks the hands FilteredHandHistories returns. An empty field
+// matches every hand.
+type HandFilter struct {
+	Channel string // matched ignoring case
+	Game    string // such as "holdem"
+	// Stakes such as "5/10" match the hands dealt at those blinds, with
+	// or without an ante; "5/10, ante 1" only those with that ante.
+	Stakes string
+}
+
+// FilteredHandHistories returns the hands that match filter, oldest first.
+// The filter is applied by the query, so only those hands are read.
+func FilteredHandHistories(filter HandFilter) ([]HandHistory, error) {
+	where := []string{"1 = 1"}
+	var args []any
+	if filter.Channel != "" {
+		where = append(where, "channel = ? COLLATE NOCASE")
+		args = append(args, filter.Channel)
+	}
+	if filter.Game != "" {
+		where = append(where, "game = ?")
+		args = append(args, filter.Game)
+	}
+	if filter.Stakes != "" {
+		where = append(where, "(stakes = ? OR stakes LIKE ?)")
+		args = append(args, filter.Stakes, filter.Stakes+", %")
+	}
+	return handHistories(strings.Join(where, " AND "), args...)
+}
+
+// handHistories returns the finished hands matching the where clause,
+// oldest first.
+func handHistories(where string, args ...any) ([]HandHistory, error) {
+	rows, err := query("SELECT id, channel, started_at, log, game, stakes FROM hand_history WHERE "+where+" AND log != '' ORDER BY started_at", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var histories []HandHistory
+	for rows.Next() {
+		var history HandHistory
+		var log string
+		if err := rows.Scan(&history.ID, &history.Channel, &history.StartedAt, &log, &history.Game, &history.Stakes); err != nil {
+			return nil, err
+		}
+		history.Lines = strings.Split(log, "\n")
+		histories = append(histories, history)
+	}
+	return histories, rows.Err()
+}
+
+// GetHandHistory returns the hand with the given ID, or nil if there is no
+// such hand or it hasn't finished.
+func GetHandHistory(id int64) (*HandHistory, error) {
+	history := HandHistory{ID: id}
+	var log string
+	err := queryRow("SELECT channel, started_at, log, game, stakes FROM hand_history WHERE id = ? AND log != ''", id).
+		Scan(&history.Channel, &history.StartedAt, &log, &history.Game, &history.Stakes)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	history.Lines = strings.Split(log, "\n")
+	return &history, nil
+}
+
+func createHostTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS player_hosts (
+			nick TEXT,
+			host TEXT,
+			ident TEXT NOT NULL DEFAULT '',
+			account TEXT NOT NULL DEFAULT '',
+			last_seen DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (nick, host)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	for _, column := range []string{"ident", "account"} {
+		exists, err := hasColumn("player_hosts", column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := exec("ALTER TABLE player_hosts ADD COLUMN " + column + " TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("failed to migrate player_hosts: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// RecordHost notes that nick sat down at a table as ident@host.
+func RecordHost(nick, ident, host string) error {
+	_, err := exec(`
+		INSERT INTO player_hosts (nick, host, ident) VALUES (?, ?, ?)
+		ON CONFLICT (nick, host) DO UPDATE SET ident = excluded.ident, last_seen = CURRENT_TIMESTAMP
+	`, nick, host, ident)
+	return err
+}
+
+// RecordAccount notes the services account nick was logged in to, on the
+// host they last sat down from.
+func RecordAccount(nick, account string) error {
+	_, err := exec(`
+		UPDATE player_hosts SET account = ?
+		WHERE nick = ? AND last_seen = (SELECT MAX(last_seen) FROM player_hosts WHERE nick = ?)
+	`, account, nick, nick)
+	return err
+}
+
+// SharedHosts returns the hosts more than one nick has played from, with
+// those nicks.
+func SharedHosts() (map[string][]string, error) {
+	rows, err := query(`
+		SELECT host, nick FROM player_hosts
+		WHERE host IN (SELECT host FROM player_hosts GROUP BY host HAVING COUNT(*) > 1)
+		ORDER BY host, nick
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	hosts := make(map[string][]string)
+	for rows.Next() {
+		var host, nick string
+		if err := rows.Scan(&host, &nick); err != nil {
+			return nil, err
+		}
+		hosts[host] = append(hosts[host], nick)
+	}
+	return hosts, rows.Err()
+}
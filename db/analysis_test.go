@@ -0,0 +1,62 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilteredHandHistories(t *testing.T) {
+	openTestDB(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	hands := []struct {
+		channel, game, stakes string
+	}{
+		{"#poker", "holdem", "5/10"},
+		{"#Poker", "holdem", "5/10, ante 1"},
+		{"#poker", "holdem", "50/100"},
+		{"#poker", "omaha", "5/10"},
+		{"#other", "holdem", "5/10"},
+	}
+	for i, hand := range hands {
+		if err := SaveHandHistory(0, hand.channel, start.Add(time.Duration(i)*time.Minute), hand.game, hand.stakes, []string{"Round over! ann wins 15"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A hand still being played has no log yet.
+	if _, err := StartHandHistory("#poker", start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filter HandFilter
+		want   []int64
+	}{
+		{HandFilter{}, []int64{1, 2, 3, 4, 5}},
+		{HandFilter{Game: "holdem"}, []int64{1, 2, 3, 5}},
+		{HandFilter{Game: "holdem", Stakes: "5/10"}, []int64{1, 2, 5}},
+		{HandFilter{Stakes: "5/10, ante 1"}, []int64{2}},
+		{HandFilter{Stakes: "5/1"}, nil},
+		{HandFilter{Channel: "#POKER", Game: "holdem", Stakes: "5/10"}, []int64{1, 2}},
+		{HandFilter{Game: "five card draw"}, nil},
+	}
+	for _, test := range tests {
+		histories, err := FilteredHandHistories(test.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int64
+		for _, history := range histories {
+			got = append(got, history.ID)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%+v matched hands %v, want %v", test.filter, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%+v matched hands %v, want %v", test.filter, got, test.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import "time"
+
+// AuditEntry is one operator action in the audit log: who did it, what,
+// to whom or what, and where.
+type AuditEntry struct {
+	At      time.Time
+	Actor   string
+	Action  string
+	Target  string
+	Channel string
+	Detail  string
+}
+
+func createAuditTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			at DATETIME,
+			actor TEXT,
+			action TEXT,
+			target TEXT DEFAULT '',
+			channel TEXT DEFAULT '',
+			detail TEXT DEFAULT ''
+		)
+	`)
+	return err
+}
+
+// Audit adds entry to the audit log, timestamped now.
+func Audit(entry AuditEntry) error {
+	_, err := exec("INSERT INTO audit_log (at, actor, action, target, channel, detail) VALUES (?, ?, ?, ?, ?, ?)",
+		time.Now(), entry.Actor, entry.Action, entry.Target, entry.Channel, entry.Detail)
+	return err
+}
+
+// AuditLog returns the entries since the given time, newest first and at
+// most limit of them. Empty actor and action match every entry; actor
+// matches the start of the actor, so a nick finds its hostmasks.
+func AuditLog(since time.Time, actor, action string, limit int) ([]AuditEntry, error) {
+	rows, err := query(`
+		SELECT at, actor, action, target, channel, detail FROM audit_log
+		WHERE at >= ? AND (? = '' OR actor = ? OR actor LIKE ? ESCAPE '\') AND (? = '' OR action = ?)
+		ORDER BY id DESC LIMIT ?
+	`, since, actor, actor, escapeLike(actor)+"!%", action, action, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []AuditEntry
+	for rows.Next() {
+		var e AuditEntry
+		if err := rows.Scan(&e.At, &e.Actor, &e.Action, &e.Target, &e.Channel, &e.Detail); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
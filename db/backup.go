@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// snapshotMode is the mode snapshots are created with, before anything is
+// written to them: they hold every account's bankroll and token.
+const snapshotMode = 0o600
+
+// Backup writes a consistent snapshot of the bot's database to destPath
+// using SQLite's online backup API, so it is safe while games are running.
+// Cached players are written first, so the snapshot has them.
+func Backup(destPath string) error {
+	if err := Flush(); err != nil {
+		return err
+	}
+	if err := createSnapshot(destPath); err != nil {
+		return err
+	}
+	return copyDatabase(db, destPath)
+}
+
+// BackupFile snapshots the database at srcPath to destPath. It can be run
+// against the database of a running bot. srcPath must exist: SQLite would
+// create an empty database there and back that up.
+func BackupFile(srcPath, destPath string) error {
+	if _, err := os.Stat(srcPath); err != nil {
+		return err
+	}
+	src, err := sql.Open("sqlite3", srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if err := createSnapshot(destPath); err != nil {
+		return err
+	}
+	return copyDatabase(src, destPath)
+}
+
+// createSnapshot creates the file a snapshot is written to, readable only
+// by the bot's user, so it's never readable by others while it's written.
+func createSnapshot(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, snapshotMode)
+	if err != nil {
+		return err
+	}
+	if err := file.Chmod(snapshotMode); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// RestoreFile replaces the database at destPath with the snapshot at
+// backupPath. The bot must not be running against destPath.
+func RestoreFile(backupPath, destPath string) error {
+	src, err := sql.Open("sqlite3", backupPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return copyDatabase(src, destPath)
+}
+
+func copyDatabase(src *sql.DB, destPath string) error {
+	dest, err := sql.Open("sqlite3", destPath)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := destDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", destDriver)
+			}
+			srcSQLite, ok := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("unexpected driver connection %T", srcDriver)
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Close()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
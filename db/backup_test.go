@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackupFileNeedsTheSource(t *testing.T) {
+	dir := t.TempDir()
+	missing, snapshot := filepath.Join(dir, "missing.db"), filepath.Join(dir, "snapshot.db")
+	if err := BackupFile(missing, snapshot); err == nil {
+		t.Error("a missing database was backed up")
+	}
+	for _, path := range []string{missing, snapshot} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("%s was created", filepath.Base(path))
+		}
+	}
+}
+
+func TestBackupFileIsPrivate(t *testing.T) {
+	dir := t.TempDir()
+	source, snapshot := filepath.Join(dir, "poker.db"), filepath.Join(dir, "snapshot.db")
+	cache.Lock()
+	cache.players = make(map[playerKey]*cachedPlayer)
+	cache.Unlock()
+	if err := Initialize(source); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close() })
+	if _, err := CreatePlayer(context.Background(), "net", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BackupFile(source, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != snapshotMode {
+		t.Errorf("the snapshot's mode is %o, want %o", mode, snapshotMode)
+	}
+	if info.Size() == 0 {
+		t.Error("the snapshot is empty")
+	}
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Ban keeps the players matching Mask, a nick or a nick!user@host pattern,
+// from playing until Expires, or for good if it's zero. Shadow bans are
+// never told they're banned.
+type Ban struct {
+	ID        int64
+	Mask      string
+	Reason    string
+	BannedBy  string
+	Shadow    bool
+	CreatedAt time.Time
+	Expires   time.Time
+}
+
+// Expired reports whether the ban is over.
+func (b Ban) Expired() bool {
+	return !b.Expires.IsZero() && time.Now().After(b.Expires)
+}
+
+func createBanTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS bans (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			mask TEXT,
+			reason TEXT DEFAULT '',
+			banned_by TEXT,
+			shadow INTEGER DEFAULT 0,
+			created_at DATETIME,
+			expires_at DATETIME
+		)
+	`)
+	return err
+}
+
+// AddBan stores b and returns its ID.
+func AddBan(b Ban) (int64, error) {
+	var expires sql.NullTime
+	if !b.Expires.IsZero() {
+		expires = sql.NullTime{Time: b.Expires, Valid: true}
+	}
+	result, err := exec("INSERT INTO bans (mask, reason, banned_by, shadow, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		b.Mask, b.Reason, b.BannedBy, b.Shadow, time.Now(), expires)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// RemoveBans lifts every ban on mask and returns how many there were.
+func RemoveBans(mask string) (int, error) {
+	result, err := exec("DELETE FROM bans WHERE mask = ? COLLATE NOCASE", mask)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	return int(removed), err
+}
+
+// ActiveBans returns the bans that haven't expired, oldest first.
+func ActiveBans() ([]Ban, error) {
+	rows, err := query("SELECT id, mask, reason, banned_by, shadow, created_at, expires_at FROM bans ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bans []Ban
+	for rows.Next() {
+		var b Ban
+		var expires sql.NullTime
+		if err := rows.Scan(&b.ID, &b.Mask, &b.Reason, &b.BannedBy, &b.Shadow, &b.CreatedAt, &expires); err != nil {
+			return nil, err
+		}
+		b.Expires = expires.Time
+		if !b.Expired() {
+			bans = append(bans, b)
+		}
+	}
+	return bans, rows.Err()
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"sync"
+)
+
+// playerKey identifies a players row.
+type playerKey struct {
+	economy string
+	nick    string
+}
+
+// cachedPlayer is a players row as the bot last saw or saved it. Dirty rows
+// have been saved since the last Flush and not written yet.
+type cachedPlayer struct {
+	money    int
+	handsWon int
+	dirty    bool
+}
+
+// cache keeps the players rows in use, so $score and seating players don't
+// read the database and saving them after each hand doesn't write it.
+// Saves are written in one transaction by Flush, at the end of every hand
+// and by Close.
+var cache = struct {
+	sync.Mutex
+	players map[playerKey]*cachedPlayer
+}{players: make(map[playerKey]*cachedPlayer)}
+
+// Flush writes the players saved since the last flush to the database.
+func Flush() error {
+	cache.Lock()
+	defer cache.Unlock()
+
+	var dirty []playerKey
+	for key, p := range cache.players {
+		if p.dirty {
+			dirty = append(dirty, key)
+		}
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+	tx, err := beginContext(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, key := range dirty {
+		if err := writePlayer(tx, key, cache.players[key]); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, key := range dirty {
+		cache.players[key].dirty = false
+	}
+	return nil
+}
+
+func writePlayer(tx *txn, key playerKey, p *cachedPlayer) error {
+	_, err := tx.Exec("UPDATE players SET money = ?, hands_won = ? WHERE economy = ? AND nick = ?", p.money, p.handsWon, key.economy, key.nick)
+	return err
+}
+
+// playerTx is a transaction that changes players rows behind the cache's
+// back. It holds the cache until it ends, writes the cached rows it is
+// about to change first, and drops them from the cache when it commits, so
+// they're read back from the database.
+type playerTx struct {
+	*txn
+	changed []playerKey
+}
+
+func beginPlayers() (*playerTx, error) {
+	return beginPlayersContext(base)
+}
+
+func beginPlayersContext(ctx context.Context) (*playerTx, error) {
+	cache.Lock()
+	tx, err := beginContext(ctx)
+	if err != nil {
+		cache.Unlock()
+		return nil, err
+	}
+	return &playerTx{txn: tx}, nil
+}
+
+// changing writes the cached row of nick in economy into the transaction,
+// if it has unsaved changes, and marks it to be dropped on commit.
+func (tx *playerTx) changing(economy, nick string) error {
+	key := playerKey{economy, nick}
+	p := cache.players[key]
+	if p == nil {
+		return nil
+	}
+	if p.dirty {
+		if err := writePlayer(tx.txn, key, p); err != nil {
+			return err
+		}
+	}
+	tx.changed = append(tx.changed, key)
+	return nil
+}
+
+func (tx *playerTx) Commit() error {
+	defer cache.Unlock()
+	if err := tx.txn.Commit(); err != nil {
+		return err
+	}
+	for _, key := range tx.changed {
+		delete(cache.players, key)
+	}
+	return nil
+}
+
+func (tx *playerTx) Rollback() error {
+	defer cache.Unlock()
+	return tx.txn.Rollback()
+}
@@ -0,0 +1,96 @@
+package db
+
+func createCashTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS cash_stacks (
+			economy TEXT NOT NULL DEFAULT '',
+			channel TEXT,
+			nick TEXT,
+			stack INTEGER,
+			PRIMARY KEY (economy, channel, nick)
+		)
+	`)
+	return err
+}
+
+// BuyIntoCashGame moves amount from nick's bankroll to a stack at the cash
+// game at channel.
+func BuyIntoCashGame(economy, channel, nick string, amount int) error {
+	tx, err := beginPlayers()
+	if err != nil {
+		return err
+	}
+	if err := recordTransaction(tx, economy, nick, -amount, "cash game buy-in"); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO cash_stacks (economy, channel, nick, stack) VALUES (?, ?, ?, ?)
+		ON CONFLICT (economy, channel, nick) DO UPDATE SET stack = stack + excluded.stack
+	`, economy, channel, nick, amount); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateCashStack records nick's stack at the cash game at channel after a
+// hand.
+func UpdateCashStack(economy, channel, nick string, stack int) error {
+	_, err := exec("UPDATE cash_stacks SET stack = ? WHERE economy = ? AND channel = ? AND nick = ?", stack, economy, channel, nick)
+	return err
+}
+
+// CashOut moves nick's stack at the cash game at channel, stack chips,
+// back to their bankroll.
+func CashOut(economy, channel, nick string, stack int) error {
+	tx, err := beginPlayers()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM cash_stacks WHERE economy = ? AND channel = ? AND nick = ?", economy, channel, nick); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if stack > 0 {
+		if err := recordTransaction(tx, economy, nick, stack, "cash game cash-out"); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReturnCashStacks cashes out every stack left at a cash game, for use at
+// startup, when no game is running, so chips at the table when the bot
+// stopped aren't lost. It returns how many there were.
+func ReturnCashStacks() (int, error) {
+	rows, err := query("SELECT economy, channel, nick, stack FROM cash_stacks")
+	if err != nil {
+		return 0, err
+	}
+	type stack struct {
+		economy, channel, nick string
+		chips                  int
+	}
+	var stacks []stack
+	for rows.Next() {
+		var s stack
+		if err := rows.Scan(&s.economy, &s.channel, &s.nick, &s.chips); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		stacks = append(stacks, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, s := range stacks {
+		if err := CashOut(s.economy, s.channel, s.nick, s.chips); err != nil {
+			return 0, err
+		}
+	}
+	return len(stacks), nil
+}
@@ -0,0 +1,68 @@
+package db
+
+import "database/sql"
+
+// CheatRecord is how a player's $cheat attempts went: how often they got
+// away with it, and how often they were caught.
+type CheatRecord struct {
+	Nick      string
+	Succeeded int
+	Caught    int
+}
+
+func createCheatTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS cheats (
+			nick TEXT PRIMARY KEY,
+			succeeded INTEGER NOT NULL DEFAULT 0,
+			caught INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	return err
+}
+
+// RecordCheat counts one $cheat by nick, caught or not.
+func RecordCheat(nick string, caught bool) error {
+	succeeded, failed := 1, 0
+	if caught {
+		succeeded, failed = 0, 1
+	}
+	_, err := exec(`
+		INSERT INTO cheats (nick, succeeded, caught) VALUES (?, ?, ?)
+		ON CONFLICT (nick) DO UPDATE SET
+			succeeded = succeeded + excluded.succeeded, caught = caught + excluded.caught
+	`, nick, succeeded, failed)
+	return err
+}
+
+// GetCheatRecord returns nick's cheats, all zero if they never tried.
+func GetCheatRecord(nick string) (CheatRecord, error) {
+	record := CheatRecord{Nick: nick}
+	err := queryRow("SELECT succeeded, caught FROM cheats WHERE nick = ?", nick).Scan(&record.Succeeded, &record.Caught)
+	if err == sql.ErrNoRows {
+		return record, nil
+	}
+	return record, err
+}
+
+// MostCaught returns up to limit players who have been caught cheating,
+// the most often caught first.
+func MostCaught(limit int) ([]CheatRecord, error) {
+	rows, err := query(`
+		SELECT nick, succeeded, caught FROM cheats WHERE caught > 0
+		ORDER BY caught DESC, succeeded ASC, nick LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []CheatRecord
+	for rows.Next() {
+		var r CheatRecord
+		if err := rows.Scan(&r.Nick, &r.Succeeded, &r.Caught); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
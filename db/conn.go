@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// queryTimeout bounds every statement and transaction, retries
+	// included, so a locked database can't hang the goroutine waiting on it.
+	queryTimeout = 10 * time.Second
+	// busyTimeout is how long SQLite itself waits for a lock before a
+	// statement fails with SQLITE_BUSY.
+	busyTimeout = 2 * time.Second
+	// busyRetries is how many more times a statement that failed with
+	// SQLITE_BUSY is tried, busyBackoff apart and doubling.
+	busyRetries = 3
+	busyBackoff = 50 * time.Millisecond
+	// slowQuery is how long a statement or transaction can take before it
+	// is logged.
+	slowQuery = 250 * time.Millisecond
+	// maxConns caps the pool. In WAL mode readers don't block the writer,
+	// so a few connections let the web server read while a hand is saved.
+	maxConns = 4
+)
+
+// base is the parent of every operation's context. Cancelling the context
+// given to SetContext cuts short the queries of work being abandoned at
+// shutdown.
+var base = context.Background()
+
+// SetContext makes ctx the parent of every database operation but Flush,
+// which must still get the players written once ctx is done. Call it before
+// anything uses the database.
+func SetContext(ctx context.Context) {
+	base = ctx
+}
+
+// open opens the database at path in WAL mode. Transactions take the write
+// lock when they begin, so two of them can't deadlock upgrading their read
+// locks, and wait busyTimeout for it. A path of ":memory:" opens an empty
+// database in memory, such as for tests, on a single connection, since each
+// connection to one would have a database of its own.
+func open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", path, busyTimeout.Milliseconds())
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	conns := maxConns
+	if path == ":memory:" {
+		conns = 1
+	}
+	conn.SetMaxOpenConns(conns)
+	conn.SetMaxIdleConns(conns)
+	return conn, nil
+}
+
+func exec(query string, args ...any) (sql.Result, error) {
+	return execContext(base, query, args...)
+}
+
+// execContext is exec under parent, for callers that pass a context of
+// their own.
+func execContext(parent context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := context.WithTimeout(parent, queryTimeout)
+	defer cancel()
+	defer logSlow(query, time.Now())
+	var result sql.Result
+	err := retry(ctx, func() error {
+		var err error
+		result, err = db.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// rows are the results of query. Close ends the query's timeout.
+type rows struct {
+	*sql.Rows
+	query  string
+	start  time.Time
+	cancel context.CancelFunc
+}
+
+func query(query string, args ...any) (*rows, error) {
+	return queryContext(base, query, args...)
+}
+
+func queryContext(parent context.Context, query string, args ...any) (*rows, error) {
+	ctx, cancel := context.WithTimeout(parent, queryTimeout)
+	start := time.Now()
+	var result *sql.Rows
+	err := retry(ctx, func() error {
+		var err error
+		result, err = db.QueryContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		cancel()
+		logSlow(query, start)
+		return nil, err
+	}
+	return &rows{Rows: result, query: query, start: start, cancel: cancel}, nil
+}
+
+func (r *rows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	logSlow(r.query, r.start)
+	return err
+}
+
+// row is the result of queryRow. The query runs when it's scanned.
+type row struct {
+	ctx   context.Context
+	query string
+	args  []any
+}
+
+func queryRow(query string, args ...any) *row {
+	return queryRowContext(base, query, args...)
+}
+
+func queryRowContext(ctx context.Context, query string, args ...any) *row {
+	return &row{ctx: ctx, query: query, args: args}
+}
+
+func (r *row) Scan(dest ...any) error {
+	ctx, cancel := context.WithTimeout(r.ctx, queryTimeout)
+	defer cancel()
+	defer logSlow(r.query, time.Now())
+	return retry(ctx, func() error {
+		return db.QueryRowContext(ctx, r.query, r.args...).Scan(dest...)
+	})
+}
+
+// txn is a transaction with queryTimeout to finish in. Its statements run
+// under the transaction's deadline.
+type txn struct {
+	*sql.Tx
+	start  time.Time
+	cancel context.CancelFunc
+}
+
+func begin() (*txn, error) {
+	return beginContext(base)
+}
+
+func beginContext(parent context.Context) (*txn, error) {
+	ctx, cancel := context.WithTimeout(parent, queryTimeout)
+	start := time.Now()
+	var tx *sql.Tx
+	err := retry(ctx, func() error {
+		var err error
+		tx, err = db.BeginTx(ctx, nil)
+		return err
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &txn{Tx: tx, start: start, cancel: cancel}, nil
+}
+
+func (tx *txn) Commit() error {
+	err := tx.Tx.Commit()
+	tx.cancel()
+	logSlow("transaction", tx.start)
+	return err
+}
+
+func (tx *txn) Rollback() error {
+	err := tx.Tx.Rollback()
+	tx.cancel()
+	return err
+}
+
+// retry runs op until it doesn't fail with SQLITE_BUSY or SQLITE_LOCKED,
+// busyRetries times at most, or ctx is done.
+func retry(ctx context.Context, op func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := op()
+		if !isBusy(err) || attempt == busyRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(busyBackoff << attempt):
+		}
+	}
+}
+
+func isBusy(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked)
+}
+
+func logSlow(query string, start time.Time) {
+	if took := time.Since(start); took > slowQuery {
+		log.Printf("Slow query, %s: %s", took.Round(time.Millisecond), strings.Join(strings.Fields(query), " "))
+	}
+}
@@ -1,18 +1,34 @@
+// Package db stores the bot's players, ledger, hand histories and the rest
+// in SQLite.
+//
+// Its functions take no context.Context. Every statement runs under the
+// context given to SetContext, which shutdown cancels, and is cut off after
+// queryTimeout, so no caller can hang on the database or outlive the bot
+// with a query; the players functions are mostly answered from the cache
+// without a query at all. A context per call would only repeat that.
 package db
 
 import (
 	"database/sql"
 	"fmt"
-	"poker-bot/models"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 var db *sql.DB
 
+// HouseNick is the players row that banks the side games in each economy.
+// '$' can't appear in an IRC nick, so no player can own it.
+const HouseNick = "$house"
+
+// SharedEconomy is the economy of channels that don't have one of their own.
+const SharedEconomy = ""
+
 func Initialize(dbPath string) error {
 	var err error
-	db, err = sql.Open("sqlite3", dbPath)
+	db, err = open(dbPath)
 	if err != nil {
 		return err
 	}
@@ -20,45 +36,241 @@ func Initialize(dbPath string) error {
 }
 
 func createTables() error {
-	_, err := db.Exec(`
+	_, err := exec(`
 		CREATE TABLE IF NOT EXISTS players (
-			nick TEXT PRIMARY KEY,
+			economy TEXT NOT NULL DEFAULT '',
+			nick TEXT,
 			money INTEGER,
-			hands_won INTEGER
+			hands_won INTEGER,
+			PRIMARY KEY (economy, nick)
 		)
 	`)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := migrateEconomies(); err != nil {
+		return err
+	}
+	_, err = exec(`
+		CREATE TABLE IF NOT EXISTS transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			economy TEXT NOT NULL DEFAULT '',
+			nick TEXT,
+			amount INTEGER,
+			reason TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = exec(`
+		CREATE TABLE IF NOT EXISTS hand_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			channel TEXT,
+			started_at DATETIME,
+			log TEXT,
+			game TEXT NOT NULL DEFAULT '',
+			stakes TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	for _, column := range []string{"game", "stakes"} {
+		exists, err := hasColumn("hand_history", column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := exec("ALTER TABLE hand_history ADD COLUMN " + column + " TEXT NOT NULL DEFAULT ''"); err != nil {
+				return fmt.Errorf("failed to migrate hand_history: %v", err)
+			}
+		}
+	}
+	if _, err := exec("CREATE INDEX IF NOT EXISTS hand_history_game ON hand_history (game, stakes)"); err != nil {
+		return err
+	}
+	if err := createAccountTables(); err != nil {
+		return err
+	}
+	if err := createHostTable(); err != nil {
+		return err
+	}
+	if err := createTournamentTables(); err != nil {
+		return err
+	}
+	if err := createProfileTable(); err != nil {
+		return err
+	}
+	if err := createRecordTable(); err != nil {
+		return err
+	}
+	if err := createCashTable(); err != nil {
+		return err
+	}
+	if err := createBanTable(); err != nil {
+		return err
+	}
+	if err := createMaintenanceTable(); err != nil {
+		return err
+	}
+	if err := createCheatTable(); err != nil {
+		return err
+	}
+	if err := createItemTable(); err != nil {
+		return err
+	}
+	if err := createSeasonTables(); err != nil {
+		return err
+	}
+	if err := createSeriesTables(); err != nil {
+		return err
+	}
+	return createAuditTable()
 }
 
-func GetOrCreatePlayer(nick string) (*models.Player, error) {
-	var money int
-	var handsWon int
-	err := db.QueryRow("SELECT money, hands_won FROM players WHERE nick = ?", nick).Scan(&money, &handsWon)
-	if err == sql.ErrNoRows {
-		// Player doesn't exist, create a new one
-		money = 1000 // Starting money
-		handsWon = 0
-		_, err = db.Exec("INSERT INTO players (nick, money, hands_won) VALUES (?, ?, ?)", nick, money, handsWon)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create new player: %v", err)
+// migrateEconomies moves databases from before economies, where players
+// were keyed by nick alone, into the shared economy.
+func migrateEconomies() error {
+	hasEconomy, err := hasColumn("players", "economy")
+	if err != nil || hasEconomy {
+		return err
+	}
+
+	tx, err := begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range []string{
+		"ALTER TABLE players RENAME TO players_old",
+		`CREATE TABLE players (
+			economy TEXT NOT NULL DEFAULT '',
+			nick TEXT,
+			money INTEGER,
+			hands_won INTEGER,
+			PRIMARY KEY (economy, nick)
+		)`,
+		"INSERT INTO players (economy, nick, money, hands_won) SELECT '', nick, money, hands_won FROM players_old",
+		"DROP TABLE players_old",
+	} {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate players: %v", err)
 		}
-	} else if err != nil {
-		return nil, fmt.Errorf("failed to get player: %v", err)
 	}
+	if exists, err := hasTable(tx, "transactions"); err != nil {
+		tx.Rollback()
+		return err
+	} else if exists {
+		if _, err := tx.Exec("ALTER TABLE transactions ADD COLUMN economy TEXT NOT NULL DEFAULT ''"); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate transactions: %v", err)
+		}
+	}
+	return tx.Commit()
+}
 
-	return models.NewPlayer(nick, money, handsWon), nil
+func hasColumn(table, column string) (bool, error) {
+	rows, err := query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+func hasTable(tx *txn, table string) (bool, error) {
+	var count int
+	err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count)
+	return count > 0, err
+}
+
+// RecordTransaction adds amount, which may be negative, to a player's
+// bankroll and records the change and its reason in the transactions ledger,
+// atomically.
+func RecordTransaction(economy, nick string, amount int, reason string) error {
+	tx, err := beginPlayers()
+	if err != nil {
+		return err
+	}
+	if err := recordTransaction(tx, economy, nick, amount, reason); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
-func UpdatePlayer(player *models.Player) error {
-	_, err := db.Exec("UPDATE players SET money = ?, hands_won = ? WHERE nick = ?", player.Money, player.HandsWon, player.Nick)
+func recordTransaction(tx *playerTx, economy, nick string, amount int, reason string) error {
+	if err := tx.changing(economy, nick); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE players SET money = money + ? WHERE economy = ? AND nick = ?", amount, economy, nick); err != nil {
+		return err
+	}
+	_, err := tx.Exec("INSERT INTO transactions (economy, nick, amount, reason) VALUES (?, ?, ?, ?)", economy, nick, amount, reason)
 	return err
 }
 
-func GetPlayerStats(nick string) (money int, handsWon int, err error) {
-	err = db.QueryRow("SELECT money, hands_won FROM players WHERE nick = ?", nick).Scan(&money, &handsWon)
-	return
+// SettleWithHouse moves amount from the economy's house account to a
+// player, or from the player to the house when amount is negative, recording
+// both sides in the transactions ledger.
+func SettleWithHouse(economy, nick string, amount int, reason string) error {
+	tx, err := beginPlayers()
+	if err != nil {
+		return err
+	}
+	if err := settleWithHouse(tx, economy, nick, amount, reason); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func settleWithHouse(tx *playerTx, economy, nick string, amount int, reason string) error {
+	if _, err := tx.Exec("INSERT OR IGNORE INTO players (economy, nick, money, hands_won) VALUES (?, ?, 0, 0)", economy, HouseNick); err != nil {
+		return err
+	}
+	if err := recordTransaction(tx, economy, nick, amount, reason); err != nil {
+		return err
+	}
+	return recordTransaction(tx, economy, HouseNick, -amount, reason)
+}
+
+// StartHandHistory reserves the hand history of a hand as it is dealt, so
+// its ID can be announced before the hand is saved.
+func StartHandHistory(channel string, startedAt time.Time) (int64, error) {
+	result, err := exec("INSERT INTO hand_history (channel, started_at, log) VALUES (?, ?, '')", channel, startedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// SaveHandHistory stores the log of a finished hand, one event per line,
+// with the game and stakes it was dealt at, in the hand history reserved as
+// id, or in a new one if id is 0.
+func SaveHandHistory(id int64, channel string, startedAt time.Time, game, stakes string, lines []string) error {
+	if id == 0 {
+		_, err := exec("INSERT INTO hand_history (channel, started_at, log, game, stakes) VALUES (?, ?, ?, ?, ?)", channel, startedAt, strings.Join(lines, "\n"), game, stakes)
+		return err
+	}
+	_, err := exec("UPDATE hand_history SET log = ?, game = ?, stakes = ? WHERE id = ?", strings.Join(lines, "\n"), game, stakes, id)
+	return err
 }
 
-func Close() {
+// Close writes the cached players and closes the database.
+func Close() error {
+	err := Flush()
 	db.Close()
+	return err
 }
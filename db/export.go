@@ -0,0 +1,59 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// Transaction is one entry in the bankroll ledger.
+type Transaction struct {
+	Economy   string    `json:"economy"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Transactions returns nick's ledger entries in every economy, oldest first.
+func Transactions(nick string) ([]Transaction, error) {
+	rows, err := query("SELECT economy, amount, reason, created_at FROM transactions WHERE nick = ? ORDER BY id", nick)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var transactions []Transaction
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.Economy, &t.Amount, &t.Reason, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// escapeLike escapes the wildcards in s for a LIKE pattern with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// PlayerHandHistories returns the hand histories that mention nick, oldest
+// first. Callers check the lines for whether nick played the hand.
+func PlayerHandHistories(nick string) ([]HandHistory, error) {
+	pattern := "%" + escapeLike(nick) + "%"
+	rows, err := query(`SELECT id, channel, started_at, log, game, stakes FROM hand_history WHERE log LIKE ? ESCAPE '\' ORDER BY started_at`, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var histories []HandHistory
+	for rows.Next() {
+		var history HandHistory
+		var log string
+		if err := rows.Scan(&history.ID, &history.Channel, &history.StartedAt, &log, &history.Game, &history.Stakes); err != nil {
+			return nil, err
+		}
+		history.Lines = strings.Split(log, "\n")
+		histories = append(histories, history)
+	}
+	return histories, rows.Err()
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ForgetPlayer erases nick from the database. What is only about them, their
+// bankrolls, stacks, items, web account, hosts, profile and cheats, is
+// deleted. Rows other players' stats and the ledger depend on, their
+// transactions, tournament and season standings, series points and titles,
+// records and the lines of hand histories, are kept under a pseudonym
+// instead, which is returned. It starts with '$', like HouseNick, so no
+// player can own it. The table logs are files, not the database; the
+// caller rewrites those under the pseudonym itself.
+func ForgetPlayer(nick string) (string, error) {
+	raw := make([]byte, 4)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	pseudonym := "$forgotten-" + hex.EncodeToString(raw)
+
+	tx, err := beginPlayers()
+	if err != nil {
+		return "", err
+	}
+	for key := range cache.players {
+		if key.nick == nick {
+			tx.changed = append(tx.changed, key)
+		}
+	}
+	for _, statement := range []string{
+		"DELETE FROM players WHERE nick = ?",
+		"DELETE FROM cash_stacks WHERE nick = ?",
+		"DELETE FROM accounts WHERE nick = ?",
+		"DELETE FROM player_hosts WHERE nick = ?",
+		"DELETE FROM profiles WHERE nick = ?",
+		"DELETE FROM cheats WHERE nick = ?",
+		"DELETE FROM items WHERE nick = ?",
+	} {
+		if _, err := tx.Exec(statement, nick); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+	for _, table := range []string{"transactions", "tournament_standings", "game_standings", "records", "season_standings", "series_points"} {
+		if _, err := tx.Exec("UPDATE "+table+" SET nick = ? WHERE nick = ?", pseudonym, nick); err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+	if _, err := tx.Exec("UPDATE series SET champion = ? WHERE champion = ?", pseudonym, nick); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	pattern := "%" + escapeLike(nick) + "%"
+	rows, err := tx.Query(`SELECT id, log FROM hand_history WHERE log LIKE ? ESCAPE '\'`, pattern)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	logs := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var log string
+		if err := rows.Scan(&id, &log); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return "", err
+		}
+		logs[id] = log
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return "", err
+	}
+	for id, log := range logs {
+		rewritten := replaceNick(log, nick, pseudonym)
+		if rewritten == log {
+			continue // only part of a longer nick
+		}
+		if _, err := tx.Exec("UPDATE hand_history SET log = ? WHERE id = ?", rewritten, id); err != nil {
+			tx.Rollback()
+			return "", fmt.Errorf("failed to rewrite hand %d: %v", id, err)
+		}
+	}
+	return pseudonym, tx.Commit()
+}
+
+// replaceNick replaces nick in text where it stands alone, not where it is
+// part of a longer nick.
+func replaceNick(text, nick, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, nick)
+		if i < 0 {
+			break
+		}
+		end := i + len(nick)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		b.WriteString(text[:i])
+		if (i > 0 && isNickRune(before)) || (end < len(text) && isNickRune(after)) {
+			b.WriteString(nick)
+		} else {
+			b.WriteString(replacement)
+		}
+		text = text[end:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+func isNickRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_[]\\`^{}|", r)
+}
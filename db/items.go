@@ -0,0 +1,95 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Items a player can buy in the $shop. Each is used up the first time it
+// does something.
+const (
+	ItemBodyguard = "bodyguard"
+	ItemCharm     = "charm"
+)
+
+// ErrNotEnoughMoney is returned by BuyItem when the buyer can't pay.
+var ErrNotEnoughMoney = errors.New("not enough money")
+
+func createItemTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS items (
+			economy TEXT NOT NULL DEFAULT '',
+			nick TEXT,
+			item TEXT,
+			count INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (economy, nick, item)
+		)
+	`)
+	return err
+}
+
+// BuyItem sells nick one item in economy for price, paid to the house, and
+// records it in the ledger, all at once. It returns ErrNotEnoughMoney if
+// nick's bankroll is short of the price.
+func BuyItem(economy, nick, item string, price int) error {
+	tx, err := beginPlayers()
+	if err != nil {
+		return err
+	}
+	if err := tx.changing(economy, nick); err != nil {
+		tx.Rollback()
+		return err
+	}
+	var money int
+	if err := tx.QueryRow("SELECT money FROM players WHERE economy = ? AND nick = ?", economy, nick).Scan(&money); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if money < price {
+		tx.Rollback()
+		return ErrNotEnoughMoney
+	}
+	if err := settleWithHouse(tx, economy, nick, -price, fmt.Sprintf("bought a %s", item)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO items (economy, nick, item, count) VALUES (?, ?, ?, 1)
+		ON CONFLICT (economy, nick, item) DO UPDATE SET count = count + 1
+	`, economy, nick, item); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Items returns how many of each item nick holds in economy. Items they've
+// used up are left out.
+func Items(economy, nick string) (map[string]int, error) {
+	rows, err := query("SELECT item, count FROM items WHERE economy = ? AND nick = ? AND count > 0", economy, nick)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make(map[string]int)
+	for rows.Next() {
+		var item string
+		var count int
+		if err := rows.Scan(&item, &count); err != nil {
+			return nil, err
+		}
+		items[item] = count
+	}
+	return items, rows.Err()
+}
+
+// UseItem uses up one of nick's item in economy, and reports whether they
+// had one.
+func UseItem(economy, nick, item string) (bool, error) {
+	result, err := exec("UPDATE items SET count = count - 1 WHERE economy = ? AND nick = ? AND item = ? AND count > 0", economy, nick, item)
+	if err != nil {
+		return false, err
+	}
+	used, err := result.RowsAffected()
+	return used > 0, err
+}
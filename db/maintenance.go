@@ -0,0 +1,60 @@
+package db
+
+import (
+	"strings"
+	"time"
+)
+
+// Maintenance is a channel closed to new games and joins by an admin, such
+// as before a restart.
+type Maintenance struct {
+	Channel   string
+	Reason    string
+	SetBy     string
+	StartedAt time.Time
+}
+
+func createMaintenanceTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS maintenance (
+			channel TEXT PRIMARY KEY COLLATE NOCASE,
+			reason TEXT DEFAULT '',
+			set_by TEXT,
+			started_at DATETIME
+		)
+	`)
+	return err
+}
+
+// SetMaintenance puts m.Channel into maintenance, replacing any earlier
+// reason.
+func SetMaintenance(m Maintenance) error {
+	_, err := exec("INSERT OR REPLACE INTO maintenance (channel, reason, set_by, started_at) VALUES (?, ?, ?, ?)",
+		m.Channel, m.Reason, m.SetBy, m.StartedAt)
+	return err
+}
+
+// ClearMaintenance takes channel out of maintenance.
+func ClearMaintenance(channel string) error {
+	_, err := exec("DELETE FROM maintenance WHERE channel = ?", channel)
+	return err
+}
+
+// MaintenanceChannels returns the channels in maintenance, keyed by
+// lowercased channel.
+func MaintenanceChannels() (map[string]Maintenance, error) {
+	rows, err := query("SELECT channel, reason, set_by, started_at FROM maintenance")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	channels := make(map[string]Maintenance)
+	for rows.Next() {
+		var m Maintenance
+		if err := rows.Scan(&m.Channel, &m.Reason, &m.SetBy, &m.StartedAt); err != nil {
+			return nil, err
+		}
+		channels[strings.ToLower(m.Channel)] = m
+	}
+	return channels, rows.Err()
+}
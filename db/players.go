@@ -0,0 +1,160 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"poker-bot/models"
+)
+
+// StartingMoney is the bankroll a player gets the first time they play in
+// an economy.
+const StartingMoney = 1000
+
+// The player functions that may reach the database take a context, which
+// bounds their queries along with queryTimeout. Players served from the
+// cache don't wait on it.
+
+// CreatePlayer adds nick to economy with StartingMoney. It fails if they're
+// already there.
+func CreatePlayer(ctx context.Context, economy, nick string) (*models.Player, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	return createPlayer(ctx, economy, nick)
+}
+
+func createPlayer(ctx context.Context, economy, nick string) (*models.Player, error) {
+	key := playerKey{economy, nick}
+	if cache.players[key] != nil {
+		return nil, fmt.Errorf("player %s already exists", nick)
+	}
+	if _, err := execContext(ctx, "INSERT INTO players (economy, nick, money, hands_won) VALUES (?, ?, ?, 0)", economy, nick, StartingMoney); err != nil {
+		return nil, fmt.Errorf("failed to create new player: %v", err)
+	}
+	cache.players[key] = &cachedPlayer{money: StartingMoney}
+	return newPlayer(economy, nick, cache.players[key]), nil
+}
+
+// GetPlayer returns nick's player in economy, or nil if they've never
+// played there.
+func GetPlayer(ctx context.Context, economy, nick string) (*models.Player, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	return getPlayer(ctx, economy, nick)
+}
+
+func getPlayer(ctx context.Context, economy, nick string) (*models.Player, error) {
+	key := playerKey{economy, nick}
+	if p := cache.players[key]; p != nil {
+		return newPlayer(economy, nick, p), nil
+	}
+	p := &cachedPlayer{}
+	err := queryRowContext(ctx, "SELECT money, hands_won FROM players WHERE economy = ? AND nick = ?", economy, nick).Scan(&p.money, &p.handsWon)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player: %v", err)
+	}
+	cache.players[key] = p
+	return newPlayer(economy, nick, p), nil
+}
+
+// GetOrCreatePlayer returns nick's player in economy, creating them the
+// first time they play there.
+func GetOrCreatePlayer(ctx context.Context, economy, nick string) (*models.Player, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	player, err := getPlayer(ctx, economy, nick)
+	if player != nil || err != nil {
+		return player, err
+	}
+	return createPlayer(ctx, economy, nick)
+}
+
+func newPlayer(economy, nick string, p *cachedPlayer) *models.Player {
+	player := models.NewPlayer(nick, p.money, p.handsWon)
+	player.Economy = economy
+	return player
+}
+
+// UpdatePlayer saves player's bankroll and stats. They're written to the
+// database by the next Flush.
+func UpdatePlayer(player *models.Player) error {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.players[playerKey{player.Economy, player.Nick}] = &cachedPlayer{money: player.Money, handsWon: player.HandsWon, dirty: true}
+	return nil
+}
+
+func UpdateHandsWon(economy, nick string, handsWon int) error {
+	cache.Lock()
+	defer cache.Unlock()
+	if p := cache.players[playerKey{economy, nick}]; p != nil {
+		p.handsWon = handsWon
+		p.dirty = true
+		return nil
+	}
+	_, err := exec("UPDATE players SET hands_won = ? WHERE economy = ? AND nick = ?", handsWon, economy, nick)
+	return err
+}
+
+func GetPlayerStats(economy, nick string) (money int, handsWon int, err error) {
+	cache.Lock()
+	defer cache.Unlock()
+	if p := cache.players[playerKey{economy, nick}]; p != nil {
+		return p.money, p.handsWon, nil
+	}
+	err = queryRow("SELECT money, hands_won FROM players WHERE economy = ? AND nick = ?", economy, nick).Scan(&money, &handsWon)
+	return
+}
+
+// DeletePlayer removes nick's bankroll in economy and any stacks they have
+// at its cash tables. Their transactions stay in the ledger.
+func DeletePlayer(ctx context.Context, economy, nick string) error {
+	tx, err := beginPlayersContext(ctx)
+	if err != nil {
+		return err
+	}
+	tx.changed = append(tx.changed, playerKey{economy, nick})
+	for _, statement := range []string{
+		"DELETE FROM players WHERE economy = ? AND nick = ?",
+		"DELETE FROM cash_stacks WHERE economy = ? AND nick = ?",
+	} {
+		if _, err := tx.Exec(statement, economy, nick); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListPlayers returns up to limit players, skipping the first offset, in
+// order of economy and nick. The house accounts aren't players.
+func ListPlayers(ctx context.Context, offset, limit int) ([]*models.Player, error) {
+	cache.Lock()
+	defer cache.Unlock()
+	rows, err := queryContext(ctx, `
+		SELECT economy, nick, money, hands_won FROM players
+		WHERE nick != ?
+		ORDER BY economy, nick LIMIT ? OFFSET ?
+	`, HouseNick, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var players []*models.Player
+	for rows.Next() {
+		var economy, nick string
+		p := &cachedPlayer{}
+		if err := rows.Scan(&economy, &nick, &p.money, &p.handsWon); err != nil {
+			return nil, err
+		}
+		if cached := cache.players[playerKey{economy, nick}]; cached != nil {
+			p = cached
+		}
+		players = append(players, newPlayer(economy, nick, p))
+	}
+	return players, rows.Err()
+}
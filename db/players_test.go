@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// openTestDB gives the test an empty database in memory and an empty
+// player cache.
+func openTestDB(t *testing.T) {
+	t.Helper()
+	cache.Lock()
+	cache.players = make(map[playerKey]*cachedPlayer)
+	cache.Unlock()
+	if err := Initialize(":memory:"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close() })
+}
+
+// forgetCache drops the cached players, so the next reads come from the
+// database.
+func forgetCache(t *testing.T) {
+	t.Helper()
+	if err := Flush(); err != nil {
+		t.Fatal(err)
+	}
+	cache.Lock()
+	cache.players = make(map[playerKey]*cachedPlayer)
+	cache.Unlock()
+}
+
+func TestCreatePlayer(t *testing.T) {
+	openTestDB(t)
+	player, err := CreatePlayer(context.Background(), "net", "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Nick != "ann" || player.Economy != "net" || player.Money != StartingMoney || player.HandsWon != 0 {
+		t.Errorf("created %+v, want ann in net with %d chips", player, StartingMoney)
+	}
+	if _, err := CreatePlayer(context.Background(), "net", "ann"); err == nil {
+		t.Error("created ann twice")
+	}
+	if _, err := CreatePlayer(context.Background(), "other", "ann"); err != nil {
+		t.Errorf("creating ann in another economy: %v", err)
+	}
+}
+
+func TestGetPlayer(t *testing.T) {
+	openTestDB(t)
+	player, err := GetPlayer(context.Background(), "net", "ann")
+	if err != nil || player != nil {
+		t.Fatalf("GetPlayer of a new nick = %v, %v, want nil, nil", player, err)
+	}
+	if _, err := CreatePlayer(context.Background(), "net", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	forgetCache(t)
+	player, err = GetPlayer(context.Background(), "net", "ann")
+	if err != nil || player == nil || player.Money != StartingMoney {
+		t.Fatalf("GetPlayer = %+v, %v, want ann with %d chips", player, err, StartingMoney)
+	}
+	if player, _ := GetPlayer(context.Background(), "other", "ann"); player != nil {
+		t.Error("ann has a bankroll in an economy they never played in")
+	}
+}
+
+func TestGetOrCreatePlayer(t *testing.T) {
+	openTestDB(t)
+	player, err := GetOrCreatePlayer(context.Background(), "net", "ann")
+	if err != nil || player.Money != StartingMoney {
+		t.Fatalf("GetOrCreatePlayer = %+v, %v, want a new player", player, err)
+	}
+	player.Money = 40
+	UpdatePlayer(player)
+	if player, _ := GetOrCreatePlayer(context.Background(), "net", "ann"); player.Money != 40 {
+		t.Errorf("GetOrCreatePlayer gave ann %d chips, want the 40 saved", player.Money)
+	}
+}
+
+func TestUpdatePlayer(t *testing.T) {
+	openTestDB(t)
+	player, err := CreatePlayer(context.Background(), "net", "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	player.Money, player.HandsWon = 1500, 3
+	if err := UpdatePlayer(player); err != nil {
+		t.Fatal(err)
+	}
+	if money, handsWon, _ := GetPlayerStats("net", "ann"); money != 1500 || handsWon != 3 {
+		t.Errorf("cached stats are %d chips and %d hands won, want 1500 and 3", money, handsWon)
+	}
+	forgetCache(t)
+	if money, handsWon, err := GetPlayerStats("net", "ann"); err != nil || money != 1500 || handsWon != 3 {
+		t.Errorf("flushed stats are %d chips and %d hands won (%v), want 1500 and 3", money, handsWon, err)
+	}
+}
+
+func TestUpdateHandsWon(t *testing.T) {
+	openTestDB(t)
+	if _, err := CreatePlayer(context.Background(), "net", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	forgetCache(t)
+	// Uncached, it's written straight to the database.
+	if err := UpdateHandsWon("net", "ann", 7); err != nil {
+		t.Fatal(err)
+	}
+	if money, handsWon, _ := GetPlayerStats("net", "ann"); money != StartingMoney || handsWon != 7 {
+		t.Errorf("stats are %d chips and %d hands won, want %d and 7", money, handsWon, StartingMoney)
+	}
+	// Cached, it waits for the next Flush.
+	if _, err := GetPlayer(context.Background(), "net", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateHandsWon("net", "ann", 8); err != nil {
+		t.Fatal(err)
+	}
+	forgetCache(t)
+	if _, handsWon, _ := GetPlayerStats("net", "ann"); handsWon != 8 {
+		t.Errorf("%d hands won after the flush, want 8", handsWon)
+	}
+}
+
+func TestDeletePlayer(t *testing.T) {
+	openTestDB(t)
+	player, err := CreatePlayer(context.Background(), "net", "ann")
+	if err != nil {
+		t.Fatal(err)
+	}
+	player.Money = 20
+	UpdatePlayer(player)
+	if _, err := CreatePlayer(context.Background(), "other", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeletePlayer(context.Background(), "net", "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if player, err := GetPlayer(context.Background(), "net", "ann"); err != nil || player != nil {
+		t.Errorf("GetPlayer after DeletePlayer = %+v, %v, want nil, nil", player, err)
+	}
+	forgetCache(t)
+	if player, _ := GetPlayer(context.Background(), "net", "ann"); player != nil {
+		t.Error("the unsaved bankroll came back after the flush")
+	}
+	if player, _ := GetPlayer(context.Background(), "other", "ann"); player == nil {
+		t.Error("DeletePlayer removed ann from another economy")
+	}
+}
+
+func TestListPlayers(t *testing.T) {
+	openTestDB(t)
+	for _, key := range []playerKey{{"b", "cat"}, {"a", "bob"}, {"a", "ann"}} {
+		if _, err := CreatePlayer(context.Background(), key.economy, key.nick); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := RecordTransaction("a", "ann", -10, "test"); err != nil {
+		t.Fatal(err)
+	}
+	players, err := ListPlayers(context.Background(), 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, player := range players {
+		got = append(got, player.Economy+"/"+player.Nick)
+	}
+	if want := "a/ann a/bob b/cat"; strings.Join(got, " ") != want {
+		t.Errorf("ListPlayers = %v, want %s without the house", got, want)
+	}
+	if players[0].Money != StartingMoney-10 {
+		t.Errorf("ann has %d chips, want %d", players[0].Money, StartingMoney-10)
+	}
+	players, err = ListPlayers(context.Background(), 1, 1)
+	if err != nil || len(players) != 1 || players[0].Nick != "bob" {
+		t.Errorf("ListPlayers(context.Background(), 1, 1) = %v, %v, want bob", players, err)
+	}
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Profile is what a player shows other players about themselves, and the
+// settings they keep to themselves.
+type Profile struct {
+	Nick    string `json:"nick"`
+	Avatar  string `json:"avatar,omitempty"`
+	Color   string `json:"color,omitempty"`
+	Tagline string `json:"tagline,omitempty"`
+	// PotOdds adds the amount to call and the pot odds to the notice
+	// telling the player it's their turn.
+	PotOdds bool `json:"-"`
+	// StreetCards sends the player their hole cards again on every new
+	// street, with the board and their best hand.
+	StreetCards bool `json:"-"`
+	// TurnPrefix starts the channel's turn announcement with the player's
+	// nick, for clients that only alert on a line starting with it, and
+	// TurnPing also sends them a short private message.
+	TurnPrefix bool `json:"-"`
+	TurnPing   bool `json:"-"`
+}
+
+func createProfileTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS profiles (
+			nick TEXT PRIMARY KEY,
+			avatar TEXT DEFAULT '',
+			color TEXT DEFAULT '',
+			tagline TEXT DEFAULT '',
+			pot_odds INTEGER NOT NULL DEFAULT 0,
+			street_cards INTEGER NOT NULL DEFAULT 0,
+			turn_prefix INTEGER NOT NULL DEFAULT 0,
+			turn_ping INTEGER NOT NULL DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	for _, column := range []string{"pot_odds", "street_cards", "turn_prefix", "turn_ping"} {
+		exists, err := hasColumn("profiles", column)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := exec("ALTER TABLE profiles ADD COLUMN " + column + " INTEGER NOT NULL DEFAULT 0"); err != nil {
+				return fmt.Errorf("failed to migrate profiles: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetProfile returns nick's profile, empty if they never set one.
+func GetProfile(nick string) (Profile, error) {
+	profile := Profile{Nick: nick}
+	err := queryRow("SELECT avatar, color, tagline, pot_odds, street_cards, turn_prefix, turn_ping FROM profiles WHERE nick = ?", nick).
+		Scan(&profile.Avatar, &profile.Color, &profile.Tagline, &profile.PotOdds, &profile.StreetCards, &profile.TurnPrefix, &profile.TurnPing)
+	if err == sql.ErrNoRows {
+		return profile, nil
+	}
+	return profile, err
+}
+
+// SaveProfile stores profile, replacing the player's old one.
+func SaveProfile(profile Profile) error {
+	_, err := exec(`
+		INSERT INTO profiles (nick, avatar, color, tagline, pot_odds, street_cards, turn_prefix, turn_ping) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (nick) DO UPDATE SET avatar = excluded.avatar, color = excluded.color, tagline = excluded.tagline,
+			pot_odds = excluded.pot_odds, street_cards = excluded.street_cards,
+			turn_prefix = excluded.turn_prefix, turn_ping = excluded.turn_ping
+	`, profile.Nick, profile.Avatar, profile.Color, profile.Tagline, profile.PotOdds, profile.StreetCards, profile.TurnPrefix, profile.TurnPing)
+	return err
+}
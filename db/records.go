@@ -0,0 +1,109 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Kinds of channel record.
+const (
+	RecordPot  = "pot"
+	RecordHand = "hand"
+)
+
+// Record is the best of one kind at a channel on one day: the biggest pot
+// won, or the strongest hand shown down. For hands, Value is the hand's
+// category and Hand its name.
+type Record struct {
+	Channel string
+	Kind    string
+	Day     string // 2006-01-02
+	Nick    string
+	Value   int
+	Hand    string
+	SetAt   time.Time
+}
+
+func createRecordTable() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS records (
+			channel TEXT,
+			kind TEXT,
+			day TEXT,
+			nick TEXT,
+			value INTEGER,
+			hand TEXT DEFAULT '',
+			set_at DATETIME,
+			PRIMARY KEY (channel, kind, day)
+		)
+	`)
+	return err
+}
+
+// DayRecord returns the channel's record of kind on day, or nil if there
+// is none yet.
+func DayRecord(channel, kind, day string) (*Record, error) {
+	return scanRecord(queryRow(`
+		SELECT channel, kind, day, nick, value, hand, set_at FROM records
+		WHERE channel = ? AND kind = ? AND day = ?
+	`, channel, kind, day))
+}
+
+// AllTimeRecord returns the channel's best ever of kind, the earliest if
+// several days tie, or nil if there is none yet.
+func AllTimeRecord(channel, kind string) (*Record, error) {
+	return scanRecord(queryRow(`
+		SELECT channel, kind, day, nick, value, hand, set_at FROM records
+		WHERE channel = ? AND kind = ?
+		ORDER BY value DESC, set_at ASC LIMIT 1
+	`, channel, kind))
+}
+
+func scanRecord(row *row) (*Record, error) {
+	var r Record
+	err := row.Scan(&r.Channel, &r.Kind, &r.Day, &r.Nick, &r.Value, &r.Hand, &r.SetAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SaveRecord stores r as the record of its kind for its channel and day.
+func SaveRecord(r Record) error {
+	_, err := exec(`
+		INSERT INTO records (channel, kind, day, nick, value, hand, set_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (channel, kind, day) DO UPDATE SET
+			nick = excluded.nick, value = excluded.value, hand = excluded.hand, set_at = excluded.set_at
+	`, r.Channel, r.Kind, r.Day, r.Nick, r.Value, r.Hand, r.SetAt)
+	return err
+}
+
+// RecordsSince returns the channel's daily records that were also all-time
+// records when they were set, since the given time, oldest first.
+func RecordsSince(channel string, since time.Time) ([]Record, error) {
+	rows, err := query(`
+		SELECT channel, kind, day, nick, value, hand, set_at FROM records r
+		WHERE channel = ? AND set_at >= ? AND NOT EXISTS (
+			SELECT 1 FROM records earlier
+			WHERE earlier.channel = r.channel AND earlier.kind = r.kind
+			AND earlier.set_at < r.set_at AND earlier.value >= r.value
+		)
+		ORDER BY set_at
+	`, channel, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.Channel, &r.Kind, &r.Day, &r.Nick, &r.Value, &r.Hand, &r.SetAt); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Season is a stretch of play whose standings are kept when it ends. EndedAt
+// is zero while it's in play.
+type Season struct {
+	Number    int
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+// SeasonStanding is where a player finished a season in one economy, by
+// the chips they won. Trophy marks the top finishers, who also got Bonus.
+type SeasonStanding struct {
+	Season  int
+	Economy string
+	Place   int
+	Nick    string
+	Net     int
+	Hands   int
+	Bonus   int
+	Trophy  bool
+}
+
+func createSeasonTables() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS seasons (
+			number INTEGER PRIMARY KEY,
+			started_at DATETIME,
+			ended_at DATETIME
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = exec(`
+		CREATE TABLE IF NOT EXISTS season_standings (
+			season INTEGER,
+			economy TEXT NOT NULL DEFAULT '',
+			place INTEGER,
+			nick TEXT,
+			net INTEGER,
+			hands INTEGER,
+			bonus INTEGER NOT NULL DEFAULT 0,
+			trophy INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (season, economy, place)
+		)
+	`)
+	return err
+}
+
+// CurrentSeason returns the season in play, or nil before the first one.
+func CurrentSeason() (*Season, error) {
+	return scanSeason(queryRow("SELECT number, started_at, ended_at FROM seasons WHERE ended_at IS NULL ORDER BY number DESC LIMIT 1"))
+}
+
+// GetSeason returns season number, or nil if there's no such season.
+func GetSeason(number int) (*Season, error) {
+	return scanSeason(queryRow("SELECT number, started_at, ended_at FROM seasons WHERE number = ?", number))
+}
+
+func scanSeason(row *row) (*Season, error) {
+	var s Season
+	var ended sql.NullTime
+	err := row.Scan(&s.Number, &s.StartedAt, &ended)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.EndedAt = ended.Time
+	return &s, nil
+}
+
+// StartSeason starts the first season at the given time.
+func StartSeason(at time.Time) (*Season, error) {
+	if _, err := exec("INSERT INTO seasons (number, started_at) VALUES ((SELECT COALESCE(MAX(number), 0) + 1 FROM seasons), ?)", at); err != nil {
+		return nil, err
+	}
+	return CurrentSeason()
+}
+
+// EndSeason ends season at the given time and starts the next one, all at
+// once: it stores the standings, pays each their Bonus into their bankroll
+// through the ledger, and returns the new season.
+func EndSeason(season *Season, at time.Time, standings []SeasonStanding) (*Season, error) {
+	tx, err := beginPlayers()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range standings {
+		if _, err := tx.Exec("INSERT INTO season_standings (season, economy, place, nick, net, hands, bonus, trophy) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			season.Number, s.Economy, s.Place, s.Nick, s.Net, s.Hands, s.Bonus, s.Trophy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if s.Bonus == 0 {
+			continue
+		}
+		if err := recordTransaction(tx, s.Economy, s.Nick, s.Bonus, fmt.Sprintf("season %d bonus", season.Number)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE seasons SET ended_at = ? WHERE number = ?", at, season.Number); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err := tx.Exec("INSERT INTO seasons (number, started_at) VALUES (?, ?)", season.Number+1, at); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &Season{Number: season.Number + 1, StartedAt: at}, nil
+}
+
+// SeasonStandings returns the final standings of season number in economy,
+// best first.
+func SeasonStandings(number int, economy string) ([]SeasonStanding, error) {
+	return scanStandings(query(`
+		SELECT season, economy, place, nick, net, hands, bonus, trophy FROM season_standings
+		WHERE season = ? AND economy = ? ORDER BY place
+	`, number, economy))
+}
+
+// Trophies returns the seasons nick finished among the top in economy,
+// oldest first.
+func Trophies(economy, nick string) ([]SeasonStanding, error) {
+	return scanStandings(query(`
+		SELECT season, economy, place, nick, net, hands, bonus, trophy FROM season_standings
+		WHERE economy = ? AND nick = ? AND trophy = 1 ORDER BY season
+	`, economy, nick))
+}
+
+func scanStandings(rows *rows, err error) ([]SeasonStanding, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var standings []SeasonStanding
+	for rows.Next() {
+		var s SeasonStanding
+		if err := rows.Scan(&s.Season, &s.Economy, &s.Place, &s.Nick, &s.Net, &s.Hands, &s.Bonus, &s.Trophy); err != nil {
+			return nil, err
+		}
+		standings = append(standings, s)
+	}
+	return standings, rows.Err()
+}
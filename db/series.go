@@ -0,0 +1,111 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Series is a tournament series: players earn points in the games of every
+// channel while it runs, and the top Qualifiers are seeded into its
+// championship, whose winner is its Champion. EndedAt is zero while it runs.
+type Series struct {
+	ID         int
+	Name       string
+	Qualifiers int
+	StartedAt  time.Time
+	EndedAt    time.Time
+	Champion   string
+}
+
+// SeriesPoints is a player's points in a series.
+type SeriesPoints struct {
+	Nick   string
+	Points int
+}
+
+func createSeriesTables() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS series (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT,
+			qualifiers INTEGER,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			ended_at DATETIME,
+			champion TEXT NOT NULL DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = exec(`
+		CREATE TABLE IF NOT EXISTS series_points (
+			series INTEGER,
+			nick TEXT,
+			points INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (series, nick)
+		)
+	`)
+	return err
+}
+
+// CurrentSeries returns the series running, or nil if there's none.
+func CurrentSeries() (*Series, error) {
+	var s Series
+	var ended sql.NullTime
+	err := queryRow("SELECT id, name, qualifiers, started_at, ended_at, champion FROM series WHERE ended_at IS NULL ORDER BY id DESC LIMIT 1").
+		Scan(&s.ID, &s.Name, &s.Qualifiers, &s.StartedAt, &ended, &s.Champion)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.EndedAt = ended.Time
+	return &s, nil
+}
+
+// StartSeries starts a series called name whose championship seats the top
+// qualifiers.
+func StartSeries(name string, qualifiers int) (*Series, error) {
+	if _, err := exec("INSERT INTO series (name, qualifiers) VALUES (?, ?)", name, qualifiers); err != nil {
+		return nil, err
+	}
+	return CurrentSeries()
+}
+
+// EndSeries ends series, crowning champion, who is empty if the series
+// ended without a championship.
+func EndSeries(series int, champion string) error {
+	_, err := exec("UPDATE series SET ended_at = CURRENT_TIMESTAMP, champion = ? WHERE id = ?", champion, series)
+	return err
+}
+
+// AwardSeriesPoints adds points to nick's total in the series running, if
+// there is one.
+func AwardSeriesPoints(nick string, points int) error {
+	_, err := exec(`
+		INSERT INTO series_points (series, nick, points)
+		SELECT id, ?, ? FROM series WHERE ended_at IS NULL
+		ON CONFLICT (series, nick) DO UPDATE SET points = points + excluded.points
+	`, nick, points)
+	return err
+}
+
+// SeriesLeaders returns up to limit players with points in series, the most
+// first.
+func SeriesLeaders(series, limit int) ([]SeriesPoints, error) {
+	rows, err := query("SELECT nick, points FROM series_points WHERE series = ? AND points > 0 ORDER BY points DESC, nick LIMIT ?", series, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var leaders []SeriesPoints
+	for rows.Next() {
+		var p SeriesPoints
+		if err := rows.Scan(&p.Nick, &p.Points); err != nil {
+			return nil, err
+		}
+		leaders = append(leaders, p)
+	}
+	return leaders, rows.Err()
+}
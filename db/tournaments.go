@@ -0,0 +1,77 @@
+package db
+
+import "poker-bot/game"
+
+func createTournamentTables() error {
+	_, err := exec(`
+		CREATE TABLE IF NOT EXISTS tournament_standings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			tournament INTEGER,
+			channel TEXT,
+			place INTEGER,
+			nick TEXT,
+			prize INTEGER,
+			finished_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = exec(`
+		CREATE TABLE IF NOT EXISTS game_standings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game INTEGER,
+			channel TEXT,
+			place INTEGER,
+			nick TEXT,
+			stack INTEGER,
+			finished_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// SaveStandings stores the final standings of a tournament at channel.
+// Rows of one tournament share a tournament number.
+func SaveStandings(channel string, standings []game.Standing) error {
+	tx, err := begin()
+	if err != nil {
+		return err
+	}
+	var tournament int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(tournament), 0) + 1 FROM tournament_standings").Scan(&tournament); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, standing := range standings {
+		if _, err := tx.Exec("INSERT INTO tournament_standings (tournament, channel, place, nick, prize) VALUES (?, ?, ?, ?, ?)",
+			tournament, channel, standing.Place, standing.Nick, standing.Prize); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveGameStandings stores the final standings of a game at channel that
+// wasn't a tournament, ranked by stack. Rows of one game share a game
+// number.
+func SaveGameStandings(channel string, standings []game.Standing) error {
+	tx, err := begin()
+	if err != nil {
+		return err
+	}
+	var number int
+	if err := tx.QueryRow("SELECT COALESCE(MAX(game), 0) + 1 FROM game_standings").Scan(&number); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, standing := range standings {
+		if _, err := tx.Exec("INSERT INTO game_standings (game, channel, place, nick, stack) VALUES (?, ?, ?, ?, ?)",
+			number, channel, standing.Place, standing.Nick, standing.Stack); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,103 @@
+// Package digest sums up a week of play at each channel from the hand
+// histories and the records table.
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"poker-bot/collusion"
+	"poker-bot/db"
+)
+
+// Summary is a channel's week.
+type Summary struct {
+	Channel string
+	Hands   int
+	// Winner is the player who won the most chips over the week, counting
+	// pots won less what they put in. Blinds and antes aren't in the
+	// histories, so they aren't counted.
+	Winner string
+	Won    int
+	// BiggestPot is the biggest pot won, and PotWinner who won it.
+	BiggestPot int
+	PotWinner  string
+	// Records are the channel records set during the week.
+	Records []db.Record
+}
+
+// Weekly sums up the hands played since the given time, per channel. Only
+// channels where hands were played are in the map.
+func Weekly(since time.Time) (map[string]*Summary, error) {
+	histories, err := db.HandHistories(since)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*Summary)
+	net := make(map[string]map[string]int) // channel -> nick -> chips won
+	for _, history := range histories {
+		s := summaries[history.Channel]
+		if s == nil {
+			s = &Summary{Channel: history.Channel}
+			summaries[history.Channel] = s
+			net[history.Channel] = make(map[string]int)
+		}
+		hand := collusion.ParseHand(history.Channel, history.Lines)
+		s.Hands++
+		for nick, invested := range hand.Invested {
+			net[history.Channel][nick] -= invested
+		}
+		if hand.Winner == "" {
+			continue
+		}
+		for nick, won := range hand.Won {
+			net[history.Channel][nick] += won
+		}
+		if hand.Pot > s.BiggestPot {
+			s.BiggestPot, s.PotWinner = hand.Pot, hand.Winner
+		}
+	}
+
+	for channel, s := range summaries {
+		nicks := make([]string, 0, len(net[channel]))
+		for nick := range net[channel] {
+			nicks = append(nicks, nick)
+		}
+		sort.Strings(nicks)
+		for _, nick := range nicks {
+			if won := net[channel][nick]; won > s.Won {
+				s.Winner, s.Won = nick, won
+			}
+		}
+		if s.Records, err = db.RecordsSince(channel, since); err != nil {
+			return nil, err
+		}
+	}
+	return summaries, nil
+}
+
+// String is the digest as the bot posts it.
+func (s *Summary) String() string {
+	parts := []string{fmt.Sprintf("This week at %s: %d hands played", s.Channel, s.Hands)}
+	if s.Winner != "" {
+		parts = append(parts, fmt.Sprintf("biggest winner %s (+%d)", s.Winner, s.Won))
+	}
+	if s.PotWinner != "" {
+		parts = append(parts, fmt.Sprintf("biggest pot %d won by %s", s.BiggestPot, s.PotWinner))
+	}
+	if len(s.Records) > 0 {
+		records := make([]string, len(s.Records))
+		for i, r := range s.Records {
+			if r.Kind == db.RecordHand {
+				records[i] = fmt.Sprintf("%s's %s", r.Nick, r.Hand)
+			} else {
+				records[i] = fmt.Sprintf("%s's pot of %d", r.Nick, r.Value)
+			}
+		}
+		parts = append(parts, "records set: "+strings.Join(records, ", "))
+	}
+	return strings.Join(parts, ", ") + "."
+}
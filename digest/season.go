@@ -0,0 +1,96 @@
+package digest
+
+import (
+	"sort"
+	"time"
+
+	"poker-bot/collusion"
+	"poker-bot/db"
+)
+
+// Standing is a player's results over a season, or on a leaderboard, in
+// one economy.
+type Standing struct {
+	Nick  string
+	Net   int
+	Hands int
+}
+
+// Standings ranks the players of the hands played since the given time by
+// the chips they won, counted as for the weekly winner, separately in each
+// economy: economyOf names the economy a channel's hands were played in.
+// Ties go to the player of more hands, then by nick.
+func Standings(since time.Time, economyOf func(channel string) string) (map[string][]Standing, error) {
+	histories, err := db.HandHistories(since)
+	if err != nil {
+		return nil, err
+	}
+	return rank(histories, economyOf), nil
+}
+
+// Leaderboard ranks the players of the hands filter picks that were played
+// in economy, as Standings does.
+func Leaderboard(economy string, filter db.HandFilter, economyOf func(channel string) string) ([]Standing, error) {
+	histories, err := db.FilteredHandHistories(filter)
+	if err != nil {
+		return nil, err
+	}
+	var played []db.HandHistory
+	for _, history := range histories {
+		if economyOf(history.Channel) == economy {
+			played = append(played, history)
+		}
+	}
+	return rank(played, economyOf)[economy], nil
+}
+
+func rank(histories []db.HandHistory, economyOf func(channel string) string) map[string][]Standing {
+	players := make(map[string]map[string]*Standing) // economy -> nick -> standing
+	for _, history := range histories {
+		economy := economyOf(history.Channel)
+		if players[economy] == nil {
+			players[economy] = make(map[string]*Standing)
+		}
+		standing := func(nick string) *Standing {
+			s := players[economy][nick]
+			if s == nil {
+				s = &Standing{Nick: nick}
+				players[economy][nick] = s
+			}
+			return s
+		}
+		hand := collusion.ParseHand(history.Channel, history.Lines)
+		for nick, invested := range hand.Invested {
+			s := standing(nick)
+			s.Net -= invested
+			s.Hands++
+		}
+		for nick, won := range hand.Won {
+			s := standing(nick)
+			s.Net += won
+			if _, invested := hand.Invested[nick]; !invested {
+				s.Hands++
+			}
+		}
+	}
+
+	standings := make(map[string][]Standing, len(players))
+	for economy, byNick := range players {
+		ranked := make([]Standing, 0, len(byNick))
+		for _, s := range byNick {
+			ranked = append(ranked, *s)
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			a, b := ranked[i], ranked[j]
+			if a.Net != b.Net {
+				return a.Net > b.Net
+			}
+			if a.Hands != b.Hands {
+				return a.Hands > b.Hands
+			}
+			return a.Nick < b.Nick
+		})
+		standings[economy] = ranked
+	}
+	return standings
+}
@@ -0,0 +1,165 @@
+// Package flavor is the bot's table talk: now and then it comments on what
+// happens in a game with a line picked from a text pack.
+package flavor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// chance is the odds that an event with lines in the pack gets one.
+	chance = 1.0 / 3
+	// interval is the least time between two comments in a channel.
+	interval = 30 * time.Second
+	// tonePack prefixes the tone packs' keys among the channels'.
+	tonePack = "tone:"
+)
+
+// Pack maps event keys to the lines that can be said when one happens. Lines
+// are text/template templates executed with the event.
+type Pack map[string][]string
+
+// DefaultPack is used in channels without a pack of their own, and for the
+// keys a channel's pack leaves out.
+var DefaultPack = Pack{
+	"all_in": {
+		"Chips in the middle. Somebody's going home happy.",
+		"All in! Hold your breath.",
+	},
+	"showdown": {
+		"{{.Nick}} drags a pot of {{.Amount}}.",
+		"Ship it to {{.Nick}}!",
+	},
+	"showdown:straight": {
+		"{{.Nick}} gets there with the straight!",
+		"Gutshot? Open-ender? Who cares, {{.Nick}} has the straight.",
+	},
+	"showdown:flush": {
+		"Five of a suit for {{.Nick}}. Pretty.",
+	},
+	"showdown:full house": {
+		"{{.Nick}} fills up! Full house.",
+	},
+	"showdown:four of a kind": {
+		"Quads! {{.Nick}} won't see that again this week.",
+	},
+	"showdown:straight flush": {
+		"A straight flush. {{.Nick}}, frame that one.",
+	},
+	"showdown:royal flush": {
+		"A ROYAL FLUSH. Everybody take a screenshot.",
+	},
+	"win": {
+		"{{.Nick}} takes it down without a fight.",
+		"Nobody wanted to play with {{.Nick}}.",
+	},
+	"street:river": {
+		"Here comes the river...",
+	},
+}
+
+// Engine picks flavor lines per channel, rate limited so the table talk
+// doesn't drown out the game.
+type Engine struct {
+	packs map[string]map[string][]*template.Template // channel or tone -> key -> lines
+	last  map[string]time.Time
+	rand  *rand.Rand
+}
+
+// Load reads the packs in dir: default.json replaces DefaultPack,
+// <tone>.json, such as classic.json, is used in the channels that speak in
+// that tone, and <channel>.json, such as #poker.json, is used in that
+// channel. A missing dir leaves just DefaultPack.
+func Load(dir string) (*Engine, error) {
+	e := &Engine{
+		packs: make(map[string]map[string][]*template.Template),
+		last:  make(map[string]time.Time),
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if err := e.add("", DefaultPack); err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var pack Pack
+		if err := json.Unmarshal(data, &pack); err != nil {
+			return nil, fmt.Errorf("invalid flavor pack %s: %v", path, err)
+		}
+		channel := strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".json"))
+		switch {
+		case channel == "default":
+			channel = ""
+		case IsTone(channel):
+			channel = tonePack + channel
+		}
+		if err := e.add(channel, pack); err != nil {
+			return nil, fmt.Errorf("invalid flavor pack %s: %v", path, err)
+		}
+	}
+	return e, nil
+}
+
+func (e *Engine) add(channel string, pack Pack) error {
+	lines := make(map[string][]*template.Template)
+	for key, texts := range pack {
+		for _, text := range texts {
+			line, err := template.New(key).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return err
+			}
+			lines[key] = append(lines[key], line)
+		}
+	}
+	e.packs[channel] = lines
+	return nil
+}
+
+// Comment returns a line for an event at channel, which speaks in tone, or
+// false if the table talk stays quiet this time. keys go from most to least
+// specific; the first one with lines in the channel's pack, or else the
+// tone's, or else the default pack, is used.
+func (e *Engine) Comment(channel, tone string, keys []string, event interface{}) (string, bool) {
+	channel = strings.ToLower(channel)
+	if time.Since(e.last[channel]) < interval {
+		return "", false
+	}
+
+	lines := e.lines(channel, tone, keys)
+	if len(lines) == 0 || e.rand.Float64() >= chance {
+		return "", false
+	}
+
+	var out bytes.Buffer
+	if err := lines[e.rand.Intn(len(lines))].Execute(&out, event); err != nil {
+		return "", false
+	}
+	e.last[channel] = time.Now()
+	return out.String(), true
+}
+
+func (e *Engine) lines(channel, tone string, keys []string) []*template.Template {
+	for _, key := range keys {
+		for _, pack := range []string{channel, tonePack + tone, ""} {
+			if lines := e.packs[pack][key]; len(lines) > 0 {
+				return lines
+			}
+		}
+	}
+	return nil
+}
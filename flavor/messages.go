@@ -0,0 +1,75 @@
+package flavor
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// Tones the bot can speak in, picked per channel in the config.
+// FamilyFriendly keeps it clean; Classic is the bot's old voice, salty
+// language and all.
+const (
+	FamilyFriendly = "family-friendly"
+	Classic        = "classic"
+)
+
+// Details fill in a message's template. Each message uses the fields it
+// needs.
+type Details struct {
+	Nick   string
+	Amount int
+	Count  int
+	Wait   string
+	Hand   string
+}
+
+// Messages are the catalog of lines the bot always says, unlike table talk,
+// such as a cheater's penalty: tone -> key -> text/template template. A key
+// a tone leaves out is said in FamilyFriendly.
+var Messages = map[string]map[string]string{
+	FamilyFriendly: {
+		"cheat:caught":      "{{.Nick}} tried to cheat and got caught! They're out of the round and lose {{.Amount}} chips to the pot as penalty.",
+		"cheat:bodyguard":   "{{.Nick}} got caught cheating, but their bodyguard stares the dealer down. No penalty, but they're out of the round.",
+		"slow_roll":         "{{.Nick}} took {{.Wait}} to get to showdown with a {{.Hand}}. Slow roll!",
+		"slow_roll:penalty": "{{.Nick}} has slow rolled {{.Count}} times, so their turn clock is cut to {{.Wait}}.",
+	},
+	Classic: {
+		"cheat:caught": "{{.Nick}} is a bitch and tried to cheat! They're kicked from the round and lose {{.Amount}} chips to the pot as penalty.",
+	},
+}
+
+var messages = parseMessages()
+
+func parseMessages() map[string]map[string]*template.Template {
+	parsed := make(map[string]map[string]*template.Template, len(Messages))
+	for tone, lines := range Messages {
+		parsed[tone] = make(map[string]*template.Template, len(lines))
+		for key, text := range lines {
+			parsed[tone][key] = template.Must(template.New(key).Option("missingkey=error").Parse(text))
+		}
+	}
+	return parsed
+}
+
+// IsTone reports whether the catalog has messages in tone.
+func IsTone(tone string) bool {
+	_, ok := Messages[tone]
+	return ok
+}
+
+// Message returns the catalog's line for key in tone, or in FamilyFriendly
+// if tone has none. It's empty for a key the catalog doesn't have.
+func Message(tone, key string, details Details) string {
+	line := messages[tone][key]
+	if line == nil {
+		line = messages[FamilyFriendly][key]
+	}
+	if line == nil {
+		return ""
+	}
+	var out bytes.Buffer
+	if err := line.Execute(&out, details); err != nil {
+		return ""
+	}
+	return out.String()
+}
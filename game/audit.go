@@ -0,0 +1,48 @@
+package game
+
+import (
+	"fmt"
+
+	"poker-bot/models"
+)
+
+// ChipTotal is every chip on the table: the players' stacks plus the pot.
+// Side pots are a partition of the pot, not chips on top of it.
+func ChipTotal(g Game) int {
+	total := g.GetPot()
+	for _, player := range g.GetPlayers() {
+		total += player.Money
+	}
+	return total
+}
+
+// ChipAudit checks that a hand neither creates nor destroys chips. It is
+// taken at the start of a hand; chips that legitimately enter or leave the
+// table afterwards (a player leaving, a rake, a penalty) must be recorded
+// with Leave or Adjust before the next Check.
+type ChipAudit struct {
+	expected int
+}
+
+func NewChipAudit(g Game) *ChipAudit {
+	return &ChipAudit{expected: ChipTotal(g)}
+}
+
+// Leave records a player taking their stack away from the table.
+func (a *ChipAudit) Leave(player *models.Player) {
+	a.expected -= player.Money
+}
+
+// Adjust records chips entering (positive) or leaving (negative) the table
+// outside of the players' stacks.
+func (a *ChipAudit) Adjust(delta int) {
+	a.expected += delta
+}
+
+// Check returns an error if the chips on the table no longer add up.
+func (a *ChipAudit) Check(g Game) error {
+	if total := ChipTotal(g); total != a.expected {
+		return fmt.Errorf("chip total is %d, expected %d (%+d)", total, a.expected, total-a.expected)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package game
+
+import "poker-bot/models"
+
+// Dealer holds a game's deck and deals from it. The games draw their cards
+// through it rather than slicing a deck themselves, so the order the cards
+// come in can be swapped out: crypto/rand at the tables, a seed for
+// verified shuffles, and a fixed order for scripted hands.
+type Dealer interface {
+	// Shuffle gathers a full deck for a new hand and puts it in order.
+	Shuffle()
+	// Draw deals n cards from the top of the deck, or as many as are left.
+	Draw(n int) []models.Card
+	// Burn discards n cards from the top of the deck unseen.
+	Burn(n int)
+	// Return puts cards back into play under the deck, shuffled the way
+	// the dealer shuffles, such as a draw game's muck once the deck runs
+	// out.
+	Return(cards []models.Card)
+	// Stack moves card to the top of the deck, if it's still in it, and
+	// leaves the others in order, so no card is added or lost. It reports
+	// whether it found the card.
+	Stack(card models.Card) bool
+	// Remaining returns the cards left in the deck, top first. The caller
+	// must not modify them.
+	Remaining() []models.Card
+}
+
+// NewDealer returns the dealer the tables use, which shuffles with
+// crypto/rand.
+func NewDealer() Dealer {
+	return &deck{
+		fresh: func() []models.Card {
+			cards := GenerateDeck()
+			ShuffleDeck(cards)
+			return cards
+		},
+		mix: ShuffleDeck,
+	}
+}
+
+// NewSeededDealer returns a dealer that shuffles deterministically from
+// seed, so anyone holding the seed can reproduce the deal. Cards returned
+// mid-hand are shuffled from the seed too, so the whole hand stays
+// reproducible.
+func NewSeededDealer(seed []byte) Dealer {
+	muck := append(append([]byte{}, seed...), "muck"...)
+	return &deck{
+		fresh: func() []models.Card {
+			cards := GenerateDeck()
+			ShuffleDeckWithSeed(cards, seed)
+			return cards
+		},
+		mix: func(cards []models.Card) {
+			ShuffleDeckWithSeed(cards, muck)
+		},
+	}
+}
+
+// NewStackedDealer returns a dealer for tests and scripted hands whose
+// every deck starts with top, in order, followed by the rest of the cards
+// in GenerateDeck order. Returned cards go under the deck as they are.
+func NewStackedDealer(top []models.Card) Dealer {
+	return &deck{
+		fresh: func() []models.Card {
+			return StackDeck(top)
+		},
+		mix: func([]models.Card) {},
+	}
+}
+
+// deck is a Dealer that orders new decks with fresh and returned cards with
+// mix. It is empty until the first Shuffle.
+type deck struct {
+	cards []models.Card
+	fresh func() []models.Card
+	mix   func([]models.Card)
+}
+
+func (d *deck) Shuffle() {
+	d.cards = d.fresh()
+}
+
+func (d *deck) Draw(n int) []models.Card {
+	n = min(n, len(d.cards))
+	drawn := d.cards[:n:n]
+	d.cards = d.cards[n:]
+	return drawn
+}
+
+func (d *deck) Burn(n int) {
+	d.cards = d.cards[min(n, len(d.cards)):]
+}
+
+func (d *deck) Return(cards []models.Card) {
+	d.mix(cards)
+	d.cards = append(d.cards, cards...)
+}
+
+func (d *deck) Stack(card models.Card) bool {
+	for i, c := range d.cards {
+		if c == card {
+			copy(d.cards[1:i+1], d.cards[:i])
+			d.cards[0] = card
+			return true
+		}
+	}
+	return false
+}
+
+func (d *deck) Remaining() []models.Card {
+	return d.cards
+}
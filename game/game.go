@@ -7,6 +7,7 @@ import (
 
 type Game interface {
 	AddPlayer(*models.Player)
+	AddLatePlayer(*models.Player)
 	RemovePlayer(string)
 	FindPlayer(string) *models.Player
 	NextTurn()
@@ -19,6 +20,8 @@ type Game interface {
 	UpdateRiver()
 	EvaluateHands() *models.Player
 	GetType() string
+	Stakes() BlindLevel
+	Structure() Structure
 	GetPlayers() []*models.Player
 	GetDeck() []models.Card
 	GetRiver() []models.Card
@@ -28,17 +31,21 @@ type Game interface {
 	IsInProgress() bool
 	SetInProgress(bool)
 	IsRoundOver() bool
+	IsBettingRoundOver() bool
     AddToPot(amount int)
 	GetChannel() string
 	ResetRound()
 	CalculateSidePots()
 	GetStage() int
+	SetDeckSeed([]byte)
+	MaxPlayers() int
+	GetHandCount() int
 }
 
 type BaseGame struct {
 	Type       string
 	Players    []*models.Player
-	Deck       []models.Card
+	Dealer     Dealer
 	River      []models.Card
 	Pot        int
 	CurrentBet int
@@ -46,12 +53,51 @@ type BaseGame struct {
 	InProgress bool
 	Channel    string
 	Stage      int
+	HandCount  int
+	Posting    map[string]bool // late joiners who owe a big blind on their first hand
 }
 
 func (g *BaseGame) AddPlayer(player *models.Player) {
 	g.Players = append(g.Players, player)
 }
 
+// AddLatePlayer seats a player who joins after the game has started. They
+// post a big blind on their first hand unless they are already in the blinds.
+func (g *BaseGame) AddLatePlayer(player *models.Player) {
+	g.AddPlayer(player)
+	if g.Posting == nil {
+		g.Posting = make(map[string]bool)
+	}
+	g.Posting[player.Nick] = true
+}
+
+// PostLateBlinds takes a live big blind from every late joiner who is not
+// sitting in one of the blind seats this hand.
+func (g *BaseGame) PostLateBlinds(bigBlind int, blindSeats ...int) {
+	for i, player := range g.Players {
+		if !g.Posting[player.Nick] {
+			continue
+		}
+		delete(g.Posting, player.Nick)
+		if containsSeat(blindSeats, i) {
+			continue
+		}
+		post := min(bigBlind, player.Money)
+		player.Bet = post
+		player.Money -= post
+		g.Pot += post
+	}
+}
+
+func containsSeat(seats []int, seat int) bool {
+	for _, s := range seats {
+		if s == seat {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *BaseGame) RemovePlayer(nick string) {
 	for i, player := range g.Players {
 		if player.Nick == nick {
@@ -71,10 +117,84 @@ func (g *BaseGame) FindPlayer(nick string) *models.Player {
 }
 
 func (g *BaseGame) NextTurn() {
-	g.Turn = (g.Turn + 1) % len(g.Players)
-	for g.Players[g.Turn].Folded {
-		g.Turn = (g.Turn + 1) % len(g.Players)
+	g.SeatTurn(g.Turn + 1)
+}
+
+// SeatTurn gives the turn to the first player still in the hand at or after
+// seat, wrapping around the table.
+func (g *BaseGame) SeatTurn(seat int) {
+	for i := 0; i < len(g.Players); i++ {
+		turn := (seat + i) % len(g.Players)
+		if !g.Players[turn].Folded {
+			g.Turn = turn
+			return
+		}
+	}
+}
+
+// NewStreet clears the bets and actions of the previous betting round and
+// gives the turn to the first live player at or after seat.
+func (g *BaseGame) NewStreet(seat int) {
+	for _, player := range g.Players {
+		player.Bet = 0
+		player.Acted = false
+	}
+	g.CurrentBet = 0
+	g.SeatTurn(seat)
+}
+
+// IsBettingRoundOver reports whether every player still in the hand has
+// acted since the last raise and either matched the current bet or is all-in.
+// Once everyone else is all-in, a player who has matched the bet has no one
+// left to bet against, so they don't need to act.
+func (g *BaseGame) IsBettingRoundOver() bool {
+	if AllIn(g) {
+		for _, player := range g.Players {
+			if !player.Folded && player.Money > 0 && player.Bet < g.CurrentBet {
+				return false
+			}
+		}
+		return true
+	}
+
+	activePlayers := 0
+	for _, player := range g.Players {
+		if player.Folded {
+			continue
+		}
+		activePlayers++
+		if player.Money > 0 && (!player.Acted || player.Bet != g.CurrentBet) {
+			return false
+		}
+	}
+	return activePlayers > 0
+}
+
+// AllIn reports whether two or more players are still in the hand and at
+// most one of them has chips behind, so there can be no more betting.
+func AllIn(g interface{ GetPlayers() []*models.Player }) bool {
+	live, withChips := 0, 0
+	for _, player := range g.GetPlayers() {
+		if player.Folded {
+			continue
+		}
+		live++
+		if player.Money > 0 {
+			withChips++
+		}
 	}
+	return live >= 2 && withChips <= 1
+}
+
+// PotOdds returns what player has to put in to call, no more than their
+// stack, and the pot they would be calling into. toCall is 0 if they can
+// check.
+func PotOdds(g Game, player *models.Player) (toCall, pot int) {
+	toCall = min(g.GetCurrentBet()-player.Bet, player.Money)
+	if toCall < 0 {
+		toCall = 0
+	}
+	return toCall, g.GetPot()
 }
 
 func (g *BaseGame) Bet(player *models.Player, amount int) error {
@@ -89,12 +209,32 @@ func (g *BaseGame) Bet(player *models.Player, amount int) error {
 	g.Pot += amount
 	if player.Bet > g.CurrentBet {
 		g.CurrentBet = player.Bet
+		g.reopenAction(player)
 	}
+	player.Acted = true
 	return nil
 }
 
+// reopenAction makes everyone else act again after a raise.
+func (g *BaseGame) reopenAction(raiser *models.Player) {
+	for _, player := range g.Players {
+		if player != raiser {
+			player.Acted = false
+		}
+	}
+}
+
+// Call matches the current bet, or puts the player all in for what they
+// have left if that's less.
 func (g *BaseGame) Call(player *models.Player) error {
 	amountToCall := g.CurrentBet - player.Bet
+	if amountToCall > player.Money {
+		g.Pot += player.Money
+		player.Bet += player.Money
+		player.Money = 0
+		player.Acted = true
+		return nil
+	}
 	return g.Bet(player, amountToCall)
 }
 
@@ -107,9 +247,23 @@ func (g *BaseGame) Check(player *models.Player) error {
 	if player.Bet < g.CurrentBet {
 		return errors.New("cannot check, must call or raise")
 	}
+	player.Acted = true
 	return nil
 }
 
+// Reroll swaps the player's i'th card for the top card of the deck, which
+// it returns, and puts the old card back under the deck. It reports false,
+// changing nothing, if there's no such card or the deck is empty.
+func (g *BaseGame) Reroll(player *models.Player, i int) (models.Card, bool) {
+	if i < 0 || i >= len(player.Hand) || len(g.Dealer.Remaining()) == 0 {
+		return models.Card{}, false
+	}
+	card := g.Dealer.Draw(1)[0]
+	g.Dealer.Return([]models.Card{player.Hand[i]})
+	player.Hand[i] = card
+	return card, true
+}
+
 func (g *BaseGame) Fold(player *models.Player) {
 	player.Folded = true
 }
@@ -123,7 +277,7 @@ func (g *BaseGame) GetPlayers() []*models.Player {
 }
 
 func (g *BaseGame) GetDeck() []models.Card {
-	return g.Deck
+	return g.Dealer.Remaining()
 }
 
 func (g *BaseGame) GetRiver() []models.Card {
@@ -158,12 +312,17 @@ func (g *BaseGame) ResetRound() {
 	for _, player := range g.Players {
 		player.Bet = 0
 		player.Folded = false
+		player.Acted = false
 		player.Hand = make([]models.Card, 0)
 	}
 	g.Pot = 0
 	g.CurrentBet = 0
 	g.River = make([]models.Card, 0)
-	g.Deck = GenerateDeck()
+	g.HandCount++
+	if g.Dealer == nil {
+		g.Dealer = NewDealer()
+	}
+	g.Dealer.Shuffle()
 }
 
 
@@ -171,10 +330,34 @@ func (g *BaseGame) AddToPot(amount int) {
 	g.Pot += amount
 }
 
+func (g *BaseGame) GetHandCount() int {
+	return g.HandCount
+}
+
 func (g *BaseGame) GetStage() int {
 	return g.Stage
 }
 
+// SetScriptedDeck makes every following ResetRound deal from cards in order
+// instead of shuffling. A nil deck restores normal shuffling.
+func (g *BaseGame) SetScriptedDeck(cards []models.Card) {
+	if cards == nil {
+		g.Dealer = NewDealer()
+		return
+	}
+	g.Dealer = NewStackedDealer(cards)
+}
+
+// SetDeckSeed makes the next ResetRound shuffle deterministically from seed.
+// A nil seed restores the default crypto/rand shuffle.
+func (g *BaseGame) SetDeckSeed(seed []byte) {
+	if seed == nil {
+		g.Dealer = NewDealer()
+		return
+	}
+	g.Dealer = NewSeededDealer(seed)
+}
+
 func GenerateDeck() []models.Card {
 	suits := []string{"Hearts", "Diamonds", "Clubs", "Spades"}
 	values := []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
@@ -188,3 +371,32 @@ func GenerateDeck() []models.Card {
 
 	return deck
 }
+
+// EquityReporter is implemented by board games that can work out each live
+// player's chance of winning before the board is complete.
+type EquityReporter interface {
+	Equity() map[string]float64
+}
+
+// DeckStacker is implemented by board games that can rig the next card on
+// the board: StackNextCard puts the card left in the deck that best helps
+// nick on top of it, and returns it, or false if no board card is to come.
+type DeckStacker interface {
+	StackNextCard(nick string) (models.Card, bool)
+}
+
+// Showdown is implemented by games that can name and compare the players'
+// hands at showdown. The category ranks hands from 0 for high card to 9 for
+// a royal flush; CompareHands returns 1 if a's hand is better, -1 if b's is
+// and 0 for a tie.
+type Showdown interface {
+	DescribeHand(*models.Player) (name string, category int)
+	CompareHands(a, b *models.Player) int
+}
+
+// Rerolls is implemented by games that can swap one of a player's cards for
+// the top card of the deck, such as for a lucky charm. Every game built on
+// BaseGame does.
+type Rerolls interface {
+	Reroll(player *models.Player, i int) (models.Card, bool)
+}
@@ -0,0 +1,297 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"poker-bot/models"
+)
+
+// Scriptable is implemented by every game that embeds BaseGame.
+type Scriptable interface {
+	Game
+	SetScriptedDeck([]models.Card)
+}
+
+// StackDeck returns a full 52-card deck that starts with top, in order,
+// followed by the remaining cards in GenerateDeck order. Scripts only need
+// to list the cards they care about.
+func StackDeck(top []models.Card) []models.Card {
+	deck := make([]models.Card, 0, 52)
+	deck = append(deck, top...)
+	for _, card := range GenerateDeck() {
+		if !containsCard(top, card) {
+			deck = append(deck, card)
+		}
+	}
+	return deck
+}
+
+// ParseCard parses the short form produced by Card.String, e.g. "10H" or "As".
+func ParseCard(text string) (models.Card, error) {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if len(text) < 2 {
+		return models.Card{}, fmt.Errorf("invalid card %q", text)
+	}
+
+	value, suit := text[:len(text)-1], text[len(text)-1:]
+	suits := map[string]string{"H": "Hearts", "D": "Diamonds", "C": "Clubs", "S": "Spades"}
+	if _, ok := suits[suit]; !ok {
+		return models.Card{}, fmt.Errorf("invalid suit in card %q", text)
+	}
+	if value == "T" {
+		value = "10"
+	}
+	for _, v := range []string{"2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"} {
+		if v == value {
+			return models.Card{Suit: suits[suit], Value: value}, nil
+		}
+	}
+	return models.Card{}, fmt.Errorf("invalid value in card %q", text)
+}
+
+// ParseCards parses a space separated list of cards, e.g. "AS KS 10H".
+func ParseCards(text string) ([]models.Card, error) {
+	cards := make([]models.Card, 0)
+	for _, field := range strings.Fields(text) {
+		card, err := ParseCard(field)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// MustParseCards is ParseCards for literal scripts; it panics on bad input.
+func MustParseCards(text string) []models.Card {
+	cards, err := ParseCards(text)
+	if err != nil {
+		panic(err)
+	}
+	return cards
+}
+
+// Action is one scripted step. Kind is one of "bet", "call", "raise",
+// "check", "fold", "draw" (acted by Nick, who must be on turn) or "street",
+// which deals the next street without a player. Draw discards the 0-based
+// positions in Cards.
+type Action struct {
+	Nick   string
+	Kind   string
+	Amount int
+	Cards  []int
+}
+
+type drawGame interface {
+	DrawCards(*models.Player, []int) error
+}
+
+type Seat struct {
+	Nick  string
+	Money int
+}
+
+// Script describes a single hand: who sits where, the order the deck is
+// dealt in (cards are dealt round-robin, one per player per pass, then the
+// board) and the actions taken.
+type Script struct {
+	Seats   []Seat
+	Deck    []models.Card
+	Actions []Action
+}
+
+type Result struct {
+	Pot    int
+	Stacks map[string]int
+	Winner string
+}
+
+// Expect compares the result with the expected pot, stacks and winner. Only
+// the stacks listed in stacks are compared; an empty winner is not checked.
+func (r *Result) Expect(pot int, stacks map[string]int, winner string) error {
+	if r.Pot != pot {
+		return fmt.Errorf("pot is %d, want %d", r.Pot, pot)
+	}
+	for nick, want := range stacks {
+		if got := r.Stacks[nick]; got != want {
+			return fmt.Errorf("%s has %d chips, want %d", nick, got, want)
+		}
+	}
+	if winner != "" && r.Winner != winner {
+		return fmt.Errorf("winner is %q, want %q", r.Winner, winner)
+	}
+	return nil
+}
+
+// Run plays script on g, which must be freshly constructed, and awards the
+// pot the way the IRC handler does. Stacks are reported after the pot is
+// awarded; Pot is the size of the pot that was awarded, and Winner who won
+// the most of it. Chip conservation is checked after every action.
+func Run(g Scriptable, script Script) (*Result, error) {
+	i := 0
+	return play(g, script.Seats, script.Deck, func() (Action, bool) {
+		if i == len(script.Actions) {
+			return Action{}, false
+		}
+		i++
+		return script.Actions[i-1], true
+	})
+}
+
+// Random plays a hand on g, which must be freshly constructed, dealt from a
+// deck shuffled with rng, in which whoever is on turn picks a legal action
+// at random until the hand is over. It returns the script it played, so a
+// failing hand can be replayed with Run, along with the result.
+func Random(g Scriptable, seats []Seat, rng *rand.Rand) (Script, *Result, error) {
+	deck := GenerateDeck()
+	rng.Shuffle(len(deck), func(a, b int) {
+		deck[a], deck[b] = deck[b], deck[a]
+	})
+	script := Script{Seats: seats, Deck: deck}
+	result, err := play(g, seats, deck, func() (Action, bool) {
+		if len(script.Actions) == maxActions || LastStanding(g) != nil || g.IsRoundOver() {
+			return Action{}, false
+		}
+		action := randomAction(g, rng)
+		script.Actions = append(script.Actions, action)
+		return action, true
+	})
+	return script, result, err
+}
+
+// maxActions stops a random hand that somehow never ends.
+const maxActions = 1000
+
+// randomAction picks a legal action for the player on turn in g: the next
+// street once the betting round is over, else a draw during the draw, else
+// a fold, check, call, bet or raise of a random size.
+func randomAction(g Game, rng *rand.Rand) Action {
+	if g.IsBettingRoundOver() {
+		return Action{Kind: "street"}
+	}
+	player := g.GetPlayers()[g.GetTurn()]
+	if drawer, ok := g.(interface{ IsDrawPhase() bool }); ok && drawer.IsDrawPhase() {
+		discards := rng.Perm(len(player.Hand))[:rng.Intn(4)]
+		return Action{Nick: player.Nick, Kind: "draw", Cards: discards}
+	}
+	toCall := g.GetCurrentBet() - player.Bet
+	switch n := rng.Intn(10); {
+	case player.Money > toCall && n >= 7:
+		raise := 1 + rng.Intn(player.Money-toCall)
+		if g.GetCurrentBet() == 0 {
+			return Action{Nick: player.Nick, Kind: "bet", Amount: raise}
+		}
+		return Action{Nick: player.Nick, Kind: "raise", Amount: raise}
+	case toCall > 0 && n < 2:
+		return Action{Nick: player.Nick, Kind: "fold"}
+	case toCall > 0 || player.Money == 0:
+		return Action{Nick: player.Nick, Kind: "call"}
+	default:
+		return Action{Nick: player.Nick, Kind: "check"}
+	}
+}
+
+// play seats seats at g, deals from deck and plays the actions next returns
+// until it returns false, then awards the pot.
+func play(g Scriptable, seats []Seat, deck []models.Card, next func() (Action, bool)) (*Result, error) {
+	for _, seat := range seats {
+		g.AddPlayer(models.NewPlayer(seat.Nick, seat.Money, 0))
+	}
+	g.SetScriptedDeck(deck)
+	g.SetInProgress(true)
+	g.ResetRound()
+	audit := NewChipAudit(g)
+	g.DealCards()
+
+	for i := 1; ; i++ {
+		action, ok := next()
+		if !ok {
+			break
+		}
+		err := runAction(g, action)
+		if err == nil {
+			err = audit.Check(g)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("action %d (%s %s): %v", i, action.Nick, action.Kind, err)
+		}
+	}
+
+	result := &Result{Pot: g.GetPot(), Stacks: make(map[string]int)}
+	var awards []Award
+	if winner := LastStanding(g); winner != nil {
+		awards = []Award{{Nick: winner.Nick, Amount: g.GetPot()}}
+	} else {
+		committed := make(map[string]int)
+		for _, seat := range seats {
+			committed[seat.Nick] = seat.Money - g.FindPlayer(seat.Nick).Money
+		}
+		awards = Settle(g, committed)
+	}
+	for _, award := range awards {
+		g.FindPlayer(award.Nick).Money += award.Amount
+	}
+	if len(awards) > 0 {
+		result.Winner = awards[0].Nick
+	}
+	for _, player := range g.GetPlayers() {
+		result.Stacks[player.Nick] = player.Money
+	}
+	return result, nil
+}
+
+func runAction(g Game, action Action) error {
+	if action.Kind == "street" {
+		g.UpdateRiver()
+		return nil
+	}
+
+	player := g.FindPlayer(action.Nick)
+	if player == nil {
+		return fmt.Errorf("%s is not seated", action.Nick)
+	}
+	if current := g.GetPlayers()[g.GetTurn()]; current != player {
+		return fmt.Errorf("out of turn, %s to act", current.Nick)
+	}
+
+	var err error
+	switch action.Kind {
+	case "bet":
+		err = g.Bet(player, action.Amount)
+	case "call":
+		err = g.Call(player)
+	case "raise":
+		err = g.Raise(player, action.Amount)
+	case "check":
+		err = g.Check(player)
+	case "fold":
+		g.Fold(player)
+	case "draw":
+		drawer, ok := g.(drawGame)
+		if !ok {
+			return fmt.Errorf("%s is not a draw game", g.GetType())
+		}
+		err = drawer.DrawCards(player, action.Cards)
+	default:
+		err = fmt.Errorf("unknown action %q", action.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	if LastStanding(g) == nil && !g.IsRoundOver() {
+		g.NextTurn()
+	}
+	return nil
+}
+
+func containsCard(cards []models.Card, card models.Card) bool {
+	for _, c := range cards {
+		if c == card {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package game
+
+import "sort"
+
+// ICM returns each player's equity in the prize pool under the Independent
+// Chip Model (Malmuth-Harville): the chance of finishing first is the share
+// of the chips in play, and each lower place is worked out the same way over
+// the players who are left. prizes lists the prize for each place, winner
+// first; places beyond the last prize pay nothing.
+func ICM(stacks []int, prizes []int) []float64 {
+	total := 0
+	for _, stack := range stacks {
+		total += stack
+	}
+	equity := make([]float64, len(stacks))
+	if total == 0 {
+		return equity
+	}
+
+	placed := make([]bool, len(stacks))
+	var finish func(place, chipsLeft int, chance float64)
+	finish = func(place, chipsLeft int, chance float64) {
+		if place >= len(prizes) || chipsLeft == 0 {
+			return
+		}
+		for i, stack := range stacks {
+			if placed[i] || stack == 0 {
+				continue
+			}
+			p := chance * float64(stack) / float64(chipsLeft)
+			equity[i] += p * float64(prizes[place])
+			placed[i] = true
+			finish(place+1, chipsLeft-stack, p)
+			placed[i] = false
+		}
+	}
+	finish(0, total, 1)
+	return equity
+}
+
+// ICMChop rounds the ICM equities to whole chips so that they add up to the
+// prizes exactly, giving the leftover units to the largest remainders.
+func ICMChop(stacks []int, prizes []int) []int {
+	equity := ICM(stacks, prizes)
+	pool := 0
+	for place, prize := range prizes {
+		if place < len(stacks) {
+			pool += prize
+		}
+	}
+
+	chop := make([]int, len(equity))
+	order := make([]int, len(equity))
+	for i, e := range equity {
+		chop[i] = int(e)
+		pool -= chop[i]
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return equity[order[a]]-float64(chop[order[a]]) > equity[order[b]]-float64(chop[order[b]])
+	})
+	for i := 0; pool > 0 && len(order) > 0; i = (i + 1) % len(order) {
+		chop[order[i]]++
+		pool--
+	}
+	return chop
+}
@@ -0,0 +1,36 @@
+package game
+
+import "fmt"
+
+// Positions names the seats of a hand dealt to seats players with the
+// button at seat button: BTN, then SB and BB in the two seats after it,
+// then UTG round to CO on the button's right. Heads up the blinds are
+// still the two seats after the button, so the button is the big blind.
+func Positions(seats, button int) []string {
+	names := make([]string, seats)
+	if seats == 0 {
+		return names
+	}
+	names[button%seats] = "BTN"
+	names[(button+1)%seats] = "SB"
+	names[(button+2)%seats] = "BB"
+
+	// The seats left, in the order they act before the flop. The first is
+	// under the gun and the last three, counting back from the button, are
+	// the cutoff, hijack and lojack.
+	others := seats - 3
+	for i := 0; i < others; i++ {
+		fromButton := others - i
+		var name string
+		switch {
+		case i == 0:
+			name = "UTG"
+		case fromButton <= 3:
+			name = []string{"CO", "HJ", "LJ"}[fromButton-1]
+		default:
+			name = fmt.Sprintf("UTG+%d", i)
+		}
+		names[(button+3+i)%seats] = name
+	}
+	return names
+}
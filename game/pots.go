@@ -0,0 +1,95 @@
+package game
+
+import (
+	"sort"
+
+	"poker-bot/models"
+)
+
+// Pot is the main pot or a side pot of a hand, with the players who can
+// still win it.
+type Pot struct {
+	Amount   int
+	Eligible []string
+}
+
+// Pots splits the pot of the hand in play into the main pot and side pots
+// from what each player has committed to it this hand. Every all-in player
+// closes a pot at what they put in: the main pot is what all the players
+// still in can win, and each side pot only those who put in more, or still
+// can. Chips committed nobody can account for, such as those of a player
+// who has left the table, go to the main pot.
+func Pots(g Game, committed map[string]int) []Pot {
+	var live []*models.Player
+	var caps []int
+	top := 0
+	for _, player := range g.GetPlayers() {
+		top = max(top, committed[player.Nick])
+		if player.Folded || len(player.Hand) == 0 {
+			continue
+		}
+		live = append(live, player)
+		if player.Money == 0 {
+			caps = append(caps, committed[player.Nick])
+		}
+	}
+	sort.Ints(caps)
+	caps = append(caps, top)
+
+	var pots []Pot
+	accounted, prev := 0, 0
+	for _, level := range caps {
+		if level <= prev {
+			continue
+		}
+		pot := Pot{}
+		for _, player := range g.GetPlayers() {
+			pot.Amount += min(max(committed[player.Nick], prev), level) - prev
+		}
+		for _, player := range live {
+			if player.Money > 0 || committed[player.Nick] >= level {
+				pot.Eligible = append(pot.Eligible, player.Nick)
+			}
+		}
+		accounted += pot.Amount
+		pots = append(pots, pot)
+		prev = level
+	}
+
+	if len(pots) == 0 {
+		pots = append(pots, Pot{})
+		for _, player := range live {
+			pots[0].Eligible = append(pots[0].Eligible, player.Nick)
+		}
+	}
+	pots[0].Amount += g.GetPot() - accounted
+	return pots
+}
+
+// LastStanding returns the one player who hasn't folded once everyone else
+// has, or nil while two or more are still in. The pot is theirs at once,
+// whether or not the bets in front of them were matched.
+func LastStanding(g Game) *models.Player {
+	var last *models.Player
+	for _, player := range g.GetPlayers() {
+		if player.Folded {
+			continue
+		}
+		if last != nil {
+			return nil
+		}
+		last = player
+	}
+	return last
+}
+
+// Forfeit moves up to amount chips from player's stack into the pot, such
+// as a penalty, and returns how many it moved. The chips stay on the table,
+// so a ChipAudit needs no adjustment for them, and they count as committed
+// to the hand like a bet.
+func Forfeit(g Game, player *models.Player, amount int) int {
+	amount = min(max(amount, 0), player.Money)
+	player.Money -= amount
+	g.AddToPot(amount)
+	return amount
+}
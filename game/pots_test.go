@@ -0,0 +1,39 @@
+package game_test
+
+import (
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+	"poker-bot/modes"
+)
+
+func TestForfeit(t *testing.T) {
+	tests := []struct {
+		name           string
+		stack, penalty int
+		want           int
+	}{
+		{"part of the stack", 1000, 250, 250},
+		{"the whole stack", 1000, 1000, 1000},
+		{"more than the stack", 300, 1000, 300},
+		{"an empty stack", 0, 100, 0},
+		{"a negative penalty", 1000, -50, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := modes.NewHoldem("#test")
+			g.AddToPot(40)
+			player := models.NewPlayer("ann", test.stack, 0)
+			if got := game.Forfeit(g, player, test.penalty); got != test.want {
+				t.Errorf("Forfeit moved %d chips, want %d", got, test.want)
+			}
+			if player.Money != test.stack-test.want {
+				t.Errorf("the stack is %d, want %d", player.Money, test.stack-test.want)
+			}
+			if pot := g.GetPot(); pot != 40+test.want {
+				t.Errorf("the pot is %d, want %d", pot, 40+test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"sort"
+
+	"poker-bot/models"
+)
+
+// Award is what one player wins of the pot of a hand.
+type Award struct {
+	Nick   string
+	Amount int
+}
+
+// Settle works out who wins the pot of a hand that went to showdown, from
+// what each player committed to it. Each pot from Pots goes to the best
+// hand among the players who can win it, split evenly between tied hands,
+// the odd chips going to whoever of them sits first left of the button.
+// A pot nobody still in can win, such as one a folded player's bets
+// opened, goes to the best hand of all. The awards are one per winner,
+// summed over the pots, the biggest first. Games that can't compare hands
+// give the whole pot to the winner EvaluateHands picks.
+func Settle(g Game, committed map[string]int) []Award {
+	rules, ok := g.(Showdown)
+	if !ok {
+		winner := g.EvaluateHands()
+		if winner == nil {
+			return nil
+		}
+		return []Award{{Nick: winner.Nick, Amount: g.GetPot()}}
+	}
+
+	players := g.GetPlayers()
+	first := 0
+	if buttoned, ok := g.(Buttoned); ok && len(players) > 0 {
+		first = buttoned.Button() + 1
+	}
+	var live []*models.Player // in seat order from the button
+	for i := range players {
+		player := players[(first+i)%len(players)]
+		if !player.Folded && len(player.Hand) > 0 {
+			live = append(live, player)
+		}
+	}
+	if len(live) == 0 {
+		return nil
+	}
+
+	won := make(map[string]int)
+	for _, pot := range Pots(g, committed) {
+		if pot.Amount == 0 {
+			continue
+		}
+		eligible := make(map[string]bool, len(pot.Eligible))
+		for _, nick := range pot.Eligible {
+			eligible[nick] = true
+		}
+		var best []*models.Player
+		for _, player := range live {
+			if len(pot.Eligible) > 0 && !eligible[player.Nick] {
+				continue
+			}
+			switch {
+			case len(best) == 0:
+				best = []*models.Player{player}
+			case rules.CompareHands(player, best[0]) > 0:
+				best = []*models.Player{player}
+			case rules.CompareHands(player, best[0]) == 0:
+				best = append(best, player)
+			}
+		}
+		share, odd := pot.Amount/len(best), pot.Amount%len(best)
+		for i, player := range best {
+			won[player.Nick] += share
+			if i < odd {
+				won[player.Nick]++
+			}
+		}
+	}
+
+	var awards []Award
+	for _, player := range live {
+		if amount, ok := won[player.Nick]; ok {
+			awards = append(awards, Award{Nick: player.Nick, Amount: amount})
+		}
+	}
+	sort.SliceStable(awards, func(i, j int) bool {
+		return awards[i].Amount > awards[j].Amount
+	})
+	return awards
+}
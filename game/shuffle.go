@@ -0,0 +1,96 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"sort"
+
+	"poker-bot/models"
+)
+
+// ShuffleDeck shuffles the deck in place using crypto/rand.
+func ShuffleDeck(deck []models.Card) {
+	for i := len(deck) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			panic("crypto/rand unavailable: " + err.Error())
+		}
+		j := int(n.Int64())
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+}
+
+// ShuffleDeckWithSeed permutes the deck deterministically from seed so that
+// anyone holding the seed can reproduce the deal. It runs Fisher-Yates from
+// the bottom of the deck up, drawing each swap index from big-endian uint32s
+// of SHA-256(seed || counter) with rejection sampling to avoid modulo bias.
+func ShuffleDeckWithSeed(deck []models.Card, seed []byte) {
+	stream := &seedStream{seed: seed}
+	for i := len(deck) - 1; i > 0; i-- {
+		j := stream.intn(i + 1)
+		deck[i], deck[j] = deck[j], deck[i]
+	}
+}
+
+// SeedFromEntropy combines player-submitted entropy into a deck seed. The
+// contributions are hashed as "nick\x00entropy\x00" in nick order, so the
+// seed does not depend on the order in which they were submitted. It has
+// to be given every committed contribution: a seed from only those that
+// turned up would let a player choose it by holding theirs back.
+func SeedFromEntropy(entropy map[string]string) []byte {
+	nicks := make([]string, 0, len(entropy))
+	for nick := range entropy {
+		nicks = append(nicks, nick)
+	}
+	sort.Strings(nicks)
+
+	hash := sha256.New()
+	for _, nick := range nicks {
+		hash.Write([]byte(nick))
+		hash.Write([]byte{0})
+		hash.Write([]byte(entropy[nick]))
+		hash.Write([]byte{0})
+	}
+	return hash.Sum(nil)
+}
+
+// Commitment returns the hex SHA-256 of a player's entropy, published before
+// the deal so the player can later check their contribution was used.
+func Commitment(entropy string) string {
+	sum := sha256.Sum256([]byte(entropy))
+	return hex.EncodeToString(sum[:])
+}
+
+type seedStream struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func (s *seedStream) uint32() uint32 {
+	if len(s.buf) < 4 {
+		block := make([]byte, len(s.seed)+8)
+		copy(block, s.seed)
+		binary.BigEndian.PutUint64(block[len(s.seed):], s.counter)
+		sum := sha256.Sum256(block)
+		s.buf = sum[:]
+		s.counter++
+	}
+	v := binary.BigEndian.Uint32(s.buf)
+	s.buf = s.buf[4:]
+	return v
+}
+
+func (s *seedStream) intn(n int) int {
+	max := uint32(n)
+	limit := math.MaxUint32 - math.MaxUint32%max
+	for {
+		if v := s.uint32(); v < limit {
+			return int(v % max)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package game
+
+// Structure is a game's betting structure, which caps what a player may
+// bet. Its value is the abbreviation a hand's title starts with, as in
+// "NL Texas Hold'em 5/10".
+type Structure string
+
+// NoLimit lets a player bet anything up to their whole stack. It's the
+// only structure the games here play: Bet enforces a minimum, no maximum.
+const NoLimit Structure = "NL"
+
+// Structure returns NoLimit. A game with a cap on bets overrides it.
+func (g *BaseGame) Structure() Structure {
+	return NoLimit
+}
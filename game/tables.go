@@ -0,0 +1,96 @@
+package game
+
+// A multi-table tournament plays at several tables, each its own game,
+// sharing one Tournament. The tables deal independently until the bubble,
+// where play goes hand-for-hand: a table that finishes its hand waits for
+// the others to finish theirs before dealing the next, so that nobody can
+// stall their way into the money while the other tables play on, and
+// players who bust in the same hand at different tables are placed
+// against each other by their stacks.
+
+// AddTable adds a table to the tournament.
+func (t *Tournament) AddTable(table string) {
+	t.tables = append(t.tables, table)
+}
+
+// Tables returns the tournament's tables, or nil if it plays at a single
+// table that was never added.
+func (t *Tournament) Tables() []string {
+	return append([]string(nil), t.tables...)
+}
+
+// HandForHand reports whether the tables play hand-for-hand: the tournament
+// is at more than one table and the next player out finishes on the
+// bubble, once the rebuy period is over.
+func (t *Tournament) HandForHand() bool {
+	return len(t.tables) > 1 && !t.RebuyOpen() && t.Remaining() == t.PlacesPaid()+1
+}
+
+// Waiting reports whether table has finished the hand-for-hand hand and
+// waits for the other tables to finish theirs.
+func (t *Tournament) Waiting(table string) bool {
+	return t.finished[table]
+}
+
+// FinishHand records the players who busted in the hand just played at
+// table, given their stacks when it was dealt. Outside hand-for-hand play
+// they are eliminated at once. Hand-for-hand, the table waits for the
+// others, and the players who busted at any of them are eliminated
+// together, as by EliminateHand, when the last one finishes. A table that
+// can't deal a hand finishes it with no busts. FinishHand returns the
+// players eliminated, in order, and the other tables that were waiting and
+// can now deal.
+func (t *Tournament) FinishHand(table string, startingStacks map[string]int) (eliminated, released []string) {
+	if !t.HandForHand() && len(t.finished) == 0 {
+		if len(startingStacks) == 0 {
+			return nil, nil
+		}
+		return t.EliminateHand(startingStacks), nil
+	}
+	t.finished[table] = true
+	for nick, stack := range startingStacks {
+		t.handBusts[nick] = stack
+	}
+	return t.release(table)
+}
+
+// RemoveTable takes a table out of the tournament once its players have
+// been moved to the others. Like FinishHand, it returns the players
+// eliminated and the tables released if the hand-for-hand hand was only
+// waiting for this one.
+func (t *Tournament) RemoveTable(table string) (eliminated, released []string) {
+	for i, other := range t.tables {
+		if other == table {
+			t.tables = append(t.tables[:i], t.tables[i+1:]...)
+			break
+		}
+	}
+	delete(t.levels, table)
+	if !t.finished[table] && len(t.finished) == 0 {
+		return nil, nil
+	}
+	delete(t.finished, table)
+	return t.release(table)
+}
+
+// release ends the hand-for-hand hand once every table has finished it,
+// returning the players eliminated and the tables, other than the one
+// that finished last, that were waiting.
+func (t *Tournament) release(last string) (eliminated, released []string) {
+	for _, table := range t.tables {
+		if !t.finished[table] {
+			return nil, nil
+		}
+	}
+	for _, table := range t.tables {
+		if table != last {
+			released = append(released, table)
+		}
+	}
+	if len(t.handBusts) > 0 {
+		eliminated = t.EliminateHand(t.handBusts)
+	}
+	t.finished = make(map[string]bool)
+	t.handBusts = make(map[string]int)
+	return eliminated, released
+}
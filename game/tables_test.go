@@ -0,0 +1,127 @@
+package game_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"poker-bot/game"
+)
+
+// bubbleTournament returns a nine-entry tournament, three places paid, at
+// tables #a and #b, with players p0 to p3 left: one off the money.
+func bubbleTournament() *game.Tournament {
+	t := game.NewTournament()
+	for i := 0; i < 9; i++ {
+		t.Enter(fmt.Sprintf("p%d", i))
+	}
+	t.EliminateHand(map[string]int{"p4": 100, "p5": 200, "p6": 300, "p7": 400, "p8": 500})
+	t.AddTable("#a")
+	t.AddTable("#b")
+	return t
+}
+
+func TestFinishHandOffTheBubble(t *testing.T) {
+	tournament := bubbleTournament()
+	tournament.RemoveTable("#b")
+	if tournament.HandForHand() {
+		t.Fatal("a tournament at one table plays hand-for-hand")
+	}
+	eliminated, released := tournament.FinishHand("#a", map[string]int{"p3": 700})
+	if !reflect.DeepEqual(eliminated, []string{"p3"}) || released != nil {
+		t.Errorf("FinishHand = %v, %v, want p3 eliminated at once", eliminated, released)
+	}
+}
+
+func TestFinishHandHandForHand(t *testing.T) {
+	tournament := bubbleTournament()
+	if !tournament.HandForHand() {
+		t.Fatal("the tables don't play hand-for-hand on the bubble")
+	}
+
+	eliminated, released := tournament.FinishHand("#a", map[string]int{"p0": 900})
+	if eliminated != nil || released != nil {
+		t.Errorf("the first table to finish got %v, %v", eliminated, released)
+	}
+	if !tournament.Waiting("#a") || tournament.Waiting("#b") {
+		t.Error("#a isn't the table waiting")
+	}
+	if tournament.Remaining() != 4 {
+		t.Errorf("%d players are left before every table finished, want 4", tournament.Remaining())
+	}
+
+	eliminated, released = tournament.FinishHand("#b", map[string]int{"p1": 600})
+	if want := []string{"p1", "p0"}; !reflect.DeepEqual(eliminated, want) {
+		t.Errorf("eliminated %v, want %v, the shorter stack first", eliminated, want)
+	}
+	if want := []string{"#a"}; !reflect.DeepEqual(released, want) {
+		t.Errorf("released %v, want %v", released, want)
+	}
+	if tournament.Waiting("#a") || tournament.HandForHand() {
+		t.Error("play is still hand-for-hand after the bubble burst")
+	}
+
+	// Players busting in the same hand at different tables are placed by
+	// their stacks, the bigger finishing in the money.
+	standings := tournament.Standings([]string{"p2", "p3"})
+	if standings[2].Nick != "p0" || standings[2].Prize == 0 || standings[3].Nick != "p1" || standings[3].Prize != 0 {
+		t.Errorf("standings %+v, want p0 third and paid, p1 fourth", standings[:4])
+	}
+}
+
+func TestRemoveTableReleasesHandForHand(t *testing.T) {
+	tournament := bubbleTournament()
+	tournament.AddTable("#c")
+	tournament.FinishHand("#a", nil)
+	tournament.FinishHand("#b", map[string]int{"p3": 400})
+
+	eliminated, released := tournament.RemoveTable("#c")
+	if !reflect.DeepEqual(eliminated, []string{"p3"}) {
+		t.Errorf("eliminated %v, want p3", eliminated)
+	}
+	if want := []string{"#a", "#b"}; !reflect.DeepEqual(released, want) {
+		t.Errorf("released %v, want %v", released, want)
+	}
+	if want := []string{"#a", "#b"}; !reflect.DeepEqual(tournament.Tables(), want) {
+		t.Errorf("tables %v, want %v", tournament.Tables(), want)
+	}
+}
+
+func TestLevelUpAtEveryTable(t *testing.T) {
+	tournament := bubbleTournament()
+	start := time.Now()
+	tournament.Start(start)
+	later := start.Add(tournament.LevelDuration + time.Second)
+
+	if !tournament.LevelUp("#a", later) {
+		t.Error("#a didn't go up a level")
+	}
+	if !tournament.LevelUp("#b", later) {
+		t.Error("#b didn't go up a level after #a")
+	}
+	if tournament.LevelUp("#a", later) {
+		t.Error("#a went up the same level twice")
+	}
+}
+
+func TestPrizesForBigFields(t *testing.T) {
+	tournament := game.NewTournament()
+	for i := 0; i < 50; i++ {
+		tournament.Enter(fmt.Sprintf("p%d", i))
+	}
+	prizes := tournament.Prizes()
+	if len(prizes) != 10 {
+		t.Fatalf("%d places are paid, want 10", len(prizes))
+	}
+	total := 0
+	for i, prize := range prizes {
+		total += prize
+		if i > 0 && prize > prizes[i-1] {
+			t.Errorf("place %d pays %d, more than place %d's %d", i+1, prize, i, prizes[i-1])
+		}
+	}
+	if total != tournament.PrizePool {
+		t.Errorf("the prizes add up to %d, want the prize pool of %d", total, tournament.PrizePool)
+	}
+}
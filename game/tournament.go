@@ -0,0 +1,455 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"poker-bot/models"
+)
+
+type BlindLevel struct {
+	SmallBlind int
+	BigBlind   int
+	Ante       int
+}
+
+func (l BlindLevel) String() string {
+	if l.BigBlind == 0 {
+		return fmt.Sprintf("ante %d", l.Ante)
+	}
+	if l.Ante > 0 {
+		return fmt.Sprintf("%d/%d, ante %d", l.SmallBlind, l.BigBlind, l.Ante)
+	}
+	return fmt.Sprintf("%d/%d", l.SmallBlind, l.BigBlind)
+}
+
+// Blinded is implemented by games whose forced bets follow the tournament
+// blind levels.
+type Blinded interface {
+	SetBlinds(smallBlind, bigBlind, ante int)
+}
+
+var DefaultBlindLevels = []BlindLevel{
+	{5, 10, 0}, {10, 20, 0}, {15, 30, 0}, {25, 50, 5}, {50, 100, 10},
+	{75, 150, 15}, {100, 200, 25}, {150, 300, 25}, {200, 400, 50},
+	{300, 600, 75}, {500, 1000, 100},
+}
+
+type TournamentEntry struct {
+	Nick   string
+	Rebuys int
+	AddOn  bool
+}
+
+// Tournament holds the structure and money of a freezeout or rebuy
+// tournament. Players pay BuyIn from their bankroll for StartingStack
+// tournament chips; the buy-ins, rebuys and add-ons make up PrizePool.
+// Rebuys are open to busted players while Level < RebuyLevels, and the
+// one-time add-on is sold at the break after the rebuy period.
+//
+// Levels run on a wall clock that starts with the first hand: each lasts
+// LevelDuration, and after every BreakEvery levels play pauses for
+// BreakDuration. A new level's blinds apply from the next hand dealt.
+//
+// A tournament can play at several tables at once, sharing its clock,
+// entries and prize pool; see AddTable.
+type Tournament struct {
+	BuyIn         int
+	StartingStack int
+	Levels        []BlindLevel
+	Level         int
+	LevelDuration time.Duration
+	BreakEvery    int
+	BreakDuration time.Duration
+	LateRegLevels int
+	RebuyLevels   int
+	RebuyCost     int
+	RebuyChips    int
+	AddOnCost     int
+	AddOnChips    int
+	PrizePool     int
+	Entries       map[string]*TournamentEntry
+	Eliminated    []string // in elimination order, first out first
+	busts         map[string]bust
+	hands         int // hands with eliminations so far
+	started       time.Time
+	pending       map[string]int
+	tables        []string        // in the order they were added
+	levels        map[string]int  // the level each table last played
+	finished      map[string]bool // tables done with the hand-for-hand hand
+	handBusts     map[string]int  // busted in it, with their starting stacks
+}
+
+// bust is when a player was eliminated: the hand, counting only hands that
+// eliminated someone, and their stack when it was dealt.
+type bust struct {
+	hand  int
+	stack int
+}
+
+// Standing is a player's place at the end of a tournament and their prize,
+// or at the end of any other game and their stack. Players who tie share a
+// place and split its prizes.
+type Standing struct {
+	Place int
+	Nick  string
+	Prize int
+	Stack int
+}
+
+func NewTournament() *Tournament {
+	return &Tournament{
+		BuyIn:         100,
+		StartingStack: 1000,
+		Levels:        DefaultBlindLevels,
+		LevelDuration: 10 * time.Minute,
+		BreakEvery:    4,
+		BreakDuration: 5 * time.Minute,
+		LateRegLevels: 2,
+		Entries:       make(map[string]*TournamentEntry),
+		busts:         make(map[string]bust),
+		pending:       make(map[string]int),
+		levels:        make(map[string]int),
+		finished:      make(map[string]bool),
+		handBusts:     make(map[string]int),
+	}
+}
+
+// NewRebuyTournament is NewTournament with a three-level rebuy period and an
+// add-on at the following break.
+func NewRebuyTournament() *Tournament {
+	t := NewTournament()
+	t.SetRebuyLevels(3)
+	t.RebuyCost = t.BuyIn
+	t.RebuyChips = t.StartingStack
+	t.AddOnCost = t.BuyIn
+	t.AddOnChips = t.StartingStack * 3 / 2
+	return t
+}
+
+// SetRebuyLevels sets how many levels the rebuy period lasts. The add-on is
+// sold at the break that ends it, so the breaks come every that many
+// levels.
+func (t *Tournament) SetRebuyLevels(levels int) {
+	t.RebuyLevels = levels
+	t.BreakEvery = levels
+}
+
+func (t *Tournament) CurrentLevel() BlindLevel {
+	return t.LevelAt(t.Level)
+}
+
+// LevelAt returns the blinds of a 0-based level. The last level repeats
+// once the structure runs out.
+func (t *Tournament) LevelAt(level int) BlindLevel {
+	if level >= len(t.Levels) {
+		return t.Levels[len(t.Levels)-1]
+	}
+	return t.Levels[level]
+}
+
+// Clock is a reading of the tournament clock. During a break Level is the
+// level that starts when the break ends.
+type Clock struct {
+	Level     int
+	OnBreak   bool
+	Remaining time.Duration
+}
+
+func (t *Tournament) Started() bool {
+	return !t.started.IsZero()
+}
+
+// Clock reads the clock at now. Before the first hand the clock stands at
+// the start of the current level.
+func (t *Tournament) Clock(now time.Time) Clock {
+	if !t.Started() || t.LevelDuration <= 0 {
+		return Clock{Level: t.Level, Remaining: t.LevelDuration}
+	}
+
+	elapsed := now.Sub(t.started)
+	level := 0
+	for {
+		if elapsed < t.LevelDuration {
+			return Clock{Level: level, Remaining: t.LevelDuration - elapsed}
+		}
+		elapsed -= t.LevelDuration
+		level++
+		if t.BreakEvery > 0 && level%t.BreakEvery == 0 {
+			if elapsed < t.BreakDuration {
+				return Clock{Level: level, OnBreak: true, Remaining: t.BreakDuration - elapsed}
+			}
+			elapsed -= t.BreakDuration
+		}
+	}
+}
+
+// Start starts the clock, on the first hand.
+func (t *Tournament) Start(now time.Time) {
+	t.started = now
+}
+
+// Advance moves the tournament to the level the clock is in at now and
+// reports whether the level went up.
+func (t *Tournament) Advance(now time.Time) bool {
+	level := t.Clock(now).Level
+	if level <= t.Level {
+		return false
+	}
+	t.Level = level
+	return true
+}
+
+// LevelUp is Advance for one of the tournament's tables: it reports whether
+// the level went up since table last asked, so that every table puts up
+// the new blinds, not just the first to deal after the clock moved on.
+func (t *Tournament) LevelUp(table string, now time.Time) bool {
+	t.Advance(now)
+	if t.levels[table] >= t.Level {
+		return false
+	}
+	t.levels[table] = t.Level
+	return true
+}
+
+func (t *Tournament) Enter(nick string) {
+	t.Entries[nick] = &TournamentEntry{Nick: nick}
+	t.PrizePool += t.BuyIn
+}
+
+// Withdraw takes nick's entry back before they've played, taking their
+// buy-in out of the prize pool. It reports whether nick had an entry.
+func (t *Tournament) Withdraw(nick string) bool {
+	if _, entered := t.Entries[nick]; !entered {
+		return false
+	}
+	delete(t.Entries, nick)
+	t.PrizePool -= t.BuyIn
+	return true
+}
+
+func (t *Tournament) LateRegistrationOpen() bool {
+	return t.Level < t.LateRegLevels
+}
+
+func (t *Tournament) RebuyOpen() bool {
+	return t.Level < t.RebuyLevels
+}
+
+// AddOnOpen reports whether this is the break after the rebuy period, the
+// only time the add-on is sold.
+func (t *Tournament) AddOnOpen() bool {
+	return t.AddOnCost > 0 && t.Level == t.RebuyLevels
+}
+
+// EliminateHand records the players who busted in one hand, given their
+// stacks when it was dealt. The player who started the hand with fewer
+// chips finishes lower; players who started it with the same stack tie.
+// Busted players who rebuy are taken off the list again, so it only holds
+// players who are out for good. It returns the players in the order they
+// were eliminated.
+func (t *Tournament) EliminateHand(startingStacks map[string]int) []string {
+	busted := make([]string, 0, len(startingStacks))
+	for nick := range startingStacks {
+		busted = append(busted, nick)
+	}
+	sort.Slice(busted, func(i, j int) bool {
+		if startingStacks[busted[i]] != startingStacks[busted[j]] {
+			return startingStacks[busted[i]] < startingStacks[busted[j]]
+		}
+		return busted[i] < busted[j]
+	})
+
+	t.hands++
+	for _, nick := range busted {
+		t.Eliminated = append(t.Eliminated, nick)
+		t.busts[nick] = bust{hand: t.hands, stack: startingStacks[nick]}
+	}
+	return busted
+}
+
+// tied reports whether two players busted in the same hand from the same
+// stack.
+func (t *Tournament) tied(a, b string) bool {
+	bustA, outA := t.busts[a]
+	bustB, outB := t.busts[b]
+	return outA && outB && bustA == bustB
+}
+
+// CanRebuy returns why nick can't rebuy right now, or nil if they can,
+// without changing the tournament, so the rebuy can be paid for first.
+func (t *Tournament) CanRebuy(nick string) error {
+	if t.Entries[nick] == nil {
+		return errors.New("you're not entered in this tournament")
+	}
+	if !t.RebuyOpen() {
+		return errors.New("the rebuy period is over")
+	}
+	for _, out := range t.Eliminated {
+		if out == nick {
+			return nil
+		}
+	}
+	return errors.New("you can only rebuy once you've busted")
+}
+
+func (t *Tournament) Rebuy(nick string) error {
+	if err := t.CanRebuy(nick); err != nil {
+		return err
+	}
+	for i, out := range t.Eliminated {
+		if out == nick {
+			t.Eliminated = append(t.Eliminated[:i], t.Eliminated[i+1:]...)
+			break
+		}
+	}
+	delete(t.busts, nick)
+	t.Entries[nick].Rebuys++
+	t.PrizePool += t.RebuyCost
+	return nil
+}
+
+// CanAddOn returns why nick can't take the add-on right now, or nil if they
+// can, without changing the tournament.
+func (t *Tournament) CanAddOn(nick string) error {
+	entry := t.Entries[nick]
+	if entry == nil {
+		return errors.New("you're not entered in this tournament")
+	}
+	if !t.AddOnOpen() {
+		return errors.New("the add-on is only available at the break after the rebuy period")
+	}
+	if entry.AddOn {
+		return errors.New("you have already taken the add-on")
+	}
+	return nil
+}
+
+func (t *Tournament) AddOn(nick string) error {
+	if err := t.CanAddOn(nick); err != nil {
+		return err
+	}
+	t.Entries[nick].AddOn = true
+	t.PrizePool += t.AddOnCost
+	t.pending[nick] += t.AddOnChips
+	return nil
+}
+
+// TakePendingChips returns the add-on chips nick bought since the last hand
+// and clears them. Chips are only added between hands so that the pot and
+// stacks stay consistent mid-hand.
+func (t *Tournament) TakePendingChips(nick string) int {
+	chips := t.pending[nick]
+	delete(t.pending, nick)
+	return chips
+}
+
+// Remaining is how many entrants are still in the tournament.
+func (t *Tournament) Remaining() int {
+	return len(t.Entries) - len(t.Eliminated)
+}
+
+// PlacesPaid is how many places win a prize.
+func (t *Tournament) PlacesPaid() int {
+	return len(t.Prizes())
+}
+
+// Prizes splits the prize pool into a prize per paid place, winner first.
+// The last paid place absorbs the rounding remainder. Fields of 30 or more,
+// too big for one table, pay a fifth of the entrants, each place's share
+// falling off with its number, so that the bubble can come before the
+// final table.
+func (t *Tournament) Prizes() []int {
+	var shares []int
+	switch entries := len(t.Entries); {
+	case entries >= 30:
+		shares = make([]int, entries/5)
+		for i := range shares {
+			shares[i] = 1000 / (i + 1)
+		}
+	case entries >= 9:
+		shares = []int{50, 30, 20}
+	case entries >= 5:
+		shares = []int{65, 35}
+	default:
+		shares = []int{100}
+	}
+
+	total := 0
+	for _, share := range shares {
+		total += share
+	}
+	prizes := make([]int, len(shares))
+	paid := 0
+	for i, share := range shares {
+		prizes[i] = t.PrizePool * share / total
+		if i == len(shares)-1 {
+			prizes[i] = t.PrizePool - paid
+		}
+		paid += prizes[i]
+	}
+	return prizes
+}
+
+// Standings ranks the players still in, best first, ahead of the
+// eliminated players in reverse order of elimination, and pays Prizes over
+// the places. If fewer players finished than there are prizes, the last of
+// them takes the rest. Tied players split the prizes for the places they
+// share, the first of them taking any odd chips.
+func (t *Tournament) Standings(remaining []string) []Standing {
+	order := append([]string{}, remaining...)
+	for i := len(t.Eliminated) - 1; i >= 0; i-- {
+		order = append(order, t.Eliminated[i])
+	}
+
+	prizes := t.Prizes()
+	if len(order) == 0 {
+		return nil
+	}
+	if len(order) < len(prizes) {
+		for _, prize := range prizes[len(order):] {
+			prizes[len(order)-1] += prize
+		}
+		prizes = prizes[:len(order)]
+	}
+
+	standings := make([]Standing, len(order))
+	for i := 0; i < len(order); {
+		j := i + 1
+		for j < len(order) && t.tied(order[i], order[j]) {
+			j++
+		}
+		pool := 0
+		for k := i; k < j && k < len(prizes); k++ {
+			pool += prizes[k]
+		}
+		share, odd := pool/(j-i), pool%(j-i)
+		for k := i; k < j; k++ {
+			standings[k] = Standing{Place: i + 1, Nick: order[k], Prize: share}
+		}
+		standings[i].Prize += odd
+		i = j
+	}
+	return standings
+}
+
+// RankStacks ranks the players at the end of a game that isn't a
+// tournament by their stacks, most chips first. Players with the same
+// stack share a place.
+func RankStacks(players []*models.Player) []Standing {
+	ranked := append([]*models.Player{}, players...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Money > ranked[j].Money
+	})
+	standings := make([]Standing, len(ranked))
+	for i, player := range ranked {
+		place := i + 1
+		if i > 0 && player.Money == ranked[i-1].Money {
+			place = standings[i-1].Place
+		}
+		standings[i] = Standing{Place: place, Nick: player.Nick, Stack: player.Money}
+	}
+	return standings
+}
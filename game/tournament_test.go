@@ -0,0 +1,60 @@
+package game_test
+
+import (
+	"testing"
+
+	"poker-bot/game"
+)
+
+func TestCanRebuyLeavesTheTournament(t *testing.T) {
+	tournament := game.NewRebuyTournament()
+	tournament.Enter("ann")
+	tournament.Enter("bob")
+	if err := tournament.CanRebuy("ann"); err == nil {
+		t.Error("ann can rebuy before busting")
+	}
+	tournament.EliminateHand(map[string]int{"ann": 100})
+	pool := tournament.PrizePool
+	if err := tournament.CanRebuy("ann"); err != nil {
+		t.Fatalf("ann can't rebuy after busting: %v", err)
+	}
+	if tournament.PrizePool != pool || tournament.Remaining() != 1 || tournament.Entries["ann"].Rebuys != 0 {
+		t.Fatal("CanRebuy changed the tournament")
+	}
+
+	if err := tournament.Rebuy("ann"); err != nil {
+		t.Fatal(err)
+	}
+	if tournament.PrizePool != pool+tournament.RebuyCost || tournament.Remaining() != 2 || tournament.Entries["ann"].Rebuys != 1 {
+		t.Errorf("after the rebuy: pool %d, %d left, %d rebuys", tournament.PrizePool, tournament.Remaining(), tournament.Entries["ann"].Rebuys)
+	}
+	if err := tournament.CanRebuy("cat"); err == nil {
+		t.Error("cat can rebuy without entering")
+	}
+}
+
+func TestCanAddOnLeavesTheTournament(t *testing.T) {
+	tournament := game.NewRebuyTournament()
+	tournament.Enter("ann")
+	if err := tournament.CanAddOn("ann"); err == nil {
+		t.Error("ann can take the add-on during the rebuy period")
+	}
+	tournament.Level = tournament.RebuyLevels
+	pool := tournament.PrizePool
+	if err := tournament.CanAddOn("ann"); err != nil {
+		t.Fatalf("ann can't take the add-on at the break: %v", err)
+	}
+	if tournament.PrizePool != pool || tournament.Entries["ann"].AddOn || tournament.TakePendingChips("ann") != 0 {
+		t.Fatal("CanAddOn changed the tournament")
+	}
+
+	if err := tournament.AddOn("ann"); err != nil {
+		t.Fatal(err)
+	}
+	if tournament.PrizePool != pool+tournament.AddOnCost || tournament.TakePendingChips("ann") != tournament.AddOnChips {
+		t.Error("the add-on wasn't counted")
+	}
+	if err := tournament.CanAddOn("ann"); err == nil {
+		t.Error("ann can take the add-on twice")
+	}
+}
@@ -0,0 +1,42 @@
+package game
+
+// Buttoned is implemented by games with a dealer button that moves round the
+// table, so that it keeps its place when the table switches variants.
+type Buttoned interface {
+	Button() int
+	SetButton(int)
+}
+
+// Base gives access to the shared table state of a game that embeds BaseGame.
+func (g *BaseGame) Base() *BaseGame {
+	return g
+}
+
+type based interface {
+	Base() *BaseGame
+}
+
+// SwitchVariant seats the players of from, with their stacks, at to, a
+// freshly constructed game of another variant. It carries over the hand
+// count, owed late blinds, deck settings and button, and must be called
+// between hands.
+func SwitchVariant(from, to Game) {
+	for _, player := range from.GetPlayers() {
+		to.AddPlayer(player)
+	}
+	to.SetInProgress(from.IsInProgress())
+
+	if old, ok := from.(based); ok {
+		if next, ok := to.(based); ok {
+			prev, base := old.Base(), next.Base()
+			base.HandCount = prev.HandCount
+			base.Posting = prev.Posting
+			base.Dealer = prev.Dealer
+		}
+	}
+	if old, ok := from.(Buttoned); ok {
+		if next, ok := to.(Buttoned); ok {
+			next.SetButton(old.Button())
+		}
+	}
+}
@@ -5,9 +5,7 @@ go 1.22.5
 require (
 	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/thoj/go-ircevent v0.0.0-20210723090443-73e444401d64
+	golang.org/x/net v0.0.0-20210614182718-04defd469f4e
 )
 
-require (
-	golang.org/x/net v0.0.0-20210614182718-04defd469f4e // indirect
-	golang.org/x/text v0.3.6 // indirect
-)
+require golang.org/x/text v0.3.6 // indirect
@@ -0,0 +1,92 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"poker-bot/collusion"
+)
+
+// isAdmin reports whether the sender matches one of the admin hostmasks in
+// the config.
+func (h *Handler) isAdmin(cmd *Command) bool {
+	for _, mask := range h.config.Admins {
+		if matchMask(mask, cmd.Source) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) handleAdmin(cmd *Command) {
+	if !h.isAdmin(cmd) {
+		h.notice(cmd.Nick, "You're not a bot admin.")
+		return
+	}
+
+	args := cmd.Args
+	if len(args) == 0 {
+		h.notice(cmd.Nick, "Usage: $admin suspicious [days] | reload | forget <nick> | ban <mask> <duration> [reason] | shadowban <mask> <duration> [reason] | unban <mask> | bans | maintenance [on [reason] | off] | series start <qualifiers> <name> | series end")
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "suspicious":
+		days := 30
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				h.notice(cmd.Nick, "Usage: $admin suspicious [days]")
+				return
+			}
+			days = n
+		}
+		go h.reportSuspicious(cmd.Nick, days)
+	case "reload":
+		if err := h.runReload(); err != nil {
+			h.notice(cmd.Nick, fmt.Sprintf("Reload failed, keeping the old settings: %v", err))
+			return
+		}
+		h.logAudit(cmd.Source, "reload", "", "", "")
+		h.notice(cmd.Nick, "Settings reloaded.")
+	case "ban", "shadowban":
+		h.adminBan(cmd, args, strings.ToLower(args[0]) == "shadowban")
+	case "unban":
+		h.adminUnban(cmd, args)
+	case "bans":
+		h.adminListBans(cmd)
+	case "maintenance":
+		h.adminMaintenance(cmd, args)
+	case "series":
+		h.adminSeries(cmd, args)
+	case "forget":
+		if len(args) != 2 {
+			h.notice(cmd.Nick, "Usage: $admin forget <nick>")
+			return
+		}
+		h.adminForget(cmd, args[1])
+	default:
+		h.notice(cmd.Nick, "Usage: $admin suspicious [days] | reload | forget <nick> | ban <mask> <duration> [reason] | shadowban <mask> <duration> [reason] | unban <mask> | bans | maintenance [on [reason] | off] | series start <qualifiers> <name> | series end")
+	}
+}
+
+// reportSuspicious sends an admin the collusion report for the last days.
+// It reads every hand in that time, so it runs off the event loop.
+func (h *Handler) reportSuspicious(nick string, days int) {
+	flags, err := collusion.Report(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		log.Printf("Error running collusion report: %v", err)
+		h.notice(nick, "Error running the report.")
+		return
+	}
+	if len(flags) == 0 {
+		h.notice(nick, fmt.Sprintf("Nothing suspicious in the last %d days.", days))
+		return
+	}
+	h.notice(nick, fmt.Sprintf("%d suspicious patterns in the last %d days:", len(flags), days))
+	for _, flag := range flags {
+		h.notice(nick, flag.String())
+	}
+}
@@ -0,0 +1,23 @@
+package irc
+
+import (
+	"log"
+
+	"poker-bot/game"
+)
+
+func (h *Handler) startChipAudit(channel string) {
+	h.audits[channel] = game.NewChipAudit(h.games[channel])
+}
+
+// auditChips logs when the chips on a table stop adding up. It never
+// interrupts the game; the log line is there to catch accounting bugs.
+func (h *Handler) auditChips(channel string) {
+	audit, g := h.audits[channel], h.games[channel]
+	if audit == nil || g == nil {
+		return
+	}
+	if err := audit.Check(g); err != nil {
+		log.Printf("Chip audit failed in %s: %v", channel, err)
+	}
+}
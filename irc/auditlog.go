@@ -0,0 +1,20 @@
+package irc
+
+import (
+	"log"
+
+	"poker-bot/db"
+)
+
+// controlActor is the actor of commands from the control socket, which
+// only the bot's own user can reach.
+const controlActor = "pokerctl"
+
+// logAudit records an operator action in the audit log. Admins are recorded
+// by their full nick!user@host, not just the nick they happened to use.
+func (h *Handler) logAudit(actor, action, channel, target, detail string) {
+	entry := db.AuditEntry{Actor: actor, Action: action, Channel: channel, Target: target, Detail: detail}
+	if err := db.Audit(entry); err != nil {
+		log.Printf("Error writing the audit log (%s %s %s): %v", actor, action, target, err)
+	}
+}
@@ -0,0 +1,157 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+)
+
+// loadBans reads the active bans into memory, the first time they're
+// needed and whenever an admin changes them, so checking every command
+// against them doesn't touch the database.
+func (h *Handler) loadBans() {
+	bans, err := db.ActiveBans()
+	if err != nil {
+		log.Printf("Error loading bans: %v", err)
+		return
+	}
+	h.bans = bans
+	h.bansLoaded = true
+}
+
+// banFor returns the ban on the sender of event, or nil if they aren't
+// banned. Admins can't be banned.
+func (h *Handler) banFor(cmd *Command) *db.Ban {
+	if !h.bansLoaded {
+		h.loadBans()
+	}
+	if len(h.bans) == 0 || h.isAdmin(cmd) {
+		return nil
+	}
+	for i := range h.bans {
+		ban := &h.bans[i]
+		if ban.Expired() {
+			continue
+		}
+		if strings.ContainsAny(ban.Mask, "!@") {
+			if matchMask(ban.Mask, cmd.Source) {
+				return ban
+			}
+		} else if strings.EqualFold(ban.Mask, cmd.Nick) {
+			return ban
+		}
+	}
+	return nil
+}
+
+// describeBan is what a banned player is told when they try to play.
+func describeBan(ban *db.Ban) string {
+	message := "You're banned from playing"
+	if !ban.Expires.IsZero() {
+		message += " until " + ban.Expires.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	if ban.Reason != "" {
+		message += ": " + ban.Reason
+	}
+	return message + "."
+}
+
+// adminBan is $admin ban|shadowban <mask> <duration|perm> [reason]. The
+// mask is a nick or a nick!user@host pattern.
+func (h *Handler) adminBan(cmd *Command, args []string, shadow bool) {
+	if len(args) < 3 {
+		h.notice(cmd.Nick, fmt.Sprintf("Usage: $admin %s <nick or nick!user@host> <duration, like 2h or 7d, or perm> [reason]", args[0]))
+		return
+	}
+	ban := db.Ban{
+		Mask:     args[1],
+		Reason:   strings.Join(args[3:], " "),
+		BannedBy: cmd.Nick,
+		Shadow:   shadow,
+	}
+	if args[2] != "perm" {
+		duration, err := parseBanDuration(args[2])
+		if err != nil {
+			h.notice(cmd.Nick, fmt.Sprintf("Bad duration %q: use minutes, hours or days, like 30m, 2h or 7d, or perm.", sanitize(args[2])))
+			return
+		}
+		ban.Expires = time.Now().Add(duration)
+	}
+
+	id, err := db.AddBan(ban)
+	if err != nil {
+		log.Printf("Error banning %s: %v", ban.Mask, err)
+		h.notice(cmd.Nick, "Error saving the ban.")
+		return
+	}
+	h.loadBans()
+	log.Printf("%s banned %s (ban %d, shadow %t): %s", cmd.Nick, ban.Mask, id, shadow, ban.Reason)
+	h.logAudit(cmd.Source, strings.ToLower(args[0]), "", ban.Mask, fmt.Sprintf("%s: %s", args[2], ban.Reason))
+	h.notice(cmd.Nick, fmt.Sprintf("Banned %s.", sanitize(ban.Mask)))
+}
+
+// adminUnban is $admin unban <mask>.
+func (h *Handler) adminUnban(cmd *Command, args []string) {
+	if len(args) != 2 {
+		h.notice(cmd.Nick, "Usage: $admin unban <nick or nick!user@host>")
+		return
+	}
+	removed, err := db.RemoveBans(args[1])
+	if err != nil {
+		log.Printf("Error unbanning %s: %v", args[1], err)
+		h.notice(cmd.Nick, "Error lifting the ban.")
+		return
+	}
+	if removed == 0 {
+		h.notice(cmd.Nick, fmt.Sprintf("%s isn't banned.", sanitize(args[1])))
+		return
+	}
+	h.loadBans()
+	log.Printf("%s unbanned %s", cmd.Nick, args[1])
+	h.logAudit(cmd.Source, "unban", "", args[1], "")
+	h.notice(cmd.Nick, fmt.Sprintf("Unbanned %s.", sanitize(args[1])))
+}
+
+// adminListBans is $admin bans.
+func (h *Handler) adminListBans(cmd *Command) {
+	h.loadBans()
+	if len(h.bans) == 0 {
+		h.notice(cmd.Nick, "Nobody is banned.")
+		return
+	}
+	for _, ban := range h.bans {
+		line := fmt.Sprintf("%s by %s", ban.Mask, ban.BannedBy)
+		if ban.Shadow {
+			line += ", shadow"
+		}
+		if ban.Expires.IsZero() {
+			line += ", permanent"
+		} else {
+			line += ", until " + ban.Expires.UTC().Format("2006-01-02 15:04 UTC")
+		}
+		if ban.Reason != "" {
+			line += ": " + ban.Reason
+		}
+		h.notice(cmd.Nick, line)
+	}
+}
+
+// parseBanDuration parses a Go duration, or a whole number of days like 7d.
+func parseBanDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(s)
+	if err == nil && duration <= 0 {
+		err = fmt.Errorf("duration %q isn't positive", s)
+	}
+	return duration, err
+}
@@ -0,0 +1,132 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"poker-bot/db"
+	"poker-bot/modes"
+)
+
+// sideGameOpen reports whether nick can start a side game. Side games play
+// straight from the bankroll, so they are closed to players seated where
+// their stack is their bankroll, written back over it when hands end, and
+// to players with a side game hand already in play.
+func (h *Handler) sideGameOpen(channel, nick string) bool {
+	if h.blackjack[nick] != nil || h.videoPoker[nick] != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, finish the hand you're playing first.", nick))
+		return false
+	}
+	if h.bankrollTable(nick) != "" {
+		h.privmsg(channel, fmt.Sprintf("%s, side games are for between games. You're seated at a poker table.", nick))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) handleBlackjack(cmd *Command) {
+	channel := cmd.Channel
+	if len(cmd.Args) < 1 {
+		h.privmsg(channel, "Usage: $blackjack <bet>, then $hit, $stand or $double")
+		return
+	}
+	if !h.sideGameOpen(channel, cmd.Nick) {
+		return
+	}
+
+	bet, err := strconv.Atoi(cmd.Args[0])
+	if err != nil || bet <= 0 {
+		h.privmsg(channel, "Invalid bet amount.")
+		return
+	}
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting player %s: %v", cmd.Nick, err)
+		return
+	}
+	if player.Money < bet {
+		h.privmsg(channel, fmt.Sprintf("%s, you only have %d.", cmd.Nick, player.Money))
+		return
+	}
+	if err := db.SettleWithHouse(player.Economy, cmd.Nick, -bet, "blackjack bet"); err != nil {
+		log.Printf("Error taking blackjack bet from %s: %v", cmd.Nick, err)
+		return
+	}
+
+	hand := modes.NewBlackjack(cmd.Nick, bet)
+	hand.Economy = player.Economy
+	h.blackjack[cmd.Nick] = hand
+	h.showBlackjack(channel, hand)
+}
+
+func (h *Handler) handleHit(cmd *Command) {
+	hand := h.blackjack[cmd.Nick]
+	if hand == nil {
+		return
+	}
+	hand.Hit()
+	h.showBlackjack(cmd.Channel, hand)
+}
+
+func (h *Handler) handleBlackjackStand(cmd *Command) {
+	hand := h.blackjack[cmd.Nick]
+	hand.Stand()
+	h.showBlackjack(cmd.Channel, hand)
+}
+
+func (h *Handler) handleDouble(cmd *Command) {
+	channel := cmd.Channel
+	hand := h.blackjack[cmd.Nick]
+	if hand == nil {
+		return
+	}
+
+	money, _, err := db.GetPlayerStats(hand.Economy, cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting stats for %s: %v", cmd.Nick, err)
+		return
+	}
+	if money < hand.Bet {
+		h.privmsg(channel, fmt.Sprintf("%s, you don't have %d more to double.", cmd.Nick, hand.Bet))
+		return
+	}
+	stake := hand.Bet
+	if err := hand.Double(); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v.", cmd.Nick, err))
+		return
+	}
+	if err := db.SettleWithHouse(hand.Economy, cmd.Nick, -stake, "blackjack double"); err != nil {
+		log.Printf("Error taking blackjack double from %s: %v", cmd.Nick, err)
+	}
+	h.showBlackjack(channel, hand)
+}
+
+// showBlackjack shows the hand, and settles it with the house once it's over.
+func (h *Handler) showBlackjack(channel string, hand *modes.Blackjack) {
+	if !hand.Done {
+		h.privmsg(channel, fmt.Sprintf("%s: %v (%d) vs dealer %v ?. $hit, $stand or $double",
+			hand.Nick, hand.Player, modes.BlackjackTotal(hand.Player), hand.Dealer[0]))
+		return
+	}
+
+	delete(h.blackjack, hand.Nick)
+	payout := hand.Payout()
+	if payout > 0 {
+		if err := db.SettleWithHouse(hand.Economy, hand.Nick, payout, "blackjack payout"); err != nil {
+			log.Printf("Error paying blackjack win to %s: %v", hand.Nick, err)
+		}
+	}
+
+	var result string
+	switch {
+	case payout > hand.Bet:
+		result = fmt.Sprintf("%s wins %d!", hand.Nick, payout-hand.Bet)
+	case payout == hand.Bet:
+		result = "Push."
+	default:
+		result = fmt.Sprintf("%s loses %d.", hand.Nick, hand.Bet)
+	}
+	h.privmsg(channel, fmt.Sprintf("%s: %v (%d) vs dealer %v (%d). %s",
+		hand.Nick, hand.Player, modes.BlackjackTotal(hand.Player), hand.Dealer, modes.BlackjackTotal(hand.Dealer), result))
+}
@@ -0,0 +1,71 @@
+package irc
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"poker-bot/db"
+	"poker-bot/game"
+)
+
+// ledgerTotal adds up nick's entries in economy whose reasons start
+// with reason.
+func ledgerTotal(t *testing.T, nick, economy, reason string) int {
+	t.Helper()
+	transactions, err := db.Transactions(nick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, transaction := range transactions {
+		if transaction.Economy == economy && strings.HasPrefix(transaction.Reason, reason) {
+			total += transaction.Amount
+		}
+	}
+	return total
+}
+
+func TestBlackjackLedger(t *testing.T) {
+	h := newTestHandler(t)
+	economy := h.economy("#blackjack")
+	jack, err := db.GetOrCreatePlayer(context.Background(), economy, "jack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, house := ledgerTotal(t, "jack", economy, "blackjack"), ledgerTotal(t, db.HouseNick, economy, "blackjack")
+	// A natural on the deal settles at once, so deal until there's a hand
+	// to double on.
+	settled := 0
+	for bets := 0; h.blackjack["jack"] == nil; bets++ {
+		if bets == 10 {
+			t.Fatal("ten naturals in a row")
+		}
+		settled = ledgerTotal(t, "jack", economy, "blackjack")
+		say(t, h, "jack", "#blackjack", "$blackjack 10")
+	}
+	hand := h.blackjack["jack"]
+	if got := ledgerTotal(t, "jack", economy, "blackjack") - settled; got != -10 {
+		t.Fatalf("the bet took %d from jack's ledger, want -10", got)
+	}
+	hand.Deck = game.NewStackedDealer(game.MustParseCards("10H"))
+	hand.Deck.Shuffle()
+	hand.Player = game.MustParseCards("5S 6D")
+	hand.Dealer = game.MustParseCards("10C 7H")
+	say(t, h, "jack", "#blackjack", "$double")
+
+	if h.blackjack["jack"] != nil {
+		t.Fatal("the hand is still in play after doubling")
+	}
+	// The double takes another 10 and 21 against 17 pays back 40.
+	won := settled - before - 20 + 40
+	if got := ledgerTotal(t, "jack", economy, "blackjack") - before; got != won {
+		t.Errorf("jack's ledger moved by %d, want %d", got, won)
+	}
+	if got := ledgerTotal(t, db.HouseNick, economy, "blackjack") - house; got != -won {
+		t.Errorf("the house's ledger moved by %d, want %d", got, -won)
+	}
+	if got := savedMoney(t, jack); got != jack.Money+won {
+		t.Errorf("jack has %d, want %d", got, jack.Money+won)
+	}
+}
@@ -0,0 +1,172 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+
+	"poker-bot/db"
+	"poker-bot/models"
+)
+
+const (
+	// cashBuyInBlinds is the cash game buy-in, in big blinds. Players with
+	// less in their bankroll buy in for all of it.
+	cashBuyInBlinds = 100
+	// cashMinBuyInBlinds is the least a player can sit down with.
+	cashMinBuyInBlinds = 10
+)
+
+// cashTable is a table started with --cash. Players buy in for a stack that
+// stays at the table from hand to hand, apart from their bankroll, and is
+// paid back into it when they $leave or the game ends.
+type cashTable struct {
+	leaving map[string]bool // players who leave when the hand is over
+}
+
+// stackIsBankroll reports whether a player's stack at channel is their
+// bankroll, written back over it after every hand. It isn't at tournament
+// and --cash tables, where players buy chips for the table.
+func (h *Handler) stackIsBankroll(channel string) bool {
+	return h.tournaments[channel] == nil && h.cashTables[channel] == nil
+}
+
+// bankrollTable returns the table where nick plays with their bankroll as
+// their stack, seated or waiting to be dealt in, or "" if there's none. The
+// table writes the stack back over the bankroll, so nothing else may charge
+// the bankroll while they're there.
+func (h *Handler) bankrollTable(nick string) string {
+	for table := range h.games {
+		if h.stackIsBankroll(table) && h.bankrollPlayer(table, nick) != nil {
+			return table
+		}
+	}
+	return ""
+}
+
+// bankrollPlayer returns nick's player at the bankroll table, seated or
+// waiting to be dealt in.
+func (h *Handler) bankrollPlayer(table, nick string) *models.Player {
+	if player := h.games[table].FindPlayer(nick); player != nil {
+		return player
+	}
+	for _, player := range h.lateJoins[table] {
+		if player.Nick == nick {
+			return player
+		}
+	}
+	return nil
+}
+
+// atBankrollTable reports whether nick is at a bankroll table other than
+// channel, telling them so.
+func (h *Handler) atBankrollTable(channel, nick string) bool {
+	table := h.bankrollTable(nick)
+	if table == "" || table == channel {
+		return false
+	}
+	h.privmsg(channel, fmt.Sprintf("%s, you're playing your bankroll at %s. Finish there first.", nick, table))
+	return true
+}
+
+// cashBuyIn moves the buy-in from the player's bankroll to their stack at
+// the table.
+func (h *Handler) cashBuyIn(channel string, player *models.Player) bool {
+	bigBlind := h.cashStakes(channel).BigBlind
+	amount := min(player.Money, cashBuyInBlinds*bigBlind)
+	if amount < cashMinBuyInBlinds*bigBlind {
+		h.privmsg(channel, fmt.Sprintf("%s, the least you can sit down with is %d and you only have %d.", player.Nick, cashMinBuyInBlinds*bigBlind, player.Money))
+		return false
+	}
+	if err := db.BuyIntoCashGame(player.Economy, channel, player.Nick, amount); err != nil {
+		log.Printf("Error buying %s into the cash game at %s: %v", player.Nick, channel, err)
+		h.privmsg(channel, fmt.Sprintf("Error adding player %s to the game.", player.Nick))
+		return false
+	}
+	player.Money = amount
+	h.privmsg(channel, fmt.Sprintf("%s buys in for %d.", player.Nick, amount))
+	return true
+}
+
+// cashOut pays a player's stack back into their bankroll.
+func (h *Handler) cashOut(channel string, player *models.Player) {
+	if err := db.CashOut(player.Economy, channel, player.Nick, player.Money); err != nil {
+		log.Printf("Error cashing out %s at %s: %v", player.Nick, channel, err)
+	}
+}
+
+// handleLeave takes a player away from a cash table with their stack: at
+// once if they're waiting for a seat or no hand is in play, otherwise when
+// the hand is over.
+func (h *Handler) handleLeave(cmd *Command) {
+	channel := cmd.Channel
+	cash := h.cashTables[channel]
+	if cash == nil {
+		h.privmsg(channel, "Only cash game players can $leave. Everyone else plays to the end.")
+		return
+	}
+
+	for i, player := range h.lateJoins[channel] {
+		if player.Nick == cmd.Nick {
+			h.lateJoins[channel] = append(h.lateJoins[channel][:i], h.lateJoins[channel][i+1:]...)
+			h.cashOut(channel, player)
+			h.privmsg(channel, fmt.Sprintf("%s leaves the table with %d.", player.Nick, player.Money))
+			return
+		}
+	}
+	player := h.games[channel].FindPlayer(cmd.Nick)
+	if player == nil {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not at the table.", cmd.Nick))
+		return
+	}
+	if h.audits[channel] != nil {
+		cash.leaving[player.Nick] = true
+		h.privmsg(channel, fmt.Sprintf("%s will leave the table when this hand is over.", player.Nick))
+		return
+	}
+
+	h.games[channel].RemovePlayer(player.Nick)
+	h.cashOut(channel, player)
+	h.privmsg(channel, fmt.Sprintf("%s leaves the table with %d.", player.Nick, player.Money))
+	if h.shouldEndGame(channel) {
+		h.endGame(channel)
+	}
+}
+
+// settleCashSeats runs at the end of each hand at a cash table: players
+// with no chips left lose their seat until they $rebuy, and players who
+// asked to leave take their stacks.
+func (h *Handler) settleCashSeats(channel string) {
+	cash := h.cashTables[channel]
+	table := h.games[channel]
+	for _, player := range append([]*models.Player{}, table.GetPlayers()...) {
+		switch {
+		case cash.leaving[player.Nick]:
+			table.RemovePlayer(player.Nick)
+			h.cashOut(channel, player)
+			h.privmsg(channel, fmt.Sprintf("%s leaves the table with %d.", player.Nick, player.Money))
+		case player.Money == 0:
+			table.RemovePlayer(player.Nick)
+			h.cashOut(channel, player)
+			h.privmsg(channel, fmt.Sprintf("%s is felted! $rebuy to buy back in.", player.Nick))
+		default:
+			if err := db.UpdateCashStack(player.Economy, channel, player.Nick, player.Money); err != nil {
+				log.Printf("Error saving %s's stack at %s: %v", player.Nick, channel, err)
+			}
+		}
+	}
+	cash.leaving = make(map[string]bool)
+}
+
+// closeCashTable pays every stack at the table, seated or about to be, back
+// into the bankrolls when the game ends.
+func (h *Handler) closeCashTable(channel string) {
+	if h.cashTables[channel] == nil {
+		return
+	}
+	for _, player := range h.games[channel].GetPlayers() {
+		h.cashOut(channel, player)
+	}
+	for _, player := range h.lateJoins[channel] {
+		h.cashOut(channel, player)
+	}
+}
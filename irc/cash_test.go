@@ -0,0 +1,84 @@
+package irc
+
+import (
+	"testing"
+
+	"poker-bot/models"
+)
+
+func TestCashBuyInAndLeave(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "cash1", "#cash", "$start holdem --cash")
+	h.mu.Lock()
+	for _, nick := range []string{"cash1", "cash2", "cash3", "cash4"} {
+		if !h.seatPlayer("#cash", nick) {
+			h.mu.Unlock()
+			t.Fatalf("%s wasn't seated", nick)
+		}
+	}
+	table := h.games["#cash"]
+	buyIn := cashBuyInBlinds * h.cashStakes("#cash").BigBlind
+	h.mu.Unlock()
+
+	seated := make(map[string]*models.Player)
+	for _, player := range table.GetPlayers() {
+		seated[player.Nick] = player
+		economy := player.Economy
+		if player.Money != buyIn {
+			t.Errorf("%s sat down with %d, want %d", player.Nick, player.Money, buyIn)
+		}
+		if got := ledgerTotal(t, player.Nick, economy, "cash game"); got != -buyIn {
+			t.Errorf("%s's buy-in took %d from their ledger, want %d", player.Nick, got, -buyIn)
+		}
+		if got := savedMoney(t, player); got != 0 {
+			t.Errorf("%s's bankroll is %d after buying in, want 0", player.Nick, got)
+		}
+	}
+
+	// Between hands, a player leaves at once with their stack.
+	say(t, h, "cash4", "#cash", "$leave")
+	if table.FindPlayer("cash4") != nil {
+		t.Error("cash4 is still seated after leaving between hands")
+	}
+	if got := savedMoney(t, seated["cash4"]); got != buyIn {
+		t.Errorf("cash4's bankroll is %d after leaving, want %d", got, buyIn)
+	}
+
+	// During a hand, they leave once it's over, with what they have then.
+	h.mu.Lock()
+	h.startRound("#cash")
+	h.mu.Unlock()
+	say(t, h, "cash3", "#cash", "$leave")
+	if table.FindPlayer("cash3") == nil {
+		t.Fatal("cash3 left in the middle of a hand")
+	}
+	if got := savedMoney(t, seated["cash3"]); got != 0 {
+		t.Errorf("cash3's bankroll is %d before the hand is over, want 0", got)
+	}
+	for folds := 0; table.FindPlayer("cash3") != nil; folds++ {
+		if folds == 2 {
+			t.Fatal("cash3 is still seated after the hand")
+		}
+		say(t, h, onTurn(table).Nick, "#cash", "$fold")
+	}
+	cash3 := seated["cash3"]
+	if got := savedMoney(t, cash3); got != cash3.Money {
+		t.Errorf("cash3's bankroll is %d after leaving, want their stack of %d", got, cash3.Money)
+	}
+	if got := ledgerTotal(t, "cash3", cash3.Economy, "cash game"); got != cash3.Money-buyIn {
+		t.Errorf("cash3's ledger totals %d, want %d", got, cash3.Money-buyIn)
+	}
+
+	// The chips at the table, in the next hand's pot and in the bankrolls
+	// are what was bought in.
+	total := table.GetPot()
+	for _, player := range seated {
+		total += savedMoney(t, player)
+		if table.FindPlayer(player.Nick) != nil {
+			total += player.Money
+		}
+	}
+	if total != 4*buyIn {
+		t.Errorf("the players have %d between their stacks and bankrolls, want %d", total, 4*buyIn)
+	}
+}
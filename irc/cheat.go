@@ -0,0 +1,510 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/flavor"
+	"poker-bot/game"
+	"poker-bot/models"
+	"poker-bot/modes"
+)
+
+const (
+	cheatSuccessRate = 80   // 1 in 80 chance of success
+	cheatPenaltyRate = 0.02 // 2% penalty for failed cheat attempt
+	peekSuccessRate  = 4    // 1 in 4 chance of a peek going unseen
+	peekPenaltyRate  = 0.05 // 5% penalty for getting caught peeking
+	stackSuccessRate = 10   // 1 in 10 chance of stacking the deck unseen
+	stackPenaltyRate = 0.05 // 5% penalty for getting caught stacking it
+)
+
+// cheatKind is one way to $cheat, named by the command's first argument.
+// Each has its own odds of working and penalty share of the cheater's
+// stack when they're caught. prepare, if set, checks the rest of the
+// arguments before anything is risked and returns why they won't do;
+// succeed plays out a cheat that worked.
+type cheatKind struct {
+	odds    int
+	penalty float64
+	prepare func(h *Handler, channel string, player *models.Player, args []string) string
+	succeed func(h *Handler, channel string, player *models.Player, args []string)
+}
+
+// cheatKinds are the ways to $cheat. A bare $cheat is "hand".
+var cheatKinds = map[string]cheatKind{
+	"hand": {
+		odds:    cheatSuccessRate,
+		penalty: cheatPenaltyRate,
+		prepare: unverifiedHand,
+		succeed: func(h *Handler, channel string, player *models.Player, _ []string) {
+			h.handleSuccessfulCheat(channel, player, h.games[channel])
+		},
+	},
+	"peek": {
+		odds:    peekSuccessRate,
+		penalty: peekPenaltyRate,
+		prepare: peekTarget,
+		succeed: peekCard,
+	},
+	"stack": {
+		odds:    stackSuccessRate,
+		penalty: stackPenaltyRate,
+		prepare: boardToCome,
+		succeed: stackDeck,
+	},
+}
+
+func (h *Handler) handleCheat(cmd *Command) {
+	channel := cmd.Channel
+	game := h.games[channel]
+
+	if game == nil {
+		h.privmsg(channel, "No game in progress.")
+		return
+	}
+
+	player := game.FindPlayer(cmd.Nick)
+	if player == nil {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
+		return
+	}
+
+	name, args := "hand", cmd.Args
+	if len(args) > 0 {
+		name, args = strings.ToLower(args[0]), args[1:]
+	}
+	kind, ok := cheatKinds[name]
+	if !ok {
+		h.notice(cmd.Nick, "Usage: $cheat [peek <nick> | stack]")
+		return
+	}
+	if refusal := h.cheatRefusal(player); refusal != "" {
+		h.notice(cmd.Nick, refusal)
+		return
+	}
+	if kind.prepare != nil {
+		if refusal := kind.prepare(h, channel, player, args); refusal != "" {
+			h.notice(cmd.Nick, refusal)
+			return
+		}
+	}
+	h.countCheat(player)
+
+	// Attempt to cheat PRISON RULES YO
+	caught := rand.Intn(kind.odds) != 0
+	if err := db.RecordCheat(player.Nick, caught); err != nil {
+		log.Printf("Error recording %s's cheat: %v", player.Nick, err)
+	}
+	if !caught {
+		// Successful cheat
+		kind.succeed(h, channel, player, args)
+	} else {
+		// Failed cheat attempt
+		h.handleFailedCheat(channel, player, game, kind.penalty)
+	}
+}
+
+// peekTarget checks that $cheat peek names another player still in the
+// hand.
+func peekTarget(h *Handler, channel string, player *models.Player, args []string) string {
+	if len(args) != 1 {
+		return "Usage: $cheat peek <nick>"
+	}
+	target := h.games[channel].FindPlayer(args[0])
+	switch {
+	case target == player:
+		return "You already know your own cards."
+	case target == nil || target.Folded || len(target.Hand) == 0:
+		return fmt.Sprintf("%s isn't in the hand.", sanitize(args[0]))
+	}
+	return ""
+}
+
+// peekCard privately shows the cheater one of the target's hole cards, at
+// random.
+func peekCard(h *Handler, channel string, player *models.Player, args []string) {
+	target := h.games[channel].FindPlayer(args[0])
+	card := target.Hand[rand.Intn(len(target.Hand))]
+	h.notice(player.Nick, fmt.Sprintf("Nobody saw a thing. One of %s's cards is %s.", target.Nick, h.cardText([]models.Card{card})))
+}
+
+// shameSize is how many players $shame lists.
+const shameSize = 5
+
+// handleShame shows the hall of shame: the players caught cheating most
+// often.
+func (h *Handler) handleShame(cmd *Command) {
+	records, err := db.MostCaught(shameSize)
+	if err != nil {
+		log.Printf("Error getting the hall of shame: %v", err)
+		h.privmsg(cmd.Channel, "Error retrieving the hall of shame.")
+		return
+	}
+	if len(records) == 0 {
+		h.privmsg(cmd.Channel, "Nobody has been caught cheating. Yet.")
+		return
+	}
+	entries := make([]string, 0, len(records))
+	for i, r := range records {
+		entries = append(entries, fmt.Sprintf("%d. %s caught %d (got away with %d)", i+1, r.Nick, r.Caught, r.Succeeded))
+	}
+	h.privmsg(cmd.Channel, "Hall of shame: "+strings.Join(entries, ", ")+".")
+}
+
+// cheatRefusal returns why player can't $cheat right now, or "" if they
+// can: the config limits how often a player may cheat in a hand, and makes
+// them wait some hands after one they cheated in. The cooldown doesn't
+// count against more cheats in the hand that started it.
+func (h *Handler) cheatRefusal(player *models.Player) string {
+	cooldown := h.cooldowns[player.Nick]
+	switch {
+	case h.config.CheatsPerHand == 0:
+		return "Cheating is turned off here."
+	case player.Cheats >= h.config.CheatsPerHand:
+		return "You've cheated enough for one hand."
+	case player.Cheats > 0:
+	case cooldown == 1:
+		return "The dealer is still watching you. You can cheat again next hand."
+	case cooldown > 1:
+		return fmt.Sprintf("The dealer is still watching you. You can cheat again in %d hands.", cooldown)
+	}
+	return ""
+}
+
+// countCheat counts a $cheat against player's limit for the hand and
+// starts their cooldown: the hand in play, then CheatCooldown more.
+func (h *Handler) countCheat(player *models.Player) {
+	player.Cheats++
+	h.cooldowns[player.Nick] = h.config.CheatCooldown + 1
+}
+
+// newCheatHand starts each player's count of cheats afresh for a new hand
+// at channel, and counts down the cooldowns of those dealt in. Cooldowns
+// are kept by nick on the handler, not on the player, who is loaded afresh
+// whenever they join, so leaving and coming back doesn't end one; hands
+// spent waiting for a seat aren't played, so they don't count.
+func (h *Handler) newCheatHand(channel string) {
+	for _, player := range h.games[channel].GetPlayers() {
+		player.Cheats = 0
+		if cooldown := h.cooldowns[player.Nick]; cooldown > 1 {
+			h.cooldowns[player.Nick] = cooldown - 1
+		} else {
+			delete(h.cooldowns, player.Nick)
+		}
+	}
+}
+
+// unverifiedHand refuses to swap a hand at a table whose deck is verified:
+// the revealed seed has to reproduce every hand dealt.
+func unverifiedHand(h *Handler, channel string, _ *models.Player, _ []string) string {
+	if h.shuffles[channel] != nil {
+		return "The deck is verified here, so your hand can't be swapped."
+	}
+	return ""
+}
+
+func (h *Handler) handleSuccessfulCheat(channel string, player *models.Player, game game.Game) {
+	switch g := game.(type) {
+	case *modes.Holdem:
+		h.handleHoldemCheat(channel, player, g)
+	case *modes.Omaha:
+		h.handleOmahaCheat(channel, player, g)
+	case *modes.FiveCardDraw:
+		h.handleFiveCardDrawCheat(channel, player, g)
+	default:
+		log.Printf("Unknown game type for cheating")
+		h.notice(player.Nick, "Cheat failed due to unknown game type.")
+	}
+}
+
+func (h *Handler) handleHoldemCheat(channel string, player *models.Player, game *modes.Holdem) {
+	river := game.GetRiver()
+	allCards := append(river, h.getAllOtherPlayerCards(game)...)
+	stage := game.GetStage() // 0: preflop, 1: flop, 2: turn, 3: river
+
+	switch stage {
+	case 0: // Pre-flop
+		player.Hand = getBestStartingHand(allCards)
+	case 1, 2, 3: // Flop, Turn, River
+		player.Hand = getBestPossibleHand(river, allCards)
+	}
+
+	h.notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
+}
+
+func (h *Handler) handleOmahaCheat(channel string, player *models.Player, game *modes.Omaha) {
+	river := game.GetRiver()
+	allCards := append(river, h.getAllOtherPlayerCards(game)...)
+	stage := game.GetStage() // 0: preflop, 1: flop, 2: turn, 3: river
+
+	switch stage {
+	case 0: // Pre-flop
+		player.Hand = getBestOmahaStartingHand(allCards)
+	case 1, 2, 3: // Flop, Turn, River
+		player.Hand = getBestPossibleOmahaHand(river, allCards)
+	}
+
+	h.notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
+}
+
+func (h *Handler) handleFiveCardDrawCheat(channel string, player *models.Player, game *modes.FiveCardDraw) {
+	allCards := h.getAllOtherPlayerCards(game)
+	player.Hand = getBestFiveCardDrawHand(allCards)
+	h.notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
+}
+
+// boardToCome checks that $cheat stack is in a board game with board
+// cards still to come, at a table whose deck isn't verified: a stacked deck
+// wouldn't match the seed revealed after the hand.
+func boardToCome(h *Handler, channel string, _ *models.Player, _ []string) string {
+	if h.shuffles[channel] != nil {
+		return "The deck is verified here, so it can't be stacked."
+	}
+	table := h.games[channel]
+	if _, ok := table.(game.DeckStacker); !ok {
+		return "There's no board to stack in this game."
+	}
+	if len(table.GetRiver()) >= 5 {
+		return "The board is already out."
+	}
+	return ""
+}
+
+// stackDeck puts the card that helps the cheater most on top of the deck,
+// by their equity with it, so it comes next on the board.
+func stackDeck(h *Handler, channel string, player *models.Player, _ []string) {
+	card, ok := h.games[channel].(game.DeckStacker).StackNextCard(player.Nick)
+	if !ok {
+		h.notice(player.Nick, "Your cheat worked, but there was nothing left to stack.")
+		return
+	}
+	h.notice(player.Nick, fmt.Sprintf("Nobody saw a thing. %s is on top of the deck.", h.cardText([]models.Card{card})))
+}
+
+// handleFailedCheat folds a player caught cheating and moves their penalty,
+// rate of their stack, into the pot for whoever wins the hand. The chips
+// only change hands, so the table's chip audit holds, and the stack is
+// saved with everyone else's when the hand settles. A bodyguard from the
+// shop takes the penalty instead, but not the fold.
+func (h *Handler) handleFailedCheat(channel string, player *models.Player, table game.Game, rate float64) {
+	if h.useItem(player, db.ItemBodyguard) {
+		h.logAudit(h.nick, "cheat penalty", channel, player.Nick, "blocked by a bodyguard")
+		h.privmsg(channel, h.message(channel, "cheat:bodyguard", flavor.Details{Nick: player.Nick}))
+		h.fold(channel, player)
+		return
+	}
+	penalty := game.Forfeit(table, player, int(float64(player.Money)*rate))
+	h.logAudit(h.nick, "cheat penalty", channel, player.Nick, fmt.Sprintf("%d into the pot", penalty))
+
+	h.privmsg(channel, h.message(channel, "cheat:caught", flavor.Details{Nick: player.Nick, Amount: penalty}))
+	h.fold(channel, player)
+}
+
+func (h *Handler) getAllOtherPlayerCards(game game.Game) []models.Card {
+	var cards []models.Card
+	for _, p := range game.GetPlayers() {
+		if !p.Folded {
+			cards = append(cards, p.Hand...)
+		}
+	}
+	return cards
+}
+
+// Helper functions for cheating mechanism
+
+func getBestStartingHand(usedCards []models.Card) []models.Card {
+	possibleHands := [][]string{
+		{"A", "A"}, {"K", "K"}, {"Q", "Q"}, {"A", "K"},
+		{"J", "J"}, {"10", "10"}, {"A", "Q"}, {"K", "Q"},
+	}
+
+	for _, hand := range possibleHands {
+		newHand := tryMakeHand(hand, usedCards)
+		if newHand != nil {
+			return newHand
+		}
+	}
+
+	// If all else fails, return two random high cards
+	return getRandomHighCards(usedCards, 2)
+}
+
+func getBestPossibleHand(river, usedCards []models.Card) []models.Card {
+	// Check for possible flush
+	flushSuit := getFlushSuit(river)
+	if flushSuit != "" {
+		return getHighestCards(flushSuit, usedCards, 2)
+	}
+
+	// Check for possible straight
+	straightCards := getPossibleStraightCards(river)
+	if len(straightCards) > 0 {
+		return getHighestCards(straightCards[0].Suit, usedCards, 2)
+	}
+
+	// If no flush or straight possible, get highest pair or high cards
+	return getHighestPairOrCards(river, usedCards)
+}
+
+func getBestOmahaStartingHand(usedCards []models.Card) []models.Card {
+	possibleHands := [][]string{
+		{"A", "A", "K", "K"}, {"A", "A", "Q", "Q"}, {"K", "K", "Q", "Q"},
+		{"A", "K", "Q", "J"}, {"A", "A", "J", "10"}, {"K", "K", "J", "10"},
+	}
+
+	for _, hand := range possibleHands {
+		newHand := tryMakeHand(hand, usedCards)
+		if newHand != nil {
+			return newHand
+		}
+	}
+
+	// If all else fails, return four random high cards
+	return getRandomHighCards(usedCards, 4)
+}
+
+func getBestPossibleOmahaHand(river, usedCards []models.Card) []models.Card {
+	// Similar to getBestPossibleHand, but returns 4 cards instead of 2
+	// Implement Omaha-specific logic here
+	// This is a simplified version and should be expanded for real use
+	hand := getBestPossibleHand(river, usedCards)
+	hand = append(hand, getRandomHighCards(append(usedCards, hand...), 2)...)
+	return hand
+}
+
+func getBestFiveCardDrawHand(usedCards []models.Card) []models.Card {
+	possibleHands := [][]string{
+		{"A", "K", "Q", "J", "10"}, // Royal Flush
+		{"A", "A", "A", "A", "K"},  // Four of a Kind
+		{"A", "A", "A", "K", "K"},  // Full House
+	}
+
+	for _, hand := range possibleHands {
+		newHand := tryMakeHand(hand, usedCards)
+		if newHand != nil {
+			return newHand
+		}
+	}
+
+	// If all else fails, return five random high cards
+	return getRandomHighCards(usedCards, 5)
+}
+
+func tryMakeHand(values []string, usedCards []models.Card) []models.Card {
+	suits := []string{"Hearts", "Diamonds", "Clubs", "Spades"}
+	hand := make([]models.Card, len(values))
+
+	for i, value := range values {
+		for _, suit := range suits {
+			card := models.Card{Suit: suit, Value: value}
+			if !containsCard(usedCards, card) {
+				hand[i] = card
+				break
+			}
+		}
+		if hand[i].Suit == "" {
+			return nil // Couldn't make this hand
+		}
+	}
+
+	return hand
+}
+
+func getFlushSuit(river []models.Card) string {
+	suitCounts := make(map[string]int)
+	for _, card := range river {
+		suitCounts[card.Suit]++
+		if suitCounts[card.Suit] >= 3 {
+			return card.Suit
+		}
+	}
+	return ""
+}
+
+func getPossibleStraightCards(river []models.Card) []models.Card {
+	values := make(map[string]bool)
+	for _, card := range river {
+		values[card.Value] = true
+	}
+
+	straightValues := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
+	for i := 0; i <= 9; i++ {
+		count := 0
+		for j := 0; j < 5; j++ {
+			if values[straightValues[i+j]] {
+				count++
+			}
+		}
+		if count >= 3 {
+			return river // Potential straight, return the river cards
+		}
+	}
+	return nil
+}
+
+func getHighestCards(suit string, usedCards []models.Card, count int) []models.Card {
+	values := []string{"A", "K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2"}
+	hand := make([]models.Card, 0)
+
+	for _, value := range values {
+		card := models.Card{Suit: suit, Value: value}
+		if !containsCard(usedCards, card) {
+			hand = append(hand, card)
+			if len(hand) == count {
+				break
+			}
+		}
+	}
+
+	return hand
+}
+
+func getHighestPairOrCards(river, usedCards []models.Card) []models.Card {
+	values := make(map[string]int)
+	for _, card := range river {
+		values[card.Value]++
+	}
+
+	// Check for pair
+	for value, count := range values {
+		if count == 2 {
+			return tryMakeHand([]string{value, value}, usedCards)
+		}
+	}
+
+	// If no pair, get highest cards
+	return getRandomHighCards(usedCards, 2)
+}
+
+func getRandomHighCards(usedCards []models.Card, count int) []models.Card {
+	values := []string{"A", "K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2"}
+	suits := []string{"Hearts", "Diamonds", "Clubs", "Spades"}
+	hand := make([]models.Card, 0)
+
+	for _, value := range values {
+		for _, suit := range suits {
+			card := models.Card{Suit: suit, Value: value}
+			if !containsCard(usedCards, card) {
+				hand = append(hand, card)
+				if len(hand) == count {
+					return hand
+				}
+			}
+		}
+	}
+
+	return hand
+}
+
+func containsCard(cards []models.Card, card models.Card) bool {
+	for _, c := range cards {
+		if c.Suit == card.Suit && c.Value == card.Value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,182 @@
+package irc
+
+import (
+	"context"
+	"testing"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// startHand starts a hold'em table at channel and deals a hand to nicks.
+func startHand(t *testing.T, h *Handler, channel string, nicks ...string) game.Game {
+	t.Helper()
+	say(t, h, nicks[0], channel, "$start holdem")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, nick := range nicks {
+		if !h.seatPlayer(channel, nick) {
+			t.Fatalf("%s wasn't seated", nick)
+		}
+	}
+	h.startRound(channel)
+	table := h.games[channel]
+	if table == nil || len(table.GetPlayers()[0].Hand) == 0 {
+		t.Fatal("the hand wasn't dealt")
+	}
+	return table
+}
+
+// onTurn returns the player whose turn it is.
+func onTurn(table game.Game) *models.Player {
+	return table.GetPlayers()[table.GetTurn()]
+}
+
+func savedMoney(t *testing.T, player *models.Player) int {
+	t.Helper()
+	saved, err := db.GetPlayer(context.Background(), player.Economy, player.Nick)
+	if err != nil || saved == nil {
+		t.Fatalf("getting %s: %v", player.Nick, err)
+	}
+	return saved.Money
+}
+
+func TestCaughtCheaterForfeitsIntoThePot(t *testing.T) {
+	h := newTestHandler(t)
+	nicks := []string{"caught1", "caught2", "caught3"}
+	table := startHand(t, h, "#caught", nicks...)
+	cheater := onTurn(table)
+	stack, pot := cheater.Money, table.GetPot()
+	bankrolls := func() int {
+		total := 0
+		for _, nick := range nicks {
+			total += savedMoney(t, &models.Player{Economy: cheater.Economy, Nick: nick})
+		}
+		return total
+	}
+	before, saved := bankrolls(), savedMoney(t, cheater)
+
+	h.mu.Lock()
+	h.handleFailedCheat("#caught", cheater, table, 0.25)
+	h.mu.Unlock()
+
+	penalty := stack / 4
+	if !cheater.Folded {
+		t.Error("the cheater wasn't folded")
+	}
+	if cheater.Money != stack-penalty {
+		t.Errorf("the cheater has %d chips, want %d", cheater.Money, stack-penalty)
+	}
+	if got := table.GetPot(); got != pot+penalty {
+		t.Errorf("the pot is %d, want %d", got, pot+penalty)
+	}
+	// The penalty is taken from the stack at the table; the bankroll is
+	// only saved when the hand settles.
+	if got := savedMoney(t, cheater); got != saved {
+		t.Errorf("%d chips were saved mid-hand, want the %d from before it", got, saved)
+	}
+
+	// Fold the hand to the big blind, who wins the penalty with the pot.
+	say(t, h, onTurn(table).Nick, "#caught", "$fold")
+	if got := savedMoney(t, cheater); got != stack-penalty {
+		t.Errorf("%d chips were saved when the hand settled, want %d", got, stack-penalty)
+	}
+	if after := bankrolls(); after != before {
+		t.Errorf("the players have %d chips between them after the hand, %d before", after, before)
+	}
+}
+
+func TestBodyguardTakesTheCheatPenalty(t *testing.T) {
+	h := newTestHandler(t)
+	table := startHand(t, h, "#bodyguard", "guarded1", "guarded2", "guarded3")
+	cheater := onTurn(table)
+	if err := db.BuyItem(cheater.Economy, cheater.Nick, db.ItemBodyguard, 0); err != nil {
+		t.Fatal(err)
+	}
+	stack, pot := cheater.Money, table.GetPot()
+
+	h.mu.Lock()
+	h.handleFailedCheat("#bodyguard", cheater, table, 0.25)
+	h.mu.Unlock()
+
+	if !cheater.Folded {
+		t.Error("the cheater wasn't folded")
+	}
+	if cheater.Money != stack {
+		t.Errorf("the cheater has %d chips, want all %d", cheater.Money, stack)
+	}
+	if got := table.GetPot(); got != pot {
+		t.Errorf("the pot is %d, want %d", got, pot)
+	}
+	items, err := db.Items(cheater.Economy, cheater.Nick)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items[db.ItemBodyguard] != 0 {
+		t.Errorf("the cheater still has %d bodyguards", items[db.ItemBodyguard])
+	}
+}
+
+func TestCheatPenaltyIsLimitedToTheStack(t *testing.T) {
+	h := newTestHandler(t)
+	table := startHand(t, h, "#penalty", "penalty1", "penalty2", "penalty3")
+	cheater := onTurn(table)
+	stack, pot := cheater.Money, table.GetPot()
+
+	h.mu.Lock()
+	h.handleFailedCheat("#penalty", cheater, table, 3)
+	h.mu.Unlock()
+
+	if cheater.Money != 0 {
+		t.Errorf("the cheater has %d chips, want 0", cheater.Money)
+	}
+	if got := table.GetPot(); got != pot+stack {
+		t.Errorf("the pot is %d, want %d", got, pot+stack)
+	}
+}
+
+func TestCheatsPerHandWithACooldown(t *testing.T) {
+	h := newTestHandler(t)
+	h.config.CheatsPerHand = 2
+	h.config.CheatCooldown = 1
+	table := startHand(t, h, "#cooldown", "cooldown1", "cooldown2")
+	cheater := onTurn(table)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := 1; i <= 2; i++ {
+		if refusal := h.cheatRefusal(cheater); refusal != "" {
+			t.Fatalf("cheat %d was refused: %s", i, refusal)
+		}
+		h.countCheat(cheater)
+	}
+	if h.cheatRefusal(cheater) == "" {
+		t.Error("a third cheat in the hand was allowed")
+	}
+
+	h.newCheatHand("#cooldown")
+	if h.cheatRefusal(cheater) == "" {
+		t.Error("the cheater could cheat again in the next hand")
+	}
+	h.newCheatHand("#cooldown")
+	if refusal := h.cheatRefusal(cheater); refusal != "" {
+		t.Errorf("the cooldown didn't end: %s", refusal)
+	}
+}
+
+func TestCheatCooldownOutlastsRejoining(t *testing.T) {
+	h := newTestHandler(t)
+	table := startHand(t, h, "#rejoin", "rejoin1", "rejoin2")
+	cheater := onTurn(table)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.countCheat(cheater)
+	h.newCheatHand("#rejoin")
+	// Leaving and joining again loads the player afresh.
+	rejoined := models.NewPlayer(cheater.Nick, cheater.Money, 0)
+	if h.cheatRefusal(rejoined) == "" {
+		t.Error("rejoining ended the cheat cooldown")
+	}
+}
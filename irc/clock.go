@@ -0,0 +1,77 @@
+package irc
+
+import (
+	"fmt"
+	"time"
+
+	"poker-bot/game"
+)
+
+// announceLevel applies the tournament's current blind level to the game
+// and announces it.
+func (h *Handler) announceLevel(channel string) {
+	t := h.tournaments[channel]
+	level := t.CurrentLevel()
+	if blinded, ok := h.games[channel].(game.Blinded); ok {
+		blinded.SetBlinds(level.SmallBlind, level.BigBlind, level.Ante)
+	}
+	h.privmsg(channel, fmt.Sprintf("Level %d: blinds now %s.", t.Level+1, level))
+	if t.AddOnOpen() {
+		h.privmsg(channel, fmt.Sprintf("The rebuy period is over. $addon for %d chips (%d) during this level.", t.AddOnChips, t.AddOnCost))
+	}
+}
+
+// takeBreak pauses the table between hands when the tournament clock is on
+// a break, and deals the next hand when the break is over. It reports
+// whether the table is on a break.
+func (h *Handler) takeBreak(channel string) bool {
+	t := h.tournaments[channel]
+	if t == nil || !t.Started() {
+		return false
+	}
+
+	clock := t.Clock(time.Now())
+	if !clock.OnBreak {
+		return false
+	}
+	levelUp := t.LevelUp(channel, time.Now())
+
+	h.privmsg(channel, fmt.Sprintf("Break! Play resumes in %s with blinds %s.", clock.Remaining.Round(time.Second), t.CurrentLevel()))
+	if t.AddOnOpen() {
+		h.privmsg(channel, fmt.Sprintf("The rebuy period is over. $addon for %d chips (%d) during the break.", t.AddOnChips, t.AddOnCost))
+	}
+
+	table := h.games[channel]
+	h.breaks[channel] = h.afterTable(channel, clock.Remaining, func() {
+		if h.games[channel] != table {
+			return
+		}
+		delete(h.breaks, channel)
+		if levelUp {
+			h.announceLevel(channel)
+		}
+		h.startRound(channel)
+	})
+	return true
+}
+
+func (h *Handler) handleClock(cmd *Command) {
+	channel := cmd.Channel
+	t := h.tournaments[channel]
+	if t == nil {
+		h.privmsg(channel, "There is no tournament running here.")
+		return
+	}
+	if !t.Started() {
+		h.privmsg(channel, fmt.Sprintf("The clock starts with the first hand. Level 1: %s for %s.", t.CurrentLevel(), t.LevelDuration))
+		return
+	}
+
+	clock := t.Clock(time.Now())
+	remaining := clock.Remaining.Round(time.Second)
+	if clock.OnBreak {
+		h.privmsg(channel, fmt.Sprintf("On break, %s left. Next: level %d, %s.", remaining, clock.Level+1, t.LevelAt(clock.Level)))
+		return
+	}
+	h.privmsg(channel, fmt.Sprintf("Level %d (%s): %s left. Next: %s.", clock.Level+1, t.LevelAt(clock.Level), remaining, t.LevelAt(clock.Level+1)))
+}
@@ -0,0 +1,51 @@
+package irc
+
+import (
+	"strings"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+// Command is a $command from a message, as the dispatcher parsed it. The
+// handlers take their arguments from it, never from the IRC event.
+type Command struct {
+	Name    string   // lowercased, with the $
+	Args    []string // the words after the name
+	Text    string   // everything after the name, as it was typed
+	Channel string   // where it was sent: a channel, or the bot for a private message
+	Nick    string
+	User    string
+	Host    string
+	Source  string // nick!user@host
+}
+
+// parseCommand parses a PRIVMSG into a Command. It reports false if the
+// message isn't one.
+func parseCommand(event *irc.Event) (*Command, bool) {
+	if len(event.Arguments) == 0 {
+		return nil, false
+	}
+	message := strings.TrimSpace(event.Message())
+	name, text, _ := strings.Cut(message, " ")
+	if !strings.HasPrefix(name, "$") {
+		return nil, false
+	}
+	return &Command{
+		Name:    strings.ToLower(name),
+		Args:    strings.Fields(text),
+		Text:    strings.TrimSpace(text),
+		Channel: event.Arguments[0],
+		Nick:    event.Nick,
+		User:    event.User,
+		Host:    event.Host,
+		Source:  event.Source,
+	}, true
+}
+
+// Arg returns the i'th argument, or "" if there are fewer.
+func (c *Command) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package irc
+
+import (
+	"reflect"
+	"testing"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		message string
+		ok      bool
+		name    string
+		args    []string
+		text    string
+	}{
+		{message: "$bet 100", ok: true, name: "$bet", args: []string{"100"}, text: "100"},
+		{message: "$BET 100", ok: true, name: "$bet", args: []string{"100"}, text: "100"},
+		{message: "$call", ok: true, name: "$call", args: []string{}, text: ""},
+		{message: "$call ", ok: true, name: "$call", args: []string{}, text: ""},
+		{message: "  $raise   50  ", ok: true, name: "$raise", args: []string{"50"}, text: "50"},
+		{message: "$start  holdem   --verified", ok: true, name: "$start", args: []string{"holdem", "--verified"}, text: "holdem   --verified"},
+		{message: "$leaderboard five card draw 5/10", ok: true, name: "$leaderboard", args: []string{"five", "card", "draw", "5/10"}, text: "five card draw 5/10"},
+		{message: "bet 100"},
+		{message: "nice hand $bob"},
+		{message: ""},
+		{message: "   "},
+	}
+	for _, test := range tests {
+		cmd, ok := parseCommand(&irc.Event{
+			Code:      "PRIVMSG",
+			Nick:      "ann",
+			User:      "~a",
+			Host:      "example.com",
+			Source:    "ann!~a@example.com",
+			Arguments: []string{"#poker", test.message},
+		})
+		if ok != test.ok {
+			t.Errorf("parseCommand(%q) reported %v, want %v", test.message, ok, test.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if cmd.Name != test.name || cmd.Text != test.text || !reflect.DeepEqual(cmd.Args, test.args) {
+			t.Errorf("parseCommand(%q) = %q %q %q, want %q %q %q", test.message, cmd.Name, cmd.Args, cmd.Text, test.name, test.args, test.text)
+		}
+		if cmd.Channel != "#poker" || cmd.Nick != "ann" || cmd.Source != "ann!~a@example.com" {
+			t.Errorf("parseCommand(%q) is from %s in %s, want ann!~a@example.com in #poker", test.message, cmd.Source, cmd.Channel)
+		}
+	}
+
+	if _, ok := parseCommand(&irc.Event{Code: "PRIVMSG", Nick: "ann"}); ok {
+		t.Error("parseCommand parsed an event without arguments")
+	}
+}
+
+func TestCommandArg(t *testing.T) {
+	cmd := &Command{Args: []string{"holdem", "5/10"}}
+	if got := cmd.Arg(1); got != "5/10" {
+		t.Errorf("Arg(1) = %q, want 5/10", got)
+	}
+	if got := cmd.Arg(2); got != "" {
+		t.Errorf("Arg(2) = %q, want empty", got)
+	}
+}
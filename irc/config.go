@@ -0,0 +1,45 @@
+package irc
+
+import (
+	"time"
+
+	"poker-bot/config"
+	"poker-bot/game"
+	"poker-bot/modes"
+)
+
+// SetConfig applies operator settings. Turn clocks and stakes change from
+// the next hand at every table; the hand in play keeps what it was dealt
+// with.
+func (h *Handler) SetConfig(c config.Config) {
+	h.config = c
+	h.limiter.SetInterval(time.Duration(c.CommandInterval))
+	modes.SetEquityWorkers(c.EquityWorkers)
+}
+
+// Reload runs the reloader set with SetReloader, between IRC events.
+func (h *Handler) Reload() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.runReload()
+}
+
+func (h *Handler) runReload() error {
+	if h.reload == nil {
+		return nil
+	}
+	return h.reload()
+}
+
+// applyStakes sets a cash game's blinds before the deal, to the stakes the
+// host chose or else the config's.
+// Tournament blinds come from the level clock instead.
+func (h *Handler) applyStakes(channel string) {
+	if h.tournaments[channel] != nil {
+		return
+	}
+	if blinded, ok := h.games[channel].(game.Blinded); ok {
+		stakes := h.cashStakes(channel)
+		blinded.SetBlinds(stakes.SmallBlind, stakes.BigBlind, stakes.Ante)
+	}
+}
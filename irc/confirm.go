@@ -0,0 +1,79 @@
+package irc
+
+import (
+	"fmt"
+	"time"
+
+	"poker-bot/models"
+)
+
+const (
+	// confirmWindow is how long a player has to $confirm a large bet.
+	confirmWindow = 10 * time.Second
+	// maxAmountDigits bounds a typed amount well short of overflowing an
+	// int, and of any stack.
+	maxAmountDigits = 9
+)
+
+// pendingBet is a bet or raise held back until the player confirms it.
+type pendingBet struct {
+	nick    string
+	command string // $bet or $raise
+	amount  int
+	expires time.Time
+}
+
+// parseAmount reads a chip amount typed after $bet or $raise. Only plain
+// digits count: signs, separators and suffixes such as "1,000" or "2k" are
+// more likely typos than anything the player meant.
+func parseAmount(text string) (int, bool) {
+	if text == "" || len(text) > maxAmountDigits {
+		return 0, false
+	}
+	amount := 0
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+		amount = amount*10 + int(r-'0')
+	}
+	return amount, amount > 0
+}
+
+// holdLargeBet holds back a bet or raise that puts more than half of the
+// player's stack in the pot without putting all of it in, if the config
+// asks for large bets to be confirmed. It reports whether it was held.
+func (h *Handler) holdLargeBet(cmd *Command, player *models.Player, amount, chips int) bool {
+	if !h.config.ConfirmLargeBets || chips*2 <= player.Money || chips >= player.Money {
+		return false
+	}
+	h.pendingBets[cmd.Channel] = &pendingBet{
+		nick:    cmd.Nick,
+		command: cmd.Name,
+		amount:  amount,
+		expires: time.Now().Add(confirmWindow),
+	}
+	h.notice(cmd.Nick, fmt.Sprintf("That's %d of your %d chips. Type $confirm within %s to go ahead, or act again.", chips, player.Money, confirmWindow))
+	return true
+}
+
+// handleConfirm places the bet or raise the player was asked to confirm.
+func (h *Handler) handleConfirm(cmd *Command) {
+	channel := cmd.Channel
+	pending := h.pendingBets[channel]
+	delete(h.pendingBets, channel)
+	if pending == nil || pending.nick != cmd.Nick || time.Now().After(pending.expires) {
+		h.notice(cmd.Nick, "There's no bet waiting for you to confirm.")
+		return
+	}
+
+	player := h.games[channel].FindPlayer(cmd.Nick)
+	if player == nil {
+		return
+	}
+	if pending.command == "$raise" {
+		h.placeRaise(channel, player, pending.amount)
+		return
+	}
+	h.placeBet(channel, player, pending.amount)
+}
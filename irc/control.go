@@ -0,0 +1,304 @@
+package irc
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/models"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+// ServeControl listens for operator commands from pokerctl on a Unix socket
+// at path. Each connection sends one command line and gets the reply back;
+// replies to commands that failed start with "error: ". The socket is only
+// accessible to the bot's own user.
+func (h *Handler) ServeControl(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				log.Printf("Control socket closed: %v", err)
+				return
+			}
+			go h.serveControlConn(conn)
+		}
+	}()
+	return nil
+}
+
+// SetReloader sets what the control socket's reload command runs.
+func (h *Handler) SetReloader(reload func() error) {
+	h.reload = reload
+}
+
+func (h *Handler) serveControlConn(conn net.Conn) {
+	defer conn.Close()
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return
+	}
+	log.Printf("Control command: %s", strings.TrimSpace(line))
+
+	h.mu.Lock()
+	reply, err := h.control(strings.Fields(line))
+	h.mu.Unlock()
+
+	if err != nil {
+		reply = "error: " + err.Error()
+	}
+	fmt.Fprintln(conn, reply)
+}
+
+func (h *Handler) control(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command")
+	}
+
+	switch args[0] {
+	case "tables":
+		return h.controlTables(), nil
+	case "end":
+		if len(args) != 2 {
+			return "", fmt.Errorf("usage: end <channel>")
+		}
+		reply, err := h.voidHand(args[1])
+		if err == nil {
+			h.logAudit(controlActor, "void", args[1], "", "")
+		}
+		return reply, err
+	case "chips":
+		if len(args) != 3 && len(args) != 4 {
+			return "", fmt.Errorf("usage: chips <nick> <amount> [channel]")
+		}
+		amount, err := strconv.Atoi(args[2])
+		if err != nil {
+			return "", fmt.Errorf("invalid amount %q", args[2])
+		}
+		channel := ""
+		if len(args) == 4 {
+			channel = args[3]
+		}
+		reply, err := h.adjustChips(args[1], amount, channel)
+		if err == nil {
+			h.logAudit(controlActor, "chips", channel, args[1], fmt.Sprintf("%+d", amount))
+		}
+		return reply, err
+	case "broadcast":
+		if len(args) < 2 {
+			return "", fmt.Errorf("usage: broadcast <message>")
+		}
+		message := strings.Join(args[1:], " ")
+		h.logAudit(controlActor, "broadcast", "", "", message)
+		return h.broadcast(message), nil
+	case "reload":
+		if err := h.runReload(); err != nil {
+			return "", err
+		}
+		h.logAudit(controlActor, "reload", "", "", "")
+		return "Configuration reloaded.", nil
+	case "ratelimit":
+		return describeRateLimits(h.RateLimitStats()), nil
+	}
+	return "", fmt.Errorf("unknown command %q", args[0])
+}
+
+func (h *Handler) controlTables() string {
+	if len(h.games) == 0 {
+		return "No tables running."
+	}
+
+	channels := make([]string, 0, len(h.games))
+	for channel := range h.games {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+
+	lines := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		table := h.games[channel]
+		stacks := make([]string, 0, len(table.GetPlayers()))
+		for _, player := range table.GetPlayers() {
+			stacks = append(stacks, fmt.Sprintf("%s %d", player.Nick, player.Money))
+		}
+
+		line := fmt.Sprintf("%s: %s, hand %d, pot %d", channel, table.GetType(), table.GetHandCount(), table.GetPot())
+		if t := h.tournaments[channel]; t != nil {
+			line += fmt.Sprintf(", tournament level %d", t.Level+1)
+		}
+		if nick := h.currentTurn[channel]; nick != "" && h.audits[channel] != nil {
+			line += fmt.Sprintf(", %s to act", nick)
+		}
+		lines = append(lines, fmt.Sprintf("%s [%s]", line, strings.Join(stacks, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// describeRateLimits lists the limiter's counters, the most throttled
+// commands first.
+func describeRateLimits(stats RateLimitStats) string {
+	line := fmt.Sprintf("Tracking %d nick, channel and command keys: %d commands allowed, %d throttled.", stats.Tracked, stats.Allowed, stats.Throttled)
+	commands := make([]string, 0, len(stats.ByCommand))
+	for command := range stats.ByCommand {
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if stats.ByCommand[commands[i]] != stats.ByCommand[commands[j]] {
+			return stats.ByCommand[commands[i]] > stats.ByCommand[commands[j]]
+		}
+		return commands[i] < commands[j]
+	})
+	throttled := make([]string, len(commands))
+	for i, command := range commands {
+		throttled[i] = fmt.Sprintf("%s %d", command, stats.ByCommand[command])
+	}
+	if len(throttled) > 0 {
+		line += " Throttled: " + strings.Join(throttled, ", ") + "."
+	}
+	return line
+}
+
+// noteStacks remembers every stack at the table before the deal, so an
+// operator can void the hand.
+func (h *Handler) noteStacks(channel string) {
+	stacks := make(map[string]int)
+	for _, player := range h.games[channel].GetPlayers() {
+		stacks[player.Nick] = player.Money
+	}
+	h.stacks[channel] = stacks
+}
+
+// voidHand ends the hand in play at channel without a winner, puts every
+// stack back to what it was before the deal and deals the next hand.
+func (h *Handler) voidHand(channel string) (string, error) {
+	table := h.games[channel]
+	if table == nil || h.audits[channel] == nil {
+		return "", fmt.Errorf("no hand in play at %s", channel)
+	}
+
+	h.pauseTurnClock(channel)
+	h.stopRunOut(channel)
+	for _, player := range table.GetPlayers() {
+		if stack, ok := h.stacks[channel][player.Nick]; ok {
+			player.Money = stack
+		}
+		if err := h.savePlayer(channel, player); err != nil {
+			log.Printf("Error updating %s after a voided hand: %v", player.Nick, err)
+		}
+	}
+	h.flushPlayers()
+	table.AddToPot(-table.GetPot())
+	delete(h.audits, channel)
+	delete(h.stacks, channel)
+	h.currentTurn[channel] = ""
+
+	h.privmsg(channel, "An operator has voided this hand. Every stack is back to what it was before the deal.")
+	h.refundRailBets(channel)
+	h.closeHistory(channel)
+	if h.shouldEndGame(channel) {
+		h.endGame(channel)
+	} else {
+		h.startRound(channel)
+	}
+	return fmt.Sprintf("Voided the hand at %s.", channel), nil
+}
+
+// adjustChips adds amount, which may be negative, to a player's bankroll in
+// the economy of channel. If the player is seated at channel, or plays
+// their bankroll at a table in the same economy, the stack in play changes
+// too, as it's written over the bankroll when the hand ends; at a
+// tournament table only the tournament stack does.
+func (h *Handler) adjustChips(nick string, amount int, channel string) (string, error) {
+	if channel == "" && h.economyMode == EconomyChannel {
+		return "", fmt.Errorf("every channel has its own economy, name one")
+	}
+	economy := h.economy(channel)
+
+	var seated *models.Player
+	table := channel
+	if game := h.games[channel]; game != nil {
+		seated = game.FindPlayer(nick)
+	}
+	if at := h.bankrollTable(nick); seated == nil && at != "" && h.economy(at) == economy {
+		table, seated = at, h.bankrollPlayer(at, nick)
+	}
+	player := seated
+	if player == nil {
+		var err error
+		if player, err = db.GetOrCreatePlayer(h.ctx, economy, nick); err != nil {
+			return "", err
+		}
+	}
+	if player.Money+amount < 0 {
+		return "", fmt.Errorf("%s only has %d", nick, player.Money)
+	}
+
+	if seated == nil || h.stackIsBankroll(table) {
+		if err := db.RecordTransaction(economy, nick, amount, "operator adjustment"); err != nil {
+			return "", err
+		}
+	}
+	player.Money += amount
+	if seated != nil {
+		if audit := h.audits[table]; audit != nil && h.games[table].FindPlayer(nick) != nil {
+			audit.Adjust(amount)
+		}
+		h.privmsg(table, fmt.Sprintf("An operator has adjusted %s's stack by %+d.", nick, amount))
+	}
+	return fmt.Sprintf("%s now has %d.", nick, player.Money), nil
+}
+
+// trackChannels keeps the set of channels the bot is in, for broadcasts.
+func (h *Handler) trackChannels() {
+	h.channels = make(map[string]bool)
+	h.conn.AddCallback("JOIN", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.isBot(e.Nick) {
+			h.channels[strings.ToLower(e.Arguments[0])] = true
+		}
+	})
+	h.conn.AddCallback("PART", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.isBot(e.Nick) {
+			delete(h.channels, strings.ToLower(e.Arguments[0]))
+		}
+	})
+	h.conn.AddCallback("KICK", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(e.Arguments) > 1 && h.isBot(e.Arguments[1]) {
+			delete(h.channels, strings.ToLower(e.Arguments[0]))
+		}
+	})
+}
+
+func (h *Handler) broadcast(message string) string {
+	if len(h.channels) == 0 {
+		return "Not in any channels."
+	}
+	for channel := range h.channels {
+		h.privmsg(channel, "Announcement: "+sanitize(message))
+	}
+	return fmt.Sprintf("Sent to %d channels.", len(h.channels))
+}
@@ -0,0 +1,103 @@
+package irc
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"poker-bot/models"
+)
+
+func TestControlRateLimit(t *testing.T) {
+	h := newTestHandler(t)
+	h.limiter.SetInterval(time.Hour)
+	h.limiter.Allow("flood", "#flood", "$bet")
+	h.limiter.Allow("flood", "#flood", "$bet")
+	h.limiter.Allow("flood", "#flood", "$call")
+	h.limiter.Allow("Flood", "#Flood", "$call")
+	h.limiter.Allow("flood", "#flood", "$bet")
+
+	stats := h.RateLimitStats()
+	if stats.Throttled < 3 || stats.ByCommand["$bet"] < 2 || stats.ByCommand["$call"] < 1 {
+		t.Fatalf("the limiter counted %+v", stats)
+	}
+	reply, err := h.control([]string{"ratelimit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := describeRateLimits(stats); reply != want {
+		t.Errorf("ratelimit replied %q, want %q", reply, want)
+	}
+}
+
+func TestDescribeRateLimits(t *testing.T) {
+	tests := []struct {
+		stats RateLimitStats
+		want  string
+	}{
+		{
+			RateLimitStats{Tracked: 2, Allowed: 10},
+			"Tracking 2 nick, channel and command keys: 10 commands allowed, 0 throttled.",
+		},
+		{
+			RateLimitStats{Tracked: 1, Allowed: 1, Throttled: 4, ByCommand: map[string]uint64{"$call": 1, "$bet": 2, "$raise": 1}},
+			"Tracking 1 nick, channel and command keys: 1 commands allowed, 4 throttled. Throttled: $bet 2, $call 1, $raise 1.",
+		},
+	}
+	for _, test := range tests {
+		if got := describeRateLimits(test.stats); got != test.want {
+			t.Errorf("describeRateLimits(%+v) = %q, want %q", test.stats, got, test.want)
+		}
+	}
+}
+
+func TestRateLimitIsPerCommand(t *testing.T) {
+	limiter := newRateLimiter(time.Hour, limiterPruneAge)
+	if !limiter.Allow("quick", "#quick", "$bet") || !limiter.Allow("quick", "#quick", "$call") {
+		t.Fatal("a different command was throttled")
+	}
+	if limiter.Allow("Quick", "#QUICK", "$bet") {
+		t.Error("a repeat in another case wasn't throttled")
+	}
+}
+
+func TestAdjustChipsAtABankrollTable(t *testing.T) {
+	h := newTestHandler(t)
+	table := startHand(t, h, "#adjust", "adjust1", "adjust2")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	player := table.FindPlayer("adjust1")
+	stack := player.Money
+
+	if _, err := h.adjustChips("adjust1", 50, ""); err != nil {
+		t.Fatal(err)
+	}
+	if player.Money != stack+50 {
+		t.Errorf("the stack at the table is %d, want %d", player.Money, stack+50)
+	}
+
+	waiting := &models.Player{Nick: "adjust3", Economy: player.Economy, Money: 200}
+	h.lateJoins["#adjust"] = append(h.lateJoins["#adjust"], waiting)
+	if _, err := h.adjustChips("adjust3", -20, ""); err != nil {
+		t.Fatal(err)
+	}
+	if waiting.Money != 180 {
+		t.Errorf("the waiting player has %d, want 180", waiting.Money)
+	}
+}
+
+func TestThrottledCommandCountsAreCapped(t *testing.T) {
+	limiter := newRateLimiter(time.Hour, limiterPruneAge)
+	for i := 0; i < 2*maxCountedCommands; i++ {
+		command := fmt.Sprintf("$junk%d", i)
+		limiter.Allow("junk", "#junk", command)
+		limiter.Allow("junk", "#junk", command)
+	}
+	stats := limiter.Stats()
+	if len(stats.ByCommand) > maxCountedCommands+1 {
+		t.Errorf("throttles are counted for %d commands", len(stats.ByCommand))
+	}
+	if stats.ByCommand["other"] != maxCountedCommands {
+		t.Errorf("%d throttles were counted as other, want %d", stats.ByCommand["other"], maxCountedCommands)
+	}
+}
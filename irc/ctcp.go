@@ -0,0 +1,55 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+const botVersion = "PokerBot 1.0 - https://github.com/strangeprogram/poker-bot"
+
+// registerCTCP replaces go-ircevent's default CTCP replies with throttled
+// ones and refuses DCC offers, so the bot can't be used to amplify floods.
+func (h *Handler) registerCTCP() {
+	h.conn.Version = botVersion
+
+	for _, code := range []string{"CTCP_VERSION", "CTCP_PING", "CTCP_TIME", "CTCP_USERINFO", "CTCP_CLIENTINFO"} {
+		h.conn.ClearCallback(code)
+	}
+
+	h.conn.AddCallback("CTCP_VERSION", func(e *irc.Event) {
+		h.ctcpReply(e, "VERSION "+botVersion)
+	})
+	h.conn.AddCallback("CTCP_PING", func(e *irc.Event) {
+		h.ctcpReply(e, e.Message())
+	})
+	h.conn.AddCallback("CTCP_TIME", func(e *irc.Event) {
+		h.ctcpReply(e, "TIME "+time.Now().Format(time.RFC1123))
+	})
+	h.conn.AddCallback("CTCP_CLIENTINFO", func(e *irc.Event) {
+		h.ctcpReply(e, "CLIENTINFO PING VERSION TIME CLIENTINFO")
+	})
+	h.conn.AddCallback("CTCP", h.handleUnknownCTCP)
+}
+
+func (h *Handler) ctcpReply(event *irc.Event, reply string) {
+	if !h.limiter.Allow(event.Nick, "ctcp", "ctcp") {
+		return
+	}
+	h.conn.Notice(event.Nick, fmt.Sprintf("\x01%s\x01", stripUnsafe(reply)))
+}
+
+func (h *Handler) handleUnknownCTCP(event *irc.Event) {
+	request := event.Message()
+	if strings.HasPrefix(strings.ToUpper(request), "DCC") {
+		log.Printf("Rejected DCC request from %s: %s", event.Nick, request)
+		if h.limiter.Allow(event.Nick, "ctcp", "dcc") {
+			h.notice(event.Nick, "DCC is not accepted.")
+		}
+		return
+	}
+	log.Printf("Ignoring unknown CTCP from %s: %s", event.Nick, request)
+}
@@ -0,0 +1,171 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// dealTimeout is how long the players have to $accept a chop before play
+// resumes.
+const dealTimeout = 60 * time.Second
+
+// dealOffer is a proposed ICM chop. It is worked out from the stacks when
+// the current hand ends, then every player still in, seated or waiting for
+// a seat, must $accept it.
+type dealOffer struct {
+	proposer string
+	amounts  map[string]int // nil until the hand ends
+	accepted map[string]bool
+	timer    *time.Timer
+}
+
+func (h *Handler) handleDeal(cmd *Command) {
+	channel := cmd.Channel
+	if h.tournaments[channel] == nil {
+		h.privmsg(channel, "Deals can only be made in tournaments.")
+		return
+	}
+	if !h.inTournament(channel, cmd.Nick) {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the tournament.", cmd.Nick))
+		return
+	}
+	t := h.tournaments[channel]
+	if remaining, paid := t.Remaining(), t.PlacesPaid(); remaining > paid {
+		h.privmsg(channel, fmt.Sprintf("Deals can only be made in the money: %d players left and %d paid.", remaining, paid))
+		return
+	}
+	if len(h.tablesOf(channel)) > 1 {
+		h.privmsg(channel, "Deals can only be made at the final table.")
+		return
+	}
+	if h.deals[channel] != nil {
+		h.privmsg(channel, "A deal is already on the table.")
+		return
+	}
+
+	h.deals[channel] = &dealOffer{proposer: cmd.Nick, accepted: make(map[string]bool)}
+	h.privmsg(channel, fmt.Sprintf("%s proposes an ICM deal. The chop will be worked out when this hand ends.", cmd.Nick))
+}
+
+// offerDeal pauses the table between hands while a proposed deal is put to
+// the players. It reports whether the table is paused.
+func (h *Handler) offerDeal(channel string) bool {
+	offer := h.deals[channel]
+	if offer == nil || offer.amounts != nil {
+		return false
+	}
+
+	t := h.tournaments[channel]
+	if remaining, paid := t.Remaining(), t.PlacesPaid(); remaining > paid {
+		// Someone bought back in since the deal was proposed.
+		h.privmsg(channel, fmt.Sprintf("%d players are left and %d paid, so %s's deal is off.", remaining, paid, offer.proposer))
+		delete(h.deals, channel)
+		return false
+	}
+	players := append([]*models.Player{}, h.games[channel].GetPlayers()...)
+	players = append(players, h.lateJoins[channel]...)
+	stacks := make([]int, len(players))
+	for i, player := range players {
+		stacks[i] = player.Money
+	}
+	prizes := t.Prizes()
+	if len(prizes) > len(players) {
+		prizes = prizes[:len(players)]
+	}
+
+	offer.amounts = make(map[string]int)
+	terms := make([]string, 0, len(players))
+	for i, amount := range game.ICMChop(stacks, prizes) {
+		offer.amounts[players[i].Nick] = amount
+		terms = append(terms, fmt.Sprintf("%s %d", players[i].Nick, amount))
+	}
+	h.privmsg(channel, fmt.Sprintf("ICM deal: %s. Everyone must $accept within %s, or $decline to play on.", strings.Join(terms, ", "), dealTimeout))
+
+	table := h.games[channel]
+	offer.timer = h.afterTable(channel, dealTimeout, func() {
+		if h.games[channel] != table || h.deals[channel] != offer {
+			return
+		}
+		h.privmsg(channel, "Not everyone accepted the deal. Play resumes.")
+		delete(h.deals, channel)
+		h.startRound(channel)
+	})
+	return true
+}
+
+// inTournament reports whether nick is still in the tournament at channel,
+// seated or waiting to be dealt in after a rebuy.
+func (h *Handler) inTournament(channel, nick string) bool {
+	if h.games[channel].FindPlayer(nick) != nil {
+		return true
+	}
+	for _, player := range h.lateJoins[channel] {
+		if player.Nick == nick {
+			return true
+		}
+	}
+	return false
+}
+
+// withdrawDeal calls off the deal being put to the players at channel when
+// nick buys in while they vote, since the chop was worked out without them.
+// A deal still waiting for the hand to end takes them in when it's worked
+// out.
+func (h *Handler) withdrawDeal(channel, nick string) {
+	offer := h.deals[channel]
+	if offer == nil || offer.amounts == nil {
+		return
+	}
+	if _, ok := offer.amounts[nick]; ok {
+		return
+	}
+	offer.timer.Stop()
+	delete(h.deals, channel)
+	h.privmsg(channel, fmt.Sprintf("%s is back in, so the deal is off. Play resumes.", nick))
+	h.startRound(channel)
+}
+
+func (h *Handler) handleAccept(cmd *Command) {
+	channel := cmd.Channel
+	offer := h.deals[channel]
+	if offer == nil || offer.amounts == nil {
+		h.privmsg(channel, "There is no deal to accept.")
+		return
+	}
+	if _, ok := offer.amounts[cmd.Nick]; !ok {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not part of this deal.", cmd.Nick))
+		return
+	}
+
+	offer.accepted[cmd.Nick] = true
+	if len(offer.accepted) < len(offer.amounts) {
+		h.privmsg(channel, fmt.Sprintf("%s accepts the deal (%d/%d).", cmd.Nick, len(offer.accepted), len(offer.amounts)))
+		return
+	}
+
+	offer.timer.Stop()
+	h.privmsg(channel, "Deal accepted by everyone!")
+	h.endGame(channel)
+}
+
+func (h *Handler) handleDecline(cmd *Command) {
+	channel := cmd.Channel
+	offer := h.deals[channel]
+	if offer == nil || offer.amounts == nil {
+		h.privmsg(channel, "There is no deal to decline.")
+		return
+	}
+	if _, ok := offer.amounts[cmd.Nick]; !ok {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not part of this deal.", cmd.Nick))
+		return
+	}
+
+	offer.timer.Stop()
+	delete(h.deals, channel)
+	h.privmsg(channel, fmt.Sprintf("%s declines the deal. Play resumes.", cmd.Nick))
+	h.startRound(channel)
+}
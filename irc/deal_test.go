@@ -0,0 +1,86 @@
+package irc
+
+import (
+	"fmt"
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// dealTable starts a nine-entry tournament at channel, three places paid,
+// with seated still in and everyone else eliminated.
+func dealTable(t *testing.T, h *Handler, channel string, seated ...string) *game.Tournament {
+	t.Helper()
+	say(t, h, seated[0], channel, "$start holdem")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	tournament := game.NewRebuyTournament()
+	for i := 0; i < 9; i++ {
+		nick := fmt.Sprintf("%s-out%d", channel[1:], i)
+		if i < len(seated) {
+			nick = seated[i]
+		} else {
+			tournament.Eliminated = append(tournament.Eliminated, nick)
+		}
+		tournament.Enter(nick)
+	}
+	h.tournaments[channel] = tournament
+	for _, nick := range seated {
+		h.games[channel].AddPlayer(&models.Player{Nick: nick, Money: 1000, Economy: h.economy(channel)})
+	}
+	return tournament
+}
+
+func TestDealOnlyInTheMoney(t *testing.T) {
+	h := newTestHandler(t)
+	tournament := dealTable(t, h, "#dealbubble", "bubble1", "bubble2", "bubble3", "bubble4")
+
+	say(t, h, "bubble1", "#dealbubble", "$deal")
+	if h.deals["#dealbubble"] != nil {
+		t.Fatal("a deal was proposed on the bubble")
+	}
+
+	h.mu.Lock()
+	tournament.Eliminated = append(tournament.Eliminated, "bubble4")
+	h.games["#dealbubble"].RemovePlayer("bubble4")
+	h.mu.Unlock()
+	say(t, h, "bubble1", "#dealbubble", "$deal")
+	if h.deals["#dealbubble"] == nil {
+		t.Fatal("no deal was proposed in the money")
+	}
+}
+
+func TestDealIncludesPendingEntrants(t *testing.T) {
+	h := newTestHandler(t)
+	dealTable(t, h, "#dealpending", "pending1", "pending2", "pending3")
+	h.mu.Lock()
+	waiting := h.games["#dealpending"].FindPlayer("pending3")
+	h.games["#dealpending"].RemovePlayer("pending3")
+	h.lateJoins["#dealpending"] = append(h.lateJoins["#dealpending"], waiting)
+	h.mu.Unlock()
+
+	say(t, h, "pending3", "#dealpending", "$deal")
+	h.mu.Lock()
+	paused := h.offerDeal("#dealpending")
+	h.mu.Unlock()
+	if !paused {
+		t.Fatal("the deal wasn't put to the players")
+	}
+	offer := h.deals["#dealpending"]
+	if len(offer.amounts) != 3 {
+		t.Fatalf("the deal pays %v, want all three players", offer.amounts)
+	}
+	if offer.amounts["pending3"] == 0 {
+		t.Error("the player waiting for a seat gets nothing from the deal")
+	}
+
+	say(t, h, "pending3", "#dealpending", "$accept")
+	if !offer.accepted["pending3"] {
+		t.Error("the player waiting for a seat couldn't accept the deal")
+	}
+	say(t, h, "pending3", "#dealpending", "$decline")
+	if h.deals["#dealpending"] != nil {
+		t.Error("the player waiting for a seat couldn't decline the deal")
+	}
+}
@@ -0,0 +1,47 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// duplicateWindow is how soon the same command with the same arguments is
+// taken for a double send, which IRC lag makes common, and ignored.
+const duplicateWindow = 2 * time.Second
+
+// sentCommand is the last command a nick sent in a channel.
+type sentCommand struct {
+	text    string
+	at      time.Time
+	ignored bool // a repeat was ignored and the nick told so
+}
+
+// duplicate reports whether cmd repeats the command its nick sent in the
+// same channel within duplicateWindow, telling them the first time it
+// ignores one. It runs before the rate limiter, which would drop the
+// repeat without a word.
+func (h *Handler) duplicate(cmd *Command) bool {
+	now := time.Now()
+	key := keyFor(cmd.Nick, cmd.Channel, "")
+	text := cmd.Name + " " + strings.Join(cmd.Args, " ")
+	last, ok := h.recent[key]
+	if ok && last.text == text && now.Sub(last.at) < duplicateWindow {
+		if !last.ignored {
+			last.ignored = true
+			h.recent[key] = last
+			h.notice(cmd.Nick, fmt.Sprintf("Ignored a second %s within %s, probably lag. Send it again in a moment if you meant it.", cmd.Name, duplicateWindow))
+		}
+		return true
+	}
+
+	if len(h.recent) >= 256 {
+		for k, sent := range h.recent {
+			if now.Sub(sent.at) >= duplicateWindow {
+				delete(h.recent, k)
+			}
+		}
+	}
+	h.recent[key] = sentCommand{text: text, at: now}
+	return false
+}
@@ -0,0 +1,61 @@
+package irc
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/config"
+	"poker-bot/digest"
+)
+
+// RunDigests posts the weekly digest to every channel the bot is in that
+// saw play during the week, at the time the config gives. The config is
+// checked every minute, so a reload moves the digest without a restart.
+func (h *Handler) RunDigests() {
+	var last time.Time
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-h.ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		h.mu.Lock()
+		when := h.config.WeeklyDigest
+		h.mu.Unlock()
+		if !digestDue(when, now) || now.Sub(last) < time.Hour {
+			continue
+		}
+		last = now
+
+		summaries, err := digest.Weekly(now.AddDate(0, 0, -7))
+		if err != nil {
+			log.Printf("Error building the weekly digest: %v", err)
+			continue
+		}
+		h.mu.Lock()
+		for channel, summary := range summaries {
+			if h.channels[strings.ToLower(channel)] {
+				h.privmsg(channel, summary.String())
+			}
+		}
+		h.mu.Unlock()
+	}
+}
+
+// digestDue reports whether now is the minute of the week that when, a
+// WeeklyDigest setting, names.
+func digestDue(when string, now time.Time) bool {
+	if when == "" {
+		return false
+	}
+	day, at, err := config.ParseDigest(when)
+	if err != nil {
+		return false
+	}
+	clock := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute
+	return now.Weekday() == day && clock == at
+}
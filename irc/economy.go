@@ -0,0 +1,44 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+
+	"poker-bot/db"
+)
+
+// Economy modes: one bankroll per player everywhere, one per network the bot
+// is connected to, or one per channel.
+const (
+	EconomyShared  = "shared"
+	EconomyNetwork = "network"
+	EconomyChannel = "channel"
+)
+
+// SetEconomy chooses how bankrolls are separated. Chips won in one economy
+// can't be spent in another.
+func (h *Handler) SetEconomy(mode string) error {
+	switch mode {
+	case EconomyShared, EconomyNetwork, EconomyChannel:
+		h.economyMode = mode
+		return nil
+	}
+	return fmt.Errorf("unknown economy %q, want %s, %s or %s", mode, EconomyShared, EconomyNetwork, EconomyChannel)
+}
+
+// economy returns the economy that bankrolls at channel belong to.
+func (h *Handler) economy(channel string) string {
+	return economyOf(h.economyMode, h.server, channel)
+}
+
+// economyOf returns the economy of channel on server in the given economy
+// mode, for work done away from the handler's mutex.
+func economyOf(mode, server, channel string) string {
+	switch mode {
+	case EconomyNetwork:
+		return strings.ToLower(server)
+	case EconomyChannel:
+		return strings.ToLower(server) + "/" + strings.ToLower(channel)
+	}
+	return db.SharedEconomy
+}
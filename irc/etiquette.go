@@ -0,0 +1,76 @@
+package irc
+
+import (
+	"time"
+
+	"poker-bot/flavor"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+const (
+	// slowRollThink is how long a player can take over the action that
+	// takes the hand to showdown before winning with a strong hand counts
+	// as a slow roll.
+	slowRollThink = 10 * time.Second
+	// slowRollCategory is the weakest hand that is too strong to tank with:
+	// a straight.
+	slowRollCategory = 4
+	// slowRollLimit is how many slow rolls a player gets before their turn
+	// clock is cut.
+	slowRollLimit = 2
+)
+
+// etiquette tracks how long players take to act at a table, to catch slow
+// rolls: taking a long time over the final call with a hand that was never
+// in doubt.
+type etiquette struct {
+	turnStarted time.Time
+	lastActor   string
+	lastThink   time.Duration // time the last actor took to act
+	slowRolls   map[string]int
+}
+
+func (h *Handler) tableEtiquette(channel string) *etiquette {
+	e := h.etiquette[channel]
+	if e == nil {
+		e = &etiquette{slowRolls: make(map[string]int)}
+		h.etiquette[channel] = e
+	}
+	return e
+}
+
+// recordThinkTime notes how long nick took over the action they just took.
+func (h *Handler) recordThinkTime(channel, nick string) {
+	e := h.tableEtiquette(channel)
+	e.lastActor = nick
+	e.lastThink = time.Since(e.turnStarted)
+}
+
+// turnTimeout is the turn clock for nick, cut short for repeat slow-rollers.
+func (h *Handler) turnTimeout(channel, nick string) time.Duration {
+	if h.tableEtiquette(channel).slowRolls[nick] >= slowRollLimit {
+		return time.Duration(h.config.SlowRollTimeout)
+	}
+	return time.Duration(h.config.TurnTimeout)
+}
+
+// checkSlowRoll checks whether the winner at showdown slow rolled: tanked
+// over the action that took the hand to showdown while holding a strong hand.
+func (h *Handler) checkSlowRoll(channel string, winner *models.Player) {
+	rules, ok := h.games[channel].(game.Showdown)
+	if !ok {
+		return
+	}
+
+	e := h.tableEtiquette(channel)
+	name, category := rules.DescribeHand(winner)
+	if e.lastActor != winner.Nick || e.lastThink < slowRollThink || category < slowRollCategory {
+		return
+	}
+	e.slowRolls[winner.Nick]++
+	h.privmsg(channel, h.message(channel, "slow_roll", flavor.Details{Nick: winner.Nick, Wait: e.lastThink.Round(time.Second).String(), Hand: name}))
+	if e.slowRolls[winner.Nick] == slowRollLimit {
+		h.privmsg(channel, h.message(channel, "slow_roll:penalty", flavor.Details{Nick: winner.Nick, Count: slowRollLimit, Wait: time.Duration(h.config.SlowRollTimeout).String()}))
+	}
+}
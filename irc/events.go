@@ -0,0 +1,114 @@
+package irc
+
+import (
+	"time"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// Kinds of table event.
+const (
+	EventHandStart = "hand_start"
+	EventStreet    = "street"
+	EventAllIn     = "all_in"
+	EventShowdown  = "showdown"
+	EventWin       = "win" // everyone else folded
+	EventGameOver  = "game_over"
+	EventAction    = "action" // a player acted or was timed out
+)
+
+// Event is something that happened at a table, for what reacts to games
+// without being part of them, such as table talk.
+type Event struct {
+	Kind    string    `json:"kind"`
+	Channel string    `json:"channel"`
+	Time    time.Time `json:"time"`
+	Nick    string    `json:"nick,omitempty"`   // a winner, for showdowns and wins
+	Amount  int       `json:"amount,omitempty"` // what they won of the pot
+	Hand    string    `json:"hand,omitempty"`   // the winning hand's name at a showdown
+	Street  string    `json:"street,omitempty"` // flop, turn or river
+
+	// HandID, Button and Posts describe the deal, for hand starts: the
+	// hand's ID in the history, who has the button in games with one,
+	// and the blinds and antes taken.
+	HandID int64  `json:"hand_id,omitempty"`
+	Button string `json:"button,omitempty"`
+	Posts  []Post `json:"posts,omitempty"`
+
+	// Table is the table after the event, with every hole card in it, or
+	// nil once the game is over. Use For to hide the cards from viewers.
+	Table *TableState `json:"-"`
+}
+
+// Post is the forced bets one player put in at the deal: a blind, or the
+// big blind a late joiner owes, and an ante.
+type Post struct {
+	Nick     string `json:"nick"`
+	Position string `json:"position,omitempty"` // BTN, SB, BB, UTG and so on
+	Blind    int    `json:"blind,omitempty"`
+	Ante     int    `json:"ante,omitempty"`
+}
+
+// Subscribe calls fn with every table event, in the order they happen.
+// Subscribers run between IRC events and must not block.
+func (h *Handler) Subscribe(fn func(Event)) {
+	h.subscribers = append(h.subscribers, fn)
+}
+
+func (h *Handler) emit(event Event) {
+	event.Time = time.Now()
+	event.Table = h.tableState(event.Channel)
+	for _, fn := range h.subscribers {
+		fn(event)
+	}
+}
+
+// streetName names the street just dealt on a board of the given size.
+func streetName(boardSize int) string {
+	switch boardSize {
+	case 3:
+		return "flop"
+	case 4:
+		return "turn"
+	case 5:
+		return "river"
+	}
+	return ""
+}
+
+func (h *Handler) showdownEvent(channel string, winner *models.Player, amount int) Event {
+	event := Event{Kind: EventShowdown, Channel: channel, Nick: winner.Nick, Amount: amount}
+	if rules, ok := h.games[channel].(game.Showdown); ok {
+		event.Hand, _ = rules.DescribeHand(winner)
+	}
+	return event
+}
+
+// handStartEvent describes the hand just dealt at channel, with the forced
+// bets worked out from the stacks noted before the deal.
+func (h *Handler) handStartEvent(channel string) Event {
+	table := h.games[channel]
+	event := Event{Kind: EventHandStart, Channel: channel}
+	if history := h.histories[channel]; history != nil {
+		event.HandID = history.id
+	}
+	players := table.GetPlayers()
+	var positions []string
+	if buttoned, ok := table.(game.Buttoned); ok && len(players) > 0 {
+		positions = game.Positions(len(players), buttoned.Button())
+		event.Button = players[buttoned.Button()%len(players)].Nick
+	}
+	for i, player := range players {
+		posted := h.stacks[channel][player.Nick] - player.Money
+		if posted <= 0 {
+			continue
+		}
+		post := Post{Nick: player.Nick, Blind: player.Bet, Ante: posted - player.Bet}
+		if positions != nil {
+			post.Position = positions[i]
+		}
+		event.Posts = append(event.Posts, post)
+	}
+	return event
+}
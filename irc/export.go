@@ -0,0 +1,99 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/stats"
+)
+
+// downloadLifetime is how long an $export link works.
+const downloadLifetime = 15 * time.Minute
+
+// download is a file the web server hands out once, to whoever has the
+// link.
+type download struct {
+	name        string
+	contentType string
+	data        []byte
+	expires     time.Time
+}
+
+// handleExport builds the player's stats export and sends them a link to
+// download it from the web server: $export stats [json|csv].
+func (h *Handler) handleExport(cmd *Command) {
+	if len(cmd.Args) < 1 || strings.ToLower(cmd.Args[0]) != "stats" || len(cmd.Args) > 2 {
+		h.notice(cmd.Nick, "Usage: $export stats [json|csv]")
+		return
+	}
+	format := "json"
+	if len(cmd.Args) == 2 {
+		format = strings.ToLower(cmd.Args[1])
+	}
+	if format != "json" && format != "csv" {
+		h.notice(cmd.Nick, "Exports are json or csv.")
+		return
+	}
+	if h.config.WebURL == "" {
+		h.notice(cmd.Nick, "Exports are downloaded from the web dashboard, and this bot doesn't have one.")
+		return
+	}
+	go h.export(cmd.Nick, format, strings.TrimSuffix(h.config.WebURL, "/"))
+}
+
+func (h *Handler) export(nick, format, webURL string) {
+	export, err := stats.Build(nick)
+	var data []byte
+	if err == nil {
+		if format == "csv" {
+			data, err = export.CSV()
+		} else {
+			data, err = export.JSON()
+		}
+	}
+	if err != nil {
+		log.Printf("Error exporting stats for %s: %v", nick, err)
+		h.notice(nick, "Error exporting your stats.")
+		return
+	}
+	token, err := newWebToken()
+	if err != nil {
+		h.notice(nick, "Error creating a download link.")
+		return
+	}
+
+	contentType := "application/json"
+	if format == "csv" {
+		contentType = "text/csv"
+	}
+	h.mu.Lock()
+	for old, d := range h.downloads {
+		if time.Now().After(d.expires) {
+			delete(h.downloads, old)
+		}
+	}
+	h.downloads[token] = download{
+		name:        fmt.Sprintf("%s-stats.%s", sanitize(nick), format),
+		contentType: contentType,
+		data:        data,
+		expires:     time.Now().Add(downloadLifetime),
+	}
+	h.mu.Unlock()
+	h.notice(nick, fmt.Sprintf("Your stats (%d hands, %d sessions, %d transactions): %s/download/%s works once, for %s.",
+		len(export.Hands), len(export.Sessions), len(export.Transactions), webURL, token, downloadLifetime))
+}
+
+// Download returns the file behind an $export link and forgets it, so each
+// link works once.
+func (h *Handler) Download(token string) (name, contentType string, data []byte, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	d, ok := h.downloads[token]
+	delete(h.downloads, token)
+	if !ok || time.Now().After(d.expires) {
+		return "", "", nil, false
+	}
+	return d.name, d.contentType, d.data, true
+}
@@ -0,0 +1,24 @@
+package irc
+
+import "poker-bot/flavor"
+
+// SetFlavor sets the engine that picks the bot's table talk, nil for none.
+func (h *Handler) SetFlavor(engine *flavor.Engine) {
+	h.flavor = engine
+}
+
+func (h *Handler) flavorText(event Event) {
+	if h.flavor == nil {
+		return
+	}
+	keys := []string{event.Kind}
+	switch {
+	case event.Hand != "":
+		keys = []string{event.Kind + ":" + event.Hand, event.Kind}
+	case event.Street != "":
+		keys = []string{event.Kind + ":" + event.Street, event.Kind}
+	}
+	if line, ok := h.flavor.Comment(event.Channel, h.tone(event.Channel), keys, event); ok {
+		h.privmsg(event.Channel, line)
+	}
+}
@@ -0,0 +1,124 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+)
+
+// forgetLifetime is how long a player has to confirm $forgetme.
+const forgetLifetime = 2 * time.Minute
+
+// handleForgetMe erases the player from the bot's database: $forgetme
+// explains what that means, and $forgetme confirm does it.
+func (h *Handler) handleForgetMe(cmd *Command) {
+	if len(cmd.Args) == 0 {
+		h.forgets[cmd.Nick] = time.Now().Add(forgetLifetime)
+		h.notice(cmd.Nick, "$forgetme deletes your bankroll, profile, web account and hosts for good, and replaces your nick with a pseudonym in hand histories, table logs, records, standings and the ledger.")
+		h.notice(cmd.Nick, fmt.Sprintf("It can't be undone. Type $forgetme confirm within %s to go ahead.", forgetLifetime))
+		return
+	}
+	if len(cmd.Args) != 1 || strings.ToLower(cmd.Args[0]) != "confirm" {
+		h.notice(cmd.Nick, "Usage: $forgetme, then $forgetme confirm")
+		return
+	}
+
+	expires, ok := h.forgets[cmd.Nick]
+	delete(h.forgets, cmd.Nick)
+	if !ok || time.Now().After(expires) {
+		h.notice(cmd.Nick, "Type $forgetme first to see what it does.")
+		return
+	}
+	if reason := h.forgetBlocked(cmd.Nick); reason != "" {
+		h.notice(cmd.Nick, fmt.Sprintf("You can't be forgotten while you're %s.", reason))
+		return
+	}
+	if _, err := h.forgetPlayer(cmd.Nick); err != nil {
+		h.notice(cmd.Nick, "Error erasing your data. Nothing was changed.")
+		return
+	}
+	h.notice(cmd.Nick, "Done. The bot has forgotten you.")
+}
+
+// adminForget is $admin forget <nick>, for players who ask an admin to be
+// forgotten rather than doing it themselves.
+func (h *Handler) adminForget(cmd *Command, nick string) {
+	if reason := h.forgetBlocked(nick); reason != "" {
+		h.notice(cmd.Nick, fmt.Sprintf("%s can't be forgotten while they're %s.", sanitize(nick), reason))
+		return
+	}
+	pseudonym, err := h.forgetPlayer(nick)
+	if err != nil {
+		h.notice(cmd.Nick, "Error erasing the player's data. Nothing was changed.")
+		return
+	}
+	log.Printf("%s had %s forgotten as %s", cmd.Nick, nick, pseudonym)
+	h.logAudit(cmd.Source, "forget", "", nick, pseudonym)
+	h.notice(cmd.Nick, fmt.Sprintf("%s has been forgotten. What was kept is under %s.", sanitize(nick), pseudonym))
+}
+
+// forgetBlocked says why nick can't be forgotten yet, or "" if they can:
+// chips they have in play would be paid back under their nick.
+func (h *Handler) forgetBlocked(nick string) string {
+	for channel, table := range h.games {
+		if table.FindPlayer(nick) != nil {
+			return "at the table in " + channel
+		}
+		if t := h.tournaments[channel]; t != nil && t.Entries[nick] != nil {
+			return "entered in the tournament in " + channel
+		}
+	}
+	for channel, players := range h.lateJoins {
+		for _, player := range players {
+			if player.Nick == nick {
+				return "waiting for a seat in " + channel
+			}
+		}
+	}
+	for channel, waiting := range h.waitlists {
+		for _, name := range waiting {
+			if name == nick {
+				return "on the waitlist in " + channel
+			}
+		}
+	}
+	for channel, pool := range h.railbets {
+		if _, placed := pool.bets[nick]; placed {
+			return "holding a rail bet in " + channel
+		}
+	}
+	if h.blackjack[nick] != nil || h.videoPoker[nick] != nil {
+		return "playing a side game"
+	}
+	return ""
+}
+
+// forgetPlayer erases nick from the database, the table logs and what the
+// bot holds about them in memory, and returns the pseudonym their kept rows
+// are under.
+func (h *Handler) forgetPlayer(nick string) (string, error) {
+	pseudonym, err := db.ForgetPlayer(nick)
+	if err != nil {
+		log.Printf("Error forgetting %s: %v", nick, err)
+		return "", err
+	}
+	if err := h.forgetTableLogs(nick, pseudonym); err != nil {
+		log.Printf("Error forgetting %s in the table logs: %v", nick, err)
+	}
+	delete(h.profiles, nick)
+	delete(h.hosts, nick)
+	for token, owner := range h.webTokens {
+		if owner == nick {
+			delete(h.webTokens, token)
+		}
+	}
+	for code, link := range h.linkCodes {
+		if link.nick == nick {
+			delete(h.linkCodes, code)
+		}
+	}
+	return pseudonym, nil
+}
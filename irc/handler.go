@@ -1,46 +1,146 @@
 package irc
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"poker-bot/config"
 	"poker-bot/db"
+	"poker-bot/flavor"
 	"poker-bot/game"
 	"poker-bot/models"
 	"poker-bot/modes"
 
 	irc "github.com/thoj/go-ircevent"
-)
-
-const (
-	cheatSuccessRate = 80   // 1 in 80 chance of success
-	cheatPenaltyRate = 0.02 // 2% penalty for failed cheat attempt
+	"golang.org/x/net/proxy"
 )
 
 type Handler struct {
-	conn         *irc.Connection
-	games        map[string]game.Game
-	lastCommand  map[string]time.Time
-	commandMutex sync.Mutex
-	server       string
-	nick         string
-	currentTurn  map[string]string // channeling dat channel -> current player's nick
-	turnTimer    map[string]*time.Timer
+	mu          sync.Mutex // serializes IRC events with control commands
+	ctx         context.Context
+	cancel      context.CancelFunc
+	connCancel  context.CancelFunc
+	ownNick     string // the nick the bot has, which may be an alternate
+	reclaiming  bool   // asking services for the nick back, with the turn clocks paused
+	tableLives  map[string]tableLife
+	conn        *irc.Connection
+	dialer      proxy.Dialer // how the next connection reaches the server
+	games       map[string]game.Game
+	limiter     *rateLimiter
+	server      string
+	nick        string
+	currentTurn map[string]string // channeling dat channel -> current player's nick
+	turnClocks  map[string]*turnClock
+	shuffles    map[string]*verifiedShuffle
+	audits      map[string]*game.ChipAudit
+	waitlists   map[string][]string
+	lateJoins   map[string][]*models.Player
+	tournaments map[string]*game.Tournament
+	tableWaits  map[string]bool // channel -> tournament table waiting on the others
+	breaks      map[string]*time.Timer
+	deals       map[string]*dealOffer
+	rotations   map[string]*rotation
+	blackjack   map[string]*modes.Blackjack // nick -> hand in play
+	videoPoker  map[string]*modes.VideoPoker
+	paytable    modes.Paytable
+	etiquette   map[string]*etiquette
+	showdowns   map[string]*showdown
+	histories   map[string]*handHistory
+	economyMode string
+	stacks      map[string]map[string]int // channel -> nick -> stack before the deal
+	channels    map[string]bool
+	reload      func() error
+	config      config.Config
+	subscribers []func(Event)
+	flavor      *flavor.Engine
+	webTokens   map[string]string // web token -> nick
+	linkCodes   map[string]linkCode
+	hosts       map[string]string // nick -> host they last spoke from
+	runouts     map[string]*time.Timer
+	profiles    map[string]db.Profile // nick -> profile, loaded on first use
+	railbets    map[string]*railPool
+	topics      map[string]*topicState // lowercased channel -> topic
+	private     map[string]*privateTable
+	tableHosts  map[string]string // channel -> nick of the table's host
+	tableStakes map[string]game.BlindLevel
+	limits      map[string]*gameLimit
+	cashTables  map[string]*cashTable
+	downloads   map[string]download  // token -> $export file
+	forgets     map[string]time.Time // nick -> when their $forgetme expires
+	pendingBets map[string]*pendingBet
+	earlyFolds  map[string]map[string]bool // channel -> nicks who folded out of turn
+	tableLogs   map[string]*tableLog       // lowercased channel -> open log file
+	finals      map[string]*db.Series      // channel -> series whose championship is played there
+	rebuyWaits  map[string]*time.Timer     // channel -> game short of players
+	bans        []db.Ban
+	bansLoaded  bool
+	maintenance map[string]db.Maintenance // lowercased channel -> why it's closed
+	recent      map[limiterKey]sentCommand
+	cooldowns   map[string]int // nick -> hands before they may $cheat again, the one in play included
 }
 
 func NewHandler() *Handler {
-	return &Handler{
+	h := &Handler{
 		games:       make(map[string]game.Game),
-		lastCommand: make(map[string]time.Time),
+		limiter:     newRateLimiter(time.Duration(config.Default.CommandInterval), limiterPruneAge),
 		currentTurn: make(map[string]string),
-		turnTimer:   make(map[string]*time.Timer),
-	}
+		turnClocks:  make(map[string]*turnClock),
+		shuffles:    make(map[string]*verifiedShuffle),
+		audits:      make(map[string]*game.ChipAudit),
+		waitlists:   make(map[string][]string),
+		lateJoins:   make(map[string][]*models.Player),
+		tournaments: make(map[string]*game.Tournament),
+		tableWaits:  make(map[string]bool),
+		breaks:      make(map[string]*time.Timer),
+		deals:       make(map[string]*dealOffer),
+		rotations:   make(map[string]*rotation),
+		blackjack:   make(map[string]*modes.Blackjack),
+		videoPoker:  make(map[string]*modes.VideoPoker),
+		paytable:    modes.DefaultPaytable,
+		etiquette:   make(map[string]*etiquette),
+		showdowns:   make(map[string]*showdown),
+		histories:   make(map[string]*handHistory),
+		economyMode: EconomyShared,
+		stacks:      make(map[string]map[string]int),
+		channels:    make(map[string]bool),
+		config:      config.Default,
+		webTokens:   make(map[string]string),
+		linkCodes:   make(map[string]linkCode),
+		hosts:       make(map[string]string),
+		runouts:     make(map[string]*time.Timer),
+		profiles:    make(map[string]db.Profile),
+		railbets:    make(map[string]*railPool),
+		topics:      make(map[string]*topicState),
+		private:     make(map[string]*privateTable),
+		tableHosts:  make(map[string]string),
+		tableStakes: make(map[string]game.BlindLevel),
+		limits:      make(map[string]*gameLimit),
+		cashTables:  make(map[string]*cashTable),
+		downloads:   make(map[string]download),
+		forgets:     make(map[string]time.Time),
+		pendingBets: make(map[string]*pendingBet),
+		earlyFolds:  make(map[string]map[string]bool),
+		tableLogs:   make(map[string]*tableLog),
+		finals:      make(map[string]*db.Series),
+		rebuyWaits:  make(map[string]*time.Timer),
+		recent:      make(map[limiterKey]sentCommand),
+		cooldowns:   make(map[string]int),
+		tableLives:  make(map[string]tableLife),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	h.Subscribe(h.announcePosts)
+	h.Subscribe(h.flavorText)
+	h.Subscribe(h.railBets)
+	h.Subscribe(h.trackRecords)
+	h.Subscribe(h.seriesPoints)
+	h.Subscribe(h.topicEvents)
+	return h
 }
 
 func (h *Handler) Connect(server, nick string) error {
@@ -51,10 +151,15 @@ func (h *Handler) Connect(server, nick string) error {
 	h.conn.Debug = true
 	h.conn.UseTLS = true
 	h.conn.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	h.registerCTCP()
+	h.trackNick()
 
 	h.conn.AddCallback("001", func(e *irc.Event) {
 		log.Println("Connected to server, waiting before joining #poker")
-		time.AfterFunc(5*time.Second, func() {
+		h.mu.Lock()
+		ctx := h.newConnContext()
+		h.mu.Unlock()
+		h.afterFunc(ctx, 5*time.Second, func() {
 			log.Println("Joining #poker")
 			h.conn.Join("#dev")
 		})
@@ -64,7 +169,13 @@ func (h *Handler) Connect(server, nick string) error {
 	})
 	h.conn.AddCallback("PRIVMSG", h.handleMessage)
 	h.conn.AddCallback("JOIN", h.handleRejoin)
+	h.conn.AddCallback("330", h.handleWhoisAccount)
+	h.trackChannels()
+	h.trackTopics()
 
+	if err := h.useTransport(); err != nil {
+		return fmt.Errorf("failed to connect to IRC server: %v", err)
+	}
 	err := h.conn.Connect(server)
 	if err != nil {
 		return fmt.Errorf("failed to connect to IRC server: %v", err)
@@ -75,10 +186,17 @@ func (h *Handler) Connect(server, nick string) error {
 }
 
 func (h *Handler) Run() {
-	for {
+	for h.ctx.Err() == nil {
 		h.conn.Loop()
+		if h.ctx.Err() != nil {
+			break
+		}
 		log.Println("IRC connection loop ended. Attempting to reconnect in 5 seconds...")
-		time.Sleep(5 * time.Second)
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
 		err := h.Connect(h.server, h.nick)
 		if err != nil {
 			log.Printf("Failed to reconnect: %v", err)
@@ -87,85 +205,230 @@ func (h *Handler) Run() {
 }
 
 func (h *Handler) handleMessage(event *irc.Event) {
+	cmd, ok := parseCommand(event)
+	if !ok {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dispatch(cmd)
+}
+
+// dispatch runs a command. The handler's mutex must be held.
+func (h *Handler) dispatch(cmd *Command) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("Recovered from panic in handleMessage: %v", r)
+			log.Printf("Recovered from panic running %s: %v", cmd.Name, r)
 		}
 	}()
 
-	if !h.rateLimitCheck(event.Nick) {
+	command, channel := cmd.Name, cmd.Channel
+	h.noteSource(cmd)
+
+	// Banned players can't use the bot at all. Only an ordinary ban is
+	// told why, and only when they try to play.
+	if ban := h.banFor(cmd); ban != nil {
+		if !ban.Shadow && (command == "$join" || command == "$start") {
+			h.notice(cmd.Nick, describeBan(ban))
+		}
 		return
 	}
 
-	message := strings.TrimSpace(event.Message())
-	parts := strings.Split(message, " ")
-	if len(parts) == 0 {
+	if h.duplicate(cmd) {
+		return
+	}
+	if !h.limiter.Allow(cmd.Nick, channel, command) {
 		return
 	}
 
-	command := strings.ToLower(parts[0])
-	channel := event.Arguments[0]
+	if run, ok := hostCommands[command]; ok {
+		h.runHostCommand(cmd, run)
+		return
+	}
 
 	// Commands that can be used at any time
 	switch command {
 	case "$start":
-		h.handleStartGame(event)
+		h.handleStartGame(cmd)
 		return
 	case "$join":
-		h.handleJoinGame(event)
+		h.handleJoinGame(cmd)
 		return
 	case "$score":
-		h.handleScore(event)
+		h.handleScore(cmd)
+		return
+	case "$entropy":
+		h.handleEntropy(cmd)
+		return
+	case "$commit":
+		h.handleCommit(cmd)
+		return
+	case "$rebuy":
+		h.handleRebuy(cmd)
+		return
+	case "$addon":
+		h.handleAddOn(cmd)
+		return
+	case "$clock":
+		h.handleClock(cmd)
+		return
+	case "$deal":
+		h.handleDeal(cmd)
+		return
+	case "$accept":
+		h.handleAccept(cmd)
+		return
+	case "$decline":
+		h.handleDecline(cmd)
+		return
+	case "$choose":
+		h.handleChoose(cmd)
+		return
+	case "$show":
+		h.handleShow(cmd)
+		return
+	case "$muck":
+		h.handleMuck(cmd)
+		return
+	case "$blackjack":
+		h.handleBlackjack(cmd)
+		return
+	case "$hit":
+		h.handleHit(cmd)
+		return
+	case "$double":
+		h.handleDouble(cmd)
+		return
+	case "$vp":
+		h.handleVideoPoker(cmd)
+		return
+	case "$hold":
+		h.handleHold(cmd)
+		return
+	case "$web":
+		h.handleWeb(cmd)
+		return
+	case "$link":
+		h.handleLink(cmd)
+		return
+	case "$admin":
+		h.handleAdmin(cmd)
+		return
+	case "$actions":
+		h.handleActions(cmd)
+		return
+	case "$setprofile":
+		h.handleSetProfile(cmd)
+		return
+	case "$railbet":
+		h.handleRailBet(cmd)
+		return
+	case "$records":
+		h.handleRecords(cmd)
+		return
+	case "$hand":
+		h.handleHand(cmd)
+		return
+	case "$pot":
+		h.handlePot(cmd)
+		return
+	case "$board":
+		h.handleBoard(cmd)
+		return
+	case "$stacks":
+		h.handleStacks(cmd)
+		return
+	case "$hands":
+		h.handleHands(cmd)
+		return
+	case "$rules":
+		h.handleRules(cmd)
+		return
+	case "$leave":
+		h.handleLeave(cmd)
+		return
+	case "$export":
+		h.handleExport(cmd)
 		return
+	case "$stats":
+		h.handleStats(cmd)
+		return
+	case "$shame":
+		h.handleShame(cmd)
+		return
+	case "$season":
+		h.handleSeason(cmd)
+		return
+	case "$trophies":
+		h.handleTrophies(cmd)
+		return
+	case "$leaderboard":
+		h.handleLeaderboard(cmd)
+		return
+	case "$series":
+		h.handleSeries(cmd)
+		return
+	case "$shop":
+		h.handleShop(cmd)
+		return
+	case "$buy":
+		h.handleBuy(cmd)
+		return
+	case "$forgetme":
+		h.handleForgetMe(cmd)
+		return
+	case "$stand":
+		if h.blackjack[cmd.Nick] != nil {
+			h.handleBlackjackStand(cmd)
+			return
+		}
 	}
 
-	if h.currentTurn[channel] != event.Nick {
+	run, ok := turnCommands[command]
+	if !ok {
+		return
+	}
+	if h.currentTurn[channel] != cmd.Nick {
+		h.actOutOfTurn(cmd)
+		return
+	}
+	if commands := modes.TurnCommands(h.games[channel]); command != "$confirm" && !contains(commands, command) {
+		h.notice(cmd.Nick, fmt.Sprintf("You can't %s now. Available commands: %s", command, strings.Join(commands, ", ")))
 		return
 	}
 
-	h.resetTurnTimer(channel)
-
-	switch command {
-	case "$bet":
-		h.handleBet(event)
-	case "$call":
-		h.handleCall(event)
-	case "$raise":
-		h.handleRaise(event)
-	case "$fold":
-		h.handleFold(event)
-	case "$check":
-		h.handleCheck(event)
-	case "$draw":
-		h.handleDraw(event)
-	case "$cheat":
-		h.handleCheat(event)
-	}
-}
-
-func (h *Handler) rateLimitCheck(nick string) bool {
-	h.commandMutex.Lock()
-	defer h.commandMutex.Unlock()
-
-	lastTime, exists := h.lastCommand[nick]
-	if !exists || time.Since(lastTime) >= 3*time.Second {
-		h.lastCommand[nick] = time.Now()
-		return true
+	h.restartTurnClock(channel)
+	h.recordThinkTime(channel, cmd.Nick)
+	if command != "$confirm" {
+		delete(h.pendingBets, channel)
 	}
-	return false
+
+	run(h, cmd)
+	h.emit(Event{Kind: EventAction, Channel: channel, Nick: cmd.Nick})
+	h.auditChips(channel)
 }
 
-func (h *Handler) startTurnTimer(channel string) {
-	h.turnTimer[channel] = time.AfterFunc(15*time.Second, func() {
-		h.handleTimeout(channel)
-	})
+// turnCommands are the commands of the player whose turn it is. Which of
+// them they can use is up to the variant, and the phase of the hand.
+var turnCommands = map[string]func(*Handler, *Command){
+	"$bet":     (*Handler).handleBet,
+	"$call":    (*Handler).handleCall,
+	"$raise":   (*Handler).handleRaise,
+	"$fold":    (*Handler).handleFold,
+	"$check":   (*Handler).handleCheck,
+	"$draw":    (*Handler).handleDraw,
+	"$stand":   (*Handler).handleDraw,
+	"$cheat":   (*Handler).handleCheat,
+	"$confirm": (*Handler).handleConfirm,
 }
 
-func (h *Handler) resetTurnTimer(channel string) {
-	if timer, exists := h.turnTimer[channel]; exists {
-		timer.Stop()
-		h.startTurnTimer(channel)
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
 	}
+	return false
 }
 
 func (h *Handler) handleTimeout(channel string) {
@@ -180,22 +443,59 @@ func (h *Handler) handleTimeout(channel string) {
 		return
 	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s's turn has timed out. Auto-folding.", currentPlayer))
+	h.privmsg(channel, fmt.Sprintf("%s's turn has timed out. Auto-folding.", currentPlayer))
 	game.Fold(player)
+	h.recordAction(channel, "%s folds (timed out)", currentPlayer)
+	h.emit(Event{Kind: EventAction, Channel: channel, Nick: currentPlayer})
 
 	if h.checkAllPlayersInactive(channel) {
-		h.conn.Privmsg(channel, "All players are inactive. Ending the game.")
+		h.privmsg(channel, "All players are inactive. Ending the game.")
 		h.endGame(channel)
 		return
 	}
 
+	h.advanceGame(channel)
+}
+
+// advanceGame is called after every action. It ends the hand, moves on to
+// the next street once the betting round is complete, or passes the turn.
+func (h *Handler) advanceGame(channel string) {
 	if h.checkRoundEnd(channel) {
 		return
 	}
 
+	game := h.games[channel]
+	if game.IsBettingRoundOver() {
+		if h.runOut(channel) {
+			return
+		}
+		h.clearAggressor(channel)
+		game.UpdateRiver()
+		h.announceStreet(channel)
+		h.announceNextTurn(channel)
+		return
+	}
+
 	h.nextTurn(channel)
 }
 
+func (h *Handler) announceStreet(channel string) {
+	game := h.games[channel]
+	if fiveCardDraw, ok := game.(*modes.FiveCardDraw); ok {
+		if fiveCardDraw.IsDrawPhase() {
+			h.privmsg(channel, "Betting round over. Time to draw: use $draw <card positions to discard> or $stand.")
+		} else {
+			h.privmsg(channel, "The draw is over. Second betting round!")
+		}
+		return
+	}
+
+	h.recordAction(channel, "Board: %v", game.GetRiver())
+	h.privmsg(channel, fmt.Sprintf("Board: %v", game.GetRiver()))
+	h.emit(Event{Kind: EventStreet, Channel: channel, Street: streetName(len(game.GetRiver()))})
+	h.sendStreetCards(channel)
+}
+
 func (h *Handler) nextTurn(channel string) {
 	game := h.games[channel]
 	if game == nil {
@@ -220,418 +520,687 @@ func (h *Handler) checkAllPlayersInactive(channel string) bool {
 	return true
 }
 
-func (h *Handler) handleStartGame(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleStartGame(cmd *Command) {
+	channel := cmd.Channel
 
 	if h.games[channel] != nil {
-		h.conn.Privmsg(channel, "A game is already in progress. Please wait for it to finish before starting a new one.")
+		h.privmsg(channel, "A game is already in progress. Please wait for it to finish before starting a new one.")
+		return
+	}
+	if h.closedForMaintenance(channel) {
 		return
 	}
 
-	message := strings.TrimSpace(event.Message())
-	parts := strings.Split(message, " ")
-
-	log.Printf("Received start game command: %s", message)
+	log.Printf("Received start game command: %s %s", cmd.Name, cmd.Text)
 
-	if len(parts) < 2 {
-		h.conn.Privmsg(event.Arguments[0], "Usage: $start <game_type>")
+	parts := cmd.Args
+	if len(parts) < 1 {
+		h.privmsg(cmd.Channel, "Usage: $start <game_type> [--verified] [--draw-limit <cards>] [--tournament | --rebuy] [--rebuy-levels <n>] [--tables <#channel,...>] [--level-minutes <n>] [--break-minutes <n>] [--variants <a,b,...>] [--rotate <hands>] [--private] [--championship] [--cash] [--hands <n> | --minutes <n>]")
+		h.privmsg(cmd.Channel, "Game types: "+gameTypes())
 		return
 	}
 
-	gameType := strings.ToLower(parts[1])
+	words := []string{}
+	verified, private, cash, championship := false, false, false, false
+	var limit *gameLimit
+	drawLimit := -1
+	var tournament *game.Tournament
+	levelMinutes, breakMinutes, rebuyLevels := 0, -1, 0
+	variants, rotateHands := "", 0
+	var tables []string
+	for i := 0; i < len(parts); i++ {
+		switch strings.ToLower(parts[i]) {
+		case "--verified":
+			verified = true
+		case "--private":
+			private = true
+		case "--cash":
+			cash = true
+		case "--championship":
+			championship = true
+		case "--hands", "--minutes":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, fmt.Sprintf("Usage: %s <n>", parts[i]))
+				return
+			}
+			if limit != nil {
+				h.privmsg(channel, "A game can have a hand limit or a time limit, not both.")
+				return
+			}
+			n, err := strconv.Atoi(parts[i+1])
+			if err != nil || n < 1 || n > 1000 {
+				h.privmsg(channel, "Hand and time limits must be between 1 and 1000.")
+				return
+			}
+			if strings.ToLower(parts[i]) == "--hands" {
+				limit = &gameLimit{hands: n}
+			} else {
+				limit = &gameLimit{duration: time.Duration(n) * time.Minute}
+			}
+			i++
+		case "--tournament":
+			tournament = game.NewTournament()
+		case "--rebuy":
+			tournament = game.NewRebuyTournament()
+		case "--level-minutes", "--break-minutes":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, fmt.Sprintf("Usage: %s <minutes>", parts[i]))
+				return
+			}
+			minutes, err := strconv.Atoi(parts[i+1])
+			if err != nil || minutes < 0 || minutes > 120 {
+				h.privmsg(channel, "Level and break lengths must be between 0 and 120 minutes.")
+				return
+			}
+			if strings.ToLower(parts[i]) == "--level-minutes" {
+				if minutes == 0 {
+					h.privmsg(channel, "Levels must last at least a minute.")
+					return
+				}
+				levelMinutes = minutes
+			} else {
+				breakMinutes = minutes
+			}
+			i++
+		case "--rebuy-levels":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, "Usage: --rebuy-levels <levels>")
+				return
+			}
+			levels, err := strconv.Atoi(parts[i+1])
+			if err != nil || levels < 1 || levels > 10 {
+				h.privmsg(channel, "The rebuy period must last between 1 and 10 levels.")
+				return
+			}
+			rebuyLevels = levels
+			i++
+		case "--tables":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, "Usage: --tables <#channel,#channel,...>")
+				return
+			}
+			tables = strings.Split(parts[i+1], ",")
+			i++
+		case "--variants":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, "Usage: --variants <variant,variant,...>")
+				return
+			}
+			variants = parts[i+1]
+			i++
+		case "--rotate":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, "Usage: --rotate <hands>")
+				return
+			}
+			hands, err := strconv.Atoi(parts[i+1])
+			if err != nil || hands < 1 || hands > 100 {
+				h.privmsg(channel, "Variants must rotate every 1 to 100 hands.")
+				return
+			}
+			rotateHands = hands
+			i++
+		case "--draw-limit":
+			if i+1 >= len(parts) {
+				h.privmsg(channel, "Usage: --draw-limit <cards>, 0 for no limit")
+				return
+			}
+			limit, err := strconv.Atoi(parts[i+1])
+			if err != nil || limit < 0 || limit > 5 {
+				h.privmsg(channel, "The draw limit must be between 0 and 5 cards.")
+				return
+			}
+			drawLimit = limit
+			i++
+		default:
+			words = append(words, parts[i])
+		}
+	}
+	gameType := strings.ToLower(strings.Join(words, " "))
+	if championship && tournament == nil {
+		tournament = game.NewTournament()
+	}
 
 	log.Printf("Attempting to start game of type: %s in channel: %s", gameType, channel)
 
-	var game game.Game
+	var mixed *rotation
 	switch gameType {
-	case "holdem":
-		game = modes.NewHoldem(channel)
-	case "omaha":
-		game = modes.NewOmaha(channel)
-	case "five card draw", "fivecarddraw":
-		game = modes.NewFiveCardDraw(channel)
+	case "mixed", "dealers choice", "dealer's choice":
+		var err error
+		if mixed, err = newRotation(variants, rotateHands); err != nil {
+			h.privmsg(channel, fmt.Sprintf("Can't start a %s game: %v.", gameType, err))
+			return
+		}
+		mixed.choice = gameType != "mixed"
+		gameType = mixed.variants[0]
 	default:
-		h.conn.Privmsg(channel, "Invalid game type. Supported types: holdem, omaha, five card draw")
+		if variants != "" || rotateHands > 0 {
+			h.privmsg(channel, "--variants and --rotate only apply to mixed and dealer's choice games.")
+			return
+		}
+	}
+
+	game := newGame(gameType, channel)
+	if game == nil {
+		h.privmsg(channel, "Invalid game type. Supported types: "+gameTypes())
+		return
+	}
+
+	if rebuyLevels > 0 {
+		if tournament == nil || tournament.RebuyLevels == 0 {
+			h.privmsg(channel, "--rebuy-levels only applies to rebuy tournaments.")
+			return
+		}
+		tournament.SetRebuyLevels(rebuyLevels)
+	}
+	if tournament != nil {
+		if levelMinutes > 0 {
+			tournament.LevelDuration = time.Duration(levelMinutes) * time.Minute
+		}
+		if breakMinutes == 0 {
+			tournament.BreakEvery = 0
+		} else if breakMinutes > 0 {
+			tournament.BreakDuration = time.Duration(breakMinutes) * time.Minute
+		}
+	} else if levelMinutes > 0 || breakMinutes >= 0 {
+		h.privmsg(channel, "--level-minutes and --break-minutes only apply to tournaments.")
+		return
+	}
+	if cash && tournament != nil {
+		h.privmsg(channel, "A game can be a tournament or a cash game, not both.")
 		return
 	}
+	if len(tables) > 0 {
+		if tournament == nil || private || championship || limit != nil || mixed != nil {
+			h.privmsg(channel, "--tables only applies to tournaments that aren't private, championships, mixed or limited.")
+			return
+		}
+		if reason := h.checkTables(channel, tables); reason != "" {
+			h.privmsg(channel, "Can't open the tables: "+reason)
+			return
+		}
+	}
+
+	if drawLimit >= 0 {
+		fiveCardDraw, ok := game.(*modes.FiveCardDraw)
+		if !ok {
+			h.privmsg(channel, "--draw-limit only applies to five card draw.")
+			return
+		}
+		fiveCardDraw.SetDrawLimit(drawLimit, drawLimit+1)
+	}
+
+	var table *privateTable
+	var series *db.Series
+	var seeds string
+	if championship {
+		if series, table, seeds = h.championshipTable(cmd); series == nil {
+			return
+		}
+	} else if private {
+		var err error
+		if table, err = newPrivateTable(cmd.Nick); err != nil {
+			h.privmsg(channel, "Error creating a password for the table.")
+			return
+		}
+	}
 
 	h.games[channel] = game
 	h.currentTurn[channel] = ""
-	h.conn.Privmsg(channel, fmt.Sprintf("Starting a new game of %s. Type $join to participate!", gameType))
+	h.tableHosts[channel] = cmd.Nick
+	if cash {
+		h.cashTables[channel] = &cashTable{leaving: make(map[string]bool)}
+		gameType += fmt.Sprintf(" (cash game, buy-in %d)", cashBuyInBlinds*h.cashStakes(channel).BigBlind)
+	}
+	if limit != nil {
+		h.limits[channel] = limit
+		gameType += fmt.Sprintf(" (%s, chip leader wins)", limit)
+	}
+	if mixed != nil {
+		h.rotations[channel] = mixed
+		if mixed.choice {
+			gameType = fmt.Sprintf("dealer's choice (%s)", mixed)
+		} else {
+			gameType = fmt.Sprintf("mixed games (%s)", mixed)
+		}
+	}
+	if verified {
+		h.shuffles[channel] = &verifiedShuffle{}
+		gameType += " (verified shuffle)"
+	}
+	if series != nil {
+		h.private[channel] = table
+		h.finals[channel] = series
+		h.privmsg(channel, fmt.Sprintf("Starting the %s championship, %s, hosted by %s. %s Qualifiers, $join to take your seat.", series.Name, gameType, cmd.Nick, seeds))
+	} else if table != nil {
+		h.private[channel] = table
+		h.privmsg(channel, fmt.Sprintf("Starting a new private game of %s. Seats are by invitation from %s, or $join <password>.", gameType, cmd.Nick))
+		h.notice(cmd.Nick, fmt.Sprintf("The password for your table is %s. Share it privately, or $invite <nick> to let players in.", table.password))
+	} else {
+		h.privmsg(channel, fmt.Sprintf("Starting a new game of %s, hosted by %s. Type $join to participate!", gameType, cmd.Nick))
+	}
+	if tournament != nil {
+		h.startTournament(channel, tournament)
+	}
+	if len(tables) > 0 {
+		h.openTables(channel, game.GetType(), verified, tables)
+	}
+	h.watchLobby(channel)
 }
 
-func (h *Handler) handleJoinGame(event *irc.Event) {
-	channel := event.Arguments[0]
+// newGame opens a table of the registered variant called gameType, or
+// returns nil if there is none.
+func newGame(gameType, channel string) game.Game {
+	variant, ok := modes.Lookup(gameType)
+	if !ok {
+		return nil
+	}
+	return variant.New(channel)
+}
+
+// gameTypes lists the game types $start takes.
+func gameTypes() string {
+	names := []string{}
+	for _, variant := range modes.Variants() {
+		names = append(names, variant.Name)
+	}
+	return strings.Join(append(names, "mixed", "dealer's choice"), ", ")
+}
+
+func (h *Handler) handleJoinGame(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress. Start one with $start <game_type>")
+		h.privmsg(channel, "No game in progress. Start one with $start <game_type>")
+		return
+	}
+	if h.closedForMaintenance(channel) {
 		return
 	}
 
-	if game.IsInProgress() {
-		h.conn.Privmsg(channel, "Cannot join the game at this time. The game is already in progress.")
+	if game.FindPlayer(cmd.Nick) != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, you're already at the table.", cmd.Nick))
+		return
+	}
+	if ban := h.banFor(cmd); ban != nil {
+		if !ban.Shadow {
+			h.notice(cmd.Nick, describeBan(ban))
+		}
 		return
 	}
+	if !h.admitted(channel, cmd.Nick, cmd.Arg(0)) {
+		h.privmsg(channel, fmt.Sprintf("%s, this table is private. You need an $invite from %s or the password.", cmd.Nick, h.tableHosts[channel]))
+		return
+	}
+	h.recordIdentity(cmd)
 
-	player, err := db.GetOrCreatePlayer(event.Nick)
-	if err != nil {
-		log.Printf("Error getting or creating player %s: %v", event.Nick, err)
-		h.conn.Privmsg(channel, fmt.Sprintf("Error adding player %s to the game.", event.Nick))
+	if game.IsInProgress() && h.lateRegistrationOpen(channel) {
+		h.registerLate(channel, cmd.Nick)
 		return
 	}
 
-	game.AddPlayer(player)
+	if game.IsInProgress() {
+		h.addToWaitlist(channel, cmd.Nick, "The game is already in progress.")
+		return
+	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s has joined the game.", event.Nick))
+	if len(game.GetPlayers()) >= h.maxPlayers(channel) {
+		h.addToWaitlist(channel, cmd.Nick, fmt.Sprintf("The table is full (%d seats).", h.maxPlayers(channel)))
+		return
+	}
 
-	if len(game.GetPlayers()) == 2 {
+	if h.seatPlayer(channel, cmd.Nick) && len(game.GetPlayers()) == h.minPlayers(channel) {
 		h.startRound(channel)
 	}
 }
 
-func (h *Handler) handleBet(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) seatPlayer(channel, nick string) bool {
+	game := h.games[channel]
+	player := h.loadPlayer(channel, nick)
+	if player == nil {
+		return false
+	}
+
+	game.AddPlayer(player)
+
+	h.privmsg(channel, fmt.Sprintf("%s has joined the game.", nick))
+	return true
+}
+
+func (h *Handler) handleBet(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
-	if len(event.Arguments) < 2 {
-		h.conn.Privmsg(channel, "Usage: $bet <amount>")
+	if len(cmd.Args) != 1 {
+		h.privmsg(channel, "Usage: $bet <amount>")
 		return
 	}
 
-	amount, err := strconv.Atoi(event.Arguments[1])
-	if err != nil {
-		h.conn.Privmsg(channel, "Invalid bet amount.")
+	amount, ok := parseAmount(cmd.Args[0])
+	if !ok {
+		h.privmsg(channel, "Invalid bet amount.")
 		return
 	}
+	if h.holdLargeBet(cmd, player, amount, amount) {
+		return
+	}
+	h.placeBet(channel, player, amount)
+}
 
-	err = game.Bet(player, amount)
-	if err != nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, %v", event.Nick, err))
+func (h *Handler) placeBet(channel string, player *models.Player, amount int) {
+	if err := h.games[channel].Bet(player, amount); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v", player.Nick, err))
 		return
 	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s bets %d", event.Nick, amount))
-	h.nextTurn(channel)
+	h.noteAggressor(channel, player.Nick)
+	h.recordAction(channel, "%s bets %d", player.Nick, amount)
+	h.privmsg(channel, fmt.Sprintf("%s bets %d", player.Nick, amount))
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleCall(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleCall(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
+	before := player.Money
 	err := game.Call(player)
 	if err != nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, %v", event.Nick, err))
+		h.privmsg(channel, fmt.Sprintf("%s, %v", cmd.Nick, err))
 		return
 	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s calls", event.Nick))
-	h.nextTurn(channel)
+	h.recordAction(channel, "%s calls %d", cmd.Nick, before-player.Money)
+	if player.Money == 0 && player.Bet < game.GetCurrentBet() {
+		h.privmsg(channel, fmt.Sprintf("%s calls all in for %d", cmd.Nick, before))
+	} else {
+		h.privmsg(channel, fmt.Sprintf("%s calls", cmd.Nick))
+	}
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleRaise(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleRaise(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
-	if len(event.Arguments) < 2 {
-		h.conn.Privmsg(channel, "Usage: $raise <amount>")
+	if len(cmd.Args) != 1 {
+		h.privmsg(channel, "Usage: $raise <amount>")
 		return
 	}
 
-	amount, err := strconv.Atoi(event.Arguments[1])
-	if err != nil {
-		h.conn.Privmsg(channel, "Invalid raise amount.")
+	amount, ok := parseAmount(cmd.Args[0])
+	if !ok {
+		h.privmsg(channel, "Invalid raise amount.")
+		return
+	}
+	if h.holdLargeBet(cmd, player, amount, game.GetCurrentBet()-player.Bet+amount) {
 		return
 	}
+	h.placeRaise(channel, player, amount)
+}
 
-	err = game.Raise(player, amount)
-	if err != nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, %v", event.Nick, err))
+func (h *Handler) placeRaise(channel string, player *models.Player, amount int) {
+	game := h.games[channel]
+	if err := game.Raise(player, amount); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v", player.Nick, err))
 		return
 	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s raises to %d", event.Nick, game.GetCurrentBet()))
-	h.nextTurn(channel)
+	h.noteAggressor(channel, player.Nick)
+	h.recordAction(channel, "%s raises to %d", player.Nick, game.GetCurrentBet())
+	h.privmsg(channel, fmt.Sprintf("%s raises to %d", player.Nick, game.GetCurrentBet()))
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleFold(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleFold(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
-	game.Fold(player)
-	h.conn.Privmsg(channel, fmt.Sprintf("%s folds", event.Nick))
-
-	if h.checkRoundEnd(channel) {
-		return
-	}
+	h.fold(channel, player)
+}
 
-	h.nextTurn(channel)
+func (h *Handler) fold(channel string, player *models.Player) {
+	h.games[channel].Fold(player)
+	h.recordAction(channel, "%s folds", player.Nick)
+	h.privmsg(channel, fmt.Sprintf("%s folds", player.Nick))
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleCheck(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleCheck(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
 	err := game.Check(player)
 	if err != nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, %v", event.Nick, err))
+		h.privmsg(channel, fmt.Sprintf("%s, %v", cmd.Nick, err))
 		return
 	}
 
-	h.conn.Privmsg(channel, fmt.Sprintf("%s checks", event.Nick))
-	h.nextTurn(channel)
+	h.recordAction(channel, "%s checks", cmd.Nick)
+	h.privmsg(channel, fmt.Sprintf("%s checks", cmd.Nick))
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleDraw(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) handleDraw(cmd *Command) {
+	channel := cmd.Channel
 	game := h.games[channel]
 
 	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
+		h.privmsg(channel, "No game in progress.")
 		return
 	}
 
 	fiveCardDraw, ok := game.(*modes.FiveCardDraw)
 	if !ok {
-		h.conn.Privmsg(channel, "This command is only available in Five Card Draw.")
+		h.privmsg(channel, "This command is only available in Five Card Draw.")
 		return
 	}
 
-	player := game.FindPlayer(event.Nick)
+	player := game.FindPlayer(cmd.Nick)
 	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
 		return
 	}
 
-	if len(event.Arguments) < 2 {
-		h.conn.Privmsg(channel, "Usage: $draw <card indices to discard>")
+	args := cmd.Args
+	if cmd.Name == "$stand" || (len(args) == 1 && args[0] == "0") {
+		args = nil
+	} else if len(args) == 0 {
+		h.privmsg(channel, "Usage: $draw <card positions to discard>, or $stand to keep your hand")
 		return
 	}
 
 	indices := []int{}
-	for _, arg := range event.Arguments[1:] {
+	for _, arg := range args {
 		index, err := strconv.Atoi(arg)
 		if err != nil {
-			h.conn.Privmsg(channel, fmt.Sprintf("Invalid index: %s", arg))
+			h.privmsg(channel, fmt.Sprintf("Invalid index: %s", sanitize(arg)))
 			return
 		}
 		indices = append(indices, index-1) // Convert to 0-based index
 	}
 
-	fiveCardDraw.DrawCards(player, indices)
-	h.conn.Notice(event.Nick, fmt.Sprintf("Your new hand: %v", player.Hand))
-	h.nextTurn(channel)
-}
-
-func (h *Handler) handleCheat(event *irc.Event) {
-	channel := event.Arguments[0]
-	game := h.games[channel]
-
-	if game == nil {
-		h.conn.Privmsg(channel, "No game in progress.")
-		return
-	}
-
-	player := game.FindPlayer(event.Nick)
-	if player == nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("%s, you're not in the game.", event.Nick))
+	if err := fiveCardDraw.DrawCards(player, indices); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v", cmd.Nick, err))
 		return
 	}
 
-	// Attempt to cheat PRISON RULES YO
-	if rand.Intn(cheatSuccessRate) == 0 {
-		// Successful cheat
-		h.handleSuccessfulCheat(channel, player, game)
-	} else {
-		// Failed cheat attempt
-		h.handleFailedCheat(channel, player, game)
-	}
-}
+	h.recordAction(channel, "%s draws %d", cmd.Nick, len(indices))
+	maxDraw, _ := fiveCardDraw.DrawLimit()
+	ace, keptAce := modes.KeptAce(player.Hand, indices)
 
-func (h *Handler) handleSuccessfulCheat(channel string, player *models.Player, game game.Game) {
-	switch g := game.(type) {
-	case *modes.Holdem:
-		h.handleHoldemCheat(channel, player, g)
-	case *modes.Omaha:
-		h.handleOmahaCheat(channel, player, g)
-	case *modes.FiveCardDraw:
-		h.handleFiveCardDrawCheat(channel, player, g)
+	switch {
+	case maxDraw > 0 && len(indices) > maxDraw && keptAce:
+		h.privmsg(channel, fmt.Sprintf("%s shows %s and draws %d cards", cmd.Nick, ace, len(indices)))
+	case len(indices) == 0:
+		h.privmsg(channel, fmt.Sprintf("%s stands pat", cmd.Nick))
+	case len(indices) == 1:
+		h.privmsg(channel, fmt.Sprintf("%s draws 1 card", cmd.Nick))
 	default:
-		log.Printf("Unknown game type for cheating")
-		h.conn.Notice(player.Nick, "Cheat failed due to unknown game type.")
-	}
-}
-
-func (h *Handler) handleHoldemCheat(channel string, player *models.Player, game *modes.Holdem) {
-	river := game.GetRiver()
-	allCards := append(river, h.getAllOtherPlayerCards(game)...)
-	stage := game.GetStage() // 0: preflop, 1: flop, 2: turn, 3: river
-
-	switch stage {
-	case 0: // Pre-flop
-		player.Hand = getBestStartingHand(allCards)
-	case 1, 2, 3: // Flop, Turn, River
-		player.Hand = getBestPossibleHand(river, allCards)
+		h.privmsg(channel, fmt.Sprintf("%s draws %d cards", cmd.Nick, len(indices)))
 	}
-
-	h.conn.Notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
+	h.notice(cmd.Nick, fmt.Sprintf("Your new hand: %v", player.Hand))
+	h.advanceGame(channel)
 }
 
-func (h *Handler) handleOmahaCheat(channel string, player *models.Player, game *modes.Omaha) {
-	river := game.GetRiver()
-	allCards := append(river, h.getAllOtherPlayerCards(game)...)
-	stage := game.GetStage() // 0: preflop, 1: flop, 2: turn, 3: river
-
-	switch stage {
-	case 0: // Pre-flop
-		player.Hand = getBestOmahaStartingHand(allCards)
-	case 1, 2, 3: // Flop, Turn, River
-		player.Hand = getBestPossibleOmahaHand(river, allCards)
+func (h *Handler) handleScore(cmd *Command) {
+	money, handsWon, err := db.GetPlayerStats(h.economy(cmd.Channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting stats for %s: %v", cmd.Nick, err)
+		h.privmsg(cmd.Channel, fmt.Sprintf("Error retrieving stats for %s", cmd.Nick))
+		return
 	}
 
-	h.conn.Notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
+	h.privmsg(cmd.Channel, fmt.Sprintf("%s's stats - Money: %d, Hands won: %d", cmd.Nick, money, handsWon))
 }
 
-func (h *Handler) handleFiveCardDrawCheat(channel string, player *models.Player, game *modes.FiveCardDraw) {
-	allCards := h.getAllOtherPlayerCards(game)
-	player.Hand = getBestFiveCardDrawHand(allCards)
-	h.conn.Notice(player.Nick, fmt.Sprintf("Your cheat was successful! Your new hand: %v", player.Hand))
-}
-
-func (h *Handler) handleFailedCheat(channel string, player *models.Player, game game.Game) {
-	// we calculatin
-	penalty := int(float64(player.Money) * cheatPenaltyRate)
-
-	game.RemovePlayer(player.Nick)
-
-	// Add their bet to the pot
-	game.AddToPot(player.Bet)
-
-	// Apply the penalty
-	player.Money -= penalty
-	game.AddToPot(penalty)
-
-	// Update the player in the database
-	err := db.UpdatePlayer(player)
-	if err != nil {
-		log.Printf("Error updating player %s after failed cheat: %v", player.Nick, err)
-	}
+// handleRejoin catches a seated player up on the hand when they rejoin the
+// channel.
+func (h *Handler) handleRejoin(event *irc.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 
-	// Announce the failed cheat attempt
-	h.conn.Privmsg(channel, fmt.Sprintf("%s is a bitch and tried to cheat! They're kicked from the round and lose %d chips as penalty.", player.Nick, penalty))
+	channel := event.Arguments[0]
+	game := h.games[channel]
 
-	// Check if the round should end
-	if h.checkRoundEnd(channel) {
+	if game == nil {
 		return
 	}
 
-	// Move to the next turn
-	h.nextTurn(channel)
-}
-
-func (h *Handler) getAllOtherPlayerCards(game game.Game) []models.Card {
-	var cards []models.Card
-	for _, p := range game.GetPlayers() {
-		if !p.Folded {
-			cards = append(cards, p.Hand...)
-		}
+	player := game.FindPlayer(event.Nick)
+	if player == nil {
+		return
 	}
-	return cards
-}
+	player.LastSeen = time.Now()
 
-func (h *Handler) handleScore(event *irc.Event) {
-	money, handsWon, err := db.GetPlayerStats(event.Nick)
-	if err != nil {
-		log.Printf("Error getting stats for %s: %v", event.Nick, err)
-		h.conn.Privmsg(event.Arguments[0], fmt.Sprintf("Error retrieving stats for %s", event.Nick))
+	if h.audits[channel] == nil {
+		h.notice(event.Nick, fmt.Sprintf("Welcome back! You have %d chips. The next hand hasn't been dealt yet.", player.Money))
 		return
 	}
 
-	h.conn.Privmsg(event.Arguments[0], fmt.Sprintf("%s's stats - Money: %d, Hands won: %d", event.Nick, money, handsWon))
+	summary := []string{fmt.Sprintf("Welcome back! Your hand: %v", player.Hand)}
+	if board := game.GetRiver(); len(board) > 0 {
+		summary = append(summary, fmt.Sprintf("Board: %v", board))
+	}
+	summary = append(summary, fmt.Sprintf("Pot: %d", game.GetPot()), fmt.Sprintf("Current bet: %d", game.GetCurrentBet()))
+	if player.Folded {
+		summary = append(summary, "You've folded")
+	} else if toCall := game.GetCurrentBet() - player.Bet; toCall > 0 {
+		summary = append(summary, fmt.Sprintf("To call: %d", min(toCall, player.Money)))
+	}
+	switch turn := h.currentTurn[channel]; turn {
+	case event.Nick:
+		summary = append(summary, "It's your turn")
+	case "":
+	default:
+		summary = append(summary, fmt.Sprintf("It's %s's turn", turn))
+	}
+	h.notice(event.Nick, strings.Join(summary, ". ")+".")
 }
 
-func (h *Handler) handleRejoin(event *irc.Event) {
-	channel := event.Arguments[0]
+func (h *Handler) startRound(channel string) {
 	game := h.games[channel]
-
-	if game == nil {
+	game.SetInProgress(true)
+	h.unseatBusted(channel)
+	if h.holdForTables(channel) {
 		return
 	}
+	h.seatLateJoins(channel)
+	if h.holdForRebuys(channel) || h.offerDeal(channel) || h.takeBreak(channel) {
+		return
+	}
+	if h.askChoice(channel) {
+		return
+	}
+	h.rotateVariant(channel)
 
-	player := game.FindPlayer(event.Nick)
-	if player != nil {
-		player.LastSeen = time.Now()
-		h.conn.Notice(event.Nick, fmt.Sprintf("Welcome back! Your hand: %v", player.Hand))
+	if shuffle := h.shuffles[channel]; shuffle != nil {
+		h.collectEntropy(channel, shuffle)
+		return
 	}
+	h.dealRound(channel)
 }
 
-func (h *Handler) startRound(channel string) {
+func (h *Handler) dealRound(channel string) {
 	game := h.games[channel]
-	game.SetInProgress(true)
 	game.ResetRound()
+	h.newCheatHand(channel)
+	h.openHistory(channel)
+	delete(h.earlyFolds, channel)
+	h.clearAggressor(channel)
+	h.startTournamentHand(channel)
+	h.startLimitClock(channel)
+	h.applyStakes(channel)
+	h.noteStakes(channel)
+	h.startChipAudit(channel)
+	h.noteStacks(channel)
 	game.DealCards()
+	h.recordForcedBets(channel)
+	h.recordPositions(channel)
 
 	for _, player := range game.GetPlayers() {
-		h.conn.Notice(player.Nick, fmt.Sprintf("Your hand: %v", player.Hand))
+		h.notice(player.Nick, "Your hand: "+h.cardText(player.Hand))
 	}
 
-	h.conn.Privmsg(channel, "New round started. Place your bets!")
-	h.nextTurn(channel)
+	h.privmsg(channel, h.handTitle(channel)+". Place your bets!")
+	h.emit(h.handStartEvent(channel))
+	h.announceNextTurn(channel)
 }
 
 func (h *Handler) announceNextTurn(channel string) {
@@ -647,65 +1216,90 @@ func (h *Handler) announceNextTurn(channel string) {
 
 	currentPlayer := players[currentTurn]
 	h.currentTurn[channel] = currentPlayer.Nick
+	if h.foldQueued(channel, currentPlayer.Nick) {
+		h.fold(channel, currentPlayer)
+		h.emit(Event{Kind: EventAction, Channel: channel, Nick: currentPlayer.Nick})
+		h.auditChips(channel)
+		return
+	}
 
 	log.Printf("Announcing next turn: %s", currentPlayer.Nick)
 
-	availableCommands := "$bet, $call, $raise, $fold, $check, $cheat"
-	if _, ok := game.(*modes.FiveCardDraw); ok {
-		availableCommands += ", $draw"
+	h.announceTurn(channel, currentPlayer)
+	notice := fmt.Sprintf("It's your turn. Available commands: %s", strings.Join(modes.TurnCommands(game), ", "))
+	if odds := h.potOdds(channel, currentPlayer); odds != "" {
+		notice += ". " + odds
 	}
+	h.notice(currentPlayer.Nick, notice)
 
-	h.conn.Privmsg(channel, fmt.Sprintf("It's %s's turn. Current bet: %d", currentPlayer.Nick, game.GetCurrentBet()))
-	h.conn.Notice(currentPlayer.Nick, fmt.Sprintf("It's your turn. Available commands: %s", availableCommands))
-
-	h.startTurnTimer(channel)
+	h.tableEtiquette(channel).turnStarted = time.Now()
+	h.startTurnClock(channel)
 }
 
-func (h *Handler) checkRoundEnd(channel string) bool {
+// announceTurn tells the channel whose turn it is. A player who asked with
+// $setprofile has the line start with their nick, so clients that only
+// alert on that do, and is pinged privately too.
+func (h *Handler) announceTurn(channel string, player *models.Player) {
 	game := h.games[channel]
-	if game.IsRoundOver() {
-		activePlayers := 0
-		for _, player := range game.GetPlayers() {
-			if !player.Folded {
-				activePlayers++
-			}
-		}
+	profile := h.profile(player.Nick)
+	drawing := false
+	if fiveCardDraw, ok := game.(*modes.FiveCardDraw); ok {
+		drawing = fiveCardDraw.IsDrawPhase()
+	}
+	switch {
+	case drawing && profile.TurnPrefix:
+		h.privmsg(channel, fmt.Sprintf("%s: it's your turn to draw.", player.Nick))
+	case drawing:
+		h.privmsg(channel, fmt.Sprintf("It's %s's turn to draw.", player.Nick))
+	case profile.TurnPrefix:
+		h.privmsg(channel, fmt.Sprintf("%s: it's your turn. Current bet: %d", player.Nick, game.GetCurrentBet()))
+	default:
+		h.privmsg(channel, fmt.Sprintf("It's %s's turn. Current bet: %d", player.Nick, game.GetCurrentBet()))
+	}
+	if profile.TurnPing {
+		h.privmsg(player.Nick, fmt.Sprintf("Your turn at %s.", channel))
+	}
+}
 
-		if activePlayers <= 1 {
-			var winner *models.Player
-			for _, player := range game.GetPlayers() {
-				if !player.Folded {
-					winner = player
-					break
-				}
-			}
-			if winner != nil {
-				h.endRoundWithWinner(channel, winner)
-			} else {
-				log.Println("Error: No winner found when all but one player folded")
-				h.endGame(channel)
-			}
-		} else {
-			h.endRound(channel)
-		}
+// potOdds describes the price of a call to a player who asked for pot
+// odds with $setprofile, such as "120 to call into 460, 3.8:1". It's empty
+// if they didn't, or have nothing to call.
+func (h *Handler) potOdds(channel string, player *models.Player) string {
+	toCall, pot := game.PotOdds(h.games[channel], player)
+	if toCall == 0 || !h.profile(player.Nick).PotOdds {
+		return ""
+	}
+	return fmt.Sprintf("%d to call into %d, %.1f:1", toCall, pot, float64(pot)/float64(toCall))
+}
+
+// checkRoundEnd ends the hand if it's over. When everyone else has folded,
+// the last player in takes the pot straight away, without waiting for the
+// game to call the betting round complete.
+func (h *Handler) checkRoundEnd(channel string) bool {
+	table := h.games[channel]
+	if winner := game.LastStanding(table); winner != nil {
+		h.auditChips(channel)
+		h.endRoundWithWinner(channel, winner)
+		return true
+	}
+	if table.IsRoundOver() {
+		h.auditChips(channel)
+		h.endRound(channel)
 		return true
 	}
 	return false
 }
 
 func (h *Handler) endRoundWithWinner(channel string, winner *models.Player) {
-	game := h.games[channel]
-	winner.Money += game.GetPot()
-	winner.HandsWon++
-
-	err := db.UpdatePlayer(winner)
-	if err != nil {
-		log.Printf("Error updating winner %s: %v", winner.Nick, err)
-	}
-
-	h.conn.Privmsg(channel, fmt.Sprintf("Round over! %s wins %d", winner.Nick, game.GetPot()))
-
-	if h.shouldEndGame(game) {
+	h.pauseTurnClock(channel)
+	h.settle(channel, EventWin, []game.Award{{Nick: winner.Nick, Amount: h.games[channel].GetPot()}})
+	h.keepWinningHand(channel, winner)
+	h.revealShuffle(channel)
+	h.handleBusts(channel)
+	h.flushPlayers()
+	h.closeHistory(channel)
+
+	if h.shouldEndGame(channel) {
 		h.endGame(channel)
 	} else {
 		h.startRound(channel)
@@ -713,256 +1307,170 @@ func (h *Handler) endRoundWithWinner(channel string, winner *models.Player) {
 }
 
 func (h *Handler) endRound(channel string) {
-	game := h.games[channel]
-	winner := game.EvaluateHands()
-	if winner == nil {
+	table := h.games[channel]
+	h.pauseTurnClock(channel)
+	h.useLuckyCharms(channel)
+	awards := game.Settle(table, h.committed(channel))
+	if len(awards) == 0 {
 		log.Println("Error: No winner found in endRound")
 		h.endGame(channel)
 		return
 	}
-	winner.Money += game.GetPot()
-	winner.HandsWon++
 
-	err := db.UpdatePlayer(winner)
-	if err != nil {
-		log.Printf("Error updating winner %s: %v", winner.Nick, err)
-	}
+	h.showHands(channel, awards)
+	h.settle(channel, EventShowdown, awards)
+	h.revealShuffle(channel)
+	h.handleBusts(channel)
+	h.flushPlayers()
 
-	h.conn.Privmsg(channel, fmt.Sprintf("Round over! %s wins %d", winner.Nick, game.GetPot()))
-
-	if h.shouldEndGame(game) {
+	if h.shouldEndGame(channel) {
 		h.endGame(channel)
-	} else {
+	} else if !h.holdForMucks(channel) {
+		delete(h.showdowns, channel)
+		h.closeHistory(channel)
 		h.startRound(channel)
 	}
 }
 
-func (h *Handler) shouldEndGame(game game.Game) bool {
-	activePlayers := 0
-	for _, player := range game.GetPlayers() {
-		if player.Money > 0 {
-			activePlayers++
+// settle pays out the hand at channel, and is the one place a hand's
+// winners are credited: each winner's stack and hands won, split pots
+// counting as a hand won for every winner, the "Round over!" line the hand
+// history and stats read, a win or showdown event for each winner, the
+// biggest share first, and the rail bets on the hand. Every player at the
+// table is saved, so what the losers put in, penalties included, is kept
+// too.
+func (h *Handler) settle(channel, kind string, awards []game.Award) {
+	table := h.games[channel]
+	delete(h.audits, channel)
+	wins := make([]string, len(awards))
+	for i, award := range awards {
+		winner := table.FindPlayer(award.Nick)
+		winner.Money += award.Amount
+		winner.HandsWon++
+		wins[i] = fmt.Sprintf("%s wins %d", award.Nick, award.Amount)
+	}
+	for _, player := range table.GetPlayers() {
+		if err := h.savePlayer(channel, player); err != nil {
+			log.Printf("Error updating player %s: %v", player.Nick, err)
 		}
 	}
-	return activePlayers < 2
-}
 
-func (h *Handler) endGame(channel string) {
-	game := h.games[channel]
-	var winner *models.Player
-	for _, player := range game.GetPlayers() {
-		if player.Money > 0 {
-			winner = player
-			break
+	h.privmsg(channel, "Round over! "+strings.Join(wins, ", "))
+	for _, award := range awards {
+		if kind == EventShowdown {
+			h.emit(h.showdownEvent(channel, table.FindPlayer(award.Nick), award.Amount))
+		} else {
+			h.emit(Event{Kind: kind, Channel: channel, Nick: award.Nick, Amount: award.Amount})
 		}
 	}
-
-	if winner != nil {
-		h.conn.Privmsg(channel, fmt.Sprintf("Game over! %s wins the game!", winner.Nick))
-	} else {
-		h.conn.Privmsg(channel, "Game over! It's a tie!")
-	}
-
-	// Clean up timers
-	if timer, exists := h.turnTimer[channel]; exists {
-		timer.Stop()
-		delete(h.turnTimer, channel)
-	}
-	delete(h.currentTurn, channel)
-	delete(h.games, channel)
+	h.settleRailBets(channel, awards)
 }
 
-// Helper functions for cheating mechanism
-
-func getBestStartingHand(usedCards []models.Card) []models.Card {
-	possibleHands := [][]string{
-		{"A", "A"}, {"K", "K"}, {"Q", "Q"}, {"A", "K"},
-		{"J", "J"}, {"10", "10"}, {"A", "Q"}, {"K", "Q"},
-	}
-
-	for _, hand := range possibleHands {
-		newHand := tryMakeHand(hand, usedCards)
-		if newHand != nil {
-			return newHand
+func (h *Handler) endGame(channel string) {
+	game := h.games[channel]
+	h.stopRebuyWait(channel)
+	h.refundHand(channel)
+	if s, exists := h.showdowns[channel]; exists {
+		if s.timer != nil {
+			s.timer.Stop()
 		}
-	}
-
-	// If all else fails, return two random high cards
-	return getRandomHighCards(usedCards, 2)
-}
-
-func getBestPossibleHand(river, usedCards []models.Card) []models.Card {
-	// Check for possible flush
-	flushSuit := getFlushSuit(river)
-	if flushSuit != "" {
-		return getHighestCards(flushSuit, usedCards, 2)
-	}
-
-	// Check for possible straight
-	straightCards := getPossibleStraightCards(river)
-	if len(straightCards) > 0 {
-		return getHighestCards(straightCards[0].Suit, usedCards, 2)
-	}
-
-	// If no flush or straight possible, get highest pair or high cards
-	return getHighestPairOrCards(river, usedCards)
-}
-
-func getBestOmahaStartingHand(usedCards []models.Card) []models.Card {
-	possibleHands := [][]string{
-		{"A", "A", "K", "K"}, {"A", "A", "Q", "Q"}, {"K", "K", "Q", "Q"},
-		{"A", "K", "Q", "J"}, {"A", "A", "J", "10"}, {"K", "K", "J", "10"},
-	}
-
-	for _, hand := range possibleHands {
-		newHand := tryMakeHand(hand, usedCards)
-		if newHand != nil {
-			return newHand
+		for nick, hand := range s.losers {
+			h.recordf(channel, "%s mucks %v", nick, hand)
 		}
+		delete(h.showdowns, channel)
 	}
+	h.closeHistory(channel)
 
-	// If all else fails, return four random high cards
-	return getRandomHighCards(usedCards, 4)
-}
-
-func getBestPossibleOmahaHand(river, usedCards []models.Card) []models.Card {
-	// Similar to getBestPossibleHand, but returns 4 cards instead of 2
-	// Implement Omaha-specific logic here
-	// This is a simplified version and should be expanded for real use
-	hand := getBestPossibleHand(river, usedCards)
-	hand = append(hand, getRandomHighCards(append(usedCards, hand...), 2)...)
-	return hand
-}
-
-func getBestFiveCardDrawHand(usedCards []models.Card) []models.Card {
-	possibleHands := [][]string{
-		{"A", "K", "Q", "J", "10"}, // Royal Flush
-		{"A", "A", "A", "A", "K"},  // Four of a Kind
-		{"A", "A", "A", "K", "K"},  // Full House
-	}
-
-	for _, hand := range possibleHands {
-		newHand := tryMakeHand(hand, usedCards)
-		if newHand != nil {
-			return newHand
+	// The chip leader wins: the last player with chips, unless a limit
+	// ended the game early.
+	var winner *models.Player
+	tied := false
+	for _, player := range game.GetPlayers() {
+		switch {
+		case player.Money <= 0:
+		case winner == nil || player.Money > winner.Money:
+			winner, tied = player, false
+		case player.Money == winner.Money:
+			tied = true
 		}
 	}
-
-	// If all else fails, return five random high cards
-	return getRandomHighCards(usedCards, 5)
-}
-
-func tryMakeHand(values []string, usedCards []models.Card) []models.Card {
-	suits := []string{"Hearts", "Diamonds", "Clubs", "Spades"}
-	hand := make([]models.Card, len(values))
-
-	for i, value := range values {
-		for _, suit := range suits {
-			card := models.Card{Suit: suit, Value: value}
-			if !containsCard(usedCards, card) {
-				hand[i] = card
-				break
-			}
-		}
-		if hand[i].Suit == "" {
-			return nil // Couldn't make this hand
-		}
+	if tied {
+		winner = nil
 	}
 
-	return hand
-}
-
-func getFlushSuit(river []models.Card) string {
-	suitCounts := make(map[string]int)
-	for _, card := range river {
-		suitCounts[card.Suit]++
-		if suitCounts[card.Suit] >= 3 {
-			return card.Suit
-		}
+	if h.deals[channel] != nil && h.deals[channel].amounts != nil {
+		h.privmsg(channel, "Game over! The prize pool is chopped.")
+	} else if winner != nil {
+		h.privmsg(channel, fmt.Sprintf("Game over! %s wins the game!", winner.Nick))
+		h.emit(Event{Kind: EventGameOver, Channel: channel, Nick: winner.Nick})
+	} else {
+		h.privmsg(channel, "Game over! It's a tie!")
 	}
-	return ""
-}
-
-func getPossibleStraightCards(river []models.Card) []models.Card {
-	values := make(map[string]bool)
-	for _, card := range river {
-		values[card.Value] = true
+	if h.tournaments[channel] != nil {
+		h.finishTournament(channel)
+	} else {
+		h.finishGame(channel)
 	}
+	h.closeCashTable(channel)
 
-	straightValues := []string{"A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A"}
-	for i := 0; i <= 9; i++ {
-		count := 0
-		for j := 0; j < 5; j++ {
-			if values[straightValues[i+j]] {
-				count++
-			}
-		}
-		if count >= 3 {
-			return river // Potential straight, return the river cards
+	// Clean up timers
+	h.endTableContext(channel)
+	if shuffle, exists := h.shuffles[channel]; exists {
+		if shuffle.timer != nil {
+			shuffle.timer.Stop()
 		}
+		delete(h.shuffles, channel)
 	}
-	return nil
-}
-
-func getHighestCards(suit string, usedCards []models.Card, count int) []models.Card {
-	values := []string{"A", "K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2"}
-	hand := make([]models.Card, 0)
-
-	for _, value := range values {
-		card := models.Card{Suit: suit, Value: value}
-		if !containsCard(usedCards, card) {
-			hand = append(hand, card)
-			if len(hand) == count {
-				break
-			}
+	if timer, exists := h.breaks[channel]; exists {
+		timer.Stop()
+		delete(h.breaks, channel)
+	}
+	h.stopRunOut(channel)
+	h.refundRailBets(channel)
+	if offer, exists := h.deals[channel]; exists {
+		if offer.timer != nil {
+			offer.timer.Stop()
 		}
+		delete(h.deals, channel)
 	}
-
-	return hand
-}
-
-func getHighestPairOrCards(river, usedCards []models.Card) []models.Card {
-	values := make(map[string]int)
-	for _, card := range river {
-		values[card.Value]++
+	if r, exists := h.rotations[channel]; exists && r.timer != nil {
+		r.timer.Stop()
 	}
-
-	// Check for pair
-	for value, count := range values {
-		if count == 2 {
-			return tryMakeHand([]string{value, value}, usedCards)
-		}
+	delete(h.rotations, channel)
+	delete(h.etiquette, channel)
+	delete(h.audits, channel)
+	delete(h.stacks, channel)
+	delete(h.currentTurn, channel)
+	delete(h.games, channel)
+	for _, player := range h.lateJoins[channel] {
+		h.waitlists[channel] = append(h.waitlists[channel], player.Nick)
 	}
+	delete(h.lateJoins, channel)
+	delete(h.tournaments, channel)
+	delete(h.limits, channel)
+	delete(h.tableWaits, channel)
 
-	// If no pair, get highest cards
-	return getRandomHighCards(usedCards, 2)
+	h.startWaitlistGame(channel, game.GetType())
+	h.hostNextGame(channel)
+	h.updateTopic(channel)
 }
 
-func getRandomHighCards(usedCards []models.Card, count int) []models.Card {
-	values := []string{"A", "K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2"}
-	suits := []string{"Hearts", "Diamonds", "Clubs", "Spades"}
-	hand := make([]models.Card, 0)
-
-	for _, value := range values {
-		for _, suit := range suits {
-			card := models.Card{Suit: suit, Value: value}
-			if !containsCard(usedCards, card) {
-				hand = append(hand, card)
-				if len(hand) == count {
-					return hand
-				}
-			}
-		}
+// finishGame announces and stores the final standings of a game that
+// wasn't a tournament, ranked by the players' stacks, so a game ended with
+// several players still holding chips has more than one result.
+func (h *Handler) finishGame(channel string) {
+	players := append([]*models.Player{}, h.games[channel].GetPlayers()...)
+	players = append(players, h.lateJoins[channel]...)
+	if len(players) < 2 {
+		return
 	}
-
-	return hand
-}
-
-func containsCard(cards []models.Card, card models.Card) bool {
-	for _, c := range cards {
-		if c.Suit == card.Suit && c.Value == card.Value {
-			return true
-		}
+	standings := game.RankStacks(players)
+	results := make([]string, len(standings))
+	for i, standing := range standings {
+		results[i] = fmt.Sprintf("%d. %s (%d)", standing.Place, standing.Nick, standing.Stack)
+	}
+	h.privmsg(channel, "Final standings: "+strings.Join(results, ", "))
+	if err := db.SaveGameStandings(channel, standings); err != nil {
+		log.Printf("Error saving the final standings for %s: %v", channel, err)
 	}
-	return false
 }
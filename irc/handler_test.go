@@ -0,0 +1,84 @@
+package irc
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	irc "github.com/thoj/go-ircevent"
+
+	"poker-bot/db"
+)
+
+// TestMain gives the tests a database of their own. The player cache is
+// shared by the whole package, so tests seat nicks no other test uses.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "poker-bot-irc")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := db.Initialize(filepath.Join(dir, "poker.db")); err != nil {
+		log.Fatal(err)
+	}
+	code := m.Run()
+	db.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// newTestHandler returns a handler connected to a fake IRC server that
+// reads and drops everything the bot sends.
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+		lines := bufio.NewScanner(conn)
+		for lines.Scan() {
+		}
+	}()
+
+	h := NewHandler()
+	h.bansLoaded = true
+	h.limiter.SetInterval(0)
+	h.conn = irc.IRC("bot", "bot")
+	if err := h.conn.Connect(listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	server := <-accepted
+	if server == nil {
+		t.Fatal("the bot didn't connect")
+	}
+	t.Cleanup(func() {
+		server.Close()
+		h.conn.Disconnect()
+	})
+	return h
+}
+
+// say runs text as a command from nick to target, the way the bot would on
+// receiving it.
+func say(t *testing.T, h *Handler, nick, target, text string) {
+	t.Helper()
+	cmd, ok := parseCommand(&irc.Event{Code: "PRIVMSG", Nick: nick, Source: nick + "!u@example.com", Arguments: []string{target, text}})
+	if !ok {
+		t.Fatalf("%q is not a command", text)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.recent = make(map[limiterKey]sentCommand)
+	h.dispatch(cmd)
+}
@@ -0,0 +1,213 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/modes"
+	"poker-bot/stats"
+)
+
+// handHistory is the log of the hand in play at a table: everything said to
+// the channel while it runs, plus what the channel doesn't see, such as
+// mucked hands.
+type handHistory struct {
+	id      int64 // 0 if the database couldn't reserve one
+	started time.Time
+	game    string
+	stakes  string
+	lines   []string
+	actions []string // the betting so far, with amounts, for $actions
+}
+
+func (h *Handler) openHistory(channel string) {
+	h.closeHistory(channel)
+	history := &handHistory{started: time.Now()}
+	id, err := db.StartHandHistory(channel, history.started)
+	if err != nil {
+		log.Printf("Error reserving a hand history for %s: %v", channel, err)
+	}
+	history.id = id
+	h.histories[channel] = history
+}
+
+// noteStakes keeps the game and stakes of the hand being dealt at channel
+// with its history, for leaderboards and stats by stakes.
+func (h *Handler) noteStakes(channel string) {
+	if history := h.histories[channel]; history != nil {
+		table := h.games[channel]
+		history.game, history.stakes = table.GetType(), table.Stakes().String()
+	}
+}
+
+func (h *Handler) record(channel, line string) {
+	if history := h.histories[channel]; history != nil {
+		history.lines = append(history.lines, line)
+	}
+}
+
+// closeHistory saves the hand in play, if any, to the database.
+func (h *Handler) closeHistory(channel string) {
+	history := h.histories[channel]
+	if history == nil {
+		return
+	}
+	delete(h.histories, channel)
+	if err := db.SaveHandHistory(history.id, channel, history.started, history.game, history.stakes, history.lines); err != nil {
+		log.Printf("Error saving hand history for %s: %v", channel, err)
+	}
+}
+
+// recordf adds a line the channel doesn't see to the hand history and the
+// table log.
+func (h *Handler) recordf(channel, format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...)
+	h.record(channel, line)
+	h.logTable(channel, line)
+}
+
+// recordAction adds a line to the hand's action sequence.
+func (h *Handler) recordAction(channel, format string, args ...interface{}) {
+	if history := h.histories[channel]; history != nil {
+		history.actions = append(history.actions, fmt.Sprintf(format, args...))
+	}
+}
+
+// recordForcedBets adds the blinds and antes taken by the deal to the
+// action sequence, from the stacks noted before it.
+func (h *Handler) recordForcedBets(channel string) {
+	for _, player := range h.games[channel].GetPlayers() {
+		posted := h.stacks[channel][player.Nick] - player.Money
+		if ante := posted - player.Bet; ante > 0 {
+			h.recordAction(channel, "%s antes %d", player.Nick, ante)
+		}
+		if player.Bet > 0 {
+			h.recordAction(channel, "%s posts %d", player.Nick, player.Bet)
+		}
+	}
+}
+
+// announcePosts tells the channel who has the button and what the deal
+// took from whom, as a hand starts, such as "Hand #12: btn alice, sb bob
+// posts 5, bb carol posts 10". The line goes into the hand history with
+// everything else said to the channel.
+func (h *Handler) announcePosts(event Event) {
+	if event.Kind != EventHandStart || (event.Button == "" && len(event.Posts) == 0) {
+		return
+	}
+	var parts []string
+	blinds := make(map[string]bool)
+	for _, post := range event.Posts {
+		if post.Blind > 0 {
+			blinds[post.Nick] = true
+		}
+	}
+	if event.Button != "" && !blinds[event.Button] {
+		parts = append(parts, "btn "+event.Button)
+	}
+	var antes []string
+	ante, sameAnte := 0, true
+	for _, post := range event.Posts {
+		if post.Blind > 0 {
+			parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %s posts %d", strings.ToLower(post.Position), post.Nick, post.Blind)))
+		}
+		if post.Ante > 0 {
+			antes = append(antes, fmt.Sprintf("%s antes %d", post.Nick, post.Ante))
+			if ante != 0 && post.Ante != ante {
+				sameAnte = false
+			}
+			ante = post.Ante
+		}
+	}
+	switch {
+	case len(antes) == 0:
+	case sameAnte && len(antes) > 1:
+		parts = append(parts, fmt.Sprintf("antes %d each", ante))
+	default:
+		parts = append(parts, antes...)
+	}
+
+	prefix := "This hand"
+	if event.HandID != 0 {
+		prefix = fmt.Sprintf("Hand #%d", event.HandID)
+	}
+	h.privmsg(event.Channel, prefix+": "+strings.Join(parts, ", "))
+}
+
+// recordPositions adds each player's position to the hand history, such as
+// "Positions: BTN alice, SB bob, BB carol", for games with a button. The
+// channel isn't told; the stats read it back.
+func (h *Handler) recordPositions(channel string) {
+	table := h.games[channel]
+	buttoned, ok := table.(game.Buttoned)
+	if !ok {
+		return
+	}
+	players := table.GetPlayers()
+	seats := make([]string, len(players))
+	for i, name := range game.Positions(len(players), buttoned.Button()) {
+		seats[i] = name + " " + players[i].Nick
+	}
+	h.recordf(channel, "Positions: %s", strings.Join(seats, ", "))
+}
+
+func (h *Handler) handleActions(cmd *Command) {
+	channel := cmd.Channel
+	history := h.histories[channel]
+	if history == nil || len(history.actions) == 0 {
+		h.notice(cmd.Nick, "There's no hand in play here.")
+		return
+	}
+	h.notice(cmd.Nick, fmt.Sprintf("This hand so far: %s", strings.Join(history.actions, ", ")))
+}
+
+// handTitle announces the hand being dealt at channel with its ID, the
+// betting structure, the game and the stakes, such as "Hand #4821 — NL
+// Texas Hold'em 5/10".
+func (h *Handler) handTitle(channel string) string {
+	table := h.games[channel]
+	name := table.GetType()
+	if variant, ok := modes.Lookup(name); ok {
+		name = variant.Title
+	}
+	title := fmt.Sprintf("%s %s %s", table.Structure(), name, table.Stakes())
+	if history := h.histories[channel]; history != nil && history.id != 0 {
+		return fmt.Sprintf("Hand #%d — %s", history.id, title)
+	}
+	return title
+}
+
+// handleHand sends a finished hand's record by private notice, for
+// settling disputes: $hand <id>. Players can look up the hands they played
+// in, without the cards other players mucked; admins can look up any hand.
+func (h *Handler) handleHand(cmd *Command) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(cmd.Text, "#"), 10, 64)
+	if err != nil || id <= 0 {
+		h.notice(cmd.Nick, "Usage: $hand <id>, the number announced when the hand was dealt.")
+		return
+	}
+	history, err := db.GetHandHistory(id)
+	if err != nil {
+		log.Printf("Error getting hand %d: %v", id, err)
+		h.notice(cmd.Nick, "Error retrieving the hand.")
+		return
+	}
+	admin := h.isAdmin(cmd)
+	if history == nil || (!admin && !stats.Played(cmd.Nick, history.Lines)) {
+		h.notice(cmd.Nick, fmt.Sprintf("There's no finished hand #%d that you played in.", id))
+		return
+	}
+
+	h.notice(cmd.Nick, fmt.Sprintf("Hand #%d at %s, %s:", id, history.Channel, history.StartedAt.Format("2006-01-02 15:04")))
+	for _, line := range history.Lines {
+		if nick, _, mucked := strings.Cut(line, " mucks "); mucked && !admin && nick != cmd.Nick {
+			line = nick + " mucks"
+		}
+		h.notice(cmd.Nick, line)
+	}
+}
@@ -0,0 +1,259 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// hostCommands are the commands only the host of a channel's table can
+// use. dispatch checks the sender is the host before running them.
+var hostCommands = map[string]func(*Handler, *Command){
+	"$kick":   (*Handler).handleKick,
+	"$stakes": (*Handler).handleStakes,
+	"$host":   (*Handler).handleTransferHost,
+	"$cancel": (*Handler).handleCancel,
+	"$invite": (*Handler).handleInvite,
+}
+
+// runHostCommand runs a host command if the sender hosts the table at the
+// channel it was sent to.
+func (h *Handler) runHostCommand(cmd *Command, run func(*Handler, *Command)) {
+	channel := cmd.Channel
+	host, ok := h.tableHosts[channel]
+	if !ok {
+		h.privmsg(channel, "No game in progress. Start one with $start <game_type>")
+		return
+	}
+	if cmd.Nick != host {
+		h.notice(cmd.Nick, fmt.Sprintf("Only %s, the host of this table, can do that.", host))
+		return
+	}
+	run(h, cmd)
+}
+
+// handleKick removes a player who hasn't been dealt in yet: anyone at the
+// table before the first hand, and players waiting for a seat after it.
+// Tournament buy-ins are refunded.
+func (h *Handler) handleKick(cmd *Command) {
+	channel := cmd.Channel
+	if len(cmd.Args) != 1 {
+		h.notice(cmd.Nick, "Usage: $kick <nick>")
+		return
+	}
+	nick := cmd.Args[0]
+	if nick == cmd.Nick {
+		h.notice(cmd.Nick, "You can't kick yourself. $host <nick> to hand the table over, or $cancel it.")
+		return
+	}
+
+	table := h.games[channel]
+	removed := false
+	var seated *models.Player // holding chips bought for the table
+	if player := table.FindPlayer(nick); player != nil {
+		if table.IsInProgress() {
+			h.notice(cmd.Nick, fmt.Sprintf("%s has been dealt in. Players can only be kicked before the first hand.", player.Nick))
+			return
+		}
+		table.RemovePlayer(player.Nick)
+		removed, seated = true, player
+	}
+	for i, player := range h.lateJoins[channel] {
+		if player.Nick == nick {
+			h.lateJoins[channel] = append(h.lateJoins[channel][:i], h.lateJoins[channel][i+1:]...)
+			removed, seated = true, player
+			break
+		}
+	}
+	for i, waiting := range h.waitlists[channel] {
+		if waiting == nick {
+			h.waitlists[channel] = append(h.waitlists[channel][:i], h.waitlists[channel][i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		h.notice(cmd.Nick, fmt.Sprintf("%s isn't at the table or waiting for a seat.", sanitize(nick)))
+		return
+	}
+
+	if t := h.tournaments[channel]; t != nil && t.Withdraw(nick) {
+		h.refundBuyIn(channel, nick, t.BuyIn)
+	}
+	if h.cashTables[channel] != nil && seated != nil {
+		h.cashOut(channel, seated)
+	}
+	if private := h.private[channel]; private != nil {
+		delete(private.invited, nick)
+	}
+	h.logAudit(cmd.Source, "kick", channel, nick, "")
+	h.privmsg(channel, fmt.Sprintf("%s has been removed from the table by %s.", nick, cmd.Nick))
+}
+
+func (h *Handler) refundBuyIn(channel, nick string, amount int) {
+	if err := db.RecordTransaction(h.economy(channel), nick, amount, "tournament buy-in refund"); err != nil {
+		log.Printf("Error refunding the buy-in of %s: %v", nick, err)
+	}
+}
+
+// handleStakes changes the blinds of a cash game before the first hand:
+// $stakes <small> <big> [ante].
+func (h *Handler) handleStakes(cmd *Command) {
+	channel := cmd.Channel
+	table := h.games[channel]
+	if h.tournaments[channel] != nil {
+		h.notice(cmd.Nick, "Tournaments play their blind levels.")
+		return
+	}
+	if _, ok := table.(game.Blinded); !ok {
+		h.notice(cmd.Nick, fmt.Sprintf("%s has no blinds to change.", table.GetType()))
+		return
+	}
+	if table.IsInProgress() {
+		h.notice(cmd.Nick, "The stakes can only change before the first hand.")
+		return
+	}
+
+	if len(cmd.Args) < 2 || len(cmd.Args) > 3 {
+		h.notice(cmd.Nick, "Usage: $stakes <small blind> <big blind> [ante]")
+		return
+	}
+	values := make([]int, 3)
+	for i, arg := range cmd.Args {
+		value, err := strconv.Atoi(arg)
+		if err != nil || value < 0 {
+			h.notice(cmd.Nick, "Usage: $stakes <small blind> <big blind> [ante]")
+			return
+		}
+		values[i] = value
+	}
+	stakes := game.BlindLevel{SmallBlind: values[0], BigBlind: values[1], Ante: values[2]}
+	if stakes.SmallBlind <= 0 || stakes.BigBlind < stakes.SmallBlind {
+		h.notice(cmd.Nick, "The blinds must be positive, with the big blind at least the small blind.")
+		return
+	}
+
+	h.tableStakes[channel] = stakes
+	h.privmsg(channel, fmt.Sprintf("%s set the stakes to %s.", cmd.Nick, stakes))
+	h.updateTopic(channel)
+}
+
+// handleTransferHost hands the table to another player at it.
+func (h *Handler) handleTransferHost(cmd *Command) {
+	channel := cmd.Channel
+	if len(cmd.Args) != 1 {
+		h.notice(cmd.Nick, "Usage: $host <nick>")
+		return
+	}
+	player := h.games[channel].FindPlayer(cmd.Args[0])
+	if player == nil {
+		h.notice(cmd.Nick, fmt.Sprintf("%s isn't at the table.", sanitize(cmd.Args[0])))
+		return
+	}
+
+	h.tableHosts[channel] = player.Nick
+	if private := h.private[channel]; private != nil {
+		private.invited[player.Nick] = true
+	}
+	h.privmsg(channel, fmt.Sprintf("%s is now the host of this table.", player.Nick))
+}
+
+// handleCancel closes the table before the first hand, refunding any
+// tournament buy-ins.
+func (h *Handler) handleCancel(cmd *Command) {
+	channel := cmd.Channel
+	if h.games[channel].IsInProgress() {
+		h.notice(cmd.Nick, "The game has started. It ends when one player has all the chips.")
+		return
+	}
+
+	h.cancelGame(channel)
+	h.logAudit(cmd.Source, "cancel", channel, "", "")
+	h.privmsg(channel, fmt.Sprintf("%s cancelled the game.", cmd.Nick))
+	h.updateTopic(channel)
+}
+
+// cancelGame closes a table before its first hand, refunding any
+// tournament buy-ins and paying cash stacks back. A multi-table tournament
+// closes all its tables, unless some have dealt: then only this one breaks,
+// its players moving to the others.
+func (h *Handler) cancelGame(channel string) {
+	t := h.tournaments[channel]
+	if t != nil && len(t.Tables()) > 0 {
+		// The tournament is under way at its other tables.
+		h.breakTable(channel)
+		return
+	}
+	tables := h.tablesOf(channel)
+	if t != nil {
+		for nick := range t.Entries {
+			h.refundBuyIn(channel, nick, t.BuyIn)
+		}
+	}
+	for _, table := range tables {
+		if table != channel {
+			h.clearTable(table)
+			h.privmsg(table, "The tournament is cancelled.")
+			h.updateTopic(table)
+		}
+	}
+	h.clearTable(channel)
+}
+
+// clearTable closes a table that hasn't dealt.
+func (h *Handler) clearTable(channel string) {
+	h.closeCashTable(channel)
+	if r, exists := h.rotations[channel]; exists && r.timer != nil {
+		r.timer.Stop()
+	}
+	delete(h.rotations, channel)
+	h.stopRebuyWait(channel)
+	h.endTableContext(channel)
+	delete(h.shuffles, channel)
+	delete(h.tournaments, channel)
+	delete(h.limits, channel)
+	delete(h.lateJoins, channel)
+	delete(h.waitlists, channel)
+	delete(h.currentTurn, channel)
+	delete(h.games, channel)
+	h.closeTable(channel)
+}
+
+// closeTable forgets the host, stakes, privacy, cash game and championship
+// of a table that's gone.
+func (h *Handler) closeTable(channel string) {
+	delete(h.finals, channel)
+	delete(h.cashTables, channel)
+	delete(h.tableHosts, channel)
+	delete(h.tableStakes, channel)
+	delete(h.private, channel)
+}
+
+// hostNextGame keeps the table's settings for the game the waitlist starts
+// after one ends, handing it to the first player seated if the host isn't
+// playing, and forgets them if no game started.
+func (h *Handler) hostNextGame(channel string) {
+	table := h.games[channel]
+	if table == nil || len(table.GetPlayers()) == 0 {
+		h.closeTable(channel)
+		return
+	}
+	if table.FindPlayer(h.tableHosts[channel]) == nil {
+		next := table.GetPlayers()[0].Nick
+		h.tableHosts[channel] = next
+		h.privmsg(channel, fmt.Sprintf("%s is now the host of this table.", next))
+	}
+}
+
+// cashStakes are the blinds of the cash game at channel: the host's, or
+// the config's.
+func (h *Handler) cashStakes(channel string) game.BlindLevel {
+	if stakes, ok := h.tableStakes[channel]; ok {
+		return stakes
+	}
+	return game.BlindLevel{SmallBlind: h.config.SmallBlind, BigBlind: h.config.BigBlind, Ante: h.config.Ante}
+}
@@ -0,0 +1,89 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+
+	"poker-bot/db"
+	"poker-bot/models"
+)
+
+// lateRegistrationHands is how many hands into a game new players can still
+// sit down. Later joins go on the waitlist for the next game.
+const lateRegistrationHands = 5
+
+func (h *Handler) lateRegistrationOpen(channel string) bool {
+	if h.cashTables[channel] != nil {
+		return true
+	}
+	if t := h.tournaments[channel]; t != nil {
+		return t.LateRegistrationOpen()
+	}
+	return h.games[channel].GetHandCount() < lateRegistrationHands
+}
+
+func (h *Handler) registerLate(channel, nick string) {
+	game := h.games[channel]
+	pending := h.lateJoins[channel]
+	for _, waiting := range pending {
+		if waiting.Nick == nick {
+			h.privmsg(channel, fmt.Sprintf("%s, you'll be dealt in next hand.", nick))
+			return
+		}
+	}
+
+	if len(game.GetPlayers())+len(pending) >= h.maxPlayers(channel) {
+		h.addToWaitlist(channel, nick, fmt.Sprintf("The table is full (%d seats).", h.maxPlayers(channel)))
+		return
+	}
+
+	player := h.loadPlayer(channel, nick)
+	if player == nil {
+		return
+	}
+	h.lateJoins[channel] = append(pending, player)
+	h.privmsg(channel, fmt.Sprintf("%s will be dealt in next hand.", nick))
+	h.withdrawDeal(channel, nick)
+	h.resumeAfterRebuy(channel)
+}
+
+// loadPlayer fetches a player's record for seating, buying them into the
+// tournament if the table is running one.
+func (h *Handler) loadPlayer(channel, nick string) *models.Player {
+	if h.blackjack[nick] != nil || h.videoPoker[nick] != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, finish your side game hand before sitting down.", nick))
+		return nil
+	}
+	if h.atBankrollTable(channel, nick) || !h.allowSeat(channel, nick) {
+		return nil
+	}
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(channel), nick)
+	if err != nil {
+		log.Printf("Error getting or creating player %s: %v", nick, err)
+		h.privmsg(channel, fmt.Sprintf("Error adding player %s to the game.", nick))
+		return nil
+	}
+	if h.tournaments[channel] != nil && !h.buyIn(channel, player) {
+		return nil
+	}
+	if h.cashTables[channel] != nil && !h.cashBuyIn(channel, player) {
+		return nil
+	}
+	return player
+}
+
+// seatLateJoins seats the late registrations between hands. In cash games
+// they post a big blind on their first hand, in games that have blinds;
+// tournament entries are dealt straight in.
+func (h *Handler) seatLateJoins(channel string) {
+	game := h.games[channel]
+	for _, player := range h.lateJoins[channel] {
+		if h.tournaments[channel] != nil {
+			game.AddPlayer(player)
+		} else {
+			game.AddLatePlayer(player)
+		}
+		h.privmsg(channel, fmt.Sprintf("%s takes a seat.", player.Nick))
+	}
+	delete(h.lateJoins, channel)
+}
@@ -0,0 +1,87 @@
+package irc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/digest"
+	"poker-bot/modes"
+)
+
+// leaderboardSize is how many players $leaderboard shows.
+const leaderboardSize = 10
+
+var stakesArg = regexp.MustCompile(`^\d+/\d+$`)
+
+// handleLeaderboard ranks the players of the channel's economy by the chips
+// they've won over every hand played, or only the hands of one game or at
+// one stakes: $leaderboard [game] [stakes], such as $leaderboard holdem 5/10.
+func (h *Handler) handleLeaderboard(cmd *Command) {
+	usage := "Usage: $leaderboard [game] [small/big blind], such as $leaderboard holdem 5/10"
+	args := cmd.Args
+	stakes := ""
+	if len(args) > 0 && strings.Contains(args[len(args)-1], "/") {
+		stakes = args[len(args)-1]
+		args = args[:len(args)-1]
+		if !stakesArg.MatchString(stakes) {
+			h.notice(cmd.Nick, usage)
+			return
+		}
+	}
+	game, title := "", ""
+	if len(args) > 0 {
+		variant, ok := modes.Lookup(strings.Join(args, " "))
+		if !ok {
+			h.notice(cmd.Nick, usage)
+			return
+		}
+		game, title = variant.Name, variant.Title
+	}
+	filter := db.HandFilter{Game: game, Stakes: stakes}
+	if h.economyMode == EconomyChannel {
+		// Only this channel's hands are played in its economy.
+		filter.Channel = cmd.Channel
+	}
+	go h.leaderboard(h.ctx, cmd.Channel, title, filter, h.economyMode, h.server)
+}
+
+// leaderboard reads the hand histories away from the handler's mutex, then
+// takes it to answer in the channel, unless the bot is shutting down.
+func (h *Handler) leaderboard(ctx context.Context, channel, title string, filter db.HandFilter, mode, server string) {
+	if ctx.Err() != nil {
+		return
+	}
+	message := describeLeaderboard(title, filter, economyOf(mode, server, channel), func(channel string) string {
+		return economyOf(mode, server, channel)
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	h.privmsg(channel, message)
+}
+
+func describeLeaderboard(title string, filter db.HandFilter, economy string, economyOf func(string) string) string {
+	standings, err := digest.Leaderboard(economy, filter, economyOf)
+	if err != nil {
+		log.Printf("Error getting the leaderboard: %v", err)
+		return "Error retrieving the leaderboard."
+	}
+	name := strings.TrimSpace(title + " " + filter.Stakes)
+	if name == "" {
+		name = "all games"
+	}
+	if len(standings) == 0 {
+		return fmt.Sprintf("No hands of %s have been played here yet.", name)
+	}
+	leaders := make([]string, 0, leaderboardSize)
+	for i, s := range standings[:min(len(standings), leaderboardSize)] {
+		leaders = append(leaders, fmt.Sprintf("%d. %s %+d in %d hands", i+1, s.Nick, s.Net, s.Hands))
+	}
+	return fmt.Sprintf("Leaderboard, %s: %s.", name, strings.Join(leaders, ", "))
+}
@@ -0,0 +1,92 @@
+package irc
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// The handler's work is scoped by contexts: the handler's own, cancelled by
+// Shutdown; one per IRC connection, cancelled when the server welcomes a new
+// one; and one per game, cancelled when the game ends or is cancelled.
+// Timers and goroutines started for a scope do nothing once it is done, so
+// none of them can act on a table or connection that has gone.
+
+// tableLife is the context of the game at one table.
+type tableLife struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// tableContext returns the context of the game at channel, starting one if
+// the game has none yet. With no game at channel it returns a context
+// that's already done, so work started for a game that has ended, or
+// hasn't begun, never runs.
+func (h *Handler) tableContext(channel string) context.Context {
+	if h.games[channel] == nil {
+		ctx, cancel := context.WithCancel(h.ctx)
+		cancel()
+		return ctx
+	}
+	life, ok := h.tableLives[channel]
+	if !ok {
+		life.ctx, life.cancel = context.WithCancel(h.ctx)
+		h.tableLives[channel] = life
+	}
+	return life.ctx
+}
+
+// endTableContext cancels the work still outstanding for the game at
+// channel once it's over, its turn clock included.
+func (h *Handler) endTableContext(channel string) {
+	h.stopTurnClock(channel)
+	if life, ok := h.tableLives[channel]; ok {
+		life.cancel()
+		delete(h.tableLives, channel)
+	}
+}
+
+// newConnContext starts the context of a new IRC connection, ending the
+// last one's.
+func (h *Handler) newConnContext() context.Context {
+	if h.connCancel != nil {
+		h.connCancel()
+	}
+	var ctx context.Context
+	ctx, h.connCancel = context.WithCancel(h.ctx)
+	return ctx
+}
+
+// afterFunc runs fn with the handler's mutex held once d has passed, unless
+// ctx is done by then.
+func (h *Handler) afterFunc(ctx context.Context, d time.Duration, fn func()) *time.Timer {
+	return time.AfterFunc(d, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		fn()
+	})
+}
+
+// afterTable is afterFunc for the game at channel.
+func (h *Handler) afterTable(channel string, d time.Duration, fn func()) *time.Timer {
+	return h.afterFunc(h.tableContext(channel), d, fn)
+}
+
+// Shutdown cancels everything the handler has outstanding, tables,
+// timers and background jobs, and leaves IRC.
+func (h *Handler) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	log.Println("Shutting down")
+	h.cancel()
+	for channel := range h.turnClocks {
+		h.stopTurnClock(channel)
+	}
+	if h.conn != nil && h.conn.Connected() {
+		h.conn.Quit()
+	}
+	h.closeTableLogs()
+}
@@ -0,0 +1,25 @@
+package irc
+
+import "testing"
+
+func TestNoTableContextAfterTheGame(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "ended1", "#ended", "$start holdem")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ctx := h.tableContext("#ended")
+	if ctx.Err() != nil {
+		t.Fatal("the table's context is done while the game is open")
+	}
+
+	h.cancelGame("#ended")
+	if ctx.Err() == nil {
+		t.Error("the table's context outlived the game")
+	}
+	if h.tableContext("#ended").Err() == nil {
+		t.Error("a new context was started for a table with no game")
+	}
+	if _, ok := h.tableLives["#ended"]; ok {
+		t.Error("a table with no game has a context")
+	}
+}
@@ -0,0 +1,45 @@
+package irc
+
+import (
+	"fmt"
+	"time"
+)
+
+// gameLimit ends a game after a number of hands or a length of time, set
+// with --hands or --minutes, instead of when one player has every chip.
+// The chip leader wins.
+type gameLimit struct {
+	hands    int
+	duration time.Duration
+	started  time.Time // the first deal
+}
+
+// startLimitClock starts the time limit on the first hand of the game.
+func (h *Handler) startLimitClock(channel string) {
+	if limit := h.limits[channel]; limit != nil && limit.started.IsZero() {
+		limit.started = time.Now()
+	}
+}
+
+// limitReached returns why the game at channel has reached its limit, or
+// "" if it hasn't or has none. It's checked between hands, so a hand in
+// play when time runs out is finished.
+func (h *Handler) limitReached(channel string) string {
+	limit := h.limits[channel]
+	switch {
+	case limit == nil:
+		return ""
+	case limit.hands > 0 && h.games[channel].GetHandCount() >= limit.hands:
+		return fmt.Sprintf("That was hand %d of %d.", limit.hands, limit.hands)
+	case limit.duration > 0 && !limit.started.IsZero() && time.Since(limit.started) >= limit.duration:
+		return fmt.Sprintf("Time's up after %s.", limit.duration)
+	}
+	return ""
+}
+
+func (l *gameLimit) String() string {
+	if l.hands > 0 {
+		return fmt.Sprintf("%d hands", l.hands)
+	}
+	return l.duration.String()
+}
@@ -0,0 +1,28 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// watchLobby closes the table at channel if it still hasn't dealt a hand
+// once the config's lobby timeout has passed, so a game nobody joins
+// doesn't hold the channel. The timer goes with the table's context, so it
+// can't close a later game.
+func (h *Handler) watchLobby(channel string) {
+	timeout := time.Duration(h.config.LobbyTimeout)
+	if timeout <= 0 {
+		return
+	}
+	h.afterTable(channel, timeout, func() {
+		table := h.games[channel]
+		if table == nil || table.IsInProgress() {
+			return
+		}
+		log.Printf("Closing the table at %s: %d seated after %s", channel, len(table.GetPlayers()), timeout)
+		h.cancelGame(channel)
+		h.privmsg(channel, fmt.Sprintf("Not enough players joined in %s, so the table is closed. $start a new game any time.", timeout))
+		h.updateTopic(channel)
+	})
+}
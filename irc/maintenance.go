@@ -0,0 +1,114 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+)
+
+// loadMaintenance reads the channels in maintenance into memory, the first
+// time they're needed. Until it has, h.maintenance is nil.
+func (h *Handler) loadMaintenance() {
+	channels, err := db.MaintenanceChannels()
+	if err != nil {
+		log.Printf("Error loading maintenance channels: %v", err)
+		return
+	}
+	h.maintenance = channels
+}
+
+// maintenanceFor returns the maintenance channel is in, or nil if it's
+// open.
+func (h *Handler) maintenanceFor(channel string) *db.Maintenance {
+	if h.maintenance == nil {
+		h.loadMaintenance()
+	}
+	m, ok := h.maintenance[strings.ToLower(channel)]
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// closedForMaintenance tells channel it's in maintenance, if it is, and
+// reports whether it is. New games, joins and rebuys check it first.
+func (h *Handler) closedForMaintenance(channel string) bool {
+	m := h.maintenanceFor(channel)
+	if m == nil {
+		return false
+	}
+	h.privmsg(channel, describeMaintenance(m))
+	return true
+}
+
+func describeMaintenance(m *db.Maintenance) string {
+	reason := ""
+	if m.Reason != "" {
+		reason = ": " + m.Reason
+	}
+	return fmt.Sprintf("This channel is closed for maintenance%s. Hands in play will finish, but there are no new games or seats until it's over.", reason)
+}
+
+// adminMaintenance closes the channel to new games and joins, or opens it
+// again: $admin maintenance [on [reason] | off]. Hands in play finish, and
+// cash and ordinary games end after them; tournaments play on without new
+// entrants. The setting is kept in the database, so it holds through
+// reconnects and restarts until an admin turns it off.
+func (h *Handler) adminMaintenance(cmd *Command, args []string) {
+	channel := cmd.Channel
+	if !strings.HasPrefix(channel, "#") {
+		h.notice(cmd.Nick, "Use $admin maintenance in the channel it's for.")
+		return
+	}
+	if len(args) == 1 {
+		if m := h.maintenanceFor(channel); m != nil {
+			h.notice(cmd.Nick, fmt.Sprintf("%s has been in maintenance since %s, set by %s. %s", channel, m.StartedAt.Format("2006-01-02 15:04"), m.SetBy, describeMaintenance(m)))
+		} else {
+			h.notice(cmd.Nick, fmt.Sprintf("%s isn't in maintenance.", channel))
+		}
+		return
+	}
+
+	if h.maintenance == nil {
+		h.loadMaintenance()
+	}
+	if h.maintenance == nil {
+		h.notice(cmd.Nick, "Error reading the maintenance setting.")
+		return
+	}
+	switch strings.ToLower(args[1]) {
+	case "on":
+		m := db.Maintenance{
+			Channel:   channel,
+			Reason:    strings.Join(args[2:], " "),
+			SetBy:     cmd.Source,
+			StartedAt: time.Now(),
+		}
+		if err := db.SetMaintenance(m); err != nil {
+			log.Printf("Error putting %s into maintenance: %v", channel, err)
+			h.notice(cmd.Nick, "Error saving the maintenance setting.")
+			return
+		}
+		h.maintenance[strings.ToLower(channel)] = m
+		h.logAudit(cmd.Source, "maintenance", channel, "", "on "+m.Reason)
+		h.privmsg(channel, describeMaintenance(&m))
+	case "off":
+		if h.maintenanceFor(channel) == nil {
+			h.notice(cmd.Nick, fmt.Sprintf("%s isn't in maintenance.", channel))
+			return
+		}
+		if err := db.ClearMaintenance(channel); err != nil {
+			log.Printf("Error taking %s out of maintenance: %v", channel, err)
+			h.notice(cmd.Nick, "Error saving the maintenance setting.")
+			return
+		}
+		delete(h.maintenance, strings.ToLower(channel))
+		h.logAudit(cmd.Source, "maintenance", channel, "", "off")
+		h.privmsg(channel, "Maintenance is over. $start a game any time.")
+	default:
+		h.notice(cmd.Nick, "Usage: $admin maintenance [on [reason] | off]")
+	}
+}
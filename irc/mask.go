@@ -0,0 +1,33 @@
+package irc
+
+import "strings"
+
+// matchMask reports whether source, a nick!user@host, matches the IRC
+// hostmask mask, ignoring case. In a hostmask * matches any run of
+// characters and ? any one character, / and . included, so *!*@* matches
+// cloaked hosts such as user/bob too.
+func matchMask(mask, source string) bool {
+	mask, source = strings.ToLower(mask), strings.ToLower(source)
+	m, s := 0, 0
+	star, mark := -1, 0
+	for s < len(source) {
+		switch {
+		case m < len(mask) && (mask[m] == '?' || mask[m] == source[s]):
+			m++
+			s++
+		case m < len(mask) && mask[m] == '*':
+			star, mark = m, s
+			m++
+		case star >= 0:
+			// Let the last * swallow one more character and try again.
+			mark++
+			m, s = star+1, mark
+		default:
+			return false
+		}
+	}
+	for m < len(mask) && mask[m] == '*' {
+		m++
+	}
+	return m == len(mask)
+}
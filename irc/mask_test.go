@@ -0,0 +1,27 @@
+package irc
+
+import "testing"
+
+func TestMatchMask(t *testing.T) {
+	tests := []struct {
+		mask, source string
+		want         bool
+	}{
+		{"*!*@*", "bob!~b@user/bob", true},
+		{"*!*@user/bob", "bob!~b@user/bob", true},
+		{"*!*@user/*", "bob!~b@user/bob", true},
+		{"bob!*@*", "BOB!~b@example.com", true},
+		{"b?b!*@*", "bob!~b@example.com", true},
+		{"*!*@*.example.com", "bob!~b@host.example.com", true},
+		{"*!*@*.example.com", "bob!~b@example.com", false},
+		{"alice!*@*", "bob!~b@user/bob", false},
+		{"b?b!*@*", "bb!~b@example.com", false},
+		{"*", "", true},
+		{"", "bob!~b@example.com", false},
+	}
+	for _, test := range tests {
+		if got := matchMask(test.mask, test.source); got != test.want {
+			t.Errorf("matchMask(%q, %q) = %v, want %v", test.mask, test.source, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"poker-bot/game"
+	"poker-bot/modes"
+)
+
+// defaultRotateHands is how many hands a mixed game plays of each variant
+// before moving on to the next.
+const defaultRotateHands = 6
+
+// choiceTimeout is how long the player on the button has to $choose the
+// next variant in dealer's choice before the default is dealt.
+const choiceTimeout = 30 * time.Second
+
+var defaultMixedVariants = []string{"holdem", "omaha", "five card draw"}
+
+// rotation is the variant schedule of a mixed game. In dealer's choice the
+// variants are not rotated; the player on the button picks one every hand,
+// and the first variant is the default.
+type rotation struct {
+	variants []string
+	every    int
+	current  int
+	hands    int
+
+	choice   bool
+	chooser  int    // seat that picks the next variant
+	choosing string // nick we are waiting on, if any
+	chosen   bool   // the variant for the next hand has been picked
+	timer    *time.Timer
+}
+
+// newRotation builds a rotation from a comma separated list of variants,
+// or the default list when spec is empty.
+func newRotation(spec string, every int) (*rotation, error) {
+	variants := defaultMixedVariants
+	if spec != "" {
+		variants = nil
+		for _, name := range strings.Split(spec, ",") {
+			variant := newGame(strings.ToLower(strings.TrimSpace(name)), "")
+			if variant == nil {
+				return nil, fmt.Errorf("unknown variant %q", name)
+			}
+			variants = append(variants, variant.GetType())
+		}
+	}
+	if len(variants) < 2 {
+		return nil, fmt.Errorf("a mixed game needs at least two variants")
+	}
+	if every <= 0 {
+		every = defaultRotateHands
+	}
+	return &rotation{variants: variants, every: every}, nil
+}
+
+func (r *rotation) String() string {
+	if r.choice {
+		return strings.Join(r.variants, " / ")
+	}
+	return fmt.Sprintf("%s, %d hands each", strings.Join(r.variants, " / "), r.every)
+}
+
+// maxPlayers is the seat limit of the table: the smallest of its variants in
+// a mixed game, so that everyone can still be dealt in after a switch.
+func (h *Handler) maxPlayers(channel string) int {
+	seats := h.games[channel].MaxPlayers()
+	if r := h.rotations[channel]; r != nil {
+		for _, name := range r.variants {
+			variant, _ := modes.Lookup(name)
+			seats = min(seats, variant.MaxPlayers)
+		}
+	}
+	return seats
+}
+
+// minPlayers is how many players the table needs to deal a hand: the most
+// any of its variants needs in a mixed game.
+func (h *Handler) minPlayers(channel string) int {
+	variants := []string{h.games[channel].GetType()}
+	if r := h.rotations[channel]; r != nil {
+		variants = r.variants
+	}
+	players := 2
+	for _, name := range variants {
+		if variant, ok := modes.Lookup(name); ok {
+			players = max(players, variant.MinPlayers)
+		}
+	}
+	return players
+}
+
+// rotateVariant counts the hand about to be dealt and, once the current
+// variant has had its hands, moves the table on to the next variant with
+// the same players and stacks.
+func (h *Handler) rotateVariant(channel string) {
+	r := h.rotations[channel]
+	if r == nil || r.choice {
+		return
+	}
+	if r.hands < r.every {
+		r.hands++
+		return
+	}
+
+	r.current = (r.current + 1) % len(r.variants)
+	r.hands = 1
+	h.switchVariant(channel, r.variants[r.current])
+	h.privmsg(channel, fmt.Sprintf("Switching games: the next %d hands are %s.", r.every, r.variants[r.current]))
+}
+
+// switchVariant moves the table to a new game of variant with the same
+// players and stacks.
+func (h *Handler) switchVariant(channel, variant string) {
+	previous := h.games[channel]
+	if previous.GetType() == variant {
+		return
+	}
+	next := newGame(variant, channel)
+	game.SwitchVariant(previous, next)
+	if t := h.tournaments[channel]; t != nil {
+		if blinded, ok := next.(game.Blinded); ok {
+			level := t.CurrentLevel()
+			blinded.SetBlinds(level.SmallBlind, level.BigBlind, level.Ante)
+		}
+	}
+	h.games[channel] = next
+}
+
+// askChoice pauses the table between hands in dealer's choice until the
+// player on the button picks the variant. It reports whether the table is
+// paused.
+func (h *Handler) askChoice(channel string) bool {
+	r := h.rotations[channel]
+	if r == nil || !r.choice {
+		return false
+	}
+	if r.chosen {
+		r.chosen = false
+		return false
+	}
+
+	players := h.games[channel].GetPlayers()
+	r.choosing = players[r.chooser%len(players)].Nick
+	r.chooser = (r.chooser + 1) % len(players)
+	h.privmsg(channel, fmt.Sprintf("%s, dealer's choice! $choose %s within %s, or it's %s.", r.choosing, strings.Join(r.variants, " | "), choiceTimeout, r.variants[0]))
+
+	table := h.games[channel]
+	chooser := r.choosing
+	r.timer = h.afterTable(channel, choiceTimeout, func() {
+		if h.games[channel] != table || r.choosing != chooser {
+			return
+		}
+		h.privmsg(channel, fmt.Sprintf("%s didn't choose in time.", chooser))
+		h.chooseVariant(channel, r.variants[0])
+	})
+	return true
+}
+
+func (h *Handler) handleChoose(cmd *Command) {
+	channel := cmd.Channel
+	r := h.rotations[channel]
+	if r == nil || r.choosing == "" {
+		h.privmsg(channel, "There is no variant to choose right now.")
+		return
+	}
+	if cmd.Nick != r.choosing {
+		h.privmsg(channel, fmt.Sprintf("%s, it's %s's choice.", cmd.Nick, r.choosing))
+		return
+	}
+
+	name := strings.ToLower(cmd.Text)
+	if variant := newGame(name, ""); variant != nil {
+		for _, allowed := range r.variants {
+			if variant.GetType() == allowed {
+				r.timer.Stop()
+				h.chooseVariant(channel, allowed)
+				return
+			}
+		}
+	}
+	h.privmsg(channel, fmt.Sprintf("%s, choose one of: %s", cmd.Nick, strings.Join(r.variants, ", ")))
+}
+
+func (h *Handler) chooseVariant(channel, variant string) {
+	r := h.rotations[channel]
+	r.choosing = ""
+	r.chosen = true
+	h.switchVariant(channel, variant)
+	h.privmsg(channel, fmt.Sprintf("This hand is %s.", h.games[channel].GetType()))
+	h.startRound(channel)
+}
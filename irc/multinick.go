@@ -0,0 +1,87 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"poker-bot/db"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+// noteSource remembers the host each nick last spoke from, so the host of a
+// nick seated off the waitlist is known too.
+func (h *Handler) noteSource(cmd *Command) {
+	if cmd.Host != "" {
+		h.hosts[cmd.Nick] = strings.ToLower(cmd.Host)
+	}
+}
+
+// recordIdentity stores the joining player's ident and host, and asks
+// services which account they're logged in to.
+func (h *Handler) recordIdentity(cmd *Command) {
+	if err := db.RecordHost(cmd.Nick, cmd.User, cmd.Host); err != nil {
+		log.Printf("Error recording host for %s: %v", cmd.Nick, err)
+	}
+	if h.conn != nil {
+		h.conn.Whois(cmd.Nick)
+	}
+}
+
+// handleWhoisAccount records RPL_WHOISACCOUNT: <me> <nick> <account> :is
+// logged in as.
+func (h *Handler) handleWhoisAccount(event *irc.Event) {
+	if len(event.Arguments) < 3 {
+		return
+	}
+	if err := db.RecordAccount(event.Arguments[1], event.Arguments[2]); err != nil {
+		log.Printf("Error recording account for %s: %v", event.Arguments[1], err)
+	}
+}
+
+// sameHost returns the nicks at channel's table, seated or waiting to be
+// seated, that share nick's host.
+func (h *Handler) sameHost(channel, nick string) []string {
+	host := h.hosts[nick]
+	if host == "" {
+		return nil
+	}
+	var others []string
+	check := func(other string) {
+		if other != nick && h.hosts[other] == host {
+			others = append(others, other)
+		}
+	}
+	for _, player := range h.games[channel].GetPlayers() {
+		check(player.Nick)
+	}
+	for _, player := range h.lateJoins[channel] {
+		check(player.Nick)
+	}
+	return others
+}
+
+// allowSeat warns the admins when nick shares a host with someone at the
+// table, and refuses the seat if the config says to.
+func (h *Handler) allowSeat(channel, nick string) bool {
+	others := h.sameHost(channel, nick)
+	if len(others) == 0 {
+		return true
+	}
+
+	warning := fmt.Sprintf("%s at %s is on the same host as %s.", nick, channel, strings.Join(others, ", "))
+	if h.config.BlockSharedHosts {
+		warning += " The seat was refused."
+	}
+	log.Print(warning)
+	if h.config.AdminChannel != "" {
+		h.privmsg(h.config.AdminChannel, warning)
+	}
+
+	if h.config.BlockSharedHosts {
+		h.privmsg(channel, fmt.Sprintf("%s, someone on your host is already at this table.", nick))
+		return false
+	}
+	return true
+}
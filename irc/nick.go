@@ -0,0 +1,173 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+// reclaimWait is how long after asking services to ghost whoever holds
+// the bot's nick it tries to take the nick back.
+const reclaimWait = 10 * time.Second
+
+// trackNick follows the nick the bot actually has, which isn't always the
+// one it asked for. While registering, a nick in use moves on to the
+// config's alt_nicks, then to the last one tried with a _ added. Once
+// registered, losing the nick, to a services rename or a collision, pauses
+// the turn clocks while the bot asks services for it back, since players
+// can't reach it where they expect to. The pause lasts at most twice
+// reclaimWait, so whoever takes the nick can't stall the tables; cards
+// still go out by notice from whatever nick the bot has.
+func (h *Handler) trackNick() {
+	h.ownNick = ""
+	// go-ircevent adds a _ to any nick in use, even a reclaim that failed
+	// after registration, which would leave it wrong about the bot's nick.
+	h.conn.ClearCallback("433")
+	h.conn.ClearCallback("437")
+	h.conn.AddCallback("433", h.nickInUse)
+	h.conn.AddCallback("437", h.nickInUse)
+
+	h.conn.AddCallback("001", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.ownNick = e.Arguments[0]
+		h.identify()
+		if h.renamed() {
+			h.reclaimNick()
+		}
+	})
+	h.conn.AddCallback("NICK", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if !strings.EqualFold(e.Nick, h.ownNick) {
+			return
+		}
+		wasRenamed := h.renamed()
+		h.ownNick = e.Message()
+		switch {
+		case wasRenamed && !h.renamed():
+			h.nickRegained()
+		case !wasRenamed && h.renamed():
+			h.nickLost()
+		}
+	})
+}
+
+// nickInUse handles ERR_NICKNAMEINUSE and ERR_UNAVAILRESOURCE. While
+// registering it tries the next nick; after, the bot keeps the nick it has.
+func (h *Handler) nickInUse(e *irc.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ownNick != "" || len(e.Arguments) < 2 {
+		return
+	}
+	next := h.nextNick(e.Arguments[1])
+	log.Printf("Nick %s is unavailable, trying %s", e.Arguments[1], next)
+	h.conn.SendRawf("NICK %s", next)
+}
+
+// nextNick is the nick to try after tried: the next of the bot's nick and
+// its alternates, or tried with a _ added once they're all taken.
+func (h *Handler) nextNick(tried string) string {
+	nicks := append([]string{h.nick}, h.config.AltNicks...)
+	for i, nick := range nicks[:len(nicks)-1] {
+		if strings.EqualFold(nick, tried) {
+			return nicks[i+1]
+		}
+	}
+	return tried + "_"
+}
+
+// isBot reports whether nick is the bot's nick now.
+func (h *Handler) isBot(nick string) bool {
+	return strings.EqualFold(nick, h.ownNick)
+}
+
+// renamed reports whether the bot is registered under a nick other than
+// its own.
+func (h *Handler) renamed() bool {
+	return h.ownNick != "" && !strings.EqualFold(h.ownNick, h.nick)
+}
+
+// identify identifies the bot's nick to NickServ, if the config has its
+// password.
+func (h *Handler) identify() {
+	if h.config.NickServPassword == "" {
+		return
+	}
+	h.conn.Privmsg("NickServ", fmt.Sprintf("IDENTIFY %s %s", h.nick, h.config.NickServPassword))
+}
+
+// reclaimNick asks NickServ to disconnect whoever holds the bot's nick, then
+// takes it back, pausing the turn clocks meanwhile. If the nick still isn't
+// back reclaimWait after that, the clocks run again on the nick the bot
+// has. Without the password it waits for go-ircevent, which tries for the
+// nick again every few minutes, and the clocks keep running.
+func (h *Handler) reclaimNick() {
+	if h.config.NickServPassword == "" {
+		return
+	}
+	h.reclaiming = true
+	for channel := range h.games {
+		h.pauseTurnClock(channel)
+	}
+	h.conn.Privmsg("NickServ", fmt.Sprintf("GHOST %s %s", h.nick, h.config.NickServPassword))
+	h.afterFunc(h.ctx, reclaimWait, func() {
+		if h.renamed() {
+			h.conn.SendRawf("NICK %s", h.nick)
+		}
+		h.afterFunc(h.ctx, reclaimWait, h.reclaimFailed)
+	})
+}
+
+// reclaimFailed ends the pause for a reclaim that didn't get the nick back.
+func (h *Handler) reclaimFailed() {
+	if !h.reclaiming || !h.renamed() {
+		return
+	}
+	log.Printf("Couldn't reclaim nick %s, staying %s", h.nick, h.ownNick)
+	h.resumeTurnClocks(fmt.Sprintf("I couldn't get my nick back, so I'm staying %s. The turn clock is running again.", h.ownNick))
+}
+
+// resumeTurnClocks ends a reclaim's pause, giving whoever is to act at each
+// table their full time, and tells each table so with message.
+func (h *Handler) resumeTurnClocks(message string) {
+	h.reclaiming = false
+	for channel := range h.games {
+		if h.currentTurn[channel] != "" {
+			h.startTurnClock(channel)
+		}
+		h.privmsg(channel, message)
+	}
+}
+
+// nickLost tells the players where to find the bot and tries to get its
+// nick back.
+func (h *Handler) nickLost() {
+	log.Printf("Lost nick %s, now %s", h.nick, h.ownNick)
+	message := fmt.Sprintf("I've lost my nick and am %s for now; private commands go to %s.", h.ownNick, h.ownNick)
+	if h.config.NickServPassword != "" {
+		message += " The turn clock is paused while I get it back."
+	}
+	for channel := range h.games {
+		h.privmsg(channel, message)
+	}
+	h.reclaimNick()
+}
+
+// nickRegained identifies the nick again and ends any pause of the turn
+// clocks.
+func (h *Handler) nickRegained() {
+	log.Printf("Regained nick %s", h.nick)
+	h.identify()
+	if h.reclaiming {
+		h.resumeTurnClocks(fmt.Sprintf("I'm back as %s. The turn clock is running again.", h.nick))
+		return
+	}
+	for channel := range h.games {
+		h.privmsg(channel, fmt.Sprintf("I'm back as %s.", h.nick))
+	}
+}
@@ -0,0 +1,28 @@
+package irc
+
+import "testing"
+
+func TestTurnClocksRunOnAnAltNick(t *testing.T) {
+	h := newTestHandler(t)
+	startHand(t, h, "#altnick", "alt1", "alt2")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nick, h.ownNick = "bot", "bot_"
+	clock := h.turnClocks["#altnick"]
+
+	h.nickLost()
+	h.startTurnClock("#altnick")
+	if clock.deadline.IsZero() {
+		t.Fatal("the turn clock stopped on an alt nick with no reclaim under way")
+	}
+
+	h.config.NickServPassword = "secret"
+	h.reclaimNick()
+	if !clock.deadline.IsZero() {
+		t.Fatal("the turn clock ran while the nick was being reclaimed")
+	}
+	h.reclaimFailed()
+	if clock.deadline.IsZero() || h.reclaiming {
+		t.Error("the turn clock stayed paused after the reclaim failed")
+	}
+}
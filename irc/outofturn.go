@@ -0,0 +1,43 @@
+package irc
+
+import "fmt"
+
+// actOutOfTurn answers a turn command from a player in the hand whose
+// turn it isn't. A $fold is held until the action reaches them, as
+// folding out of turn is allowed at a live table; anything else is turned
+// down with who the table is waiting on. Spectators get no answer.
+func (h *Handler) actOutOfTurn(cmd *Command) {
+	channel := cmd.Channel
+	table := h.games[channel]
+	if table == nil || h.audits[channel] == nil {
+		return
+	}
+	player := table.FindPlayer(cmd.Nick)
+	if player == nil || player.Folded {
+		return
+	}
+
+	toAct := h.currentTurn[channel]
+	switch {
+	case cmd.Name == "$fold":
+		if h.earlyFolds[channel] == nil {
+			h.earlyFolds[channel] = make(map[string]bool)
+		}
+		h.earlyFolds[channel][cmd.Nick] = true
+		h.notice(cmd.Nick, "You'll fold when the action gets to you.")
+	case toAct == "":
+		h.notice(cmd.Nick, "Nobody can act right now.")
+	default:
+		h.notice(cmd.Nick, fmt.Sprintf("It's not your turn, %s to act.", toAct))
+	}
+}
+
+// foldQueued reports whether nick folded out of turn and clears it, now
+// the action has reached them.
+func (h *Handler) foldQueued(channel, nick string) bool {
+	if !h.earlyFolds[channel][nick] {
+		return false
+	}
+	delete(h.earlyFolds[channel], nick)
+	return true
+}
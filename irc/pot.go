@@ -0,0 +1,117 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+
+	"poker-bot/game"
+	"poker-bot/modes"
+)
+
+// committed returns what each player at channel has put in the pot this
+// hand, from the stacks noted before the deal.
+func (h *Handler) committed(channel string) map[string]int {
+	committed := make(map[string]int)
+	for _, player := range h.games[channel].GetPlayers() {
+		if stack, ok := h.stacks[channel][player.Nick]; ok && stack > player.Money {
+			committed[player.Nick] = stack - player.Money
+		}
+	}
+	return committed
+}
+
+// handlePot shows the pot of the hand in play, split into the main pot and
+// side pots once a player is all in for less than the others.
+func (h *Handler) handlePot(cmd *Command) {
+	channel := cmd.Channel
+	table := h.games[channel]
+	if table == nil || h.audits[channel] == nil {
+		h.privmsg(channel, "No hand in play.")
+		return
+	}
+
+	pots := game.Pots(table, h.committed(channel))
+	if len(pots) == 1 {
+		h.privmsg(channel, fmt.Sprintf("Pot: %d. Current bet: %d.", pots[0].Amount, table.GetCurrentBet()))
+		return
+	}
+	parts := make([]string, len(pots))
+	for i, pot := range pots {
+		name := "Main pot"
+		if i > 0 {
+			name = fmt.Sprintf("Side pot %d", i)
+		}
+		parts[i] = fmt.Sprintf("%s: %d (%s)", name, pot.Amount, strings.Join(pot.Eligible, ", "))
+	}
+	h.privmsg(channel, fmt.Sprintf("%s. Current bet: %d.", strings.Join(parts, ". "), table.GetCurrentBet()))
+}
+
+// handleStacks shows every seated player's chips and where they stand in
+// the hand in play.
+func (h *Handler) handleStacks(cmd *Command) {
+	channel := cmd.Channel
+	table := h.games[channel]
+	if table == nil {
+		h.privmsg(channel, "No game running.")
+		return
+	}
+
+	inPlay := h.audits[channel] != nil
+	var leaving map[string]bool
+	if cash := h.cashTables[channel]; cash != nil {
+		leaving = cash.leaving
+	}
+	stacks := []string{}
+	for _, player := range table.GetPlayers() {
+		var status []string
+		switch {
+		case !inPlay:
+		case len(player.Hand) == 0:
+			status = append(status, "sitting out")
+		case player.Folded:
+			status = append(status, "folded")
+		case player.Money == 0:
+			status = append(status, "all in")
+		case h.currentTurn[channel] == player.Nick:
+			status = append(status, "to act")
+		}
+		if inPlay && player.Bet > 0 && !player.Folded {
+			status = append(status, fmt.Sprintf("bet %d", player.Bet))
+		}
+		if leaving[player.Nick] {
+			status = append(status, "leaving")
+		}
+		stack := fmt.Sprintf("%s %d", player.Nick, player.Money)
+		if len(status) > 0 {
+			stack += " (" + strings.Join(status, ", ") + ")"
+		}
+		stacks = append(stacks, stack)
+	}
+	for _, player := range h.lateJoins[channel] {
+		stacks = append(stacks, fmt.Sprintf("%s %d (sitting out until the next hand)", player.Nick, player.Money))
+	}
+	h.privmsg(channel, "Stacks: "+strings.Join(stacks, ", "))
+}
+
+// handleBoard shows the community cards, the street and the pot of the
+// hand in play. It reads the table as a spectator sees it, so anyone can
+// use it to catch up.
+func (h *Handler) handleBoard(cmd *Command) {
+	channel := cmd.Channel
+	state := h.tableState(channel).For("")
+	if state == nil || h.audits[channel] == nil {
+		h.privmsg(channel, "No hand in play.")
+		return
+	}
+	_, draw := h.games[channel].(*modes.FiveCardDraw)
+	switch {
+	case draw:
+		h.privmsg(channel, fmt.Sprintf("Draw has no board. Pot: %d.", state.Pot))
+	case state.Street != "":
+		h.privmsg(channel, fmt.Sprintf("%s%s: [%s]. Pot: %d.", strings.ToUpper(state.Street[:1]), state.Street[1:], strings.Join(state.Board, " "), state.Pot))
+	case len(state.Board) > 0:
+		h.privmsg(channel, fmt.Sprintf("Board: [%s]. Pot: %d.", strings.Join(state.Board, " "), state.Pot))
+	default:
+		h.privmsg(channel, fmt.Sprintf("Preflop, no board yet. Pot: %d.", state.Pot))
+	}
+}
@@ -0,0 +1,62 @@
+package irc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// privateTable is a table started with --private. Only its host, the nicks
+// they invite and players who know the password can sit down.
+type privateTable struct {
+	password string
+	invited  map[string]bool
+}
+
+func newPrivateTable(creator string) (*privateTable, error) {
+	raw := make([]byte, 3)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	return &privateTable{
+		password: hex.EncodeToString(raw),
+		invited:  map[string]bool{creator: true},
+	}, nil
+}
+
+// admitted reports whether nick may sit at channel's table, given the
+// password they joined with. A right password admits them for the rest of
+// the game.
+func (h *Handler) admitted(channel, nick, password string) bool {
+	table := h.private[channel]
+	if table == nil || table.invited[nick] {
+		return true
+	}
+	if password != "" && password == table.password {
+		table.invited[nick] = true
+		return true
+	}
+	return false
+}
+
+// handleInvite lets the host of a private table invite a nick to it.
+func (h *Handler) handleInvite(cmd *Command) {
+	channel := cmd.Channel
+	table := h.private[channel]
+	if table == nil {
+		h.privmsg(channel, "Only private tables need invitations. Anyone can $join this one.")
+		return
+	}
+	if h.finals[channel] != nil {
+		h.notice(cmd.Nick, "Only the qualifiers can play the championship.")
+		return
+	}
+	if len(cmd.Args) != 1 {
+		h.notice(cmd.Nick, "Usage: $invite <nick>")
+		return
+	}
+
+	nick := sanitize(cmd.Args[0])
+	table.invited[nick] = true
+	h.privmsg(channel, fmt.Sprintf("%s is invited to the table. Type $join to sit down, %s.", nick, nick))
+}
@@ -0,0 +1,204 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"poker-bot/db"
+)
+
+const (
+	maxAvatarLength  = 300
+	maxTaglineLength = 80
+)
+
+const profileUsage = "Usage: $setprofile avatar <url> | color <name> | tagline <text> | potodds on|off | streetcards on|off | turnprefix on|off | turnping on|off"
+
+// profileColor is a color players can pick for their nick: its mIRC color
+// code and how the web dashboard draws it.
+type profileColor struct {
+	code string
+	css  string
+}
+
+// profileColors are the mIRC colors that read well on both light and dark
+// backgrounds.
+var profileColors = map[string]profileColor{
+	"blue":      {"02", "#3b5bdb"},
+	"green":     {"03", "#2f9e44"},
+	"red":       {"04", "#e03131"},
+	"brown":     {"05", "#a0522d"},
+	"purple":    {"06", "#9c36b5"},
+	"orange":    {"07", "#f76707"},
+	"yellow":    {"08", "#fab005"},
+	"lime":      {"09", "#74b816"},
+	"teal":      {"10", "#0c8599"},
+	"cyan":      {"11", "#15aabf"},
+	"lightblue": {"12", "#4dabf7"},
+	"pink":      {"13", "#e64980"},
+	"grey":      {"14", "#868e96"},
+}
+
+// profile returns nick's profile, reading it from the database the first
+// time it's needed.
+func (h *Handler) profile(nick string) db.Profile {
+	if profile, ok := h.profiles[nick]; ok {
+		return profile
+	}
+	profile, err := db.GetProfile(nick)
+	if err != nil {
+		log.Printf("Error loading profile for %s: %v", nick, err)
+		return db.Profile{Nick: nick}
+	}
+	h.profiles[nick] = profile
+	return profile
+}
+
+// handleSetProfile sets one field of the player's profile:
+// $setprofile avatar <url> | color <name> | tagline <text> |
+// potodds on|off | streetcards on|off | turnprefix on|off | turnping on|off.
+// Leaving out the value clears the field.
+func (h *Handler) handleSetProfile(cmd *Command) {
+	args := cmd.Args
+	if len(args) == 0 {
+		h.notice(cmd.Nick, profileUsage)
+		return
+	}
+	value := strings.Join(args[1:], " ")
+
+	profile := h.profile(cmd.Nick)
+	switch strings.ToLower(args[0]) {
+	case "avatar":
+		if value != "" {
+			link, err := url.Parse(value)
+			if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" || len(value) > maxAvatarLength {
+				h.notice(cmd.Nick, fmt.Sprintf("Avatars are http or https links of up to %d characters.", maxAvatarLength))
+				return
+			}
+		}
+		profile.Avatar = value
+	case "color":
+		value = strings.ToLower(value)
+		if _, ok := profileColors[value]; value != "" && !ok {
+			h.notice(cmd.Nick, "Colors are "+strings.Join(colorNames(), ", ")+".")
+			return
+		}
+		profile.Color = value
+	case "tagline":
+		value = sanitize(value)
+		if utf8.RuneCountInString(value) > maxTaglineLength {
+			h.notice(cmd.Nick, fmt.Sprintf("Taglines are up to %d characters.", maxTaglineLength))
+			return
+		}
+		profile.Tagline = value
+	case "potodds", "streetcards", "turnprefix", "turnping":
+		var on bool
+		switch strings.ToLower(value) {
+		case "on":
+			on = true
+		case "off", "":
+		default:
+			h.notice(cmd.Nick, fmt.Sprintf("Usage: $setprofile %s on|off", strings.ToLower(args[0])))
+			return
+		}
+		switch strings.ToLower(args[0]) {
+		case "potodds":
+			profile.PotOdds = on
+		case "streetcards":
+			profile.StreetCards = on
+		case "turnprefix":
+			profile.TurnPrefix = on
+		case "turnping":
+			profile.TurnPing = on
+		}
+	default:
+		h.notice(cmd.Nick, profileUsage)
+		return
+	}
+
+	if err := db.SaveProfile(profile); err != nil {
+		log.Printf("Error saving profile for %s: %v", cmd.Nick, err)
+		h.notice(cmd.Nick, "Error saving your profile.")
+		return
+	}
+	h.profiles[cmd.Nick] = profile
+	field := strings.ToLower(args[0])
+	switch {
+	case field == "potodds" && profile.PotOdds:
+		h.notice(cmd.Nick, "Your turn notices now show the amount to call and the pot odds.")
+	case field == "potodds":
+		h.notice(cmd.Nick, "Your turn notices no longer show the pot odds.")
+	case field == "streetcards" && profile.StreetCards:
+		h.notice(cmd.Nick, "You'll get your cards, the board and your best hand privately on every new street.")
+	case field == "streetcards":
+		h.notice(cmd.Nick, "You'll no longer get your cards again on every street.")
+	case field == "turnprefix" && profile.TurnPrefix:
+		h.notice(cmd.Nick, "Your turn announcements now start with your nick.")
+	case field == "turnprefix":
+		h.notice(cmd.Nick, "Your turn announcements no longer start with your nick.")
+	case field == "turnping" && profile.TurnPing:
+		h.notice(cmd.Nick, "You'll get a private message when it's your turn.")
+	case field == "turnping":
+		h.notice(cmd.Nick, "You'll no longer get a private message when it's your turn.")
+	case value == "":
+		h.notice(cmd.Nick, fmt.Sprintf("Your %s is cleared.", field))
+	default:
+		h.notice(cmd.Nick, fmt.Sprintf("Your %s is now %s.", field, value))
+	}
+}
+
+func colorNames() []string {
+	names := make([]string, 0, len(profileColors))
+	for name := range profileColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorize paints the nicks of the players at target's table in their
+// profile colors, when the config turns colors on.
+func (h *Handler) colorize(target, message string) string {
+	table := h.games[target]
+	if !h.config.Colors || table == nil {
+		return message
+	}
+	for _, player := range table.GetPlayers() {
+		if color, ok := profileColors[h.profile(player.Nick).Color]; ok {
+			message = replaceNick(message, player.Nick, "\x03"+color.code+player.Nick+"\x03")
+		}
+	}
+	return message
+}
+
+// replaceNick replaces the occurrences of nick in message that aren't part
+// of a longer nick.
+func replaceNick(message, nick, replacement string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(message, nick)
+		if i < 0 {
+			break
+		}
+		end := i + len(nick)
+		before, _ := utf8.DecodeLastRuneInString(message[:i])
+		after, _ := utf8.DecodeRuneInString(message[end:])
+		b.WriteString(message[:i])
+		if (i > 0 && isNickRune(before)) || (end < len(message) && isNickRune(after)) {
+			b.WriteString(nick)
+		} else {
+			b.WriteString(replacement)
+		}
+		message = message[end:]
+	}
+	b.WriteString(message)
+	return b.String()
+}
+
+func isNickRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_[]\\`^{}|", r)
+}
@@ -0,0 +1,208 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/modes"
+)
+
+// maxRailBet caps a spectator's side bet on a hand, so the rail can't move
+// enough chips to make a bet worth using as a signal.
+const maxRailBet = 100
+
+// railBet is a spectator's bet that a seated player wins the hand.
+type railBet struct {
+	on      string
+	amount  int
+	economy string
+}
+
+// railPool holds the side bets on the hand in play at a table. Bets are
+// taken until the first betting round is over and are only announced when
+// the hand is settled, so nobody at the table can read anything into them.
+type railPool struct {
+	bets   map[string]railBet // spectator -> bet
+	closed bool
+}
+
+// handleRailBet takes a spectator's side bet on who wins the hand in play:
+// $railbet <nick> <amount>.
+func (h *Handler) handleRailBet(cmd *Command) {
+	channel := cmd.Channel
+	if len(cmd.Args) != 2 {
+		h.notice(cmd.Nick, fmt.Sprintf("Usage: $railbet <nick> <amount>, up to %d chips on who wins this hand.", maxRailBet))
+		return
+	}
+	pool := h.railbets[channel]
+	if pool == nil || pool.closed {
+		h.notice(cmd.Nick, "Rail bets are taken from the deal until the first betting round is over.")
+		return
+	}
+	if _, placed := pool.bets[cmd.Nick]; placed {
+		h.notice(cmd.Nick, "You already have a rail bet on this hand.")
+		return
+	}
+	if !h.onRail(channel, cmd.Nick) {
+		h.notice(cmd.Nick, "Rail bets are for spectators, not players at this table or on the same host as one.")
+		return
+	}
+
+	target := h.games[channel].FindPlayer(cmd.Args[0])
+	if target == nil || target.Folded {
+		h.notice(cmd.Nick, fmt.Sprintf("%s isn't in this hand.", sanitize(cmd.Args[0])))
+		return
+	}
+	amount, err := strconv.Atoi(cmd.Args[1])
+	if err != nil || amount <= 0 || amount > maxRailBet {
+		h.notice(cmd.Nick, fmt.Sprintf("Rail bets are between 1 and %d chips.", maxRailBet))
+		return
+	}
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting player %s: %v", cmd.Nick, err)
+		return
+	}
+	if player.Money < amount {
+		h.notice(cmd.Nick, fmt.Sprintf("You only have %d.", player.Money))
+		return
+	}
+	if err := db.RecordTransaction(player.Economy, cmd.Nick, -amount, "rail bet"); err != nil {
+		log.Printf("Error taking rail bet from %s: %v", cmd.Nick, err)
+		h.notice(cmd.Nick, "Error placing your rail bet.")
+		return
+	}
+
+	pool.bets[cmd.Nick] = railBet{on: target.Nick, amount: amount, economy: player.Economy}
+	h.notice(cmd.Nick, fmt.Sprintf("You have %d on %s to win this hand.", amount, target.Nick))
+}
+
+// onRail reports whether nick can bet on the hand at channel: they aren't
+// seated or waiting for a seat there, aren't playing a cash game anywhere,
+// whose stack would be written over the bet, and don't share a host with
+// anyone at the table.
+func (h *Handler) onRail(channel, nick string) bool {
+	for table, game := range h.games {
+		if game.FindPlayer(nick) != nil && (table == channel || h.stackIsBankroll(table)) {
+			return false
+		}
+	}
+	for _, player := range h.lateJoins[channel] {
+		if player.Nick == nick {
+			return false
+		}
+	}
+	for _, waiting := range h.waitlists[channel] {
+		if waiting == nick {
+			return false
+		}
+	}
+	host := h.hosts[nick]
+	for _, player := range h.games[channel].GetPlayers() {
+		if host != "" && h.hosts[player.Nick] == host {
+			return false
+		}
+	}
+	return true
+}
+
+// railBets follows the hand at each table: it opens a pool on the deal and
+// closes it when the first betting round is over. settle settles it.
+func (h *Handler) railBets(event Event) {
+	switch event.Kind {
+	case EventHandStart:
+		h.refundRailBets(event.Channel)
+		h.railbets[event.Channel] = &railPool{bets: make(map[string]railBet)}
+	case EventStreet, EventAllIn:
+		h.closeRailBets(event.Channel)
+	case EventAction:
+		if draw, ok := h.games[event.Channel].(*modes.FiveCardDraw); ok && draw.IsDrawPhase() {
+			h.closeRailBets(event.Channel)
+		}
+	}
+}
+
+func (h *Handler) closeRailBets(channel string) {
+	if pool := h.railbets[channel]; pool != nil {
+		pool.closed = true
+	}
+}
+
+// settleRailBets settles the pool once the hand's awards are known. A bet
+// on anyone who won part of the pot wins, so the backers of every winner
+// of a chopped or side pot are paid: the pool is shared between them in
+// proportion to their bets, the biggest backer taking any odd chips. If
+// nobody backed a winner every bet is returned.
+func (h *Handler) settleRailBets(channel string, awards []game.Award) {
+	pool := h.railbets[channel]
+	if pool == nil {
+		return
+	}
+
+	won := make(map[string]bool, len(awards))
+	var winners []string
+	for _, award := range awards {
+		if !won[award.Nick] {
+			won[award.Nick] = true
+			winners = append(winners, award.Nick)
+		}
+	}
+	total, backed := 0, 0
+	var backers []string
+	for nick, bet := range pool.bets {
+		total += bet.amount
+		if won[bet.on] {
+			backed += bet.amount
+			backers = append(backers, nick)
+		}
+	}
+	if backed == 0 {
+		h.refundRailBets(channel)
+		return
+	}
+	delete(h.railbets, channel)
+	sort.Slice(backers, func(i, j int) bool {
+		if pool.bets[backers[i]].amount != pool.bets[backers[j]].amount {
+			return pool.bets[backers[i]].amount > pool.bets[backers[j]].amount
+		}
+		return backers[i] < backers[j]
+	})
+
+	shares := make(map[string]int, len(backers))
+	paid := 0
+	for _, nick := range backers {
+		shares[nick] = total * pool.bets[nick].amount / backed
+		paid += shares[nick]
+	}
+	shares[backers[0]] += total - paid
+
+	payouts := make([]string, 0, len(backers))
+	for _, nick := range backers {
+		share := shares[nick]
+		payouts = append(payouts, fmt.Sprintf("%s %d", nick, share))
+		if err := db.RecordTransaction(pool.bets[nick].economy, nick, share, "rail bet won"); err != nil {
+			log.Printf("Error paying rail bet to %s: %v", nick, err)
+		}
+	}
+	h.privmsg(channel, fmt.Sprintf("Rail bets: %d in the pool, %d of it on %s. Paid: %s.", total, backed, strings.Join(winners, " and "), strings.Join(payouts, ", ")))
+}
+
+// refundRailBets returns the bets on a hand that won't be settled.
+func (h *Handler) refundRailBets(channel string) {
+	pool := h.railbets[channel]
+	delete(h.railbets, channel)
+	if pool == nil || len(pool.bets) == 0 {
+		return
+	}
+	for nick, bet := range pool.bets {
+		if err := db.RecordTransaction(bet.economy, nick, bet.amount, "rail bet refund"); err != nil {
+			log.Printf("Error refunding rail bet to %s: %v", nick, err)
+		}
+	}
+	h.privmsg(channel, "Rail bets on this hand are refunded.")
+}
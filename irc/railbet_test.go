@@ -0,0 +1,42 @@
+package irc
+
+import (
+	"context"
+	"testing"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+func TestRailBetsPayTheBackersOfEveryWinner(t *testing.T) {
+	h := newTestHandler(t)
+	economy := h.economy("#rail")
+	bets := map[string]railBet{
+		"backsann": {on: "ann", amount: 30, economy: economy},
+		"backsbob": {on: "bob", amount: 10, economy: economy},
+		"backscat": {on: "cat", amount: 60, economy: economy},
+	}
+	before := make(map[string]int)
+	for nick := range bets {
+		player, err := db.GetOrCreatePlayer(context.Background(), economy, nick)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before[nick] = player.Money
+	}
+	h.railbets["#rail"] = &railPool{bets: bets, closed: true}
+
+	h.settleRailBets("#rail", []game.Award{{Nick: "ann", Amount: 500}, {Nick: "bob", Amount: 500}})
+
+	// ann and bob chopped, so their backers share the 100 in the pool.
+	want := map[string]int{"backsann": 75, "backsbob": 25, "backscat": 0}
+	for nick, paid := range want {
+		if got := savedMoney(t, &models.Player{Economy: economy, Nick: nick}) - before[nick]; got != paid {
+			t.Errorf("%s was paid %d, want %d", nick, got, paid)
+		}
+	}
+	if h.railbets["#rail"] != nil {
+		t.Error("the pool is still open after the hand was settled")
+	}
+}
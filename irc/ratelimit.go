@@ -0,0 +1,126 @@
+package irc
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const limiterPruneAge = 10 * time.Minute
+
+// maxCountedCommands caps how many commands the limiter counts throttles
+// for by name. Commands are whatever a user types after a $, so beyond
+// that they're counted together as "other".
+const maxCountedCommands = 64
+
+type limiterKey struct {
+	nick    string
+	channel string
+	command string
+}
+
+// keyFor returns the key for command from nick in channel. IRC compares
+// nicks and channels without regard to case, so neither does the key.
+func keyFor(nick, channel, command string) limiterKey {
+	return limiterKey{nick: strings.ToLower(nick), channel: strings.ToLower(channel), command: command}
+}
+
+// RateLimitStats is a snapshot of the command limiter counters.
+type RateLimitStats struct {
+	Tracked   int
+	Allowed   uint64
+	Throttled uint64
+	ByCommand map[string]uint64
+}
+
+// rateLimiter throttles each command per (nick, channel) pair, so a player
+// can $call straight after a $bet but can't flood either. Entries that
+// have been idle for longer than pruneAge are dropped lazily, at most once
+// per pruneAge, so the map only holds the commands used since about then.
+type rateLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	pruneAge  time.Duration
+	last      map[limiterKey]time.Time
+	lastPrune time.Time
+	allowed   uint64
+	throttled uint64
+	byCommand map[string]uint64
+}
+
+func newRateLimiter(interval, pruneAge time.Duration) *rateLimiter {
+	return &rateLimiter{
+		interval:  interval,
+		pruneAge:  pruneAge,
+		last:      make(map[limiterKey]time.Time),
+		lastPrune: time.Now(),
+		byCommand: make(map[string]uint64),
+	}
+}
+
+// SetInterval changes how often a nick may use a command in a channel.
+func (r *rateLimiter) SetInterval(interval time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interval = interval
+}
+
+func (r *rateLimiter) Allow(nick, channel, command string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastPrune) >= r.pruneAge {
+		r.prune(now)
+	}
+
+	key := keyFor(nick, channel, command)
+	lastTime, exists := r.last[key]
+	if !exists || now.Sub(lastTime) >= r.interval {
+		r.last[key] = now
+		r.allowed++
+		return true
+	}
+
+	r.throttled++
+	if _, counted := r.byCommand[command]; !counted && len(r.byCommand) >= maxCountedCommands {
+		command = "other"
+	}
+	r.byCommand[command]++
+	return false
+}
+
+func (r *rateLimiter) prune(now time.Time) {
+	pruned := 0
+	for key, lastTime := range r.last {
+		if now.Sub(lastTime) >= r.pruneAge {
+			delete(r.last, key)
+			pruned++
+		}
+	}
+	r.lastPrune = now
+	log.Printf("Rate limiter: pruned %d idle entries, tracking %d, %d allowed, %d throttled", pruned, len(r.last), r.allowed, r.throttled)
+}
+
+func (r *rateLimiter) Stats() RateLimitStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byCommand := make(map[string]uint64, len(r.byCommand))
+	for command, count := range r.byCommand {
+		byCommand[command] = count
+	}
+	return RateLimitStats{
+		Tracked:   len(r.last),
+		Allowed:   r.allowed,
+		Throttled: r.throttled,
+		ByCommand: byCommand,
+	}
+}
+
+// RateLimitStats reports how many commands the limiter has let through or
+// throttled since startup.
+func (h *Handler) RateLimitStats() RateLimitStats {
+	return h.limiter.Stats()
+}
@@ -0,0 +1,124 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+	"poker-bot/game"
+)
+
+// trackRecords checks every won pot against the channel's biggest pot and
+// every showdown against its best hand, for the day and of all time, and
+// announces the records that fall. Tournament pots are in tournament chips,
+// so only their hands count.
+func (h *Handler) trackRecords(event Event) {
+	if event.Kind != EventWin && event.Kind != EventShowdown {
+		return
+	}
+	now := time.Now()
+	if h.tournaments[event.Channel] == nil {
+		h.checkRecord(db.Record{
+			Channel: event.Channel,
+			Kind:    db.RecordPot,
+			Day:     now.Format("2006-01-02"),
+			Nick:    event.Nick,
+			Value:   event.Amount,
+			SetAt:   now,
+		})
+	}
+
+	rules, ok := h.games[event.Channel].(game.Showdown)
+	winner := h.games[event.Channel].FindPlayer(event.Nick)
+	if event.Kind != EventShowdown || !ok || winner == nil {
+		return
+	}
+	name, category := rules.DescribeHand(winner)
+	h.checkRecord(db.Record{
+		Channel: event.Channel,
+		Kind:    db.RecordHand,
+		Day:     now.Format("2006-01-02"),
+		Nick:    event.Nick,
+		Value:   category,
+		Hand:    name,
+		SetAt:   now,
+	})
+}
+
+// checkRecord saves r if it beats the day's record. Only beating a record
+// that was already set is announced, so the first hand of the day isn't.
+func (h *Handler) checkRecord(r db.Record) {
+	day, err := db.DayRecord(r.Channel, r.Kind, r.Day)
+	if err != nil {
+		log.Printf("Error getting the %s record at %s: %v", r.Kind, r.Channel, err)
+		return
+	}
+	if day != nil && r.Value <= day.Value {
+		return
+	}
+	allTime, err := db.AllTimeRecord(r.Channel, r.Kind)
+	if err != nil {
+		log.Printf("Error getting the all-time %s record at %s: %v", r.Kind, r.Channel, err)
+		return
+	}
+	if err := db.SaveRecord(r); err != nil {
+		log.Printf("Error saving the %s record at %s: %v", r.Kind, r.Channel, err)
+		return
+	}
+
+	switch {
+	case allTime != nil && r.Value > allTime.Value:
+		h.privmsg(r.Channel, fmt.Sprintf("New all-time record! %s, beating %s from %s.", describeRecord(&r), describeRecord(allTime), allTime.Day))
+	case day != nil:
+		h.privmsg(r.Channel, fmt.Sprintf("New record for the day! %s, beating %s.", describeRecord(&r), describeRecord(day)))
+	}
+}
+
+func describeRecord(r *db.Record) string {
+	if r.Kind == db.RecordHand {
+		return fmt.Sprintf("%s's %s", r.Nick, r.Hand)
+	}
+	return fmt.Sprintf("%s's pot of %d", r.Nick, r.Value)
+}
+
+// handleRecords shows the channel's biggest pot and best hand, today and of
+// all time.
+func (h *Handler) handleRecords(cmd *Command) {
+	channel := cmd.Channel
+	today := time.Now().Format("2006-01-02")
+
+	var lines []string
+	for _, kind := range []string{db.RecordPot, db.RecordHand} {
+		label := "Biggest pot"
+		if kind == db.RecordHand {
+			label = "Best hand"
+		}
+		allTime, err := db.AllTimeRecord(channel, kind)
+		if err != nil {
+			log.Printf("Error getting the all-time %s record at %s: %v", kind, channel, err)
+			h.privmsg(channel, "Error retrieving the records.")
+			return
+		}
+		day, err := db.DayRecord(channel, kind, today)
+		if err != nil {
+			log.Printf("Error getting the %s record at %s: %v", kind, channel, err)
+			h.privmsg(channel, "Error retrieving the records.")
+			return
+		}
+		if allTime == nil {
+			continue
+		}
+		line := fmt.Sprintf("%s: %s on %s", label, describeRecord(allTime), allTime.Day)
+		if day != nil {
+			line += fmt.Sprintf(" (today: %s)", describeRecord(day))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		h.privmsg(channel, "No records at this table yet.")
+		return
+	}
+	h.privmsg(channel, strings.Join(lines, ". ")+".")
+}
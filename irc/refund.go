@@ -0,0 +1,82 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"poker-bot/models"
+)
+
+// refundHand settles the hand in play at channel when its game is ended
+// before the hand is, such as when every player timed out: the pot goes
+// back to the players by the config's force_end_refund policy, each player
+// is saved, and the refunds go to the audit log. Without it the pot would
+// vanish with the table.
+func (h *Handler) refundHand(channel string) {
+	table := h.games[channel]
+	pot := table.GetPot()
+	if h.audits[channel] == nil || pot <= 0 {
+		return
+	}
+	h.pauseTurnClock(channel)
+	h.stopRunOut(channel)
+
+	committed := h.committed(channel)
+	var recipients []*models.Player
+	for _, player := range table.GetPlayers() {
+		if h.config.ForceEndRefund == "live" && (player.Folded || len(player.Hand) == 0) {
+			continue
+		}
+		if committed[player.Nick] > 0 || h.config.ForceEndRefund == "live" {
+			recipients = append(recipients, player)
+		}
+	}
+	if len(recipients) == 0 {
+		log.Printf("Nobody to refund the pot of %d at %s to", pot, channel)
+		return
+	}
+
+	shares := shareByWeight(pot, recipients, committed)
+	refunds := make([]string, 0, len(recipients))
+	for i, player := range recipients {
+		if shares[i] == 0 {
+			continue
+		}
+		player.Money += shares[i]
+		if err := h.savePlayer(channel, player); err != nil {
+			log.Printf("Error refunding %s at %s: %v", player.Nick, channel, err)
+		}
+		h.logAudit(h.nick, "refund", channel, player.Nick, fmt.Sprintf("%d from an unfinished hand", shares[i]))
+		refunds = append(refunds, fmt.Sprintf("%s %d", player.Nick, shares[i]))
+	}
+	h.flushPlayers()
+	table.AddToPot(-pot)
+	delete(h.audits, channel)
+	h.privmsg(channel, "The hand in play is refunded: "+strings.Join(refunds, ", ")+".")
+}
+
+// shareByWeight splits amount between players in proportion to their
+// weights, evenly if none of them has any. The chips left over from
+// rounding go one each to the players in seat order.
+func shareByWeight(amount int, players []*models.Player, weights map[string]int) []int {
+	total := 0
+	for _, player := range players {
+		total += weights[player.Nick]
+	}
+	shares := make([]int, len(players))
+	given := 0
+	for i, player := range players {
+		if total > 0 {
+			shares[i] = amount * weights[player.Nick] / total
+		} else {
+			shares[i] = amount / len(players)
+		}
+		given += shares[i]
+	}
+	for i := 0; given < amount; i = (i + 1) % len(players) {
+		shares[i]++
+		given++
+	}
+	return shares
+}
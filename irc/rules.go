@@ -0,0 +1,41 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+
+	"poker-bot/modes"
+)
+
+// handleHands sends the player the hand rankings by private notice.
+func (h *Handler) handleHands(cmd *Command) {
+	h.notice(cmd.Nick, "Poker hands, best first:")
+	for _, line := range modes.HandRankings() {
+		h.notice(cmd.Nick, line)
+	}
+}
+
+// handleRules sends the player the rules of a variant by private notice:
+// $rules <variant>, or the game at the table without one.
+func (h *Handler) handleRules(cmd *Command) {
+	name := cmd.Text
+	if name == "" {
+		if table := h.games[cmd.Channel]; table != nil {
+			name = table.GetType()
+		}
+	}
+	variant, ok := modes.Lookup(name)
+	if !ok {
+		names := []string{}
+		for _, variant := range modes.Variants() {
+			names = append(names, variant.Name)
+		}
+		h.notice(cmd.Nick, fmt.Sprintf("Usage: $rules <variant>, one of %s.", strings.Join(names, ", ")))
+		return
+	}
+
+	h.notice(cmd.Nick, fmt.Sprintf("%s, %d to %d players:", variant.Title, variant.MinPlayers, variant.MaxPlayers))
+	for _, line := range variant.Rules {
+		h.notice(cmd.Nick, line)
+	}
+}
@@ -0,0 +1,89 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"poker-bot/game"
+)
+
+// runOut deals the rest of the board without further betting once everyone
+// still in the hand but at most one player is all-in. Before the board is
+// run out the hands are turned face up with each player's chance to win,
+// and the odds are updated after every street. Streets are dealt the
+// config's runout delay apart. It reports whether it ran the board out.
+func (h *Handler) runOut(channel string) bool {
+	table := h.games[channel]
+	if _, ok := table.(game.EquityReporter); !ok || !game.AllIn(table) {
+		return false
+	}
+
+	h.privmsg(channel, fmt.Sprintf("All in! %s", h.equities(channel, true)))
+	h.emit(Event{Kind: EventAllIn, Channel: channel})
+
+	// Nobody can act until the hand is over.
+	h.currentTurn[channel] = ""
+	h.pauseTurnClock(channel)
+
+	if h.config.RunoutDelay == 0 {
+		for !table.IsRoundOver() {
+			table.UpdateRiver()
+			h.announceStreet(channel)
+		}
+		h.checkRoundEnd(channel)
+		return true
+	}
+	h.dealRunOut(channel, table)
+	return true
+}
+
+// dealRunOut deals the next street of a runout after the delay, or ends the
+// hand once the board is complete.
+func (h *Handler) dealRunOut(channel string, table game.Game) {
+	if table.IsRoundOver() {
+		delete(h.runouts, channel)
+		h.checkRoundEnd(channel)
+		return
+	}
+
+	h.runouts[channel] = h.afterTable(channel, time.Duration(h.config.RunoutDelay), func() {
+		if h.games[channel] != table || h.runouts[channel] == nil {
+			return
+		}
+		table.UpdateRiver()
+		h.announceStreet(channel)
+		if !table.IsRoundOver() {
+			h.privmsg(channel, h.equities(channel, false))
+		}
+		h.dealRunOut(channel, table)
+	})
+}
+
+// stopRunOut cancels a runout in progress.
+func (h *Handler) stopRunOut(channel string) {
+	if timer, exists := h.runouts[channel]; exists {
+		timer.Stop()
+		delete(h.runouts, channel)
+	}
+}
+
+// equities lists each live player's chance to win, with their hand if
+// withHands is set.
+func (h *Handler) equities(channel string, withHands bool) string {
+	table := h.games[channel]
+	equity := table.(game.EquityReporter).Equity()
+	shown := make([]string, 0, len(equity))
+	for _, player := range table.GetPlayers() {
+		odds, live := equity[player.Nick]
+		if !live {
+			continue
+		}
+		if withHands {
+			shown = append(shown, fmt.Sprintf("%s %v %.1f%%", player.Nick, player.Hand, odds*100))
+		} else {
+			shown = append(shown, fmt.Sprintf("%s %.1f%%", player.Nick, odds*100))
+		}
+	}
+	return strings.Join(shown, ", ")
+}
@@ -0,0 +1,76 @@
+package irc
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineLength is the IRC protocol limit for a full line, CR/LF included.
+	maxLineLength = 512
+	// prefixReserve leaves room for the ":nick!user@host " prefix the server
+	// prepends when relaying our messages to other clients.
+	prefixReserve = 100
+)
+
+// sanitize strips every control character, IRC formatting codes included,
+// from player-provided text before it is interpolated into a message.
+func sanitize(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, text)
+}
+
+// stripUnsafe removes the characters that could break out of a single IRC
+// line (CR, LF, NUL) while keeping the bot's own formatting codes.
+func stripUnsafe(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '\r', '\n', 0:
+			return ' '
+		}
+		return r
+	}, text)
+}
+
+// splitMessage wraps text into chunks that fit in a single line sent to
+// target, breaking at the last space before the limit and never inside a
+// UTF-8 sequence.
+func splitMessage(command, target, text string) []string {
+	limit := maxLineLength - prefixReserve - len(command) - len(target) - len("  :\r\n")
+	text = strings.TrimSpace(stripUnsafe(text))
+
+	chunks := []string{}
+	for len(text) > limit {
+		cut := strings.LastIndex(text[:limit], " ")
+		if cut <= 0 {
+			cut = limit
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+func (h *Handler) privmsg(target, message string) {
+	h.record(target, message)
+	h.logTable(target, message)
+	for _, chunk := range splitMessage("PRIVMSG", target, h.colorize(target, message)) {
+		h.conn.Privmsg(target, chunk)
+	}
+}
+
+func (h *Handler) notice(target, message string) {
+	for _, chunk := range splitMessage("NOTICE", target, message) {
+		h.conn.Notice(target, chunk)
+	}
+}
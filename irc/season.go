@@ -0,0 +1,245 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+	"poker-bot/digest"
+	"poker-bot/models"
+)
+
+// seasonKept is how many of each economy's players have their standing
+// kept when a season ends, and how many $season shows.
+const seasonKept = 10
+
+// RunSeasons starts the first season once seasons are turned on, and ends
+// each one when it has run the config's season_length, starting the next.
+// Like the digest, the config is checked every minute.
+func (h *Handler) RunSeasons() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		var now time.Time
+		select {
+		case <-h.ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		h.checkSeason(now)
+	}
+}
+
+// checkSeason starts or ends a season as of now. The standings are worked
+// out from the hand histories away from the handler's mutex.
+func (h *Handler) checkSeason(now time.Time) {
+	h.mu.Lock()
+	length := time.Duration(h.config.SeasonLength)
+	mode, server := h.economyMode, h.server
+	h.mu.Unlock()
+	if length == 0 {
+		return
+	}
+
+	season, err := db.CurrentSeason()
+	if err != nil {
+		log.Printf("Error getting the current season: %v", err)
+		return
+	}
+	if season == nil {
+		if _, err := db.StartSeason(now); err != nil {
+			log.Printf("Error starting the first season: %v", err)
+		}
+		return
+	}
+	if now.Before(season.StartedAt.Add(length)) {
+		return
+	}
+	standings, err := digest.Standings(season.StartedAt, func(channel string) string {
+		return economyOf(mode, server, channel)
+	})
+	if err != nil {
+		log.Printf("Error getting the standings of season %d: %v", season.Number, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.endSeason(season, now, standings)
+}
+
+// endSeason keeps the final standings of season, pays the bonuses and
+// announces the winners in each channel the bot is in. Only players who
+// finished ahead can place for a trophy.
+func (h *Handler) endSeason(season *db.Season, now time.Time, standings map[string][]digest.Standing) {
+	bonuses := h.config.SeasonBonuses
+	var final []db.SeasonStanding
+	for economy, ranked := range standings {
+		for i, s := range ranked[:min(len(ranked), seasonKept)] {
+			standing := db.SeasonStanding{Economy: economy, Place: i + 1, Nick: s.Nick, Net: s.Net, Hands: s.Hands}
+			if i < len(bonuses) && s.Net > 0 {
+				standing.Trophy = true
+				standing.Bonus = bonuses[i]
+			}
+			final = append(final, standing)
+		}
+	}
+	next, err := db.EndSeason(season, now, final)
+	if err != nil {
+		log.Printf("Error ending season %d: %v", season.Number, err)
+		return
+	}
+	for _, s := range final {
+		if player := h.seatedBankroll(s.Economy, s.Nick); player != nil {
+			player.Money += s.Bonus
+		}
+	}
+
+	for channel := range h.channels {
+		var winners []string
+		for _, s := range final {
+			if s.Economy == h.economy(channel) && s.Trophy {
+				winners = append(winners, fmt.Sprintf("%d. %s %+d (bonus %d)", s.Place, s.Nick, s.Net, s.Bonus))
+			}
+		}
+		message := fmt.Sprintf("Season %d is over.", season.Number)
+		if len(winners) > 0 {
+			message += " Trophies go to " + strings.Join(winners, ", ") + "."
+		}
+		h.privmsg(channel, message+fmt.Sprintf(" Season %d starts now.", next.Number))
+	}
+}
+
+// seatedBankroll returns nick's player at a table in economy where their
+// stack is their bankroll, which is saved over the database's when they
+// leave, seated or waiting to be dealt in, or nil if they aren't at one.
+func (h *Handler) seatedBankroll(economy, nick string) *models.Player {
+	for table := range h.games {
+		if !h.stackIsBankroll(table) || h.economy(table) != economy {
+			continue
+		}
+		if player := h.bankrollPlayer(table, nick); player != nil {
+			return player
+		}
+	}
+	return nil
+}
+
+// handleSeason shows the season in play and its leaders so far, or with a
+// number, how that season finished in the channel's economy.
+func (h *Handler) handleSeason(cmd *Command) {
+	if len(cmd.Args) > 1 {
+		h.notice(cmd.Nick, "Usage: $season [number]")
+		return
+	}
+	economy := h.economy(cmd.Channel)
+	if len(cmd.Args) == 1 {
+		number, err := strconv.Atoi(strings.TrimPrefix(cmd.Args[0], "#"))
+		if err != nil || number < 1 {
+			h.notice(cmd.Nick, "Usage: $season [number]")
+			return
+		}
+		h.privmsg(cmd.Channel, describeSeasonEnd(number, economy))
+		return
+	}
+	if h.config.SeasonLength == 0 {
+		h.privmsg(cmd.Channel, "Seasons are turned off.")
+		return
+	}
+	go h.seasonLeaders(cmd.Channel, time.Duration(h.config.SeasonLength), h.economyMode, h.server)
+}
+
+// seasonLeaders reads the hand histories away from the handler's mutex,
+// then takes it to answer in the channel.
+func (h *Handler) seasonLeaders(channel string, length time.Duration, mode, server string) {
+	message := describeSeasonLeaders(length, economyOf(mode, server, channel), func(channel string) string {
+		return economyOf(mode, server, channel)
+	})
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.privmsg(channel, message)
+}
+
+func describeSeasonLeaders(length time.Duration, economy string, economyOf func(string) string) string {
+	season, err := db.CurrentSeason()
+	if err != nil {
+		log.Printf("Error getting the current season: %v", err)
+		return "Error retrieving the season."
+	}
+	if season == nil {
+		return "The first season hasn't started yet."
+	}
+	standings, err := digest.Standings(season.StartedAt, economyOf)
+	if err != nil {
+		log.Printf("Error getting the standings of season %d: %v", season.Number, err)
+		return "Error retrieving the season."
+	}
+	message := fmt.Sprintf("Season %d ends %s.", season.Number, season.StartedAt.Add(length).Format("2006-01-02 15:04"))
+	ranked := standings[economy]
+	if len(ranked) == 0 {
+		return message + " No hands played yet."
+	}
+	leaders := make([]string, 0, seasonKept)
+	for i, s := range ranked[:min(len(ranked), seasonKept)] {
+		leaders = append(leaders, fmt.Sprintf("%d. %s %+d", i+1, s.Nick, s.Net))
+	}
+	return message + " Leaders: " + strings.Join(leaders, ", ") + "."
+}
+
+func describeSeasonEnd(number int, economy string) string {
+	season, err := db.GetSeason(number)
+	if err != nil {
+		log.Printf("Error getting season %d: %v", number, err)
+		return "Error retrieving the season."
+	}
+	if season == nil {
+		return fmt.Sprintf("There's no season %d.", number)
+	}
+	if season.EndedAt.IsZero() {
+		return fmt.Sprintf("Season %d is still in play. $season shows the leaders.", number)
+	}
+	standings, err := db.SeasonStandings(number, economy)
+	if err != nil {
+		log.Printf("Error getting the standings of season %d: %v", number, err)
+		return "Error retrieving the season."
+	}
+	dates := fmt.Sprintf("Season %d (%s to %s)", number, season.StartedAt.Format("2006-01-02"), season.EndedAt.Format("2006-01-02"))
+	if len(standings) == 0 {
+		return dates + ": no hands were played here."
+	}
+	entries := make([]string, 0, len(standings))
+	for _, s := range standings {
+		entry := fmt.Sprintf("%d. %s %+d in %d hands", s.Place, s.Nick, s.Net, s.Hands)
+		if s.Trophy {
+			entry += " (trophy)"
+		}
+		entries = append(entries, entry)
+	}
+	return dates + ": " + strings.Join(entries, ", ") + "."
+}
+
+// handleTrophies lists the seasons a player placed in for a trophy.
+func (h *Handler) handleTrophies(cmd *Command) {
+	nick := cmd.Nick
+	if len(cmd.Args) > 0 {
+		nick = sanitize(cmd.Args[0])
+	}
+	trophies, err := db.Trophies(h.economy(cmd.Channel), nick)
+	if err != nil {
+		log.Printf("Error getting %s's trophies: %v", nick, err)
+		h.privmsg(cmd.Channel, "Error retrieving the trophies.")
+		return
+	}
+	if len(trophies) == 0 {
+		h.privmsg(cmd.Channel, fmt.Sprintf("%s hasn't won a season trophy yet.", nick))
+		return
+	}
+	entries := make([]string, 0, len(trophies))
+	for _, t := range trophies {
+		entries = append(entries, fmt.Sprintf("season %d, place %d", t.Season, t.Place))
+	}
+	h.privmsg(cmd.Channel, fmt.Sprintf("%s's trophies: %s.", nick, strings.Join(entries, "; ")))
+}
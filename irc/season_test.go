@@ -0,0 +1,61 @@
+package irc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"poker-bot/db"
+	"poker-bot/digest"
+	"poker-bot/models"
+)
+
+func TestSeasonBonusesReachPlayersAtTheTables(t *testing.T) {
+	h := newTestHandler(t)
+	h.config.SeasonBonuses = []int{1000, 500, 250}
+	table := startHand(t, h, "#season", "season1", "season2")
+	seated := table.FindPlayer("season1")
+	economy := seated.Economy
+	waiting, err := db.GetOrCreatePlayer(context.Background(), economy, "season3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	absent, err := db.GetOrCreatePlayer(context.Background(), economy, "season4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lateJoins["#season"] = append(h.lateJoins["#season"], waiting)
+	stack, bankroll, waitingMoney := seated.Money, savedMoney(t, seated), waiting.Money
+	loser := table.FindPlayer("season2")
+	loserBankroll := savedMoney(t, loser)
+
+	season, err := db.CurrentSeason()
+	if err == nil && season == nil {
+		season, err = db.StartSeason(time.Now().Add(-time.Hour))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.endSeason(season, time.Now(), map[string][]digest.Standing{economy: {
+		{Nick: "season1", Net: 300, Hands: 10},
+		{Nick: "season3", Net: 200, Hands: 10},
+		{Nick: "season4", Net: 100, Hands: 10},
+		{Nick: "season2", Net: -600, Hands: 10},
+	}})
+
+	if seated.Money != stack+1000 || savedMoney(t, seated) != bankroll+1000 {
+		t.Errorf("season1's stack is %d and bankroll %d, want %d and %d", seated.Money, savedMoney(t, seated), stack+1000, bankroll+1000)
+	}
+	if waiting.Money != waitingMoney+500 {
+		t.Errorf("season3, waiting for a seat, has %d, want %d", waiting.Money, waitingMoney+500)
+	}
+	if got := savedMoney(t, &models.Player{Economy: economy, Nick: "season4"}); got != absent.Money+250 {
+		t.Errorf("season4's bankroll is %d, want %d", got, absent.Money+250)
+	}
+	if got := savedMoney(t, loser); got != loserBankroll {
+		t.Errorf("season2, who lost, has a bankroll of %d, want %d", got, loserBankroll)
+	}
+}
@@ -0,0 +1,199 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/game"
+)
+
+const (
+	// seriesHandPoints is what winning a hand anywhere earns in the series
+	// running.
+	seriesHandPoints = 1
+	// seriesPlacePoints is what finishing a tournament earns for each
+	// player finished ahead of, and for playing: last place gets it once.
+	seriesPlacePoints = 10
+	// maxQualifiers is the biggest championship field a series can seed.
+	maxQualifiers = 10
+)
+
+// seriesPoints awards the series points for each hand won, at every table
+// but the championship's.
+func (h *Handler) seriesPoints(event Event) {
+	if event.Kind != EventWin && event.Kind != EventShowdown {
+		return
+	}
+	if h.finals[event.Channel] != nil {
+		return
+	}
+	if err := db.AwardSeriesPoints(event.Nick, seriesHandPoints); err != nil {
+		log.Printf("Error awarding series points to %s: %v", event.Nick, err)
+	}
+}
+
+// finishSeriesTournament runs once a tournament's standings are known. A
+// championship crowns its winner and ends the series; any other tournament
+// awards its finishers points by place.
+func (h *Handler) finishSeriesTournament(channel string, standings []game.Standing) {
+	if series := h.finals[channel]; series != nil {
+		delete(h.finals, channel)
+		champion := ""
+		for _, standing := range standings {
+			if standing.Place == 1 {
+				champion = standing.Nick
+			}
+		}
+		if err := db.EndSeries(series.ID, champion); err != nil {
+			log.Printf("Error ending the %s series: %v", series.Name, err)
+			return
+		}
+		h.logAudit(h.nick, "series end", channel, champion, series.Name)
+		h.privmsg(channel, fmt.Sprintf("%s is the %s series champion!", champion, series.Name))
+		return
+	}
+	for _, standing := range standings {
+		points := (len(standings) - standing.Place + 1) * seriesPlacePoints
+		if err := db.AwardSeriesPoints(standing.Nick, points); err != nil {
+			log.Printf("Error awarding series points to %s: %v", standing.Nick, err)
+		}
+	}
+}
+
+// championshipTable seats the championship of the series running: a
+// private table only its top qualifiers, seeded by points, are invited to.
+// It returns the series, the table and the seeds to announce, or tells the
+// admin why not and returns a nil series if there's none to play.
+func (h *Handler) championshipTable(cmd *Command) (*db.Series, *privateTable, string) {
+	if !h.isAdmin(cmd) {
+		h.notice(cmd.Nick, "Only a bot admin can start a series championship.")
+		return nil, nil, ""
+	}
+	series, err := db.CurrentSeries()
+	if err != nil {
+		log.Printf("Error getting the current series: %v", err)
+		h.notice(cmd.Nick, "Error retrieving the series.")
+		return nil, nil, ""
+	}
+	if series == nil {
+		h.notice(cmd.Nick, "No series is running.")
+		return nil, nil, ""
+	}
+	for table, running := range h.finals {
+		if running.ID == series.ID {
+			h.notice(cmd.Nick, fmt.Sprintf("The %s championship is already at %s.", series.Name, table))
+			return nil, nil, ""
+		}
+	}
+	seeds, err := db.SeriesLeaders(series.ID, series.Qualifiers)
+	if err != nil {
+		log.Printf("Error getting the %s series leaders: %v", series.Name, err)
+		h.notice(cmd.Nick, "Error retrieving the series.")
+		return nil, nil, ""
+	}
+	if len(seeds) < 2 {
+		h.notice(cmd.Nick, fmt.Sprintf("The %s series needs at least two players with points for a championship.", series.Name))
+		return nil, nil, ""
+	}
+
+	table := &privateTable{invited: make(map[string]bool)}
+	field := make([]string, 0, len(seeds))
+	for i, seed := range seeds {
+		table.invited[seed.Nick] = true
+		field = append(field, fmt.Sprintf("%d. %s (%d)", i+1, seed.Nick, seed.Points))
+	}
+	return series, table, "Seeds: " + strings.Join(field, ", ") + "."
+}
+
+// handleSeries shows the series running and who would qualify for its
+// championship.
+func (h *Handler) handleSeries(cmd *Command) {
+	series, err := db.CurrentSeries()
+	if err != nil {
+		log.Printf("Error getting the current series: %v", err)
+		h.privmsg(cmd.Channel, "Error retrieving the series.")
+		return
+	}
+	if series == nil {
+		h.privmsg(cmd.Channel, "No series is running.")
+		return
+	}
+	leaders, err := db.SeriesLeaders(series.ID, series.Qualifiers)
+	if err != nil {
+		log.Printf("Error getting the %s series leaders: %v", series.Name, err)
+		h.privmsg(cmd.Channel, "Error retrieving the series.")
+		return
+	}
+	message := fmt.Sprintf("The %s series, since %s: the top %d qualify for the championship.", series.Name, series.StartedAt.Format("2006-01-02"), series.Qualifiers)
+	if len(leaders) == 0 {
+		h.privmsg(cmd.Channel, message+" Nobody has any points yet.")
+		return
+	}
+	entries := make([]string, 0, len(leaders))
+	for i, leader := range leaders {
+		entries = append(entries, fmt.Sprintf("%d. %s %d", i+1, leader.Nick, leader.Points))
+	}
+	h.privmsg(cmd.Channel, message+" "+strings.Join(entries, ", ")+".")
+}
+
+// adminSeries starts or ends a series: $admin series start <qualifiers>
+// <name> | end. Ending it this way crowns no champion.
+func (h *Handler) adminSeries(cmd *Command, args []string) {
+	usage := fmt.Sprintf("Usage: $admin series start <qualifiers, 2 to %d> <name> | end", maxQualifiers)
+	if len(args) < 2 {
+		h.notice(cmd.Nick, usage)
+		return
+	}
+	series, err := db.CurrentSeries()
+	if err != nil {
+		log.Printf("Error getting the current series: %v", err)
+		h.notice(cmd.Nick, "Error retrieving the series.")
+		return
+	}
+	switch strings.ToLower(args[1]) {
+	case "start":
+		if len(args) < 4 {
+			h.notice(cmd.Nick, usage)
+			return
+		}
+		qualifiers, err := strconv.Atoi(args[2])
+		if err != nil || qualifiers < 2 || qualifiers > maxQualifiers {
+			h.notice(cmd.Nick, usage)
+			return
+		}
+		if series != nil {
+			h.notice(cmd.Nick, fmt.Sprintf("The %s series is still running. $admin series end it first.", series.Name))
+			return
+		}
+		name := sanitize(strings.Join(args[3:], " "))
+		if _, err := db.StartSeries(name, qualifiers); err != nil {
+			log.Printf("Error starting the %s series: %v", name, err)
+			h.notice(cmd.Nick, "Error starting the series.")
+			return
+		}
+		h.logAudit(cmd.Source, "series start", "", "", name)
+		h.notice(cmd.Nick, fmt.Sprintf("The %s series has started. Hands and tournaments at every table earn points, and the top %d qualify for the championship.", name, qualifiers))
+	case "end":
+		if series == nil {
+			h.notice(cmd.Nick, "No series is running.")
+			return
+		}
+		for table, running := range h.finals {
+			if running.ID == series.ID {
+				delete(h.finals, table)
+			}
+		}
+		if err := db.EndSeries(series.ID, ""); err != nil {
+			log.Printf("Error ending the %s series: %v", series.Name, err)
+			h.notice(cmd.Nick, "Error ending the series.")
+			return
+		}
+		h.logAudit(cmd.Source, "series end", "", "", series.Name)
+		h.notice(cmd.Nick, fmt.Sprintf("The %s series is over, with no champion.", series.Name))
+	default:
+		h.notice(cmd.Nick, usage)
+	}
+}
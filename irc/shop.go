@@ -0,0 +1,158 @@
+package irc
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// maxItems is how many of one item a player can hold.
+const maxItems = 3
+
+// shopItem is something the $shop sells for chips from the bankroll. Each
+// is used up by the hook it's for: a bodyguard by handleFailedCheat, a
+// lucky charm by useLuckyCharms.
+type shopItem struct {
+	name  string
+	price int
+	about string
+}
+
+var shopItems = []shopItem{
+	{db.ItemBodyguard, 250, "takes the penalty the next time you're caught cheating"},
+	{db.ItemCharm, 150, "swaps one of your cards for the top of the deck at a showdown, if that improves your hand"},
+}
+
+// handleShop privately lists what the shop sells, and what the player
+// already holds.
+func (h *Handler) handleShop(cmd *Command) {
+	listing := make([]string, 0, len(shopItems))
+	for _, item := range shopItems {
+		listing = append(listing, fmt.Sprintf("%s %d: %s", item.name, item.price, item.about))
+	}
+	h.notice(cmd.Nick, "Shop: "+strings.Join(listing, "; ")+". $buy <item> to buy one.")
+
+	items, err := db.Items(h.economy(cmd.Channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting %s's items: %v", cmd.Nick, err)
+		return
+	}
+	var held []string
+	for _, item := range shopItems {
+		if n := items[item.name]; n > 0 {
+			held = append(held, fmt.Sprintf("%s x%d", item.name, n))
+		}
+	}
+	if len(held) > 0 {
+		h.notice(cmd.Nick, "You have: "+strings.Join(held, ", ")+".")
+	}
+}
+
+// handleBuy sells the player an item from the shop, paid from their
+// bankroll to the house. Like side games, it's closed to players seated
+// where their stack is their bankroll, which would be written back over
+// the price.
+func (h *Handler) handleBuy(cmd *Command) {
+	if len(cmd.Args) != 1 {
+		h.notice(cmd.Nick, "Usage: $buy <item>. $shop lists them.")
+		return
+	}
+	var item *shopItem
+	for i := range shopItems {
+		if strings.EqualFold(shopItems[i].name, cmd.Args[0]) {
+			item = &shopItems[i]
+		}
+	}
+	if item == nil {
+		h.notice(cmd.Nick, fmt.Sprintf("The shop doesn't sell %s. $shop lists what it does.", sanitize(cmd.Args[0])))
+		return
+	}
+	if h.bankrollTable(cmd.Nick) != "" {
+		h.notice(cmd.Nick, "The shop is for between games. You're seated at a poker table.")
+		return
+	}
+
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(cmd.Channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting player %s: %v", cmd.Nick, err)
+		return
+	}
+	items, err := db.Items(player.Economy, cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting %s's items: %v", cmd.Nick, err)
+		h.notice(cmd.Nick, "Error buying the item.")
+		return
+	}
+	if items[item.name] >= maxItems {
+		h.notice(cmd.Nick, fmt.Sprintf("You can't carry more than %d of those.", maxItems))
+		return
+	}
+	err = db.BuyItem(player.Economy, cmd.Nick, item.name, item.price)
+	if errors.Is(err, db.ErrNotEnoughMoney) {
+		h.notice(cmd.Nick, fmt.Sprintf("A %s costs %d and you only have %d.", item.name, item.price, player.Money))
+		return
+	}
+	if err != nil {
+		log.Printf("Error selling %s a %s: %v", cmd.Nick, item.name, err)
+		h.notice(cmd.Nick, "Error buying the item.")
+		return
+	}
+	h.notice(cmd.Nick, fmt.Sprintf("You bought a %s for %d. It %s.", item.name, item.price, item.about))
+}
+
+// useItem uses up one of player's item, if they have one, and reports
+// whether they did.
+func (h *Handler) useItem(player *models.Player, item string) bool {
+	used, err := db.UseItem(player.Economy, player.Nick, item)
+	if err != nil {
+		log.Printf("Error using %s's %s: %v", player.Nick, item, err)
+		return false
+	}
+	return used
+}
+
+// useLuckyCharms runs before the hands at channel are shown down. Each live
+// player with a lucky charm has it swap the card that most improves their
+// hand for the top card of the deck, if any swap improves it at all; if
+// none does, they keep the charm. That looks at the next card without
+// using the charm up, which is intended: the betting is over and the deck
+// is shuffled before the next hand, so there's nothing to gain from it.
+// Only the player learns what it swapped. Charms don't work at verified
+// tables, where drawing from the deck would break the deal the revealed
+// seed reproduces.
+func (h *Handler) useLuckyCharms(channel string) {
+	table := h.games[channel]
+	showdown, ok := table.(game.Showdown)
+	rerolls, canReroll := table.(game.Rerolls)
+	if !ok || !canReroll || h.shuffles[channel] != nil {
+		return
+	}
+	for _, player := range table.GetPlayers() {
+		deck := table.GetDeck()
+		if player.Folded || len(player.Hand) == 0 || len(deck) == 0 {
+			continue
+		}
+		swap, best := -1, *player
+		for i := range player.Hand {
+			trial := *player
+			trial.Hand = append([]models.Card{}, player.Hand...)
+			trial.Hand[i] = deck[0]
+			if showdown.CompareHands(&trial, &best) > 0 {
+				swap, best = i, trial
+			}
+		}
+		if swap < 0 || !h.useItem(player, db.ItemCharm) {
+			continue
+		}
+		old := player.Hand[swap]
+		card, _ := rerolls.Reroll(player, swap)
+		h.recordf(channel, "%s's lucky charm swaps %v for %v", player.Nick, old, card)
+		h.privmsg(channel, fmt.Sprintf("%s's lucky charm glows.", player.Nick))
+		h.notice(player.Nick, fmt.Sprintf("Your lucky charm swapped %s for %s.", h.cardText([]models.Card{old}), h.cardText([]models.Card{card})))
+	}
+}
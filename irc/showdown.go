@@ -0,0 +1,201 @@
+package irc
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// muckTimeout is how long players with a losing hand at showdown have to
+// $show it before it is mucked.
+const muckTimeout = 10 * time.Second
+
+// showWindow is how long a player who wins without a showdown can still
+// $show their cards.
+const showWindow = 20 * time.Second
+
+// showdown is a table's showdown state: the last player to bet or raise on
+// the current street, who shows first, and after the pot is awarded the
+// losing hands that can still be shown or mucked. A player who won without
+// a showdown keeps their hand until showUntil, in case they want to show it.
+type showdown struct {
+	aggressor string
+	losers    map[string][]models.Card
+	timer     *time.Timer
+	winner    string
+	winning   []models.Card
+	showUntil time.Time
+}
+
+func (h *Handler) tableShowdown(channel string) *showdown {
+	s := h.showdowns[channel]
+	if s == nil {
+		s = &showdown{}
+		h.showdowns[channel] = s
+	}
+	return s
+}
+
+// noteAggressor records a bet or raise. It is cleared on every new street.
+func (h *Handler) noteAggressor(channel, nick string) {
+	h.tableShowdown(channel).aggressor = nick
+}
+
+func (h *Handler) clearAggressor(channel string) {
+	h.tableShowdown(channel).aggressor = ""
+}
+
+// showHands runs the showdown. The last aggressor shows first, or the first
+// player left of the button if the last street was checked through, and
+// the rest follow in seat order. A hand that beats or ties every hand shown
+// before it is turned face up, as is every hand that won some of the pot,
+// such as a side pot; the others are losing hands, which their owners may
+// $show or $muck.
+func (h *Handler) showHands(channel string, awards []game.Award) {
+	table := h.games[channel]
+	rules, ok := table.(game.Showdown)
+	if !ok {
+		return
+	}
+
+	players := table.GetPlayers()
+	first := 0
+	if buttoned, ok := table.(game.Buttoned); ok {
+		first = buttoned.Button() + 1
+	}
+	s := h.tableShowdown(channel)
+	for i, player := range players {
+		if player.Nick == s.aggressor && !player.Folded {
+			first = i
+		}
+	}
+
+	won := make(map[string]bool, len(awards))
+	for _, award := range awards {
+		won[award.Nick] = true
+	}
+	var best *models.Player
+	s.losers = make(map[string][]models.Card)
+	for i := range players {
+		player := players[(first+i)%len(players)]
+		if player.Folded {
+			continue
+		}
+		beaten := best != nil && rules.CompareHands(player, best) < 0
+		if beaten && !won[player.Nick] {
+			s.losers[player.Nick] = append([]models.Card{}, player.Hand...)
+			continue
+		}
+		if !beaten {
+			best = player
+		}
+		name, _ := rules.DescribeHand(player)
+		h.privmsg(channel, fmt.Sprintf("%s shows %v (%s)", player.Nick, player.Hand, name))
+	}
+	h.checkSlowRoll(channel, table.FindPlayer(awards[0].Nick))
+}
+
+// holdForMucks gives the losing hands at showdown muckTimeout to be shown
+// before the next hand is dealt. It reports whether the table is waiting.
+func (h *Handler) holdForMucks(channel string) bool {
+	s := h.showdowns[channel]
+	if s == nil || len(s.losers) == 0 {
+		return false
+	}
+
+	h.privmsg(channel, fmt.Sprintf("Losing hands can $show or $muck in the next %s.", muckTimeout))
+	table := h.games[channel]
+	s.timer = h.afterTable(channel, muckTimeout, func() {
+		if h.games[channel] != table || h.showdowns[channel] != s {
+			return
+		}
+		h.finishShowdown(channel)
+	})
+	return true
+}
+
+// finishShowdown mucks the losing hands nobody chose to show, recording
+// them in the hand history, and deals the next hand.
+func (h *Handler) finishShowdown(channel string) {
+	s := h.showdowns[channel]
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	for nick, hand := range s.losers {
+		h.recordf(channel, "%s mucks %v", nick, hand)
+	}
+	delete(h.showdowns, channel)
+	h.closeHistory(channel)
+	h.startRound(channel)
+}
+
+// keepWinningHand holds on to the hand of a player who won uncontested so
+// that they can $show it for the next showWindow.
+func (h *Handler) keepWinningHand(channel string, winner *models.Player) {
+	s := h.tableShowdown(channel)
+	s.winner = winner.Nick
+	s.winning = append([]models.Card{}, winner.Hand...)
+	s.showUntil = time.Now().Add(showWindow)
+	h.notice(winner.Nick, fmt.Sprintf("You can $show your hand, or $show <positions> for some of it, in the next %s.", showWindow))
+}
+
+func (h *Handler) handleShow(cmd *Command) {
+	channel := cmd.Channel
+	s := h.showdowns[channel]
+	if s == nil {
+		return
+	}
+	if s.winner == cmd.Nick && time.Now().Before(s.showUntil) {
+		h.showWinningHand(cmd, s)
+		return
+	}
+	if s.losers[cmd.Nick] == nil {
+		return
+	}
+
+	h.privmsg(channel, fmt.Sprintf("%s shows %v", cmd.Nick, s.losers[cmd.Nick]))
+	delete(s.losers, cmd.Nick)
+	if len(s.losers) == 0 {
+		h.finishShowdown(channel)
+	}
+}
+
+// showWinningHand shows all of an uncontested winner's hand, or the cards at
+// the 1-based positions they list.
+func (h *Handler) showWinningHand(cmd *Command, s *showdown) {
+	channel := cmd.Channel
+	shown := s.winning
+	if positions := cmd.Args; len(positions) > 0 {
+		shown = nil
+		for _, field := range positions {
+			position, err := strconv.Atoi(field)
+			if err != nil || position < 1 || position > len(s.winning) {
+				h.privmsg(channel, fmt.Sprintf("Usage: $show [positions], e.g. $show 1 to show your first card of %d", len(s.winning)))
+				return
+			}
+			shown = append(shown, s.winning[position-1])
+		}
+	}
+
+	h.privmsg(channel, fmt.Sprintf("%s shows %v", cmd.Nick, shown))
+	s.winner = ""
+	s.winning = nil
+}
+
+func (h *Handler) handleMuck(cmd *Command) {
+	channel := cmd.Channel
+	s := h.showdowns[channel]
+	if s == nil || s.losers[cmd.Nick] == nil {
+		return
+	}
+
+	h.recordf(channel, "%s mucks %v", cmd.Nick, s.losers[cmd.Nick])
+	delete(s.losers, cmd.Nick)
+	h.privmsg(channel, fmt.Sprintf("%s mucks", cmd.Nick))
+	if len(s.losers) == 0 {
+		h.finishShowdown(channel)
+	}
+}
@@ -0,0 +1,215 @@
+package irc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"poker-bot/game"
+)
+
+// entropyTimeout is how long players have to send their commitments, and
+// then their entropy.
+const entropyTimeout = 30 * time.Second
+
+// verifiedShuffle runs the commit-reveal shuffle of a table started with
+// --verified. Before each hand the players first send the SHA-256 of some
+// secret text, which is published, and only then the text itself, which is
+// checked against it. The deck is shuffled from the combined entropy,
+// which is revealed once the hand is over so every player can recompute
+// the deck.
+//
+// Every commitment has to be revealed. If one isn't, the deal is void and
+// the shuffle starts again without whoever held theirs back, so nobody can
+// choose between the deck with their entropy and the deck without it, and
+// the void names them, so the bot can't leave out entropy it doesn't like
+// without the player seeing it. What this guarantees is that the deck was
+// fixed before the deal, by a seed nobody could steer as long as one
+// committed player's entropy was secret. It doesn't hide the deck from the
+// bot, which holds all the entropy before the deal, and a hand nobody
+// commits to is shuffled normally.
+type verifiedShuffle struct {
+	commitments map[string]string
+	entropy     map[string]string
+	withheld    map[string]bool // held back their entropy, so out of this hand's shuffle
+	collecting  bool            // taking commitments
+	revealing   bool            // taking the entropy committed to
+	timer       *time.Timer
+}
+
+func (h *Handler) collectEntropy(channel string, shuffle *verifiedShuffle) {
+	shuffle.commitments = make(map[string]string)
+	shuffle.entropy = make(map[string]string)
+	shuffle.collecting = true
+	shuffle.timer = h.afterTable(channel, entropyTimeout, func() {
+		h.finishCommitments(channel)
+	})
+
+	h.privmsg(channel, fmt.Sprintf("Verified shuffle: PM me $commit <SHA-256 hex of some random text> within %d seconds. Once the commitments are published you'll send the text itself with $entropy.", int(entropyTimeout.Seconds())))
+}
+
+// verifiedTable returns the verified table where nick is seated and the
+// shuffle is in the phase wanted, or "".
+func (h *Handler) verifiedTable(nick string, wanted func(*verifiedShuffle) bool) string {
+	for channel, shuffle := range h.shuffles {
+		if wanted(shuffle) && h.games[channel].FindPlayer(nick) != nil {
+			return channel
+		}
+	}
+	return ""
+}
+
+func (h *Handler) handleCommit(cmd *Command) {
+	if strings.HasPrefix(cmd.Channel, "#") {
+		h.notice(cmd.Nick, "Send $commit to me by private message.")
+		return
+	}
+	commitment := strings.ToLower(cmd.Arg(0))
+	if decoded, err := hex.DecodeString(commitment); err != nil || len(decoded) != sha256.Size {
+		h.notice(cmd.Nick, "Usage: $commit <SHA-256 of your entropy, in hex>")
+		return
+	}
+
+	channel := h.verifiedTable(cmd.Nick, func(s *verifiedShuffle) bool { return s.collecting })
+	if channel == "" {
+		h.notice(cmd.Nick, "No verified table is waiting for your commitment.")
+		return
+	}
+	shuffle := h.shuffles[channel]
+	if shuffle.withheld[cmd.Nick] {
+		h.notice(cmd.Nick, fmt.Sprintf("You held back your entropy, so you're left out of this hand's shuffle at %s.", channel))
+		return
+	}
+	shuffle.commitments[cmd.Nick] = commitment
+	h.notice(cmd.Nick, fmt.Sprintf("Commitment accepted for %s. Send $entropy <your text> once the commitments are published.", channel))
+	if len(shuffle.commitments)+len(shuffle.withheld) == len(h.games[channel].GetPlayers()) {
+		shuffle.timer.Stop()
+		h.finishCommitments(channel)
+	}
+}
+
+// finishCommitments publishes the commitments and asks for the entropy, or
+// shuffles normally if nobody committed.
+func (h *Handler) finishCommitments(channel string) {
+	shuffle := h.shuffles[channel]
+	if shuffle == nil || !shuffle.collecting {
+		return
+	}
+	shuffle.collecting = false
+
+	if len(shuffle.commitments) == 0 {
+		shuffle.withheld = nil
+		h.privmsg(channel, "Nobody committed to any entropy. Shuffling this hand normally.")
+		h.games[channel].SetDeckSeed(nil)
+		h.dealRound(channel)
+		return
+	}
+
+	commitments := make([]string, 0, len(shuffle.commitments))
+	for _, nick := range sortedKeys(shuffle.commitments) {
+		commitments = append(commitments, fmt.Sprintf("%s=%s", nick, shuffle.commitments[nick]))
+	}
+	h.privmsg(channel, fmt.Sprintf("Commitments: %s. PM me $entropy <your text> within %d seconds.", strings.Join(commitments, ", "), int(entropyTimeout.Seconds())))
+	shuffle.revealing = true
+	shuffle.timer = h.afterTable(channel, entropyTimeout, func() {
+		h.finishEntropy(channel)
+	})
+}
+
+func (h *Handler) handleEntropy(cmd *Command) {
+	if strings.HasPrefix(cmd.Channel, "#") {
+		h.notice(cmd.Nick, "Send $entropy to me by private message so nobody else sees it.")
+		return
+	}
+
+	if cmd.Text == "" {
+		h.notice(cmd.Nick, "Usage: $entropy <random text>")
+		return
+	}
+
+	channel := h.verifiedTable(cmd.Nick, func(s *verifiedShuffle) bool { return s.revealing })
+	if channel == "" {
+		h.notice(cmd.Nick, "No verified table is waiting for your entropy.")
+		return
+	}
+	shuffle := h.shuffles[channel]
+	commitment, ok := shuffle.commitments[cmd.Nick]
+	if !ok {
+		h.notice(cmd.Nick, fmt.Sprintf("You didn't commit to any entropy for this hand at %s.", channel))
+		return
+	}
+	if game.Commitment(cmd.Text) != commitment || sanitize(cmd.Text) != cmd.Text {
+		h.notice(cmd.Nick, fmt.Sprintf("That doesn't match your commitment at %s. Send exactly the text you hashed.", channel))
+		return
+	}
+
+	shuffle.entropy[cmd.Nick] = cmd.Text
+	h.notice(cmd.Nick, fmt.Sprintf("Entropy accepted for %s.", channel))
+	if len(shuffle.entropy) == len(shuffle.commitments) {
+		shuffle.timer.Stop()
+		h.finishEntropy(channel)
+	}
+}
+
+// finishEntropy seeds the deck from the entropy revealed and deals. If
+// anyone who committed didn't reveal, the deal is void and the commitments
+// are taken again without them.
+func (h *Handler) finishEntropy(channel string) {
+	shuffle := h.shuffles[channel]
+	if shuffle == nil || !shuffle.revealing {
+		return
+	}
+	shuffle.revealing = false
+
+	var missing []string
+	for _, nick := range sortedKeys(shuffle.commitments) {
+		if _, ok := shuffle.entropy[nick]; !ok {
+			missing = append(missing, nick)
+		}
+	}
+	if len(missing) > 0 {
+		if shuffle.withheld == nil {
+			shuffle.withheld = make(map[string]bool)
+		}
+		for _, nick := range missing {
+			shuffle.withheld[nick] = true
+		}
+		h.privmsg(channel, fmt.Sprintf("No entropy from %s, so this shuffle is void. Starting it again without them.", strings.Join(missing, ", ")))
+		h.collectEntropy(channel, shuffle)
+		return
+	}
+
+	shuffle.withheld = nil
+	h.games[channel].SetDeckSeed(game.SeedFromEntropy(shuffle.entropy))
+	h.dealRound(channel)
+}
+
+// sortedKeys returns the nicks of a shuffle map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (h *Handler) revealShuffle(channel string) {
+	shuffle := h.shuffles[channel]
+	if shuffle == nil || len(shuffle.entropy) == 0 {
+		return
+	}
+
+	reveals := make([]string, 0, len(shuffle.entropy))
+	for _, nick := range sortedKeys(shuffle.entropy) {
+		reveals = append(reveals, fmt.Sprintf("%s=%q", nick, shuffle.entropy[nick]))
+	}
+	seed := game.SeedFromEntropy(shuffle.entropy)
+	h.privmsg(channel, fmt.Sprintf("Shuffle reveal: %s (seed %x)", strings.Join(reveals, ", "), seed))
+
+	shuffle.entropy = make(map[string]string)
+	h.games[channel].SetDeckSeed(nil)
+}
@@ -0,0 +1,76 @@
+package irc
+
+import (
+	"slices"
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+func TestWithheldEntropyVoidsTheShuffle(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "reveal1", "#reveal", "$start holdem --verified")
+	h.mu.Lock()
+	for _, nick := range []string{"reveal1", "reveal2"} {
+		if !h.seatPlayer("#reveal", nick) {
+			t.Fatalf("%s wasn't seated", nick)
+		}
+	}
+	h.startRound("#reveal")
+	h.mu.Unlock()
+
+	say(t, h, "reveal1", "bot", "$commit "+game.Commitment("first"))
+	say(t, h, "reveal2", "bot", "$commit "+game.Commitment("second"))
+	say(t, h, "reveal1", "bot", "$entropy first")
+	h.mu.Lock()
+	h.finishEntropy("#reveal")
+	shuffle := h.shuffles["#reveal"]
+	if !shuffle.collecting || len(h.games["#reveal"].GetPlayers()[0].Hand) != 0 {
+		h.mu.Unlock()
+		t.Fatal("the hand was dealt without reveal2's entropy")
+	}
+	h.mu.Unlock()
+
+	say(t, h, "reveal2", "bot", "$commit "+game.Commitment("again"))
+	if _, ok := shuffle.commitments["reveal2"]; ok {
+		t.Error("reveal2 committed again after holding back their entropy")
+	}
+	say(t, h, "reveal1", "bot", "$commit "+game.Commitment("retry"))
+	say(t, h, "reveal1", "bot", "$entropy retry")
+	if len(h.games["#reveal"].GetPlayers()[0].Hand) == 0 {
+		t.Fatal("the hand wasn't dealt once every commitment was revealed")
+	}
+	if shuffle.withheld != nil {
+		t.Error("reveal2 is still left out after the deal")
+	}
+}
+
+func TestNoHandSwapAtAVerifiedTable(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "swap1", "#swap", "$start holdem --verified")
+	h.mu.Lock()
+	for _, nick := range []string{"swap1", "swap2"} {
+		if !h.seatPlayer("#swap", nick) {
+			t.Fatalf("%s wasn't seated", nick)
+		}
+	}
+	h.startRound("#swap")
+	h.mu.Unlock()
+	for _, nick := range []string{"swap1", "swap2"} {
+		say(t, h, nick, "bot", "$commit "+game.Commitment(nick))
+	}
+	for _, nick := range []string{"swap1", "swap2"} {
+		say(t, h, nick, "bot", "$entropy "+nick)
+	}
+	cheater := h.games["#swap"].FindPlayer("swap1")
+	if len(cheater.Hand) == 0 {
+		t.Fatal("the hand wasn't dealt")
+	}
+	hand := append([]models.Card(nil), cheater.Hand...)
+
+	say(t, h, "swap1", "#swap", "$cheat")
+	if cheater.Cheats != 0 || cheater.Folded || !slices.Equal(cheater.Hand, hand) {
+		t.Error("a $cheat went ahead at a verified table")
+	}
+}
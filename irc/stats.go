@@ -0,0 +1,77 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"poker-bot/db"
+	"poker-bot/stats"
+)
+
+// handleStats shows a player's stats in the channel: $stats position
+// [nick] breaks their hands down by position at the table, and $stats
+// cheats [nick] shows how their cheating has gone.
+func (h *Handler) handleStats(cmd *Command) {
+	if len(cmd.Args) < 1 || len(cmd.Args) > 2 {
+		h.notice(cmd.Nick, "Usage: $stats position|cheats [nick]")
+		return
+	}
+	nick := cmd.Nick
+	if len(cmd.Args) == 2 {
+		nick = sanitize(cmd.Args[1])
+	}
+	switch kind := strings.ToLower(cmd.Args[0]); {
+	case strings.HasPrefix(kind, "position"):
+		go h.positionStats(cmd.Channel, nick)
+	case strings.HasPrefix(kind, "cheat"):
+		h.privmsg(cmd.Channel, describeCheats(nick))
+	default:
+		h.notice(cmd.Nick, "Usage: $stats position|cheats [nick]")
+	}
+}
+
+// positionStats reads the hand histories away from the handler's mutex,
+// then takes it to answer in the channel.
+func (h *Handler) positionStats(channel, nick string) {
+	message := describePositions(nick)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.privmsg(channel, message)
+}
+
+func describePositions(nick string) string {
+	positions, err := stats.Positions(nick)
+	if err != nil {
+		log.Printf("Error getting position stats for %s: %v", nick, err)
+		return "Error retrieving the stats."
+	}
+	if len(positions) == 0 {
+		return fmt.Sprintf("No hands with positions recorded for %s yet.", nick)
+	}
+
+	parts := make([]string, 0, len(positions))
+	for _, p := range positions {
+		parts = append(parts, fmt.Sprintf("%s %d hands, VPIP %d%%, won %d%%, net %+d",
+			p.Name, p.Hands, percent(p.VPIP, p.Hands), percent(p.Won, p.Hands), p.Net))
+	}
+	return fmt.Sprintf("%s by position: %s.", nick, strings.Join(parts, "; "))
+}
+
+func describeCheats(nick string) string {
+	record, err := db.GetCheatRecord(nick)
+	if err != nil {
+		log.Printf("Error getting cheat stats for %s: %v", nick, err)
+		return "Error retrieving the stats."
+	}
+	tries := record.Succeeded + record.Caught
+	if tries == 0 {
+		return fmt.Sprintf("%s has never tried to cheat, as far as anyone knows.", nick)
+	}
+	return fmt.Sprintf("%s's cheating: tried %d, got away with %d, caught %d (%d%%).",
+		nick, tries, record.Succeeded, record.Caught, percent(record.Caught, tries))
+}
+
+func percent(n, of int) int {
+	return (n*100 + of/2) / of
+}
@@ -0,0 +1,53 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// redSuit is the mIRC color code hearts and diamonds are shown in.
+const redSuit = "04"
+
+// cardText writes cards the way the channel sees them, such as "[QH 9C]",
+// with hearts and diamonds in red when the config turns colors on.
+func (h *Handler) cardText(cards []models.Card) string {
+	parts := make([]string, len(cards))
+	for i, card := range cards {
+		parts[i] = card.String()
+		if h.config.Colors && (card.Suit == "Hearts" || card.Suit == "Diamonds") {
+			parts[i] = "\x03" + redSuit + parts[i] + "\x03"
+		}
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// sendStreetCards privately reminds the players still in the hand at
+// channel of their hole cards, with the new board and the best hand they
+// make with it, so nobody has to scroll back. Only players who turned it
+// on with $setprofile streetcards get one.
+func (h *Handler) sendStreetCards(channel string) {
+	table := h.games[channel]
+	board := table.GetRiver()
+	if len(board) == 0 {
+		return
+	}
+	rules, _ := table.(game.Showdown)
+	street := streetName(len(board))
+	if street == "" {
+		street = "board"
+	}
+	for _, player := range table.GetPlayers() {
+		if player.Folded || len(player.Hand) == 0 || !h.profile(player.Nick).StreetCards {
+			continue
+		}
+		message := fmt.Sprintf("%s%s: your hand %s, board %s", strings.ToUpper(street[:1]), street[1:], h.cardText(player.Hand), h.cardText(board))
+		if rules != nil {
+			name, _ := rules.DescribeHand(player)
+			message += " (" + name + ")"
+		}
+		h.notice(player.Nick, message)
+	}
+}
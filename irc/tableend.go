@@ -0,0 +1,136 @@
+package irc
+
+import (
+	"fmt"
+	"time"
+
+	"poker-bot/models"
+)
+
+// A game goes on while enough players have chips in front of them to deal
+// a hand: those seated with a stack, and those waiting to be dealt in. What
+// they have in the bank doesn't count. When too few are left, the game ends,
+// unless a busted player may still buy back in, in which case it waits the
+// config's rebuy_wait for them first.
+
+// playersToDeal counts the players at channel with table chips: seated with
+// a stack, or waiting to be dealt in next hand.
+func (h *Handler) playersToDeal(channel string) int {
+	players := len(h.lateJoins[channel])
+	for _, player := range h.games[channel].GetPlayers() {
+		if player.Money > 0 {
+			players++
+		}
+	}
+	return players
+}
+
+// rebuyWindowOpen reports whether a player short of chips can still get back
+// into the game at channel: anyone can buy in to a cash table someone is
+// still playing at, and a busted tournament entrant can rebuy during the
+// rebuy period.
+func (h *Handler) rebuyWindowOpen(channel string) bool {
+	if h.config.RebuyWait <= 0 {
+		return false
+	}
+	if h.cashTables[channel] != nil {
+		return h.playersToDeal(channel) > 0
+	}
+	if t := h.tournaments[channel]; t != nil {
+		return t.RebuyOpen() && len(t.Eliminated) > 0
+	}
+	return false
+}
+
+// shouldEndGame reports whether the game at channel is over: a limit was
+// reached, the channel went into maintenance, or too few players have
+// chips to deal and nobody can rebuy. Tournaments play on through
+// maintenance, since ending one early would cut its prizes short.
+func (h *Handler) shouldEndGame(channel string) bool {
+	if reason := h.limitReached(channel); reason != "" {
+		h.privmsg(channel, reason)
+		return true
+	}
+	if h.tournaments[channel] == nil && h.maintenanceFor(channel) != nil {
+		h.privmsg(channel, "This table is closing for maintenance.")
+		return true
+	}
+	if t := h.tournaments[channel]; t != nil && len(t.Tables()) > 1 {
+		// Players are moved here from the other tables instead.
+		return false
+	}
+	if h.playersToDeal(channel) >= h.minPlayers(channel) {
+		return false
+	}
+	return !h.rebuyWindowOpen(channel)
+}
+
+// unseatBusted stands up the players at channel who have no chips left once
+// they can't buy back in, so they aren't dealt in and posted against. Only
+// tables that play for the bankrolls need it: handleBusts already takes
+// busted players off tournament and cash tables.
+func (h *Handler) unseatBusted(channel string) {
+	if !h.stackIsBankroll(channel) || h.rebuyWindowOpen(channel) {
+		return
+	}
+	table := h.games[channel]
+	for _, player := range append([]*models.Player{}, table.GetPlayers()...) {
+		if player.Money > 0 {
+			continue
+		}
+		table.RemovePlayer(player.Nick)
+		h.privmsg(channel, fmt.Sprintf("%s is out of chips and leaves the table.", player.Nick))
+	}
+}
+
+// holdForRebuys keeps the game at channel from dealing while too few seated
+// players have chips, and reports whether it is holding. The first time, it
+// starts the rebuy wait: if nobody buys back in by the end of it, the game
+// ends.
+func (h *Handler) holdForRebuys(channel string) bool {
+	if h.playersToDeal(channel) >= h.minPlayers(channel) {
+		h.stopRebuyWait(channel)
+		return false
+	}
+	if h.rebuyWaits[channel] != nil {
+		return true
+	}
+	wait := time.Duration(h.config.RebuyWait)
+	verb := "$rebuys"
+	if h.cashTables[channel] != nil {
+		verb = "$joins"
+	}
+	h.privmsg(channel, fmt.Sprintf("Not enough players have chips to deal. The game ends in %s unless someone %s.", wait, verb))
+	h.rebuyWaits[channel] = h.afterTable(channel, wait, func() {
+		delete(h.rebuyWaits, channel)
+		if h.games[channel] == nil {
+			return
+		}
+		if h.playersToDeal(channel) >= h.minPlayers(channel) {
+			h.startRound(channel)
+			return
+		}
+		h.privmsg(channel, fmt.Sprintf("Nobody bought in within %s.", wait))
+		h.endGame(channel)
+	})
+	return true
+}
+
+// resumeAfterRebuy deals the next hand at channel if the game was waiting
+// for rebuys and now has enough players with chips.
+func (h *Handler) resumeAfterRebuy(channel string) {
+	if h.tableWaits[channel] {
+		h.resumeTable(channel)
+		return
+	}
+	if h.rebuyWaits[channel] != nil && h.playersToDeal(channel) >= h.minPlayers(channel) {
+		h.startRound(channel)
+	}
+}
+
+func (h *Handler) stopRebuyWait(channel string) {
+	if timer := h.rebuyWaits[channel]; timer != nil {
+		timer.Stop()
+		delete(h.rebuyWaits, channel)
+	}
+}
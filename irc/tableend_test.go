@@ -0,0 +1,25 @@
+package irc
+
+import "testing"
+
+func TestBustedPlayersLeaveBankrollTables(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "broke1", "#broke", "$start holdem")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, nick := range []string{"broke1", "broke2", "broke3"} {
+		if !h.seatPlayer("#broke", nick) {
+			t.Fatalf("%s wasn't seated", nick)
+		}
+	}
+	table := h.games["#broke"]
+	table.FindPlayer("broke3").Money = 0
+
+	h.startRound("#broke")
+	if table.FindPlayer("broke3") != nil {
+		t.Error("the busted player is still seated")
+	}
+	if players := table.GetPlayers(); len(players) != 2 || len(players[0].Hand) == 0 {
+		t.Errorf("the hand wasn't dealt to the two players with chips")
+	}
+}
@@ -0,0 +1,139 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Table logs hold every player's nick and mucked cards, so only the bot's
+// user can read them.
+const (
+	tableLogDirMode  = 0o700
+	tableLogFileMode = 0o600
+)
+
+// tableLog is the open plaintext log of one channel: a file a day, named
+// after the channel and the date, such as poker-2026-10-16.log.
+type tableLog struct {
+	dir  string
+	day  string
+	file *os.File
+}
+
+// logTable appends a timestamped line to channel's log file, if the config
+// names a directory for them. These are for operators auditing a table
+// without the database: everything the bot says in the channel, and the
+// lines only the hand history sees, such as mucked hands.
+func (h *Handler) logTable(channel, line string) {
+	dir := h.config.TableLogDir
+	if dir == "" || !strings.HasPrefix(channel, "#") {
+		return
+	}
+	now := time.Now()
+	day := now.Format("2006-01-02")
+	key := strings.ToLower(channel)
+	tl := h.tableLogs[key]
+	if tl == nil || tl.dir != dir || tl.day != day {
+		if tl != nil {
+			tl.file.Close()
+			delete(h.tableLogs, key)
+		}
+		if err := os.MkdirAll(dir, tableLogDirMode); err != nil {
+			log.Printf("Error creating the table log directory %s: %v", dir, err)
+			return
+		}
+		file, err := os.OpenFile(filepath.Join(dir, tableLogName(channel, day)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, tableLogFileMode)
+		if err != nil {
+			log.Printf("Error opening the table log of %s: %v", channel, err)
+			return
+		}
+		if err := file.Chmod(tableLogFileMode); err != nil {
+			log.Printf("Error restricting the table log of %s: %v", channel, err)
+		}
+		tl = &tableLog{dir: dir, day: day, file: file}
+		h.tableLogs[key] = tl
+		h.pruneTableLogs(channel)
+	}
+	if _, err := fmt.Fprintf(tl.file, "%s %s\n", now.Format("15:04:05"), stripUnsafe(line)); err != nil {
+		log.Printf("Error writing the table log of %s: %v", channel, err)
+	}
+}
+
+// tableLogName is the file channel logs to on day. Characters a file name
+// can't hold are replaced.
+func tableLogName(channel, day string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(strings.TrimLeft(channel, "#")))
+	return name + "-" + day + ".log"
+}
+
+// pruneTableLogs deletes channel's log files from before the config's
+// table_log_retention, if it sets one.
+func (h *Handler) pruneTableLogs(channel string) {
+	retention := time.Duration(h.config.TableLogRetention)
+	if retention <= 0 {
+		return
+	}
+	prefix := strings.TrimSuffix(tableLogName(channel, ""), "-.log") + "-"
+	cutoff := time.Now().Add(-retention).Format("2006-01-02")
+	files, err := filepath.Glob(filepath.Join(h.config.TableLogDir, "*.log"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		day, ok := strings.CutPrefix(filepath.Base(file), prefix)
+		day = strings.TrimSuffix(day, ".log")
+		if !ok || len(day) != len(cutoff) || day >= cutoff {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			log.Printf("Error removing old table log %s: %v", file, err)
+		}
+	}
+}
+
+// forgetTableLogs replaces nick with pseudonym in every table log, for
+// $forgetme. The files are rewritten in place, so the logs still open go
+// on appending to them.
+func (h *Handler) forgetTableLogs(nick, pseudonym string) error {
+	if h.config.TableLogDir == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(h.config.TableLogDir, "*.log"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rewritten := replaceNick(string(text), nick, pseudonym)
+		if rewritten == string(text) {
+			continue
+		}
+		if err := os.WriteFile(file, []byte(rewritten), tableLogFileMode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeTableLogs closes every open table log.
+func (h *Handler) closeTableLogs() {
+	for key, tl := range h.tableLogs {
+		tl.file.Close()
+		delete(h.tableLogs, key)
+	}
+}
@@ -0,0 +1,102 @@
+package irc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"poker-bot/config"
+)
+
+func TestForgetTableLogs(t *testing.T) {
+	h := newTestHandler(t)
+	h.config.TableLogDir = t.TempDir()
+	h.logTable("#forget", "ann mucks [A♠ K♠], annie wins 40")
+
+	if err := h.forgetTableLogs("ann", "$forgotten-1"); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(h.config.TableLogDir, "*.log"))
+	if len(files) != 1 {
+		t.Fatalf("%d table logs, want 1", len(files))
+	}
+	text, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "$forgotten-1 mucks [A♠ K♠], annie wins 40\n"; string(text[len("15:04:05 "):]) != want {
+		t.Errorf("the log reads %q, want %q after the time", text, want)
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != tableLogFileMode {
+		t.Errorf("the log's mode is %o, want %o", mode, tableLogFileMode)
+	}
+
+	// The open log goes on appending after the rewrite.
+	h.logTable("#forget", "next hand")
+	if after, _ := os.ReadFile(files[0]); len(after) <= len(text) {
+		t.Error("the open log stopped appending after the rewrite")
+	}
+}
+
+func TestTableLogName(t *testing.T) {
+	tests := []struct {
+		channel, want string
+	}{
+		{"#Poker", "poker-2026-10-16.log"},
+		{"##Poker", "poker-2026-10-16.log"},
+		{"#../etc", ".._etc-2026-10-16.log"},
+		{"#a\\b", "a_b-2026-10-16.log"},
+	}
+	for _, test := range tests {
+		if got := tableLogName(test.channel, "2026-10-16"); got != test.want {
+			t.Errorf("tableLogName(%q) = %q, want %q", test.channel, got, test.want)
+		}
+	}
+}
+
+func TestTableLogsRotateDaily(t *testing.T) {
+	h := newTestHandler(t)
+	h.config.TableLogDir = t.TempDir()
+	h.logTable("#rotate", "first")
+	yesterday := h.tableLogs["#rotate"]
+	yesterday.day = "2026-01-01"
+
+	h.logTable("#rotate", "second")
+	if h.tableLogs["#rotate"] == yesterday {
+		t.Fatal("the log wasn't rotated for the new day")
+	}
+	if _, err := yesterday.file.WriteString("late\n"); err == nil {
+		t.Error("the last day's log was left open")
+	}
+}
+
+func TestTableLogRetention(t *testing.T) {
+	h := newTestHandler(t)
+	dir := t.TempDir()
+	h.config.TableLogDir = dir
+	h.config.TableLogRetention = config.Duration(48 * time.Hour)
+	for _, name := range []string{"prune-2020-01-01.log", "prune-notaday.log", "pruned-2020-01-01.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, tableLogFileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h.logTable("#prune", "a new day")
+	kept := map[string]bool{
+		"prune-2020-01-01.log":  false,
+		"prune-notaday.log":     true,
+		"pruned-2020-01-01.log": true, // another channel's
+		tableLogName("#prune", time.Now().Format("2006-01-02")): true,
+	}
+	for name, want := range kept {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if got := err == nil; got != want {
+			t.Errorf("%s kept: %t, want %t", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package irc
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// A tournament started with --tables plays at several channels at once,
+// each table a game of its own sharing one game.Tournament. Tables join
+// the tournament's engine when they deal their first hand. Between its
+// hands a table breaks when the players left fit at the others, and gives
+// players to the shortest table until no two differ by more than one; a
+// table short of players waits for the others to send it some. On the
+// bubble the engine has the tables play hand-for-hand.
+
+// tablesOf returns the tables of the tournament at channel, sorted, or just
+// channel if it isn't a multi-table tournament.
+func (h *Handler) tablesOf(channel string) []string {
+	t := h.tournaments[channel]
+	if t == nil {
+		return []string{channel}
+	}
+	var tables []string
+	for table, other := range h.tournaments {
+		if other == t {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// checkTables returns why the channels given to --tables can't host tables
+// of a tournament started at channel, or "" if they can.
+func (h *Handler) checkTables(channel string, tables []string) string {
+	seen := map[string]bool{strings.ToLower(channel): true}
+	for _, table := range tables {
+		switch {
+		case !strings.HasPrefix(table, "#"):
+			return fmt.Sprintf("%s isn't a channel.", sanitize(table))
+		case seen[strings.ToLower(table)]:
+			return fmt.Sprintf("%s is given twice.", sanitize(table))
+		case !h.channels[strings.ToLower(table)]:
+			return fmt.Sprintf("I'm not in %s.", sanitize(table))
+		case h.games[table] != nil:
+			return fmt.Sprintf("There's already a game in %s.", table)
+		case h.maintenanceFor(table) != nil:
+			return fmt.Sprintf("%s is closed for maintenance.", table)
+		}
+		seen[strings.ToLower(table)] = true
+	}
+	return ""
+}
+
+// openTables opens the other tables of the tournament just started at
+// channel, with the same game and host.
+func (h *Handler) openTables(channel, gameType string, verified bool, tables []string) {
+	t, host := h.tournaments[channel], h.tableHosts[channel]
+	for _, table := range tables {
+		h.games[table] = newGame(gameType, table)
+		h.currentTurn[table] = ""
+		h.tableHosts[table] = host
+		if verified {
+			h.shuffles[table] = &verifiedShuffle{}
+		}
+		h.privmsg(table, fmt.Sprintf("Starting a table of the %s tournament in %s, hosted by %s. Type $join to participate!", gameType, channel, host))
+		h.startTournament(table, t)
+		h.watchLobby(table)
+		h.updateTopic(table)
+	}
+	h.privmsg(channel, fmt.Sprintf("The tournament plays at %s. Players move between the tables to keep them even, and the tables play hand-for-hand on the bubble.", strings.Join(h.tablesOf(channel), ", ")))
+}
+
+// holdForTables runs between hands at a table of a multi-table tournament,
+// before the late entrants are seated. It adds the table to the
+// tournament's engine on its first hand and balances the tables, then
+// holds the table while it plays hand-for-hand and waits for the others to
+// finish their hand, or while it's short of players. It reports whether
+// the table is held or was broken.
+func (h *Handler) holdForTables(channel string) bool {
+	t := h.tournaments[channel]
+	if t == nil || len(h.tablesOf(channel)) < 2 {
+		return false
+	}
+	if !slices.Contains(t.Tables(), channel) {
+		t.AddTable(channel)
+	}
+	if h.balanceTables(channel) {
+		return true
+	}
+	if len(t.Tables()) < 2 {
+		delete(h.tableWaits, channel)
+		return false
+	}
+
+	short := h.playersToDeal(channel) < h.minPlayers(channel)
+	if short && !t.Waiting(channel) {
+		// A table that can't deal is done with the hand-for-hand hand, so
+		// the others don't wait for it.
+		h.finishTableHand(channel, nil)
+		short = h.playersToDeal(channel) < h.minPlayers(channel)
+	}
+	if !short && !t.Waiting(channel) {
+		delete(h.tableWaits, channel)
+		return false
+	}
+	if !h.tableWaits[channel] {
+		if short {
+			h.privmsg(channel, "Waiting for players to be moved here from the other tables.")
+		} else {
+			h.privmsg(channel, "Hand-for-hand: this table waits for the others to finish their hand.")
+		}
+	}
+	h.tableWaits[channel] = true
+	return true
+}
+
+// finishTableHand tells the tournament's engine that the hand at channel is
+// over, with the players who busted in it and their stacks when it was
+// dealt, then announces whoever it eliminated and deals again at the
+// tables it let go.
+func (h *Handler) finishTableHand(channel string, startingStacks map[string]int) {
+	t := h.tournaments[channel]
+	eliminated, released := t.FinishHand(channel, startingStacks)
+	h.announceEliminations(channel, eliminated)
+	for _, table := range released {
+		h.resumeTable(table)
+	}
+}
+
+// announceEliminations tells every table of the tournament at channel who
+// the last hand eliminated, or who can rebuy.
+func (h *Handler) announceEliminations(channel string, eliminated []string) {
+	if len(eliminated) == 0 {
+		return
+	}
+	t := h.tournaments[channel]
+	for _, nick := range eliminated {
+		message := fmt.Sprintf("%s is eliminated.", nick)
+		if t.RebuyOpen() {
+			message = fmt.Sprintf("%s busts! $rebuy for %d chips (%d) to get back in.", nick, t.RebuyChips, t.RebuyCost)
+		}
+		for _, table := range h.tablesOf(channel) {
+			h.privmsg(table, message)
+		}
+	}
+	h.watchBubble(channel, t.Remaining()+len(eliminated))
+}
+
+// resumeTable deals again at a tournament table waiting for the others, or
+// starts a table that hadn't dealt yet once it has the players.
+func (h *Handler) resumeTable(channel string) {
+	table := h.games[channel]
+	switch {
+	case !table.IsInProgress():
+		if len(table.GetPlayers()) >= h.minPlayers(channel) {
+			h.startRound(channel)
+		}
+	case h.tableWaits[channel]:
+		delete(h.tableWaits, channel)
+		if h.shouldEndGame(channel) {
+			h.endGame(channel)
+		} else {
+			h.startRound(channel)
+		}
+	}
+}
+
+// tableSize counts the players at channel, seated or waiting for a seat.
+func (h *Handler) tableSize(channel string) int {
+	return len(h.games[channel].GetPlayers()) + len(h.lateJoins[channel])
+}
+
+// shortestTable returns the tournament's table in play with the fewest
+// players, other than except, the first to start among equals.
+func (h *Handler) shortestTable(t *game.Tournament, except string) string {
+	shortest := ""
+	for _, table := range t.Tables() {
+		if table != except && (shortest == "" || h.tableSize(table) < h.tableSize(shortest)) {
+			shortest = table
+		}
+	}
+	return shortest
+}
+
+// balanceTables runs between hands at channel. When the players left fit
+// at one table fewer, the shortest table breaks: channel itself, or a table
+// holding for want of players. Then channel gives players to the shortest
+// table until they're within one of each other. Tables don't balance while
+// they wait on a hand-for-hand hand. It reports whether channel broke.
+func (h *Handler) balanceTables(channel string) bool {
+	t := h.tournaments[channel]
+	tables := t.Tables()
+	if len(tables) < 2 || t.Waiting(channel) {
+		return false
+	}
+
+	total := 0
+	for _, table := range tables {
+		total += h.tableSize(table)
+	}
+	if total <= h.maxPlayers(channel)*(len(tables)-1) {
+		shortest := h.shortestTable(t, "")
+		if h.tableSize(channel) == h.tableSize(shortest) {
+			shortest = channel
+		}
+		switch {
+		case shortest == channel:
+			h.breakTable(channel)
+			return true
+		case h.tableWaits[shortest] && !t.Waiting(shortest):
+			h.breakTable(shortest)
+		}
+	}
+
+	for len(t.Tables()) > 1 {
+		other := h.shortestTable(t, channel)
+		if h.tableSize(channel)-h.tableSize(other) < 2 {
+			break
+		}
+		h.moveSeat(channel, other, h.standUp(channel))
+	}
+	return false
+}
+
+// standUp takes a player away from channel to move them to another table:
+// the last to be waiting for a seat, or else the last seated.
+func (h *Handler) standUp(channel string) *models.Player {
+	if waiting := h.lateJoins[channel]; len(waiting) > 0 {
+		h.lateJoins[channel] = waiting[:len(waiting)-1]
+		return waiting[len(waiting)-1]
+	}
+	table := h.games[channel]
+	players := table.GetPlayers()
+	player := players[len(players)-1]
+	table.RemovePlayer(player.Nick)
+	return player
+}
+
+// moveSeat moves a player with their stack from one table of a tournament
+// to another: dealt in there next hand, or seated straight away at a table
+// that hasn't started.
+func (h *Handler) moveSeat(from, to string, player *models.Player) {
+	if table := h.games[to]; table.IsInProgress() {
+		h.lateJoins[to] = append(h.lateJoins[to], player)
+	} else {
+		table.AddPlayer(player)
+	}
+	h.privmsg(from, fmt.Sprintf("%s moves to %s.", player.Nick, to))
+	h.privmsg(to, fmt.Sprintf("%s moves here from %s with %d chips.", player.Nick, from, player.Money))
+	h.resumeTable(to)
+}
+
+// breakTable closes channel and moves its players, seated or waiting for a
+// seat, to the shortest of the tournament's other tables in play.
+func (h *Handler) breakTable(channel string) {
+	t := h.tournaments[channel]
+	players := append([]*models.Player{}, h.games[channel].GetPlayers()...)
+	players = append(players, h.lateJoins[channel]...)
+	h.privmsg(channel, "This table is broken. Its players move to the other tables.")
+	h.closeTournamentTable(channel)
+	for _, player := range players {
+		h.moveSeat(channel, h.shortestTable(t, ""), player)
+	}
+}
+
+// closeTournamentTable closes one table of a multi-table tournament between
+// hands, leaving the tournament to play on at the others.
+func (h *Handler) closeTournamentTable(channel string) {
+	t := h.tournaments[channel]
+	h.stopRebuyWait(channel)
+	h.endTableContext(channel)
+	h.closeHistory(channel)
+	h.refundRailBets(channel)
+	if timer, exists := h.breaks[channel]; exists {
+		timer.Stop()
+		delete(h.breaks, channel)
+	}
+	if shuffle, exists := h.shuffles[channel]; exists {
+		if shuffle.timer != nil {
+			shuffle.timer.Stop()
+		}
+		delete(h.shuffles, channel)
+	}
+	delete(h.tableWaits, channel)
+	delete(h.etiquette, channel)
+	delete(h.audits, channel)
+	delete(h.stacks, channel)
+	delete(h.currentTurn, channel)
+	delete(h.games, channel)
+	delete(h.lateJoins, channel)
+	delete(h.tournaments, channel)
+	h.closeTable(channel)
+	h.updateTopic(channel)
+
+	eliminated, released := t.RemoveTable(channel)
+	if len(released) > 0 {
+		h.announceEliminations(released[0], eliminated)
+	}
+	for _, table := range released {
+		h.resumeTable(table)
+	}
+}
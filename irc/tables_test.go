@@ -0,0 +1,159 @@
+package irc
+
+import (
+	"fmt"
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+func TestStartTables(t *testing.T) {
+	h := newTestHandler(t)
+	h.channels["#tables-b"] = true
+	say(t, h, "tables1", "#tables-a", "$start holdem --tournament --tables #tables-b,#tables-c")
+	if h.games["#tables-a"] != nil {
+		t.Fatal("the tournament started at a channel the bot isn't in")
+	}
+
+	h.channels["#tables-c"] = true
+	say(t, h, "tables1", "#tables-a", "$start holdem --tournament --tables #tables-b,#tables-c")
+	tournament := h.tournaments["#tables-a"]
+	if tournament == nil || h.tournaments["#tables-b"] != tournament || h.tournaments["#tables-c"] != tournament {
+		t.Fatal("the tables don't share the tournament")
+	}
+
+	say(t, h, "tables2", "#tables-a", "$join")
+	say(t, h, "tables2", "#tables-b", "$join")
+	if h.games["#tables-b"].FindPlayer("tables2") != nil {
+		t.Error("a player sat down at two tables of the tournament")
+	}
+
+	// Nobody has dealt yet, so cancelling closes every table.
+	say(t, h, "tables1", "#tables-a", "$cancel")
+	for _, table := range []string{"#tables-a", "#tables-b", "#tables-c"} {
+		if h.games[table] != nil || h.tournaments[table] != nil {
+			t.Errorf("%s is still open", table)
+		}
+	}
+}
+
+func TestTablesBreakWhenThePlayersFitAtOne(t *testing.T) {
+	h := newTestHandler(t)
+	h.channels["#break-b"] = true
+	say(t, h, "break1", "#break-a", "$start holdem --tournament --tables #break-b")
+	for _, nick := range []string{"break1", "break2", "break3"} {
+		say(t, h, nick, "#break-a", "$join")
+	}
+	say(t, h, "break4", "#break-b", "$join")
+	say(t, h, "break5", "#break-b", "$join")
+
+	// #break-b dealt its first hand with five players left, who fit at one
+	// table, so it broke before dealing.
+	if h.games["#break-b"] != nil || h.tournaments["#break-b"] != nil {
+		t.Fatal("#break-b didn't break")
+	}
+	tournament := h.tournaments["#break-a"]
+	if tables := tournament.Tables(); len(tables) != 1 || tables[0] != "#break-a" {
+		t.Errorf("the tournament plays at %v, want #break-a", tables)
+	}
+	if h.tableSize("#break-a") != 5 {
+		t.Errorf("%d players are at #break-a, want all 5", h.tableSize("#break-a"))
+	}
+	if tournament.Remaining() != 5 {
+		t.Errorf("%d players are left, want 5", tournament.Remaining())
+	}
+}
+
+// handForHandTables sets up a fifty-entry tournament on the bubble, ten
+// places paid and eleven players left, at two tables dealt a hand.
+func handForHandTables(t *testing.T, h *Handler) *game.Tournament {
+	t.Helper()
+	tournament := game.NewTournament()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	seated := map[string]int{"#h4h-a": 6, "#h4h-b": 5}
+	for table, players := range seated {
+		h.games[table] = newGame("holdem", table)
+		h.tournaments[table] = tournament
+		for i := 0; i < players; i++ {
+			nick := fmt.Sprintf("%s%d", table[1:], i)
+			tournament.Enter(nick)
+			h.games[table].AddPlayer(&models.Player{Nick: nick, Money: 1000, Economy: h.economy(table)})
+		}
+	}
+	for i := len(tournament.Entries); i < 50; i++ {
+		nick := fmt.Sprintf("h4h-out%d", i)
+		tournament.Enter(nick)
+		tournament.Eliminated = append(tournament.Eliminated, nick)
+	}
+	for _, table := range []string{"#h4h-a", "#h4h-b"} {
+		h.startRound(table)
+		if len(h.games[table].GetPlayers()[0].Hand) == 0 {
+			t.Fatalf("%s wasn't dealt", table)
+		}
+	}
+	if !tournament.HandForHand() {
+		t.Fatalf("%d players left and %d paid isn't the bubble", tournament.Remaining(), tournament.PlacesPaid())
+	}
+	return tournament
+}
+
+func TestHandForHand(t *testing.T) {
+	h := newTestHandler(t)
+	tournament := handForHandTables(t, h)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// #h4h-a finishes its hand first, and waits.
+	table := h.games["#h4h-a"]
+	hands := table.GetHandCount()
+	h.finishTableHand("#h4h-a", nil)
+	h.startRound("#h4h-a")
+	if !h.tableWaits["#h4h-a"] {
+		t.Fatal("#h4h-a didn't wait for #h4h-b")
+	}
+	if table.GetHandCount() != hands {
+		t.Error("#h4h-a dealt while waiting")
+	}
+
+	// #h4h-b's hand busts a player, which lets #h4h-a deal again.
+	busted := h.games["#h4h-b"].FindPlayer("h4h-b4")
+	h.games["#h4h-b"].RemovePlayer(busted.Nick)
+	h.finishTableHand("#h4h-b", map[string]int{busted.Nick: 1000})
+	if h.tableWaits["#h4h-a"] || table.GetHandCount() != hands+1 {
+		t.Error("#h4h-a didn't deal once #h4h-b finished")
+	}
+	if got := tournament.Eliminated[len(tournament.Eliminated)-1]; got != busted.Nick {
+		t.Errorf("%s was eliminated last, want %s", got, busted.Nick)
+	}
+	if tournament.HandForHand() {
+		t.Error("play is still hand-for-hand in the money")
+	}
+}
+
+func TestTablesBalance(t *testing.T) {
+	h := newTestHandler(t)
+	tournament := game.NewTournament()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, table := range []string{"#even-a", "#even-b"} {
+		h.games[table] = newGame("holdem", table)
+		h.tournaments[table] = tournament
+	}
+	seat := func(table string, players int) {
+		for i := 0; i < players; i++ {
+			nick := fmt.Sprintf("%s-%d", table[1:], i)
+			tournament.Enter(nick)
+			h.games[table].AddPlayer(&models.Player{Nick: nick, Money: 1000, Economy: h.economy(table)})
+		}
+	}
+	seat("#even-a", 9)
+	seat("#even-b", 3)
+	h.startRound("#even-b")
+	h.startRound("#even-a")
+
+	if a, b := h.tableSize("#even-a"), h.tableSize("#even-b"); a != 6 || b != 6 {
+		t.Errorf("the tables have %d and %d players, want 6 each", a, b)
+	}
+}
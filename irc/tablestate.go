@@ -0,0 +1,99 @@
+package irc
+
+import "poker-bot/models"
+
+// TableState is a snapshot of a table for web viewers. Hole cards are only
+// filled in for the viewer the state is made for.
+type TableState struct {
+	Channel    string      `json:"channel"`
+	Game       string      `json:"game"`
+	Structure  string      `json:"structure"`
+	Stakes     string      `json:"stakes"`
+	Hand       int         `json:"hand"`
+	Pot        int         `json:"pot"`
+	CurrentBet int         `json:"current_bet"`
+	Board      []string    `json:"board"`
+	Street     string      `json:"street,omitempty"`
+	Turn       string      `json:"turn,omitempty"`
+	Seats      []SeatState `json:"seats"`
+}
+
+type SeatState struct {
+	Nick    string   `json:"nick"`
+	Stack   int      `json:"stack"`
+	Bet     int      `json:"bet"`
+	Folded  bool     `json:"folded"`
+	Cards   []string `json:"cards,omitempty"`
+	Avatar  string   `json:"avatar,omitempty"`
+	Color   string   `json:"color,omitempty"` // CSS color
+	Tagline string   `json:"tagline,omitempty"`
+}
+
+// TableState returns the table at channel as viewer sees it, or nil if
+// there is no game there. An empty viewer sees no hole cards.
+func (h *Handler) TableState(channel, viewer string) *TableState {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.tableState(channel).For(viewer)
+}
+
+// tableState snapshots the table with every player's hole cards.
+func (h *Handler) tableState(channel string) *TableState {
+	table := h.games[channel]
+	if table == nil {
+		return nil
+	}
+	state := &TableState{
+		Channel:    channel,
+		Game:       table.GetType(),
+		Structure:  string(table.Structure()),
+		Stakes:     table.Stakes().String(),
+		Hand:       table.GetHandCount(),
+		Pot:        table.GetPot(),
+		CurrentBet: table.GetCurrentBet(),
+		Board:      cardStrings(table.GetRiver()),
+		Street:     streetName(len(table.GetRiver())),
+		Seats:      make([]SeatState, 0, len(table.GetPlayers())),
+	}
+	if h.audits[channel] != nil {
+		state.Turn = h.currentTurn[channel]
+	}
+	for _, player := range table.GetPlayers() {
+		profile := h.profile(player.Nick)
+		state.Seats = append(state.Seats, SeatState{
+			Nick:    player.Nick,
+			Stack:   player.Money,
+			Bet:     player.Bet,
+			Folded:  player.Folded,
+			Cards:   cardStrings(player.Hand),
+			Avatar:  profile.Avatar,
+			Color:   profileColors[profile.Color].css,
+			Tagline: profile.Tagline,
+		})
+	}
+	return state
+}
+
+// For returns a copy of the state with only viewer's hole cards in it.
+func (s *TableState) For(viewer string) *TableState {
+	if s == nil {
+		return nil
+	}
+	view := *s
+	view.Seats = make([]SeatState, len(s.Seats))
+	for i, seat := range s.Seats {
+		if seat.Nick != viewer {
+			seat.Cards = nil
+		}
+		view.Seats[i] = seat
+	}
+	return &view
+}
+
+func cardStrings(cards []models.Card) []string {
+	strings := make([]string, len(cards))
+	for i, card := range cards {
+		strings[i] = card.String()
+	}
+	return strings
+}
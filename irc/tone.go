@@ -0,0 +1,30 @@
+package irc
+
+import (
+	"strings"
+
+	"poker-bot/flavor"
+)
+
+// tone is how the bot speaks at channel: the config's tone for it, or else
+// the config's default.
+func (h *Handler) tone(channel string) string {
+	for name, tone := range h.config.ChannelTones {
+		if strings.EqualFold(name, channel) {
+			return toneOrDefault(tone)
+		}
+	}
+	return toneOrDefault(h.config.Tone)
+}
+
+func toneOrDefault(tone string) string {
+	if tone == "" {
+		return flavor.FamilyFriendly
+	}
+	return tone
+}
+
+// message returns the catalog's line for key in channel's tone.
+func (h *Handler) message(channel, key string, details flavor.Details) string {
+	return flavor.Message(h.tone(channel), key, details)
+}
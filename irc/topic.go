@@ -0,0 +1,188 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/game"
+	"poker-bot/models"
+
+	irc "github.com/thoj/go-ircevent"
+)
+
+const (
+	// topicInterval is the least time between two topic changes in a
+	// channel, so a fast game doesn't flood the channel with them.
+	topicInterval = time.Minute
+	// topicBackoff is how long the bot leaves a channel's topic alone after
+	// the server says it needs ops to change it.
+	topicBackoff = 10 * time.Minute
+	// topicSeparator sets the table status off from the rest of the topic.
+	topicSeparator = " | Poker: "
+)
+
+// topicState is what the bot knows of a channel's topic: the part that
+// isn't the bot's, what it last set and when.
+type topicState struct {
+	base   string
+	status string
+	sent   time.Time
+	denied time.Time
+	timer  *time.Timer
+}
+
+func (h *Handler) topicState(channel string) *topicState {
+	key := strings.ToLower(channel)
+	t := h.topics[key]
+	if t == nil {
+		t = &topicState{}
+		h.topics[key] = t
+	}
+	return t
+}
+
+// keepsTopic reports whether the config asks the bot to keep channel's
+// topic up to date.
+func (h *Handler) keepsTopic(channel string) bool {
+	for _, name := range h.config.TopicChannels {
+		if strings.EqualFold(name, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackTopics follows channel topics, so the bot's status can be added to
+// whatever the channel's ops set, and notices when it lacks the ops to
+// change one.
+func (h *Handler) trackTopics() {
+	// RPL_TOPIC: <me> <channel> :<topic>
+	h.conn.AddCallback("332", func(e *irc.Event) {
+		if len(e.Arguments) < 3 {
+			return
+		}
+		h.mu.Lock()
+		h.topicState(e.Arguments[1]).base = stripStatus(e.Arguments[2])
+		h.mu.Unlock()
+	})
+	h.conn.AddCallback("TOPIC", func(e *irc.Event) {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if len(e.Arguments) < 2 || h.isBot(e.Nick) {
+			return
+		}
+		t := h.topicState(e.Arguments[0])
+		t.base = stripStatus(e.Arguments[1])
+		t.status = "" // the new topic may have dropped it
+	})
+	// ERR_CHANOPRIVSNEEDED: <me> <channel> :You're not channel operator
+	h.conn.AddCallback("482", func(e *irc.Event) {
+		if len(e.Arguments) < 2 {
+			return
+		}
+		h.mu.Lock()
+		if h.keepsTopic(e.Arguments[1]) {
+			log.Printf("Can't set the topic of %s without ops, trying again in %s", e.Arguments[1], topicBackoff)
+			h.topicState(e.Arguments[1]).denied = time.Now()
+		}
+		h.mu.Unlock()
+	})
+}
+
+func stripStatus(topic string) string {
+	if strings.HasPrefix(topic, strings.TrimPrefix(topicSeparator, " | ")) {
+		return ""
+	}
+	base, _, _ := strings.Cut(topic, topicSeparator)
+	return base
+}
+
+// topicEvents refreshes the topic as hands start and end.
+func (h *Handler) topicEvents(event Event) {
+	switch event.Kind {
+	case EventHandStart, EventWin, EventShowdown, EventGameOver:
+		h.updateTopic(event.Channel)
+	}
+}
+
+// updateTopic sets channel's topic to show the table's status, if the
+// config asks for it. Changes within topicInterval of the last one wait
+// until it has passed, and only the latest status is sent.
+func (h *Handler) updateTopic(channel string) {
+	if !h.keepsTopic(channel) || h.conn == nil {
+		return
+	}
+	t := h.topicState(channel)
+	if time.Since(t.denied) < topicBackoff {
+		return
+	}
+	status := h.tableStatus(channel)
+	if status == t.status {
+		if t.timer != nil {
+			t.timer.Stop()
+			t.timer = nil
+		}
+		return
+	}
+	if wait := topicInterval - time.Since(t.sent); wait > 0 {
+		if t.timer == nil {
+			t.timer = h.afterFunc(h.ctx, wait, func() {
+				t.timer = nil
+				h.updateTopic(channel)
+			})
+		}
+		return
+	}
+
+	topic := "Poker: " + status
+	if t.base != "" {
+		topic = t.base + topicSeparator + status
+	}
+	h.conn.SendRawf("TOPIC %s :%s", channel, stripUnsafe(topic))
+	t.status = status
+	t.sent = time.Now()
+}
+
+// tableStatus sums up the table at channel for its topic: the game, the
+// stakes, the open seats and who has the most chips.
+func (h *Handler) tableStatus(channel string) string {
+	table := h.games[channel]
+	if table == nil {
+		return "no game running, $start one"
+	}
+
+	parts := []string{table.GetType()}
+	if t := h.tournaments[channel]; t != nil {
+		parts[0] += " tournament, blinds " + t.CurrentLevel().String()
+	} else if _, blinded := table.(game.Blinded); blinded {
+		parts[0] += " " + h.cashStakes(channel).String()
+	}
+
+	if h.private[channel] != nil {
+		parts[0] = "private " + parts[0]
+	}
+
+	players := table.GetPlayers()
+	open := table.MaxPlayers() - len(players) - len(h.lateJoins[channel])
+	switch {
+	case open <= 0:
+		parts = append(parts, "table full")
+	case open == 1:
+		parts = append(parts, "1 seat open")
+	default:
+		parts = append(parts, fmt.Sprintf("%d seats open", open))
+	}
+
+	var leader *models.Player
+	for _, player := range players {
+		if leader == nil || player.Money > leader.Money {
+			leader = player
+		}
+	}
+	if leader != nil {
+		parts = append(parts, fmt.Sprintf("chip leader %s (%d)", leader.Nick, leader.Money))
+	}
+	return strings.Join(parts, ", ")
+}
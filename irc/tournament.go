@@ -0,0 +1,322 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+const (
+	// tournamentReportHands is how often, in hands, a tournament table is
+	// told how the tournament stands, besides at every new level.
+	tournamentReportHands = 10
+	// bubbleWarning is how many players from the money the table is first
+	// warned that the bubble is near.
+	bubbleWarning = 3
+)
+
+func (h *Handler) startTournament(channel string, t *game.Tournament) {
+	h.tournaments[channel] = t
+	if blinded, ok := h.games[channel].(game.Blinded); ok {
+		level := t.CurrentLevel()
+		blinded.SetBlinds(level.SmallBlind, level.BigBlind, level.Ante)
+	}
+
+	structure := fmt.Sprintf("Tournament: buy-in %d for %d chips, blinds %s, %s levels.", t.BuyIn, t.StartingStack, t.CurrentLevel(), t.LevelDuration)
+	if t.BreakEvery > 0 {
+		structure += fmt.Sprintf(" A %s break every %d levels.", t.BreakDuration, t.BreakEvery)
+	}
+	if t.RebuyLevels > 0 {
+		structure += fmt.Sprintf(" Rebuys (%d for %d chips) for the first %d levels, then one add-on (%d for %d chips).", t.RebuyCost, t.RebuyChips, t.RebuyLevels, t.AddOnCost, t.AddOnChips)
+	}
+	h.privmsg(channel, structure)
+}
+
+// buyIn charges the tournament buy-in to the player's bankroll and swaps the
+// bankroll for the starting stack on the in-memory player.
+func (h *Handler) buyIn(channel string, player *models.Player) bool {
+	t := h.tournaments[channel]
+	for _, table := range h.tablesOf(channel) {
+		if table != channel && h.inTournament(table, player.Nick) {
+			h.privmsg(channel, fmt.Sprintf("%s, you're already playing this tournament in %s.", player.Nick, table))
+			return false
+		}
+	}
+	if player.Money < t.BuyIn {
+		h.privmsg(channel, fmt.Sprintf("%s, the buy-in is %d and you only have %d.", player.Nick, t.BuyIn, player.Money))
+		return false
+	}
+	if err := db.RecordTransaction(player.Economy, player.Nick, -t.BuyIn, "tournament buy-in"); err != nil {
+		log.Printf("Error charging buy-in to %s: %v", player.Nick, err)
+		h.privmsg(channel, fmt.Sprintf("Error adding player %s to the game.", player.Nick))
+		return false
+	}
+
+	t.Enter(player.Nick)
+	player.Money = t.StartingStack
+	return true
+}
+
+// savePlayer persists a player after a hand. At tournament and cash tables
+// Money holds the chips bought for the table, not the bankroll, so only the
+// stats are written.
+func (h *Handler) savePlayer(channel string, player *models.Player) error {
+	if !h.stackIsBankroll(channel) {
+		return db.UpdateHandsWon(player.Economy, player.Nick, player.HandsWon)
+	}
+	return db.UpdatePlayer(player)
+}
+
+// flushPlayers writes the players saved during a hand to the database once
+// it's over.
+func (h *Handler) flushPlayers() {
+	if err := db.Flush(); err != nil {
+		log.Printf("Error writing players to the database: %v", err)
+	}
+}
+
+// startTournamentHand runs between ResetRound and the deal: it starts the
+// clock on the first hand, moves the blinds up when the clock has reached a
+// new level and hands out add-on chips bought since the last hand.
+func (h *Handler) startTournamentHand(channel string) {
+	t := h.tournaments[channel]
+	if t == nil {
+		return
+	}
+
+	if !t.Started() {
+		t.Start(time.Now())
+	} else if t.LevelUp(channel, time.Now()) {
+		h.announceLevel(channel)
+		h.reportTournament(channel)
+	} else if h.games[channel].GetHandCount()%tournamentReportHands == 0 {
+		h.reportTournament(channel)
+	}
+
+	for _, player := range h.games[channel].GetPlayers() {
+		player.Money += t.TakePendingChips(player.Nick)
+	}
+}
+
+// handleBusts removes players who lost their last chip. During the rebuy
+// period they can $rebuy to be dealt back in; at cash tables they can
+// always buy back in. At a table playing hand-for-hand, they're only
+// eliminated once the other tables have finished their hand too.
+func (h *Handler) handleBusts(channel string) {
+	if h.cashTables[channel] != nil {
+		h.settleCashSeats(channel)
+		return
+	}
+	t := h.tournaments[channel]
+	if t == nil {
+		return
+	}
+
+	game := h.games[channel]
+	startingStacks := make(map[string]int)
+	for _, player := range append([]*models.Player{}, game.GetPlayers()...) {
+		if player.Money > 0 {
+			continue
+		}
+		game.RemovePlayer(player.Nick)
+		startingStacks[player.Nick] = h.stacks[channel][player.Nick]
+	}
+	h.finishTableHand(channel, startingStacks)
+}
+
+// reportTournament tells the table how the tournament stands: the players
+// left, the places paid, the average stack and the chip leader, over all
+// its tables.
+func (h *Handler) reportTournament(channel string) {
+	t := h.tournaments[channel]
+	var players []*models.Player
+	for _, table := range h.tablesOf(channel) {
+		players = append(players, h.games[table].GetPlayers()...)
+		players = append(players, h.lateJoins[table]...)
+	}
+	if len(players) == 0 {
+		return
+	}
+	chips := 0
+	leader := players[0]
+	for _, player := range players {
+		chips += player.Money
+		if player.Money > leader.Money {
+			leader = player
+		}
+	}
+	h.privmsg(channel, fmt.Sprintf("%d of %d players left, %d paid. Average stack %d, chip leader %s with %d.",
+		t.Remaining(), len(t.Entries), t.PlacesPaid(), chips/len(players), leader.Nick, leader.Money))
+}
+
+// watchBubble warns the tables as eliminations bring the tournament close
+// to the money, and tells them when the bubble bursts. before is how many
+// players were left before the hand's eliminations. It stays quiet while
+// busted players can still rebuy, and when only the winner is paid.
+func (h *Handler) watchBubble(channel string, before int) {
+	t := h.tournaments[channel]
+	remaining, paid := t.Remaining(), t.PlacesPaid()
+	if t.RebuyOpen() || paid < 2 || remaining == before {
+		return
+	}
+	var message string
+	switch {
+	case remaining <= paid && before > paid:
+		message = fmt.Sprintf("The bubble has burst! The %d players left are in the money, with at least %d each.", remaining, t.Prizes()[remaining-1])
+	case remaining == paid+1:
+		message = fmt.Sprintf("We're on the bubble: %d players left and %d paid. The next player out wins nothing.", remaining, paid)
+		if t.HandForHand() {
+			message += " The tables play hand-for-hand."
+		}
+	case remaining > paid && remaining-paid <= bubbleWarning:
+		message = fmt.Sprintf("The bubble is near: %d players left and %d paid.", remaining, paid)
+	default:
+		return
+	}
+	for _, table := range h.tablesOf(channel) {
+		h.privmsg(table, message)
+	}
+}
+
+func (h *Handler) handleRebuy(cmd *Command) {
+	channel := cmd.Channel
+	if h.cashTables[channel] != nil {
+		h.handleJoinGame(cmd)
+		return
+	}
+	t := h.tournaments[channel]
+	if t == nil {
+		h.privmsg(channel, "There is no tournament running here.")
+		return
+	}
+	if h.closedForMaintenance(channel) || h.atBankrollTable(channel, cmd.Nick) {
+		return
+	}
+
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting player %s: %v", cmd.Nick, err)
+		return
+	}
+	if player.Money < t.RebuyCost {
+		h.privmsg(channel, fmt.Sprintf("%s, a rebuy costs %d and you only have %d.", cmd.Nick, t.RebuyCost, player.Money))
+		return
+	}
+	if err := t.CanRebuy(cmd.Nick); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v", cmd.Nick, err))
+		return
+	}
+	// The rebuy is paid for before it's counted, so a failed charge leaves
+	// the prize pool and the player's stack as they were.
+	if err := db.RecordTransaction(player.Economy, cmd.Nick, -t.RebuyCost, "tournament rebuy"); err != nil {
+		log.Printf("Error charging rebuy to %s: %v", cmd.Nick, err)
+		h.privmsg(channel, fmt.Sprintf("%s, your rebuy couldn't be charged. Try again in a moment.", cmd.Nick))
+		return
+	}
+	t.Rebuy(cmd.Nick)
+
+	player.Money = t.RebuyChips
+	h.lateJoins[channel] = append(h.lateJoins[channel], player)
+	h.privmsg(channel, fmt.Sprintf("%s rebuys for %d chips and will be dealt in next hand. Prize pool: %d", cmd.Nick, t.RebuyChips, t.PrizePool))
+	h.withdrawDeal(channel, cmd.Nick)
+	h.resumeAfterRebuy(channel)
+}
+
+func (h *Handler) handleAddOn(cmd *Command) {
+	channel := cmd.Channel
+	t := h.tournaments[channel]
+	if t == nil {
+		h.privmsg(channel, "There is no tournament running here.")
+		return
+	}
+	if h.games[channel].FindPlayer(cmd.Nick) == nil {
+		h.privmsg(channel, fmt.Sprintf("%s, you're not in the game.", cmd.Nick))
+		return
+	}
+	if h.atBankrollTable(channel, cmd.Nick) {
+		return
+	}
+
+	money, _, err := db.GetPlayerStats(h.economy(channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting stats for %s: %v", cmd.Nick, err)
+		return
+	}
+	if money < t.AddOnCost {
+		h.privmsg(channel, fmt.Sprintf("%s, the add-on costs %d and you only have %d.", cmd.Nick, t.AddOnCost, money))
+		return
+	}
+	if err := t.CanAddOn(cmd.Nick); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v", cmd.Nick, err))
+		return
+	}
+	if err := db.RecordTransaction(h.economy(channel), cmd.Nick, -t.AddOnCost, "tournament add-on"); err != nil {
+		log.Printf("Error charging add-on to %s: %v", cmd.Nick, err)
+		h.privmsg(channel, fmt.Sprintf("%s, your add-on couldn't be charged. Try again in a moment.", cmd.Nick))
+		return
+	}
+	t.AddOn(cmd.Nick)
+
+	h.privmsg(channel, fmt.Sprintf("%s takes the add-on: %d chips next hand. Prize pool: %d", cmd.Nick, t.AddOnChips, t.PrizePool))
+}
+
+// finishTournament ranks the players still seated, or waiting to be seated,
+// by stack, followed by the eliminated players in reverse order of
+// elimination, pays the prize pool into the bankrolls of the players in
+// the money and stores the final standings, which then earn series points
+// or crown the series champion. When the players still in agreed a deal
+// they are paid the chop instead.
+func (h *Handler) finishTournament(channel string) {
+	t := h.tournaments[channel]
+	remaining := append([]*models.Player{}, h.games[channel].GetPlayers()...)
+	remaining = append(remaining, h.lateJoins[channel]...)
+	for _, table := range h.tablesOf(channel) {
+		if table == channel {
+			continue
+		}
+		// A table still waiting to deal its first hand when the others
+		// finished: its players are still in.
+		remaining = append(remaining, h.games[table].GetPlayers()...)
+		remaining = append(remaining, h.lateJoins[table]...)
+		h.privmsg(table, fmt.Sprintf("The tournament is over in %s.", channel))
+		h.closeTournamentTable(table)
+	}
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Money > remaining[j].Money
+	})
+	nicks := make([]string, len(remaining))
+	for i, player := range remaining {
+		nicks[i] = player.Nick
+	}
+
+	standings := t.Standings(nicks)
+	if offer := h.deals[channel]; offer != nil && offer.amounts != nil {
+		for i, standing := range standings {
+			if amount, ok := offer.amounts[standing.Nick]; ok {
+				standings[i].Prize = amount
+			}
+		}
+	}
+
+	results := make([]string, 0, len(standings))
+	for _, standing := range standings {
+		if standing.Prize == 0 {
+			continue
+		}
+		if err := db.RecordTransaction(h.economy(channel), standing.Nick, standing.Prize, "tournament prize"); err != nil {
+			log.Printf("Error paying %d to %s: %v", standing.Prize, standing.Nick, err)
+		}
+		results = append(results, fmt.Sprintf("%d. %s (%d)", standing.Place, standing.Nick, standing.Prize))
+	}
+	if err := db.SaveStandings(channel, standings); err != nil {
+		log.Printf("Error saving tournament standings for %s: %v", channel, err)
+	}
+	h.privmsg(channel, fmt.Sprintf("Tournament over! Prize pool %d: %s", t.PrizePool, strings.Join(results, ", ")))
+	h.finishSeriesTournament(channel, standings)
+}
@@ -0,0 +1,24 @@
+package irc
+
+import "testing"
+
+func TestStartRebuyLevels(t *testing.T) {
+	h := newTestHandler(t)
+	say(t, h, "levels1", "#levels", "$start holdem --tournament --rebuy-levels 2")
+	if h.games["#levels"] != nil {
+		t.Fatal("--rebuy-levels started a tournament without rebuys")
+	}
+	say(t, h, "levels1", "#levels", "$start holdem --rebuy --rebuy-levels 11")
+	if h.games["#levels"] != nil {
+		t.Fatal("an 11-level rebuy period was allowed")
+	}
+
+	say(t, h, "levels1", "#levels", "$start holdem --rebuy --rebuy-levels 5")
+	tournament := h.tournaments["#levels"]
+	if tournament == nil {
+		t.Fatal("the rebuy tournament didn't start")
+	}
+	if tournament.RebuyLevels != 5 || tournament.BreakEvery != 5 {
+		t.Errorf("rebuys for %d levels with a break every %d, want 5 and 5", tournament.RebuyLevels, tournament.BreakEvery)
+	}
+}
@@ -0,0 +1,113 @@
+package irc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// transportScheme is the proxy scheme the bot registers with x/net's
+	// proxy package. go-ircevent takes no dialer of its own: it dials
+	// through proxy.FromEnvironment, so pointing ALL_PROXY at this scheme
+	// is the one way to choose how it connects.
+	transportScheme = "pokerbot"
+	// dialTimeout matches go-ircevent's own connect timeout.
+	dialTimeout = time.Minute
+)
+
+var (
+	registerTransport sync.Once
+	// envProxy is the ALL_PROXY the bot was started with, still used when
+	// the config names no proxy of its own.
+	envProxy string
+)
+
+// useTransport makes the next connection to the server follow the
+// config's bind_address, prefer_ipv6 and proxy settings.
+func (h *Handler) useTransport() error {
+	h.mu.Lock()
+	c := h.config
+	h.mu.Unlock()
+
+	base := &transportDialer{
+		dialer:     net.Dialer{Timeout: dialTimeout},
+		preferIPv6: c.PreferIPv6,
+	}
+	if c.BindAddress != "" {
+		base.dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(c.BindAddress)}
+	}
+
+	registerTransport.Do(func() {
+		envProxy = os.Getenv("ALL_PROXY")
+		if envProxy == "" {
+			envProxy = os.Getenv("all_proxy")
+		}
+		proxy.RegisterDialerType(transportScheme, func(*url.URL, proxy.Dialer) (proxy.Dialer, error) {
+			return h.dialer, nil
+		})
+		os.Setenv("ALL_PROXY", transportScheme+"://")
+		os.Unsetenv("all_proxy")
+	})
+
+	via := c.Proxy
+	if via == "" {
+		via = envProxy
+	}
+	if via == "" {
+		h.dialer = base
+		return nil
+	}
+	u, err := url.Parse(via)
+	if err != nil {
+		return fmt.Errorf("invalid proxy %q: %v", via, err)
+	}
+	dialer, err := proxy.FromURL(u, base)
+	if err != nil {
+		return fmt.Errorf("invalid proxy %q: %v", via, err)
+	}
+	h.dialer = dialer
+	return nil
+}
+
+// transportDialer dials from the configured local address, trying the
+// host's IPv6 addresses first if asked to. Through a proxy, it is what
+// reaches the proxy, and the proxy resolves the IRC server's name.
+type transportDialer struct {
+	dialer     net.Dialer
+	preferIPv6 bool
+}
+
+func (d *transportDialer) Dial(network, addr string) (net.Conn, error) {
+	if !d.preferIPv6 {
+		return d.dialer.Dial(network, addr)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(ips, func(i, j int) bool {
+		return ips[i].IP.To4() == nil && ips[j].IP.To4() != nil
+	})
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = d.dialer.Dial(network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
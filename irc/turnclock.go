@@ -0,0 +1,139 @@
+package irc
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// turnWarning is how long before their turn times out the player to act is
+// warned, once, by notice. Turns no longer than that get no warning.
+const turnWarning = 10 * time.Second
+
+// turnClock times the turns at one table from a goroutine of its own. Its
+// fields are guarded by the handler's mutex. Every turn started, restarted
+// or paused moves the generation on, and a timeout is only acted on if its
+// generation is still current once the handler's mutex is held, so a
+// timeout that fires as the player acts, or after the game is over, does
+// nothing.
+type turnClock struct {
+	generation uint64
+	deadline   time.Time // zero while nobody is on the clock
+	wake       chan struct{}
+	stop       context.CancelFunc
+}
+
+// startTurnClock puts the player whose turn it is at channel on the clock,
+// unless the bot is reclaiming its nick, which pauses every clock for a
+// moment.
+func (h *Handler) startTurnClock(channel string) {
+	clock := h.turnClocks[channel]
+	if clock == nil {
+		ctx, stop := context.WithCancel(h.tableContext(channel))
+		clock = &turnClock{wake: make(chan struct{}, 1), stop: stop}
+		h.turnClocks[channel] = clock
+		go h.runTurnClock(ctx, channel, clock)
+	}
+	clock.generation++
+	clock.deadline = time.Now().Add(h.turnTimeout(channel, h.currentTurn[channel]))
+	if h.reclaiming {
+		clock.deadline = time.Time{}
+	}
+	clock.signal()
+}
+
+// restartTurnClock gives the player on the clock their full time again.
+func (h *Handler) restartTurnClock(channel string) {
+	if clock := h.turnClocks[channel]; clock != nil && !clock.deadline.IsZero() {
+		h.startTurnClock(channel)
+	}
+}
+
+// pauseTurnClock takes everyone off the clock until the next turn starts.
+func (h *Handler) pauseTurnClock(channel string) {
+	if clock := h.turnClocks[channel]; clock != nil {
+		clock.generation++
+		clock.deadline = time.Time{}
+		clock.signal()
+	}
+}
+
+// stopTurnClock ends the clock's goroutine when the table closes.
+func (h *Handler) stopTurnClock(channel string) {
+	if clock := h.turnClocks[channel]; clock != nil {
+		clock.generation++
+		clock.stop()
+		delete(h.turnClocks, channel)
+	}
+}
+
+// signal tells the clock's goroutine its deadline changed. It never
+// blocks, as it's called with the handler's mutex held, which the
+// goroutine may be waiting for.
+func (c *turnClock) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (h *Handler) runTurnClock(ctx context.Context, channel string, clock *turnClock) {
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+	warn := time.NewTimer(time.Hour)
+	warn.Stop()
+	defer warn.Stop()
+	var armed uint64 // the generation the timers are running for
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.wake:
+		case <-timer.C:
+			h.mu.Lock()
+			if clock.generation == armed && ctx.Err() == nil {
+				clock.deadline = time.Time{}
+				h.handleTimeout(channel)
+			}
+			h.mu.Unlock()
+		case <-warn.C:
+			h.mu.Lock()
+			if clock.generation == armed && ctx.Err() == nil {
+				h.warnTurn(channel)
+			}
+			h.mu.Unlock()
+		}
+
+		h.mu.Lock()
+		generation, deadline := clock.generation, clock.deadline
+		h.mu.Unlock()
+		stopTimer(timer)
+		stopTimer(warn)
+		if !deadline.IsZero() {
+			armed = generation
+			timer.Reset(time.Until(deadline))
+			if until := time.Until(deadline.Add(-turnWarning)); until > 0 {
+				warn.Reset(until)
+			}
+		}
+	}
+}
+
+// stopTimer stops timer and drains its channel if it had already fired.
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+// warnTurn tells the player to act at channel their time is nearly up.
+func (h *Handler) warnTurn(channel string) {
+	if nick := h.currentTurn[channel]; nick != "" {
+		h.notice(nick, fmt.Sprintf("%d seconds left to act at %s.", int(turnWarning/time.Second), channel))
+	}
+}
@@ -0,0 +1,83 @@
+package irc
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"poker-bot/db"
+	"poker-bot/modes"
+)
+
+// SetPaytable replaces the video poker paytable.
+func (h *Handler) SetPaytable(paytable modes.Paytable) {
+	h.paytable = paytable
+}
+
+func (h *Handler) handleVideoPoker(cmd *Command) {
+	channel := cmd.Channel
+	if len(cmd.Args) < 1 {
+		h.privmsg(channel, "Usage: $vp <bet>, then $hold <positions> to keep those cards and draw the rest")
+		return
+	}
+	if !h.sideGameOpen(channel, cmd.Nick) {
+		return
+	}
+
+	bet, err := strconv.Atoi(cmd.Args[0])
+	if err != nil || bet <= 0 {
+		h.privmsg(channel, "Invalid bet amount.")
+		return
+	}
+	player, err := db.GetOrCreatePlayer(h.ctx, h.economy(channel), cmd.Nick)
+	if err != nil {
+		log.Printf("Error getting player %s: %v", cmd.Nick, err)
+		return
+	}
+	if player.Money < bet {
+		h.privmsg(channel, fmt.Sprintf("%s, you only have %d.", cmd.Nick, player.Money))
+		return
+	}
+	if err := db.SettleWithHouse(player.Economy, cmd.Nick, -bet, "video poker bet"); err != nil {
+		log.Printf("Error taking video poker bet from %s: %v", cmd.Nick, err)
+		return
+	}
+
+	hand := modes.NewVideoPoker(cmd.Nick, bet)
+	hand.Economy = player.Economy
+	h.videoPoker[cmd.Nick] = hand
+	h.privmsg(channel, fmt.Sprintf("%s: %v. $hold the positions to keep (e.g. $hold 1 3), or just $hold to draw five.", cmd.Nick, hand.Hand))
+}
+
+func (h *Handler) handleHold(cmd *Command) {
+	channel := cmd.Channel
+	hand := h.videoPoker[cmd.Nick]
+	if hand == nil {
+		return
+	}
+
+	held := []int{}
+	for _, field := range cmd.Args {
+		position, err := strconv.Atoi(field)
+		if err != nil {
+			h.privmsg(channel, "Usage: $hold <positions>, e.g. $hold 1 3 5")
+			return
+		}
+		held = append(held, position-1)
+	}
+	if err := hand.Draw(held); err != nil {
+		h.privmsg(channel, fmt.Sprintf("%s, %v.", cmd.Nick, err))
+		return
+	}
+
+	delete(h.videoPoker, cmd.Nick)
+	payout := hand.Payout(h.paytable)
+	if payout > 0 {
+		if err := db.SettleWithHouse(hand.Economy, cmd.Nick, payout, "video poker payout"); err != nil {
+			log.Printf("Error paying video poker win to %s: %v", cmd.Nick, err)
+		}
+		h.privmsg(channel, fmt.Sprintf("%s: %v, %s! Pays %d.", cmd.Nick, hand.Hand, hand.Result(), payout))
+		return
+	}
+	h.privmsg(channel, fmt.Sprintf("%s: %v, %s. No win.", cmd.Nick, hand.Hand, hand.Result()))
+}
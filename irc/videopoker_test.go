@@ -0,0 +1,47 @@
+package irc
+
+import (
+	"context"
+	"testing"
+
+	"poker-bot/db"
+	"poker-bot/game"
+)
+
+func TestVideoPokerLedger(t *testing.T) {
+	h := newTestHandler(t)
+	economy := h.economy("#videopoker")
+	vp, err := db.GetOrCreatePlayer(context.Background(), economy, "vp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, house := ledgerTotal(t, "vp", economy, "video poker"), ledgerTotal(t, db.HouseNick, economy, "video poker")
+
+	say(t, h, "vp", "#videopoker", "$vp 5")
+	hand := h.videoPoker["vp"]
+	if hand == nil {
+		t.Fatal("no hand was dealt")
+	}
+	if got := ledgerTotal(t, "vp", economy, "video poker") - before; got != -5 {
+		t.Fatalf("the bet took %d from vp's ledger, want -5", got)
+	}
+	hand.Deck = game.NewStackedDealer(game.MustParseCards("KC KD KH"))
+	hand.Deck.Shuffle()
+	hand.Hand = game.MustParseCards("4S 9D 4C 2H 7S")
+	say(t, h, "vp", "#videopoker", "$hold 1 3")
+
+	if h.videoPoker["vp"] != nil {
+		t.Fatal("the hand is still in play after the draw")
+	}
+	// Kings full of fours pays 9 for 1, the stake included.
+	won := -5 + 45
+	if got := ledgerTotal(t, "vp", economy, "video poker") - before; got != won {
+		t.Errorf("vp's ledger moved by %d, want %d", got, won)
+	}
+	if got := ledgerTotal(t, db.HouseNick, economy, "video poker") - house; got != -won {
+		t.Errorf("the house's ledger moved by %d, want %d", got, -won)
+	}
+	if got := savedMoney(t, vp); got != vp.Money+won {
+		t.Errorf("vp has %d, want %d", got, vp.Money+won)
+	}
+}
@@ -0,0 +1,47 @@
+package irc
+
+import (
+	"fmt"
+	"strings"
+)
+
+func (h *Handler) addToWaitlist(channel, nick, reason string) {
+	for i, waiting := range h.waitlists[channel] {
+		if waiting == nick {
+			h.privmsg(channel, fmt.Sprintf("%s, you're already #%d on the waitlist.", nick, i+1))
+			return
+		}
+	}
+
+	h.waitlists[channel] = append(h.waitlists[channel], nick)
+	h.privmsg(channel, fmt.Sprintf("%s %s, you're #%d on the waitlist for the next game.", reason, nick, len(h.waitlists[channel])))
+}
+
+// startWaitlistGame opens a new game of the same type once a game ends and
+// seats everyone who was waiting, in the order they asked to join.
+func (h *Handler) startWaitlistGame(channel, gameType string) {
+	waiting := h.waitlists[channel]
+	delete(h.waitlists, channel)
+	if len(waiting) == 0 || h.closedForMaintenance(channel) {
+		return
+	}
+
+	game := newGame(gameType, channel)
+	h.games[channel] = game
+	h.currentTurn[channel] = ""
+	h.privmsg(channel, fmt.Sprintf("Starting a new game of %s for the waitlist: %s", gameType, strings.Join(waiting, ", ")))
+
+	for _, nick := range waiting {
+		if len(game.GetPlayers()) >= game.MaxPlayers() {
+			h.waitlists[channel] = append(h.waitlists[channel], nick)
+			continue
+		}
+		h.seatPlayer(channel, nick)
+	}
+
+	if len(game.GetPlayers()) >= h.minPlayers(channel) {
+		h.startRound(channel)
+		return
+	}
+	h.watchLobby(channel)
+}
@@ -0,0 +1,137 @@
+package irc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"poker-bot/db"
+)
+
+// handleWeb sends the player a token for the web table view, which shows
+// them their own hole cards. A new token replaces the old one.
+func (h *Handler) handleWeb(cmd *Command) {
+	token, err := newWebToken()
+	if err != nil {
+		h.privmsg(cmd.Channel, "Error creating a web token.")
+		return
+	}
+	for old, nick := range h.webTokens {
+		if nick == cmd.Nick {
+			delete(h.webTokens, old)
+		}
+	}
+	h.webTokens[token] = cmd.Nick
+	h.notice(cmd.Nick, fmt.Sprintf("Your web token is %s. Keep it to yourself: it shows your hole cards.", token))
+}
+
+// WebViewer returns the nick a web token was issued to, either by $web or
+// as a linked account's token.
+func (h *Handler) WebViewer(token string) (string, bool) {
+	h.mu.Lock()
+	nick, ok := h.webTokens[token]
+	h.mu.Unlock()
+	if ok {
+		return nick, true
+	}
+
+	nick, err := db.AccountNick(token)
+	if err != nil {
+		log.Printf("Error looking up web account: %v", err)
+	}
+	return nick, nick != ""
+}
+
+// linkCodeLifetime is how long a $link code can be redeemed for.
+const linkCodeLifetime = 10 * time.Minute
+
+type linkCode struct {
+	nick    string
+	expires time.Time
+}
+
+// handleLink sends the player a one-time code to link their nick to a web
+// account on the dashboard.
+func (h *Handler) handleLink(cmd *Command) {
+	raw := make([]byte, 4)
+	if _, err := rand.Read(raw); err != nil {
+		h.privmsg(cmd.Channel, "Error creating a link code.")
+		return
+	}
+	code := strings.ToUpper(hex.EncodeToString(raw))
+
+	for old, link := range h.linkCodes {
+		if link.nick == cmd.Nick || time.Now().After(link.expires) {
+			delete(h.linkCodes, old)
+		}
+	}
+	h.linkCodes[code] = linkCode{nick: cmd.Nick, expires: time.Now().Add(linkCodeLifetime)}
+	h.notice(cmd.Nick, fmt.Sprintf("Your link code is %s. Enter it on the web dashboard within %s.", code, linkCodeLifetime))
+}
+
+// RedeemLinkCode links the nick a $link code was sent to with a web account
+// and returns the account's token. Each code works once.
+func (h *Handler) RedeemLinkCode(code string) (nick, token string, err error) {
+	h.mu.Lock()
+	link, ok := h.linkCodes[strings.ToUpper(code)]
+	delete(h.linkCodes, strings.ToUpper(code))
+	h.mu.Unlock()
+	if !ok || time.Now().After(link.expires) {
+		return "", "", fmt.Errorf("unknown or expired link code")
+	}
+
+	token, err = db.LinkAccount(link.nick)
+	if err != nil {
+		return "", "", err
+	}
+	return link.nick, token, nil
+}
+
+func newWebToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// webActions are the commands a player can send from the web table view,
+// mapped to the IRC command each one runs.
+var webActions = map[string]string{
+	"bet":     "$bet",
+	"call":    "$call",
+	"raise":   "$raise",
+	"fold":    "$fold",
+	"check":   "$check",
+	"draw":    "$draw",
+	"stand":   "$stand",
+	"confirm": "$confirm",
+}
+
+// Act takes an action for nick at channel from outside IRC. It runs exactly
+// as if nick had typed the command in the channel, so everyone at the table
+// sees it there and on the web.
+func (h *Handler) Act(channel, nick, action string, args ...string) error {
+	command, ok := webActions[action]
+	if !ok {
+		return fmt.Errorf("unknown action %q", action)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.currentTurn[channel] != nick {
+		return fmt.Errorf("it's not your turn")
+	}
+
+	h.dispatch(&Command{
+		Name:    command,
+		Args:    args,
+		Text:    strings.Join(args, " "),
+		Channel: channel,
+		Nick:    nick,
+	})
+	return nil
+}
@@ -1,23 +1,104 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 
+	"poker-bot/backup"
+	"poker-bot/config"
 	"poker-bot/db"
+	"poker-bot/flavor"
 	"poker-bot/irc"
+	"poker-bot/modes"
+	"poker-bot/web"
 )
 
 func main() {
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
+	economy := flag.String("economy", irc.EconomyShared, "how bankrolls are separated: shared, network or channel")
+	backupDir := flag.String("backup-dir", "backups", "directory for scheduled database snapshots")
+	backupInterval := flag.Duration("backup-interval", 0, "how often to snapshot the database, 0 to disable; snapshots go to the POKER_S3_* bucket too if one is set")
+	backupKeep := flag.Int("backup-keep", 48, "how many scheduled snapshots to keep in -backup-dir, 0 to keep them all")
+	configPath := flag.String("config", "config.json", "settings file, reloaded on SIGHUP")
+	flavorDir := flag.String("flavor", "flavor-packs", "directory of table talk packs, reloaded on SIGHUP")
+	httpAddr := flag.String("http", "", "address to serve the WebSocket feeds on, such as :8080; empty to disable")
+	control := flag.String("control", "poker.sock", "Unix socket for pokerctl, empty to disable")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db.SetContext(ctx)
 	err := db.Initialize("poker.db")
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
+	if returned, err := db.ReturnCashStacks(); err != nil {
+		log.Fatalf("Failed to return cash game stacks: %v", err)
+	} else if returned > 0 {
+		log.Printf("Returned %d cash game stacks left from the last run to their bankrolls", returned)
+	}
+
+	if *backupInterval > 0 {
+		backup.Schedule(*backupInterval, *backupDir, *backupKeep, backup.S3ConfigFromEnv())
+	}
 
 	ircHandler := irc.NewHandler()
+	if err := ircHandler.SetEconomy(*economy); err != nil {
+		log.Fatalf("Invalid -economy: %v", err)
+	}
+	reload := func() error {
+		s, err := loadSettings(*configPath, *flavorDir)
+		if err != nil {
+			return err
+		}
+		ircHandler.SetConfig(s.config)
+		ircHandler.SetPaytable(s.paytable)
+		ircHandler.SetFlavor(s.flavor)
+		return nil
+	}
+	if err := reload(); err != nil {
+		log.Fatalf("Failed to load settings: %v", err)
+	}
+	ircHandler.SetReloader(reload)
+	go ircHandler.RunDigests()
+	go ircHandler.RunSeasons()
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	go func() {
+		<-ctx.Done()
+		ircHandler.Shutdown()
+		if err := db.Close(); err != nil {
+			log.Printf("Failed to write cached players: %v", err)
+		}
+		os.Exit(0)
+	}()
+	go func() {
+		for range hangups {
+			if err := ircHandler.Reload(); err != nil {
+				log.Printf("Reload failed, keeping the old settings: %v", err)
+				continue
+			}
+			log.Println("Reloaded settings")
+		}
+	}()
+	if *control != "" {
+		if err := ircHandler.ServeControl(*control); err != nil {
+			log.Fatalf("Failed to open control socket: %v", err)
+		}
+	}
+	if *httpAddr != "" {
+		server := web.NewServer(ircHandler)
+		go func() {
+			log.Fatalf("Web server failed: %v", server.ListenAndServe(*httpAddr))
+		}()
+	}
 	err = ircHandler.Connect("irc.supernets.org:6697", "PokerBot")
 	if err != nil {
 		log.Fatalf("Failed to connect to IRC: %v", err)
@@ -25,3 +106,29 @@ func main() {
 
 	ircHandler.Run()
 }
+
+// settings is everything reloaded on SIGHUP.
+type settings struct {
+	config   config.Config
+	paytable modes.Paytable
+	flavor   *flavor.Engine
+}
+
+// loadSettings reads the config file at configPath, paytable.json and the
+// flavor packs in flavorDir. Missing files mean the defaults.
+func loadSettings(configPath, flavorDir string) (*settings, error) {
+	s := &settings{}
+	var err error
+	if s.config, err = config.Load(configPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if s.paytable, err = modes.LoadPaytable("paytable.json"); os.IsNotExist(err) {
+		s.paytable = modes.DefaultPaytable
+	} else if err != nil {
+		return nil, err
+	}
+	if s.flavor, err = flavor.Load(flavorDir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -4,13 +4,17 @@ import "time"
 
 type Player struct {
 	Nick     string
+	Economy  string // the economy the bankroll belongs to
 	Money    int
 	HandsWon int
 	Hand     []Card
 	Bet      int
 	Folded   bool
-	Cheating bool
+	Acted    bool
 	LastSeen time.Time
+
+	// Cheats is how many times the player has tried to $cheat this hand.
+	Cheats int
 }
 
 func NewPlayer(nick string, money int, handsWon int) *Player {
@@ -21,7 +25,6 @@ func NewPlayer(nick string, money int, handsWon int) *Player {
 		Hand:     make([]Card, 0),
 		Bet:      0,
 		Folded:   false,
-		Cheating: false,
 		LastSeen: time.Now(),
 	}
 }
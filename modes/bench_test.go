@@ -0,0 +1,105 @@
+package modes
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// deals returns n random hold'em players with their hole cards, and a board
+// for each.
+func deals(n int) ([]*models.Player, [][]models.Card) {
+	rng := rand.New(rand.NewSource(1))
+	players := make([]*models.Player, n)
+	boards := make([][]models.Card, n)
+	for i := range players {
+		deck := game.GenerateDeck()
+		rng.Shuffle(len(deck), func(a, b int) {
+			deck[a], deck[b] = deck[b], deck[a]
+		})
+		players[i] = models.NewPlayer(fmt.Sprintf("p%d", i), 1000, 0)
+		players[i].Hand = deck[:2]
+		boards[i] = deck[2:7]
+	}
+	return players, boards
+}
+
+// BenchmarkEval7 scores a hold'em hand from seven cards.
+func BenchmarkEval7(b *testing.B) {
+	table := NewHoldem("#bench").(*Holdem)
+	players, boards := deals(1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.River = boards[i%len(boards)]
+		table.DescribeHand(players[i%len(players)])
+	}
+}
+
+// BenchmarkMonteCarloEquity works out a nine-way all-in before the flop by
+// sampling boards.
+func BenchmarkMonteCarloEquity(b *testing.B) {
+	table := NewHoldem("#bench").(*Holdem)
+	deck := game.GenerateDeck()
+	rand.New(rand.NewSource(1)).Shuffle(len(deck), func(a, b int) {
+		deck[a], deck[b] = deck[b], deck[a]
+	})
+	table.Dealer = game.NewStackedDealer(deck)
+	table.Dealer.Shuffle()
+	for seat := 0; seat < 9; seat++ {
+		player := models.NewPlayer(fmt.Sprintf("p%d", seat), 1000, 0)
+		player.Hand = table.Dealer.Draw(2)
+		table.Players = append(table.Players, player)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Equity()
+	}
+}
+
+// newFullHand is a four-player hold'em hand that sees every street, with a
+// bet on the flop and one player folding to it.
+func newFullHand() (game.Scriptable, game.Script) {
+	return NewHoldem("#bench").(game.Scriptable), game.Script{
+		Seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}, {Nick: "cat", Money: 1000}, {Nick: "dan", Money: 1000}},
+		Deck:  game.StackDeck(game.MustParseCards("AS KD 7C 2H AH KC 8D 3S QS 9H 4C JD 10S")),
+		Actions: []game.Action{
+			{Nick: "ann", Kind: "call"},
+			{Nick: "bob", Kind: "call"},
+			{Nick: "cat", Kind: "call"},
+			{Nick: "dan", Kind: "check"},
+			{Kind: "street"},
+			{Nick: "cat", Kind: "bet", Amount: 20},
+			{Nick: "dan", Kind: "fold"},
+			{Nick: "ann", Kind: "call"},
+			{Nick: "bob", Kind: "call"},
+			{Kind: "street"},
+			{Nick: "cat", Kind: "check"},
+			{Nick: "ann", Kind: "check"},
+			{Nick: "bob", Kind: "check"},
+			{Kind: "street"},
+			{Nick: "cat", Kind: "check"},
+			{Nick: "ann", Kind: "check"},
+			{Nick: "bob", Kind: "check"},
+		},
+	}
+}
+
+// BenchmarkFullHand plays a scripted four-player hand from the deal to the
+// showdown.
+func BenchmarkFullHand(b *testing.B) {
+	if _, err := game.Run(newFullHand()); err != nil {
+		b.Fatalf("the script no longer plays: %v", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := game.Run(newFullHand()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
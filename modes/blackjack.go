@@ -0,0 +1,120 @@
+package modes
+
+import (
+	"errors"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// Blackjack is a single hand of blackjack against the house, dealt from a
+// fresh shuffled deck. The dealer stands on all 17s and a natural pays 3:2.
+type Blackjack struct {
+	Nick    string
+	Economy string // the economy the bet was taken from
+	Bet     int
+	Deck    game.Dealer
+	Player  []models.Card
+	Dealer  []models.Card
+	Done    bool
+}
+
+func NewBlackjack(nick string, bet int) *Blackjack {
+	deck := game.NewDealer()
+	deck.Shuffle()
+	b := &Blackjack{Nick: nick, Bet: bet, Deck: deck}
+	b.Player = append(b.Player, b.draw(), b.draw())
+	b.Dealer = append(b.Dealer, b.draw(), b.draw())
+	if IsBlackjack(b.Player) || IsBlackjack(b.Dealer) {
+		b.Done = true
+	}
+	return b
+}
+
+func (b *Blackjack) draw() models.Card {
+	return b.Deck.Draw(1)[0]
+}
+
+func (b *Blackjack) Hit() error {
+	if b.Done {
+		return errors.New("the hand is over")
+	}
+	b.Player = append(b.Player, b.draw())
+	if BlackjackTotal(b.Player) >= 21 {
+		b.Stand()
+	}
+	return nil
+}
+
+// Double doubles the bet for exactly one more card. The caller collects the
+// extra stake.
+func (b *Blackjack) Double() error {
+	if b.Done {
+		return errors.New("the hand is over")
+	}
+	if len(b.Player) != 2 {
+		return errors.New("you can only double on your first two cards")
+	}
+	b.Bet *= 2
+	b.Player = append(b.Player, b.draw())
+	b.Stand()
+	return nil
+}
+
+// Stand ends the player's turn and plays out the dealer's hand.
+func (b *Blackjack) Stand() {
+	if b.Done {
+		return
+	}
+	b.Done = true
+	if BlackjackTotal(b.Player) > 21 {
+		return
+	}
+	for BlackjackTotal(b.Dealer) < 17 {
+		b.Dealer = append(b.Dealer, b.draw())
+	}
+}
+
+// Payout is what the house pays back once the hand is over, stake included:
+// 0 for a loss, the bet for a push, twice the bet for a win and 2.5 times
+// the bet for a natural.
+func (b *Blackjack) Payout() int {
+	player, dealer := BlackjackTotal(b.Player), BlackjackTotal(b.Dealer)
+	switch {
+	case IsBlackjack(b.Player) && IsBlackjack(b.Dealer):
+		return b.Bet
+	case IsBlackjack(b.Player):
+		return b.Bet + b.Bet*3/2
+	case IsBlackjack(b.Dealer), player > 21:
+		return 0
+	case dealer > 21, player > dealer:
+		return b.Bet * 2
+	case player == dealer:
+		return b.Bet
+	}
+	return 0
+}
+
+// BlackjackTotal counts aces as 11 unless that would bust the hand.
+func BlackjackTotal(cards []models.Card) int {
+	total, aces := 0, 0
+	for _, card := range cards {
+		switch card.Value {
+		case "A":
+			total += 11
+			aces++
+		case "K", "Q", "J", "10":
+			total += 10
+		default:
+			total += int(card.Value[0] - '0')
+		}
+	}
+	for total > 21 && aces > 0 {
+		total -= 10
+		aces--
+	}
+	return total
+}
+
+func IsBlackjack(cards []models.Card) bool {
+	return len(cards) == 2 && BlackjackTotal(cards) == 21
+}
@@ -0,0 +1,83 @@
+package modes
+
+import (
+	"testing"
+
+	"poker-bot/game"
+)
+
+// TestBlackjackPayout plays hands from fixed cards, with deck as the cards
+// drawn after the deal, and checks what the house pays back on a bet of
+// 100. The player hits hits times, then doubles or stands.
+func TestBlackjackPayout(t *testing.T) {
+	tests := []struct {
+		name   string
+		player string
+		dealer string
+		deck   string
+		hits   int
+		double bool
+		payout int
+	}{
+		{name: "a natural pays 3:2", player: "AS KD", dealer: "9C 8H", payout: 250},
+		{name: "naturals on both sides push", player: "AS KD", dealer: "AH QC", payout: 100},
+		{name: "the dealer's natural beats 20", player: "10S QD", dealer: "AH QC", payout: 0},
+		{name: "a win pays even money", player: "10S 9D", dealer: "10C 7H", payout: 200},
+		{name: "a tie pushes", player: "10S 8D", dealer: "10C 8H", payout: 100},
+		{name: "a lower total loses", player: "10S 7D", dealer: "10C 8H", payout: 0},
+		{name: "a bust loses before the dealer draws", player: "10S 6D", dealer: "10C 6H", deck: "KS KH", hits: 1, payout: 0},
+		{name: "the dealer busting pays", player: "10S 6D", dealer: "10C 6H", deck: "KH", payout: 200},
+		{name: "the dealer stands on a soft 17", player: "10S 8D", dealer: "AC 6H", deck: "KH", payout: 200},
+		{name: "21 on three cards beats 20", player: "5S 6D", dealer: "10C QH", deck: "10H", hits: 1, payout: 200},
+		{name: "a double pays on twice the bet", player: "5S 6D", dealer: "10C 7H", deck: "10H", double: true, payout: 400},
+		{name: "a double can lose twice the bet", player: "5S 6D", dealer: "10C 7H", deck: "2H", double: true, payout: 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := &Blackjack{
+				Nick:   "ann",
+				Bet:    100,
+				Deck:   game.NewStackedDealer(game.MustParseCards(test.deck)),
+				Player: game.MustParseCards(test.player),
+				Dealer: game.MustParseCards(test.dealer),
+			}
+			b.Deck.Shuffle()
+			if IsBlackjack(b.Player) || IsBlackjack(b.Dealer) {
+				b.Done = true
+			}
+			for i := 0; i < test.hits; i++ {
+				if err := b.Hit(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.double {
+				if err := b.Double(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b.Stand()
+			if got := b.Payout(); got != test.payout {
+				t.Errorf("Payout() = %d with %v against %v, want %d", got, b.Player, b.Dealer, test.payout)
+			}
+		})
+	}
+}
+
+func TestBlackjackDoubleOnlyOnTwoCards(t *testing.T) {
+	b := &Blackjack{
+		Bet:    100,
+		Deck:   game.NewStackedDealer(game.MustParseCards("2H 3H")),
+		Player: game.MustParseCards("5S 4D"),
+		Dealer: game.MustParseCards("10C 7H"),
+	}
+	b.Deck.Shuffle()
+	if err := b.Hit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Double(); err == nil {
+		t.Error("doubled on three cards")
+	}
+	if b.Bet != 100 {
+		t.Errorf("the bet is %d after a refused double, want 100", b.Bet)
+	}
+}
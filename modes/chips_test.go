@@ -0,0 +1,57 @@
+package modes
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"poker-bot/game"
+)
+
+// TestRandomHandsConserveChips plays random hands of every poker variant,
+// with random stacks and random actions, and checks that the ChipAudit
+// holds after every action and that the stacks add up to what was brought
+// to the table once the pot is awarded.
+func TestRandomHandsConserveChips(t *testing.T) {
+	hands := 500
+	if testing.Short() {
+		hands = 50
+	}
+	variants := []struct {
+		name string
+		new  func(string) game.Game
+	}{
+		{"holdem", NewHoldem},
+		{"omaha", NewOmaha},
+		{"five card draw", NewFiveCardDraw},
+	}
+	for _, variant := range variants {
+		t.Run(variant.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			for hand := 0; hand < hands; hand++ {
+				seats := make([]game.Seat, 2+rng.Intn(5))
+				total := 0
+				for i := range seats {
+					// Some stacks can't cover the blinds or the ante.
+					seats[i] = game.Seat{Nick: fmt.Sprintf("p%d", i), Money: 1 + rng.Intn(300)}
+					total += seats[i].Money
+				}
+				table := variant.new("#test").(game.Scriptable)
+				script, result, err := game.Random(table, seats, rng)
+				if err != nil {
+					t.Fatalf("hand %d: %v\n%+v", hand, err, script)
+				}
+				after := 0
+				for nick, stack := range result.Stacks {
+					if stack < 0 {
+						t.Fatalf("hand %d: %s has %d chips\n%+v", hand, nick, stack, script)
+					}
+					after += stack
+				}
+				if after != total {
+					t.Fatalf("hand %d: stacks add up to %d after the hand, %d before\n%+v", hand, after, total, script)
+				}
+			}
+		})
+	}
+}
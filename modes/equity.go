@@ -0,0 +1,204 @@
+package modes
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"poker-bot/models"
+)
+
+// equitySamples is how many random boards are dealt to estimate equity when
+// more than two board cards are still to come. With two or fewer every
+// board is dealt.
+const equitySamples = 5000
+
+// equityWorkers is how many goroutines share the boards of an equity run,
+// or 0 for one per CPU.
+var equityWorkers atomic.Int64
+
+// SetEquityWorkers sets how many goroutines share the boards dealt to work
+// out all-in equity. 0 uses one per CPU.
+func SetEquityWorkers(n int) {
+	equityWorkers.Store(int64(n))
+}
+
+func workerCount() int {
+	if n := int(equityWorkers.Load()); n > 0 {
+		return n
+	}
+	return runtime.NumCPU()
+}
+
+// boardEquity returns each live player's share of the pot if the board were
+// completed from deck: the fraction of boards they win, with ties split.
+// The boards are shared out between workerCount goroutines.
+func boardEquity(players []*models.Player, board, deck []models.Card, evaluate func(hole, board []models.Card) Hand) map[string]float64 {
+	live := make([]*models.Player, 0, len(players))
+	for _, player := range players {
+		if !player.Folded && len(player.Hand) > 0 {
+			live = append(live, player)
+		}
+	}
+	equity := make(map[string]float64, len(live))
+	missing := 5 - len(board)
+	if len(live) == 0 || missing < 0 || missing > len(deck) {
+		return equity
+	}
+
+	workers := workerCount()
+	tallies := make([]*equityTally, workers)
+	var wg sync.WaitGroup
+	for worker := range tallies {
+		tally := &equityTally{
+			live:     live,
+			evaluate: evaluate,
+			full:     append(make([]models.Card, 0, 5), board...),
+			wins:     make([]float64, len(live)),
+			winners:  make([]int, 0, len(live)),
+		}
+		tallies[worker] = tally
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if missing <= 2 {
+				tally.enumerate(deck, missing, worker, workers)
+				return
+			}
+			samples := equitySamples / workers
+			if worker < equitySamples%workers {
+				samples++
+			}
+			tally.sample(deck, missing, samples, rand.New(rand.NewSource(rand.Int63())))
+		}(worker)
+	}
+	wg.Wait()
+
+	boards := 0
+	wins := make([]float64, len(live))
+	for _, tally := range tallies {
+		boards += tally.boards
+		for i, won := range tally.wins {
+			wins[i] += won
+		}
+	}
+	for i, player := range live {
+		if wins[i] > 0 {
+			equity[player.Nick] = wins[i] / float64(boards)
+		}
+	}
+	return equity
+}
+
+// equityTally is one worker's share of an equity run. Scoring a board
+// allocates nothing.
+type equityTally struct {
+	live     []*models.Player
+	evaluate func(hole, board []models.Card) Hand
+	full     []models.Card // the board dealt so far, then the board scored
+	wins     []float64     // pots won by each live player
+	winners  []int
+	boards   int
+}
+
+func (t *equityTally) score() {
+	t.boards++
+	var best Hand
+	t.winners = t.winners[:0]
+	for i, player := range t.live {
+		hand := t.evaluate(player.Hand, t.full)
+		switch {
+		case len(t.winners) == 0 || hand.beats(best):
+			best = hand
+			t.winners = append(t.winners[:0], i)
+		case !best.beats(hand):
+			t.winners = append(t.winners, i)
+		}
+	}
+	for _, i := range t.winners {
+		t.wins[i] += 1 / float64(len(t.winners))
+	}
+}
+
+// enumerate scores every board whose first card to come sits at a deck
+// index the worker owns: worker, worker+workers, and so on.
+func (t *equityTally) enumerate(deck []models.Card, missing, worker, workers int) {
+	if missing == 0 {
+		if worker == 0 {
+			t.score()
+		}
+		return
+	}
+	var deal func(start, left int)
+	deal = func(start, left int) {
+		if left == 0 {
+			t.score()
+			return
+		}
+		for i := start; i <= len(deck)-left; i++ {
+			t.full = append(t.full, deck[i])
+			deal(i+1, left-1)
+			t.full = t.full[:len(t.full)-1]
+		}
+	}
+	for i := worker; i <= len(deck)-missing; i += workers {
+		t.full = append(t.full, deck[i])
+		deal(i+1, missing-1)
+		t.full = t.full[:len(t.full)-1]
+	}
+}
+
+// sample scores random boards, each completed from its own shuffle of the
+// deck's first cards.
+func (t *equityTally) sample(deck []models.Card, missing, samples int, rng *rand.Rand) {
+	dealt := len(t.full)
+	rest := append([]models.Card{}, deck...)
+	for i := 0; i < samples; i++ {
+		for j := 0; j < missing; j++ {
+			k := j + rng.Intn(len(rest)-j)
+			rest[j], rest[k] = rest[k], rest[j]
+		}
+		t.full = append(t.full[:dealt], rest[:missing]...)
+		t.score()
+	}
+}
+
+// Equity returns each live player's chance of winning the pot from the
+// cards dealt so far.
+func (h *Holdem) Equity() map[string]float64 {
+	return boardEquity(h.Players, h.River, h.Dealer.Remaining(), evaluateHoldemHand)
+}
+
+func (o *Omaha) Equity() map[string]float64 {
+	return boardEquity(o.Players, o.River, o.Dealer.Remaining(), evaluateOmahaHand)
+}
+
+// bestNextCard returns the card in deck that gives nick the best chance of
+// winning if it comes next on the board, by their equity with it there.
+// Ties go to the card nearer the top.
+func bestNextCard(players []*models.Player, board, deck []models.Card, evaluate func(hole, board []models.Card) Hand, nick string) (models.Card, bool) {
+	if len(board) >= 5 || len(deck) == 0 {
+		return models.Card{}, false
+	}
+	best, bestEquity := 0, -1.0
+	rest := make([]models.Card, 0, len(deck)-1)
+	for i, card := range deck {
+		rest = append(append(rest[:0], deck[:i]...), deck[i+1:]...)
+		next := append(board[:len(board):len(board)], card)
+		if equity := boardEquity(players, next, rest, evaluate)[nick]; equity > bestEquity {
+			best, bestEquity = i, equity
+		}
+	}
+	return deck[best], true
+}
+
+func (h *Holdem) StackNextCard(nick string) (models.Card, bool) {
+	card, ok := bestNextCard(h.Players, h.River, h.Dealer.Remaining(), evaluateHoldemHand, nick)
+	return card, ok && h.Dealer.Stack(card)
+}
+
+func (o *Omaha) StackNextCard(nick string) (models.Card, bool) {
+	card, ok := bestNextCard(o.Players, o.River, o.Dealer.Remaining(), evaluateOmahaHand, nick)
+	return card, ok && o.Dealer.Stack(card)
+}
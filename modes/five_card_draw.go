@@ -2,14 +2,47 @@ package modes
 
 import (
 	"errors"
+	"fmt"
 	"poker-bot/game"
 	"poker-bot/models"
 )
 
+const (
+	drawFirstBetting  = 0
+	drawPhase         = 1
+	drawSecondBetting = 2
+)
+
 type FiveCardDraw struct {
 	game.BaseGame
-	drawPhase bool
-	ante      int
+	stage   int // 0: first betting round, 1: draw, 2: second betting round
+	ante    int
+	maxDraw int // most cards a player may discard, 0 for no limit
+	aceDraw int // most cards a player keeping (and showing) an ace may discard
+	muck    []models.Card
+}
+
+// drawSeats is the number of seats at a draw table. A deck could deal five
+// cards to ten players, drawing from the reshuffled muck, but six keeps
+// most draws out of the muck.
+const drawSeats = 6
+
+func init() {
+	Register(Variant{
+		Name:       "five card draw",
+		Aliases:    []string{"draw"},
+		Title:      "Five Card Draw",
+		MinPlayers: 2,
+		MaxPlayers: drawSeats,
+		New:        NewFiveCardDraw,
+		Rules: []string{
+			"Everyone antes and is dealt five cards face down. There are no community cards.",
+			"A betting round, then the draw: $draw the positions of the cards to throw away for new ones, or $stand.",
+			"You may draw up to three cards, or four if you keep an ace and show it, unless the table was started with another --draw-limit.",
+			"After a second betting round, the best hand wins.",
+		},
+		Commands: []string{"$bet", "$call", "$raise", "$fold", "$check", "$draw", "$stand", "$cheat"},
+	})
 }
 
 func NewFiveCardDraw(channel string) game.Game {
@@ -17,36 +50,78 @@ func NewFiveCardDraw(channel string) game.Game {
 		BaseGame: game.BaseGame{
 			Type:       "five card draw",
 			Players:    make([]*models.Player, 0),
-			Deck:       game.GenerateDeck(),
+			Dealer:     game.NewDealer(),
 			InProgress: false,
 			Channel:    channel,
 		},
-		drawPhase: false,
-		ante:      5,
+		stage:   drawFirstBetting,
+		ante:    5,
+		maxDraw: 3,
+		aceDraw: 4,
 	}
 }
 
+// SetDrawLimit sets how many cards a player may discard, and how many when
+// they keep an ace and show it. A max of 0 lifts the limit.
+func (f *FiveCardDraw) SetDrawLimit(max, withAce int) {
+	f.maxDraw = max
+	f.aceDraw = withAce
+}
+
+func (f *FiveCardDraw) DrawLimit() (max, withAce int) {
+	return f.maxDraw, f.aceDraw
+}
+
+// MaxPlayers is the number of seats at a draw table.
+func (f *FiveCardDraw) MaxPlayers() int {
+	return drawSeats
+}
+
 func (f *FiveCardDraw) DealCards() {
 	for i := 0; i < 5; i++ {
 		for _, player := range f.Players {
-			player.Hand = append(player.Hand, f.Deck[0])
-			f.Deck = f.Deck[1:]
+			player.Hand = append(player.Hand, f.Dealer.Draw(1)...)
 		}
 	}
 	f.collectAnte()
 }
 
+// SetBlinds sets the ante from a blind level. Draw has no blinds, so levels
+// without an ante use the small blind as the ante.
+func (f *FiveCardDraw) SetBlinds(smallBlind, bigBlind, ante int) {
+	if ante == 0 {
+		ante = smallBlind
+	}
+	f.ante = ante
+}
+
+// Stakes returns the ante. Draw has no blinds.
+func (f *FiveCardDraw) Stakes() game.BlindLevel {
+	return game.BlindLevel{Ante: f.ante}
+}
+
+// AddLatePlayer seats a late joiner. Everyone antes every hand, so there is
+// no blind to post.
+func (f *FiveCardDraw) AddLatePlayer(player *models.Player) {
+	f.AddPlayer(player)
+}
+
 func (f *FiveCardDraw) collectAnte() {
 	for _, player := range f.Players {
-		player.Money -= f.ante
-		f.Pot += f.ante
+		ante := min(f.ante, player.Money)
+		player.Money -= ante
+		f.Pot += ante
 	}
 	f.Turn = 0
 }
 
+// UpdateRiver moves from the first betting round to the draw, and from the
+// draw to the second betting round. There are no community cards.
 func (f *FiveCardDraw) UpdateRiver() {
-	// No river in Five Card Draw
-	f.drawPhase = true
+	if f.stage < drawSecondBetting {
+		f.stage++
+	}
+	f.NewStreet(0)
 }
 
 func (f *FiveCardDraw) EvaluateHands() *models.Player {
@@ -67,41 +142,38 @@ func (f *FiveCardDraw) EvaluateHands() *models.Player {
 	return winner
 }
 
+func (f *FiveCardDraw) DescribeHand(player *models.Player) (string, int) {
+	hand := evaluateFiveCardDrawHand(player.Hand)
+	return hand.Name(), hand.Category()
+}
+
+func (f *FiveCardDraw) CompareHands(a, b *models.Player) int {
+	return compareHands(evaluateFiveCardDrawHand(a.Hand), evaluateFiveCardDrawHand(b.Hand))
+}
+
 func (f *FiveCardDraw) Bet(player *models.Player, amount int) error {
-	if amount > player.Money {
-		return errors.New("not enough money")
-	}
-	if amount < f.CurrentBet-player.Bet {
-		return errors.New("bet must be at least the current bet")
+	if f.stage == drawPhase {
+		return errors.New("it's the draw, use $draw")
 	}
-	player.Money -= amount
-	player.Bet += amount
-	f.Pot += amount
-	if player.Bet > f.CurrentBet {
-		f.CurrentBet = player.Bet
-	}
-	return nil
+	return f.BaseGame.Bet(player, amount)
 }
 
 func (f *FiveCardDraw) Call(player *models.Player) error {
-	amountToCall := f.CurrentBet - player.Bet
-	return f.Bet(player, amountToCall)
+	if f.stage == drawPhase {
+		return errors.New("it's the draw, use $draw")
+	}
+	return f.BaseGame.Call(player)
 }
 
 func (f *FiveCardDraw) Raise(player *models.Player, amount int) error {
-	totalBet := f.CurrentBet - player.Bet + amount
-	return f.Bet(player, totalBet)
+	return f.Bet(player, f.CurrentBet-player.Bet+amount)
 }
 
 func (f *FiveCardDraw) Check(player *models.Player) error {
-	if player.Bet < f.CurrentBet {
-		return errors.New("cannot check, must call or raise")
+	if f.stage == drawPhase {
+		return errors.New("it's the draw, use $draw")
 	}
-	return nil
-}
-
-func (f *FiveCardDraw) Fold(player *models.Player) {
-	player.Folded = true
+	return f.BaseGame.Check(player)
 }
 
 func (f *FiveCardDraw) IsRoundOver() bool {
@@ -109,35 +181,130 @@ func (f *FiveCardDraw) IsRoundOver() bool {
 	for _, player := range f.Players {
 		if !player.Folded {
 			activePlayers++
-			if player.Bet != f.CurrentBet {
-				return false
-			}
 		}
 	}
-	return activePlayers <= 1 || f.drawPhase
+	return activePlayers <= 1 || (f.stage == drawSecondBetting && f.IsBettingRoundOver())
 }
 
-func (f *FiveCardDraw) SetInProgress(inProgress bool) {
-	f.InProgress = inProgress
+// IsBettingRoundOver reports whether the current betting round, or during
+// the draw whether the draw, is complete.
+func (f *FiveCardDraw) IsBettingRoundOver() bool {
+	if f.stage != drawPhase {
+		return f.BaseGame.IsBettingRoundOver()
+	}
+	for _, player := range f.Players {
+		if !player.Folded && !player.Acted {
+			return false
+		}
+	}
+	return true
 }
 
-func (f *FiveCardDraw) DrawCards(player *models.Player, indices []int) {
-	if !f.drawPhase {
-		return
+func (f *FiveCardDraw) IsDrawPhase() bool {
+	return f.stage == drawPhase
+}
+
+// TurnCommands are the betting commands, or during the draw the commands
+// to draw, stand pat or fold.
+func (f *FiveCardDraw) TurnCommands() []string {
+	if f.IsDrawPhase() {
+		return []string{"$draw", "$stand", "$fold", "$cheat"}
+	}
+	return BettingCommands
+}
+
+// DrawCards replaces the cards at the given 0-based positions. Players draw
+// once each, in turn order; no positions means standing pat.
+func (f *FiveCardDraw) DrawCards(player *models.Player, indices []int) error {
+	if f.stage != drawPhase {
+		return errors.New("you can only draw during the draw")
+	}
+	if f.Players[f.Turn] != player {
+		return fmt.Errorf("it's %s's turn to draw", f.Players[f.Turn].Nick)
+	}
+	if player.Acted {
+		return errors.New("you have already drawn this hand")
+	}
+
+	seen := make(map[int]bool)
+	for _, index := range indices {
+		if index < 0 || index >= len(player.Hand) {
+			return fmt.Errorf("card positions must be between 1 and %d", len(player.Hand))
+		}
+		if seen[index] {
+			return fmt.Errorf("card %d listed twice", index+1)
+		}
+		seen[index] = true
+	}
+
+	if f.maxDraw > 0 && len(indices) > f.maxDraw {
+		if _, ok := KeptAce(player.Hand, indices); !ok || len(indices) > f.aceDraw {
+			return fmt.Errorf("you can draw at most %d cards (%d if you keep and show an ace)", f.maxDraw, f.aceDraw)
+		}
 	}
 
+	discards := make([]models.Card, 0, len(indices))
 	for _, index := range indices {
-		if index >= 0 && index < len(player.Hand) {
-			f.Deck = append(f.Deck, player.Hand[index])
-			player.Hand[index] = f.Deck[0]
-			f.Deck = f.Deck[1:]
+		discards = append(discards, player.Hand[index])
+	}
+	for _, index := range indices {
+		if len(f.Dealer.Remaining()) == 0 {
+			f.reshuffleMuck()
 		}
+		if len(f.Dealer.Remaining()) == 0 {
+			// Nothing else is left, so the player's own discards are redealt.
+			f.Dealer.Return(discards)
+			discards = nil
+		}
+		player.Hand[index] = f.Dealer.Draw(1)[0]
 	}
+	f.muck = append(f.muck, discards...)
+	player.Acted = true
+	return nil
+}
+
+// reshuffleMuck turns the discards and folded hands into a new deck when
+// the deck runs out mid-draw. The drawing player's own discards are not yet
+// in the muck, so they can't be dealt straight back to them.
+func (f *FiveCardDraw) reshuffleMuck() {
+	for _, player := range f.Players {
+		if player.Folded {
+			f.muck = append(f.muck, player.Hand...)
+			player.Hand = make([]models.Card, 0)
+		}
+	}
+	f.Dealer.Return(f.muck)
+	f.muck = make([]models.Card, 0)
+}
+
+// KeptAce returns an ace the player keeps when discarding the given
+// positions, the card they show to draw past the normal limit.
+func KeptAce(hand []models.Card, discards []int) (models.Card, bool) {
+	for i, card := range hand {
+		if card.Value == "A" && !containsIndex(discards, i) {
+			return card, true
+		}
+	}
+	return models.Card{}, false
+}
+
+func containsIndex(indices []int, target int) bool {
+	for _, index := range indices {
+		if index == target {
+			return true
+		}
+	}
+	return false
 }
 
 func (f *FiveCardDraw) ResetRound() {
 	f.BaseGame.ResetRound()
-	f.drawPhase = false
+	f.stage = drawFirstBetting
+	f.muck = make([]models.Card, 0)
+}
+
+func (f *FiveCardDraw) GetStage() int {
+	return f.stage
 }
 
 func (f *FiveCardDraw) CalculateSidePots() {
@@ -0,0 +1,41 @@
+package modes
+
+import (
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// fiveCardFrequencies is how many of the 2,598,960 five-card hands fall in
+// each category, from high card to royal flush.
+var fiveCardFrequencies = [10]int{1302540, 1098240, 123552, 54912, 10200, 5108, 3744, 624, 36, 4}
+
+// TestCategoryFrequencies evaluates every five-card hand and counts them by
+// category against the known frequencies.
+func TestCategoryFrequencies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("evaluates all 2,598,960 five-card hands")
+	}
+	deck := game.GenerateDeck()
+	var counts [10]int
+	hand := make([]models.Card, 5)
+	n := len(deck)
+	for a := 0; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c < n; c++ {
+				for d := c + 1; d < n; d++ {
+					for e := d + 1; e < n; e++ {
+						hand[0], hand[1], hand[2], hand[3], hand[4] = deck[a], deck[b], deck[c], deck[d], deck[e]
+						counts[getBestHand(hand).category]++
+					}
+				}
+			}
+		}
+	}
+	for category, want := range fiveCardFrequencies {
+		if counts[category] != want {
+			t.Errorf("%d hands are %s, want %d", counts[category], handNames[category], want)
+		}
+	}
+}
@@ -1,9 +1,8 @@
 package modes
 
 import (
-	"errors"
-	"fmt"
 	"log"
+	"math/bits"
 	"poker-bot/game"
 	"poker-bot/models"
 	"sort"
@@ -15,15 +14,38 @@ type Holdem struct {
 	button     int
 	smallBlind int
 	bigBlind   int
+	ante       int
 	sidePots   []int
 }
 
+// holdemSeats is the number of seats at a Hold'em table. A deck could deal
+// up to 23 players in.
+const holdemSeats = 10
+
+func init() {
+	Register(Variant{
+		Name:       "holdem",
+		Aliases:    []string{"hold'em", "texas holdem"},
+		Title:      "Texas Hold'em",
+		MinPlayers: 2,
+		MaxPlayers: holdemSeats,
+		New:        NewHoldem,
+		Rules: []string{
+			"The two players after the button post the small and big blinds, and everyone is dealt two hole cards.",
+			"A betting round, then the flop: three cards face up in the middle for everyone to use.",
+			"A betting round, then the turn, a fourth card. A betting round, then the river, a fifth.",
+			"After the last betting round, the best five-card hand from your hole cards and the board wins.",
+		},
+		Commands: BettingCommands,
+	})
+}
+
 func NewHoldem(channel string) game.Game {
 	return &Holdem{
 		BaseGame: game.BaseGame{
 			Type:       "holdem",
 			Players:    make([]*models.Player, 0),
-			Deck:       game.GenerateDeck(),
+			Dealer:     game.NewDealer(),
 			InProgress: false,
 			Channel:    channel,
 		},
@@ -35,28 +57,55 @@ func NewHoldem(channel string) game.Game {
 	}
 }
 
+// MaxPlayers is the number of seats at a Hold'em table.
+func (h *Holdem) MaxPlayers() int {
+	return holdemSeats
+}
+
 func (h *Holdem) DealCards() {
 	for i := 0; i < 2; i++ {
 		for _, player := range h.Players {
-			player.Hand = append(player.Hand, h.Deck[0])
-			h.Deck = h.Deck[1:]
+			player.Hand = append(player.Hand, h.Dealer.Draw(1)...)
 		}
 	}
 	h.collectBlinds()
 }
 
+func (h *Holdem) SetBlinds(smallBlind, bigBlind, ante int) {
+	h.smallBlind = smallBlind
+	h.bigBlind = bigBlind
+	h.ante = ante
+}
+
+// Stakes returns the blinds and ante of the hand.
+func (h *Holdem) Stakes() game.BlindLevel {
+	return game.BlindLevel{SmallBlind: h.smallBlind, BigBlind: h.bigBlind, Ante: h.ante}
+}
+
 func (h *Holdem) collectBlinds() {
 	numPlayers := len(h.Players)
 	sbPos := (h.button + 1) % numPlayers
 	bbPos := (h.button + 2) % numPlayers
 
-	h.Players[sbPos].Bet = h.smallBlind
-	h.Players[sbPos].Money -= h.smallBlind
-	h.Pot += h.smallBlind
+	if h.ante > 0 {
+		for _, player := range h.Players {
+			ante := min(h.ante, player.Money)
+			player.Money -= ante
+			h.Pot += ante
+		}
+	}
+
+	small := min(h.smallBlind, h.Players[sbPos].Money)
+	h.Players[sbPos].Bet = small
+	h.Players[sbPos].Money -= small
+	h.Pot += small
 
-	h.Players[bbPos].Bet = h.bigBlind
-	h.Players[bbPos].Money -= h.bigBlind
-	h.Pot += h.bigBlind
+	big := min(h.bigBlind, h.Players[bbPos].Money)
+	h.Players[bbPos].Bet = big
+	h.Players[bbPos].Money -= big
+	h.Pot += big
+
+	h.PostLateBlinds(h.bigBlind, sbPos, bbPos)
 
 	h.CurrentBet = h.bigBlind
 	h.Turn = (bbPos + 1) % numPlayers
@@ -65,22 +114,16 @@ func (h *Holdem) collectBlinds() {
 func (h *Holdem) UpdateRiver() {
 	switch h.stage {
 	case 0: // Flop
-		h.River = append(h.River, h.Deck[:3]...)
-		h.Deck = h.Deck[3:]
+		h.River = append(h.River, h.Dealer.Draw(3)...)
 	case 1, 2: // Turn and River
-		h.River = append(h.River, h.Deck[0])
-		h.Deck = h.Deck[1:]
+		h.River = append(h.River, h.Dealer.Draw(1)...)
 	}
 	h.stage++
 	h.resetBets()
 }
 
 func (h *Holdem) resetBets() {
-	for _, player := range h.Players {
-		player.Bet = 0
-	}
-	h.CurrentBet = 0
-	h.Turn = (h.button + 1) % len(h.Players)
+	h.NewStreet(h.button + 1)
 }
 
 func (h *Holdem) EvaluateHands() *models.Player {
@@ -109,41 +152,13 @@ func (h *Holdem) EvaluateHands() *models.Player {
 	return winner
 }
 
-func (h *Holdem) Bet(player *models.Player, amount int) error {
-	if amount > player.Money {
-		return errors.New("not enough money")
-	}
-	if amount < h.CurrentBet-player.Bet {
-		return errors.New("bet must be at least the current bet")
-	}
-	player.Money -= amount
-	player.Bet += amount
-	h.Pot += amount
-	if player.Bet > h.CurrentBet {
-		h.CurrentBet = player.Bet
-	}
-	return nil
-}
-
-func (h *Holdem) Call(player *models.Player) error {
-	amountToCall := h.CurrentBet - player.Bet
-	return h.Bet(player, amountToCall)
-}
-
-func (h *Holdem) Raise(player *models.Player, amount int) error {
-	totalBet := h.CurrentBet - player.Bet + amount
-	return h.Bet(player, totalBet)
+func (h *Holdem) DescribeHand(player *models.Player) (string, int) {
+	hand := evaluateHoldemHand(player.Hand[:len(player.Hand):len(player.Hand)], h.River)
+	return hand.Name(), hand.Category()
 }
 
-func (h *Holdem) Check(player *models.Player) error {
-	if player.Bet < h.CurrentBet {
-		return errors.New("cannot check, must call or raise")
-	}
-	return nil
-}
-
-func (h *Holdem) Fold(player *models.Player) {
-	player.Folded = true
+func (h *Holdem) CompareHands(a, b *models.Player) int {
+	return compareHands(evaluateHoldemHand(a.Hand[:len(a.Hand):len(a.Hand)], h.River), evaluateHoldemHand(b.Hand[:len(b.Hand):len(b.Hand)], h.River))
 }
 
 func (h *Holdem) IsRoundOver() bool {
@@ -151,16 +166,9 @@ func (h *Holdem) IsRoundOver() bool {
 	for _, player := range h.Players {
 		if !player.Folded {
 			activePlayers++
-			if player.Bet != h.CurrentBet {
-				return false
-			}
 		}
 	}
-	return activePlayers <= 1 || h.stage == 3
-}
-
-func (h *Holdem) SetInProgress(inProgress bool) {
-	h.InProgress = inProgress
+	return activePlayers <= 1 || (h.stage == 3 && h.IsBettingRoundOver())
 }
 
 func (h *Holdem) CalculateSidePots() {
@@ -196,6 +204,14 @@ func (h *Holdem) ResetRound() {
 	h.sidePots = make([]int, 0)
 }
 
+func (h *Holdem) Button() int {
+	return h.button
+}
+
+func (h *Holdem) SetButton(button int) {
+	h.button = button
+}
+
 func (h *Holdem) GetStage() int {
 	return h.stage
 }
@@ -206,7 +222,24 @@ func (h *Holdem) SetStage(stage int) {
 
 type Hand struct {
 	category int
-	values   []int
+	// values are the ranks that break ties within the category, best
+	// first, with zeros past the last one.
+	values [5]int
+}
+
+var handNames = []string{
+	"high card", "pair", "two pair", "three of a kind", "straight",
+	"flush", "full house", "four of a kind", "straight flush", "royal flush",
+}
+
+func (h Hand) Name() string {
+	return handNames[h.category]
+}
+
+// Category ranks the kind of hand, from 0 for high card to 9 for a royal
+// flush.
+func (h Hand) Category() int {
+	return h.category
 }
 
 func (h Hand) beats(other Hand) bool {
@@ -221,154 +254,169 @@ func (h Hand) beats(other Hand) bool {
 	return false
 }
 
+func compareHands(a, b Hand) int {
+	switch {
+	case a.beats(b):
+		return 1
+	case b.beats(a):
+		return -1
+	}
+	return 0
+}
+
 func evaluateHoldemHand(hole, community []models.Card) Hand {
-	allCards := append(hole, community...)
-	return getBestHand(allCards)
+	var tally cardTally
+	tally.add(hole)
+	tally.add(community)
+	return tally.best()
 }
 
 func getBestHand(cards []models.Card) Hand {
-	handCheckers := []func([]models.Card) (bool, []int){
-		isRoyalFlush,
-		isStraightFlush,
-		isFourOfAKind,
-		isFullHouse,
-		isFlush,
-		isStraight,
-		isThreeOfAKind,
-		isTwoPair,
-		isPair,
-		isHighCard,
-	}
-
-	for category, checker := range handCheckers {
-		if ok, values := checker(cards); ok {
-			return Hand{category: 9 - category, values: values}
-		}
-	}
-
-	panic("No valid hand found")
+	var tally cardTally
+	tally.add(cards)
+	return tally.best()
 }
 
-func isRoyalFlush(cards []models.Card) (bool, []int) {
-	if ok, values := isStraightFlush(cards); ok && values[0] == 14 {
-		return true, values
-	}
-	return false, nil
-}
+// suitNames orders the suits for cardTally's masks.
+var suitNames = [4]string{"Hearts", "Diamonds", "Clubs", "Spades"}
 
-func isStraightFlush(cards []models.Card) (bool, []int) {
-	for _, suit := range []string{"Hearts", "Diamonds", "Clubs", "Spades"} {
-		suited := filterBySuit(cards, suit)
-		if ok, values := isStraight(suited); ok {
-			return true, values
-		}
-	}
-	return false, nil
+// cardTally is what the evaluator needs to know of a set of cards: how
+// many there are of each value, and which values each suit holds as a
+// bitmask with bit v set for value v. It lives on the stack, so evaluating
+// a hand allocates nothing, which matters to equity runs that evaluate
+// hundreds of thousands of them.
+type cardTally struct {
+	counts [15]int
+	suits  [4]uint16
+	cards  int
 }
 
-func isFourOfAKind(cards []models.Card) (bool, []int) {
-	valueCounts := countValues(cards)
-	for value, count := range valueCounts {
-		if count >= 4 {
-			kickers := getKickers(cards, []int{value}, 1)
-			return true, append([]int{value}, kickers...)
+func (t *cardTally) add(cards []models.Card) {
+	for _, card := range cards {
+		value := cardValue(card)
+		if value < 2 || value > 14 {
+			continue
+		}
+		t.counts[value]++
+		t.cards++
+		for suit, name := range suitNames {
+			if card.Suit == name {
+				t.suits[suit] |= 1 << value
+				break
+			}
 		}
 	}
-	return false, nil
 }
 
-func isFullHouse(cards []models.Card) (bool, []int) {
-	valueCounts := countValues(cards)
-	var threeOfAKind, pair int
-	for value, count := range valueCounts {
-		if count >= 3 && value > threeOfAKind {
-			threeOfAKind = value
-		} else if count >= 2 && value > pair {
-			pair = value
+// best finds the best five-card hand in the tally.
+func (t *cardTally) best() Hand {
+	for _, suited := range t.suits {
+		if bits.OnesCount16(suited) < 5 {
+			continue
+		}
+		if high := straightHigh(suited); high == 14 {
+			return Hand{category: 9, values: [5]int{high}}
+		} else if high > 0 {
+			return Hand{category: 8, values: [5]int{high}}
 		}
 	}
-	if threeOfAKind > 0 && pair > 0 {
-		return true, []int{threeOfAKind, pair}
-	}
-	return false, nil
-}
 
-func isFlush(cards []models.Card) (bool, []int) {
-	for _, suit := range []string{"Hearts", "Diamonds", "Clubs", "Spades"} {
-		suited := filterBySuit(cards, suit)
-		if len(suited) >= 5 {
-			values := getValues(suited)
-			sort.Sort(sort.Reverse(sort.IntSlice(values)))
-			return true, values[:5]
+	// The highest value held four, three and two or more times, and the
+	// second highest held two or more times.
+	var quads, trips, pair, second int
+	var held uint16
+	for value := 14; value >= 2; value-- {
+		count := t.counts[value]
+		if count > 0 {
+			held |= 1 << value
+		}
+		if count >= 4 && quads == 0 {
+			quads = value
+		}
+		if count >= 3 && trips == 0 {
+			trips = value
+		} else if count >= 2 && pair == 0 {
+			pair = value
+		} else if count >= 2 && second == 0 {
+			second = value
 		}
 	}
-	return false, nil
-}
 
-func isStraight(cards []models.Card) (bool, []int) {
-	values := getValues(cards)
-	sort.Ints(values)
-	values = removeDuplicates(values)
-
-	if len(values) >= 5 {
-		for i := len(values) - 1; i >= 4; i-- {
-			if values[i]-values[i-4] == 4 {
-				return true, []int{values[i]}
+	hand := Hand{}
+	switch {
+	case quads > 0:
+		hand.category = 7
+		hand.values[0] = quads
+		t.kickers(hand.values[1:2], quads, 0)
+		return hand
+	case trips > 0 && pair > 0:
+		return Hand{category: 6, values: [5]int{trips, pair}}
+	}
+	for _, suited := range t.suits {
+		if bits.OnesCount16(suited) >= 5 {
+			hand.category = 5
+			for i, value := 0, 14; i < 5; value-- {
+				if suited&(1<<value) != 0 {
+					hand.values[i] = value
+					i++
+				}
 			}
+			return hand
 		}
 	}
-
-	if containsValue(values, 14) && containsValue(values, 2) && containsValue(values, 3) && containsValue(values, 4) && containsValue(values, 5) {
-		return true, []int{5}
+	if high := straightHigh(held); high > 0 {
+		return Hand{category: 4, values: [5]int{high}}
+	}
+
+	switch {
+	case trips > 0:
+		hand.category = 3
+		hand.values[0] = trips
+		t.kickers(hand.values[1:3], trips, 0)
+	case pair > 0 && second > 0:
+		hand.category = 2
+		hand.values[0], hand.values[1] = pair, second
+		t.kickers(hand.values[2:3], pair, second)
+	case pair > 0:
+		hand.category = 1
+		hand.values[0] = pair
+		t.kickers(hand.values[1:4], pair, 0)
+	default:
+		t.kickers(hand.values[:], 0, 0)
 	}
-
-	return false, nil
+	return hand
 }
 
-func isThreeOfAKind(cards []models.Card) (bool, []int) {
-	valueCounts := countValues(cards)
-	for value, count := range valueCounts {
-		if count >= 3 {
-			kickers := getKickers(cards, []int{value}, 2)
-			return true, append([]int{value}, kickers...)
+// kickers fills values with the highest cards in the tally, a value held
+// twice counting twice, skipping the two values already in the hand.
+// Values past the last card are left zero.
+func (t *cardTally) kickers(values []int, skip, skipAlso int) {
+	i := 0
+	for value := 14; value >= 2 && i < len(values); value-- {
+		if value == skip || value == skipAlso {
+			continue
 		}
-	}
-	return false, nil
-}
-
-func isTwoPair(cards []models.Card) (bool, []int) {
-	valueCounts := countValues(cards)
-	pairs := make([]int, 0)
-	for value, count := range valueCounts {
-		if count >= 2 {
-			pairs = append(pairs, value)
+		for n := 0; n < t.counts[value] && i < len(values); n++ {
+			values[i] = value
+			i++
 		}
 	}
-	if len(pairs) >= 2 {
-		sort.Sort(sort.Reverse(sort.IntSlice(pairs)))
-		kickers := getKickers(cards, pairs[:2], 1)
-		return true, append(pairs[:2], kickers...)
-	}
-	return false, nil
 }
 
-func isPair(cards []models.Card) (bool, []int) {
-	valueCounts := countValues(cards)
-	for value, count := range valueCounts {
-		if count >= 2 {
-			kickers := getKickers(cards, []int{value}, 3)
-			return true, append([]int{value}, kickers...)
+// straightHigh returns the top value of the highest straight in a value
+// mask, 5 for the wheel, or 0 for none.
+func straightHigh(held uint16) int {
+	const run = 0x1f
+	for high := 14; high >= 6; high-- {
+		if held>>(high-4)&run == run {
+			return high
 		}
 	}
-	return false, nil
-}
-
-func isHighCard(cards []models.Card) (bool, []int) {
-	values := getValues(cards)
-	sort.Sort(sort.Reverse(sort.IntSlice(values)))
-
-	returnCount := min(5, len(values))
-	return true, values[:returnCount]
+	const wheel = 1<<14 | 1<<5 | 1<<4 | 1<<3 | 1<<2
+	if held&wheel == wheel {
+		return 5
+	}
+	return 0
 }
 
 func min(a, b int) int {
@@ -378,69 +426,6 @@ func min(a, b int) int {
 	return b
 }
 
-func filterBySuit(cards []models.Card, suit string) []models.Card {
-	suited := make([]models.Card, 0)
-	for _, card := range cards {
-		if card.Suit == suit {
-			suited = append(suited, card)
-		}
-	}
-	return suited
-}
-
-func countValues(cards []models.Card) map[int]int {
-	valueCounts := make(map[int]int)
-	for _, card := range cards {
-		value := cardValue(card)
-		valueCounts[value]++
-	}
-	return valueCounts
-}
-
-func getValues(cards []models.Card) []int {
-	values := make([]int, len(cards))
-	for i, card := range cards {
-		values[i] = cardValue(card)
-	}
-	return values
-}
-
-func removeDuplicates(values []int) []int {
-	seen := make(map[int]bool)
-	result := make([]int, 0)
-	for _, value := range values {
-		if !seen[value] {
-			seen[value] = true
-			result = append(result, value)
-		}
-	}
-	return result
-}
-
-func containsValue(values []int, target int) bool {
-	for _, value := range values {
-		if value == target {
-			return true
-		}
-	}
-	return false
-}
-
-func getKickers(cards []models.Card, excludeValues []int, count int) []int {
-	kickers := make([]int, 0)
-	for _, card := range cards {
-		value := cardValue(card)
-		if !containsValue(excludeValues, value) {
-			kickers = append(kickers, value)
-		}
-	}
-	sort.Sort(sort.Reverse(sort.IntSlice(kickers)))
-	if len(kickers) > count {
-		kickers = kickers[:count]
-	}
-	return kickers
-}
-
 func cardValue(card models.Card) int {
 	switch card.Value {
 	case "A":
@@ -452,8 +437,11 @@ func cardValue(card models.Card) int {
 	case "J":
 		return 11
 	default:
-		var value int
-		fmt.Sscanf(card.Value, "%d", &value)
+		// By hand rather than with fmt.Sscanf, which allocates.
+		value := 0
+		for i := 0; i < len(card.Value) && card.Value[i] >= '0' && card.Value[i] <= '9'; i++ {
+			value = value*10 + int(card.Value[i]-'0')
+		}
 		return value
 	}
 }
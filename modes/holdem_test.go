@@ -0,0 +1,67 @@
+package modes
+
+import (
+	"math/rand"
+	"testing"
+
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// cardsFromBytes turns fuzz input into up to nine distinct cards, each byte
+// picking one of the 52; bytes naming a card already picked are skipped.
+func cardsFromBytes(data []byte) []models.Card {
+	deck := game.GenerateDeck()
+	seen := make(map[int]bool)
+	var cards []models.Card
+	for _, b := range data {
+		i := int(b) % len(deck)
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		cards = append(cards, deck[i])
+		if len(cards) == 9 {
+			break
+		}
+	}
+	return cards
+}
+
+// FuzzEvaluate checks getBestHand against the reference evaluator on sets
+// of five to nine cards. The deck is ordered hearts, diamonds, clubs,
+// spades, 2 to ace within each suit.
+func FuzzEvaluate(f *testing.F) {
+	f.Add([]byte{8, 9, 10, 11, 12})                 // royal flush
+	f.Add([]byte{12, 0, 1, 2, 3, 26, 40})           // wheel straight flush and a pair
+	f.Add([]byte{12, 25, 38, 51, 0, 13, 26})        // four aces, three deuces
+	f.Add([]byte{0, 13, 26, 1, 14, 27, 2})          // two sets of three
+	f.Add([]byte{0, 2, 4, 6, 8, 10, 12, 14, 16})    // seven hearts
+	f.Add([]byte{12, 13, 27, 41, 3, 17, 31, 45, 5}) // eight-high straight over a wheel
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cards := cardsFromBytes(data)
+		if len(cards) < 5 {
+			t.Skip()
+		}
+		if err := crossCheck(cards); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestEvaluateRandomHands(t *testing.T) {
+	iterations := 20000
+	if testing.Short() {
+		iterations = 2000
+	}
+	deck := game.GenerateDeck()
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < iterations; i++ {
+		rng.Shuffle(len(deck), func(a, b int) {
+			deck[a], deck[b] = deck[b], deck[a]
+		})
+		if err := crossCheck(deck[:5+rng.Intn(5)]); err != nil {
+			t.Fatalf("iteration %d: %v", i, err)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package modes
 
 import (
-	"errors"
 	"poker-bot/game"
 	"poker-bot/models"
 )
@@ -12,15 +11,35 @@ type Omaha struct {
 	button     int
 	smallBlind int
 	bigBlind   int
+	ante       int
 	sidePots   []int
 }
 
+// omahaSeats is the number of seats at an Omaha table. A deck could deal
+// up to 11 players in.
+const omahaSeats = 10
+
+func init() {
+	Register(Variant{
+		Name:       "omaha",
+		Title:      "Omaha",
+		MinPlayers: 2,
+		MaxPlayers: omahaSeats,
+		New:        NewOmaha,
+		Rules: []string{
+			"Played like Hold'em, with blinds, a flop, a turn and a river, but everyone is dealt four hole cards.",
+			"After the last betting round, the best five-card hand from your hole cards and the board wins.",
+		},
+		Commands: BettingCommands,
+	})
+}
+
 func NewOmaha(channel string) game.Game {
 	return &Omaha{
 		BaseGame: game.BaseGame{
 			Type:       "omaha",
 			Players:    make([]*models.Player, 0),
-			Deck:       game.GenerateDeck(),
+			Dealer:     game.NewDealer(),
 			InProgress: false,
 			Channel:    channel,
 		},
@@ -32,28 +51,55 @@ func NewOmaha(channel string) game.Game {
 	}
 }
 
+// MaxPlayers is the number of seats at an Omaha table.
+func (o *Omaha) MaxPlayers() int {
+	return omahaSeats
+}
+
 func (o *Omaha) DealCards() {
 	for i := 0; i < 4; i++ {
 		for _, player := range o.Players {
-			player.Hand = append(player.Hand, o.Deck[0])
-			o.Deck = o.Deck[1:]
+			player.Hand = append(player.Hand, o.Dealer.Draw(1)...)
 		}
 	}
 	o.collectBlinds()
 }
 
+func (o *Omaha) SetBlinds(smallBlind, bigBlind, ante int) {
+	o.smallBlind = smallBlind
+	o.bigBlind = bigBlind
+	o.ante = ante
+}
+
+// Stakes returns the blinds and ante of the hand.
+func (o *Omaha) Stakes() game.BlindLevel {
+	return game.BlindLevel{SmallBlind: o.smallBlind, BigBlind: o.bigBlind, Ante: o.ante}
+}
+
 func (o *Omaha) collectBlinds() {
 	numPlayers := len(o.Players)
 	sbPos := (o.button + 1) % numPlayers
 	bbPos := (o.button + 2) % numPlayers
 
-	o.Players[sbPos].Bet = o.smallBlind
-	o.Players[sbPos].Money -= o.smallBlind
-	o.Pot += o.smallBlind
+	if o.ante > 0 {
+		for _, player := range o.Players {
+			ante := min(o.ante, player.Money)
+			player.Money -= ante
+			o.Pot += ante
+		}
+	}
+
+	small := min(o.smallBlind, o.Players[sbPos].Money)
+	o.Players[sbPos].Bet = small
+	o.Players[sbPos].Money -= small
+	o.Pot += small
+
+	big := min(o.bigBlind, o.Players[bbPos].Money)
+	o.Players[bbPos].Bet = big
+	o.Players[bbPos].Money -= big
+	o.Pot += big
 
-	o.Players[bbPos].Bet = o.bigBlind
-	o.Players[bbPos].Money -= o.bigBlind
-	o.Pot += o.bigBlind
+	o.PostLateBlinds(o.bigBlind, sbPos, bbPos)
 
 	o.CurrentBet = o.bigBlind
 	o.Turn = (bbPos + 1) % numPlayers
@@ -62,22 +108,16 @@ func (o *Omaha) collectBlinds() {
 func (o *Omaha) UpdateRiver() {
 	switch o.stage {
 	case 0: // Flop
-		o.River = append(o.River, o.Deck[:3]...)
-		o.Deck = o.Deck[3:]
+		o.River = append(o.River, o.Dealer.Draw(3)...)
 	case 1, 2: // Turn and River
-		o.River = append(o.River, o.Deck[0])
-		o.Deck = o.Deck[1:]
+		o.River = append(o.River, o.Dealer.Draw(1)...)
 	}
 	o.stage++
 	o.resetBets()
 }
 
 func (o *Omaha) resetBets() {
-	for _, player := range o.Players {
-		player.Bet = 0
-	}
-	o.CurrentBet = 0
-	o.Turn = (o.button + 1) % len(o.Players)
+	o.NewStreet(o.button + 1)
 }
 
 func (o *Omaha) EvaluateHands() *models.Player {
@@ -98,41 +138,13 @@ func (o *Omaha) EvaluateHands() *models.Player {
 	return winner
 }
 
-func (o *Omaha) Bet(player *models.Player, amount int) error {
-	if amount > player.Money {
-		return errors.New("not enough money")
-	}
-	if amount < o.CurrentBet-player.Bet {
-		return errors.New("bet must be at least the current bet")
-	}
-	player.Money -= amount
-	player.Bet += amount
-	o.Pot += amount
-	if player.Bet > o.CurrentBet {
-		o.CurrentBet = player.Bet
-	}
-	return nil
-}
-
-func (o *Omaha) Call(player *models.Player) error {
-	amountToCall := o.CurrentBet - player.Bet
-	return o.Bet(player, amountToCall)
-}
-
-func (o *Omaha) Raise(player *models.Player, amount int) error {
-	totalBet := o.CurrentBet - player.Bet + amount
-	return o.Bet(player, totalBet)
-}
-
-func (o *Omaha) Check(player *models.Player) error {
-	if player.Bet < o.CurrentBet {
-		return errors.New("cannot check, must call or raise")
-	}
-	return nil
+func (o *Omaha) DescribeHand(player *models.Player) (string, int) {
+	hand := evaluateOmahaHand(player.Hand[:len(player.Hand):len(player.Hand)], o.River)
+	return hand.Name(), hand.Category()
 }
 
-func (o *Omaha) Fold(player *models.Player) {
-	player.Folded = true
+func (o *Omaha) CompareHands(a, b *models.Player) int {
+	return compareHands(evaluateOmahaHand(a.Hand[:len(a.Hand):len(a.Hand)], o.River), evaluateOmahaHand(b.Hand[:len(b.Hand):len(b.Hand)], o.River))
 }
 
 func (o *Omaha) IsRoundOver() bool {
@@ -140,16 +152,9 @@ func (o *Omaha) IsRoundOver() bool {
 	for _, player := range o.Players {
 		if !player.Folded {
 			activePlayers++
-			if player.Bet != o.CurrentBet {
-				return false
-			}
 		}
 	}
-	return activePlayers <= 1 || o.stage == 3
-}
-
-func (o *Omaha) SetInProgress(inProgress bool) {
-	o.InProgress = inProgress
+	return activePlayers <= 1 || (o.stage == 3 && o.IsBettingRoundOver())
 }
 
 func (o *Omaha) CalculateSidePots() {
@@ -163,6 +168,14 @@ func (o *Omaha) ResetRound() {
 	o.sidePots = make([]int, 0)
 }
 
+func (o *Omaha) Button() int {
+	return o.button
+}
+
+func (o *Omaha) SetButton(button int) {
+	o.button = button
+}
+
 func (o *Omaha) GetStage() int {
 	return o.stage
 }
@@ -174,5 +187,8 @@ func (o *Omaha) SetStage(stage int) {
 func evaluateOmahaHand(hand, river []models.Card) Hand {
 	// Implement Omaha-specific hand evaluation
 	// This is a placeholder and should be replaced with proper Omaha rules
-	return getBestHand(append(hand, river...))
+	var tally cardTally
+	tally.add(hand)
+	tally.add(river)
+	return tally.best()
 }
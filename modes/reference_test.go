@@ -0,0 +1,152 @@
+package modes
+
+import (
+	"fmt"
+	"sort"
+
+	"poker-bot/models"
+)
+
+// referenceBestHand is a deliberately naive evaluator used to cross-check
+// getBestHand. It scores every 5-card combination on its own and keeps the
+// best one; fewer than five cards are scored as they are, without straights
+// or flushes. It is far too slow for the game loop.
+func referenceBestHand(cards []models.Card) Hand {
+	if len(cards) <= 5 {
+		return referenceFiveCardHand(cards)
+	}
+
+	var best Hand
+	found := false
+	combo := make([]models.Card, 5)
+	var choose func(start, depth int)
+	choose = func(start, depth int) {
+		if depth == 5 {
+			hand := referenceFiveCardHand(combo)
+			if !found || hand.beats(best) {
+				best = hand
+				found = true
+			}
+			return
+		}
+		for i := start; i <= len(cards)-(5-depth); i++ {
+			combo[depth] = cards[i]
+			choose(i+1, depth+1)
+		}
+	}
+	choose(0, 0)
+	return best
+}
+
+func referenceFiveCardHand(cards []models.Card) Hand {
+	counts := countValues(cards)
+	groups := valuesByRank(counts)
+	sort.SliceStable(groups, func(i, j int) bool {
+		return counts[groups[i]] > counts[groups[j]]
+	})
+
+	flush, straight, high := false, false, 0
+	if len(cards) == 5 {
+		flush = len(filterBySuit(cards, cards[0].Suit)) == 5
+		if len(groups) == 5 {
+			switch {
+			case groups[0]-groups[4] == 4:
+				straight, high = true, groups[0]
+			case groups[0] == 14 && groups[1] == 5:
+				straight, high = true, 5
+			}
+		}
+	}
+
+	switch {
+	case straight && flush && high == 14:
+		return Hand{category: 9, values: [5]int{high}}
+	case straight && flush:
+		return Hand{category: 8, values: [5]int{high}}
+	case counts[groups[0]] == 4:
+		return Hand{category: 7, values: topValues(groups)}
+	case counts[groups[0]] == 3 && len(groups) > 1 && counts[groups[1]] == 2:
+		return Hand{category: 6, values: topValues(groups)}
+	case flush:
+		return Hand{category: 5, values: topValues(groups)}
+	case straight:
+		return Hand{category: 4, values: [5]int{high}}
+	case counts[groups[0]] == 3:
+		return Hand{category: 3, values: topValues(groups)}
+	case counts[groups[0]] == 2 && len(groups) > 1 && counts[groups[1]] == 2:
+		return Hand{category: 2, values: topValues(groups)}
+	case counts[groups[0]] == 2:
+		return Hand{category: 1, values: topValues(groups)}
+	default:
+		return Hand{category: 0, values: topValues(groups)}
+	}
+}
+
+// topValues fits up to five tie-breaking values into a Hand.
+func topValues(values []int) [5]int {
+	var top [5]int
+	copy(top[:], values)
+	return top
+}
+
+func filterBySuit(cards []models.Card, suit string) []models.Card {
+	suited := make([]models.Card, 0)
+	for _, card := range cards {
+		if card.Suit == suit {
+			suited = append(suited, card)
+		}
+	}
+	return suited
+}
+
+func countValues(cards []models.Card) map[int]int {
+	valueCounts := make(map[int]int)
+	for _, card := range cards {
+		value := cardValue(card)
+		valueCounts[value]++
+	}
+	return valueCounts
+}
+
+// valuesByRank returns the distinct values in valueCounts, highest first, so
+// groups are ranked deterministically instead of in map order.
+func valuesByRank(valueCounts map[int]int) []int {
+	values := make([]int, 0, len(valueCounts))
+	for value := range valueCounts {
+		values = append(values, value)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(values)))
+	return values
+}
+
+// crossCheck evaluates cards with both getBestHand and the reference
+// evaluator and reports the first disagreement, including a panic in either
+// of them.
+func crossCheck(cards []models.Card) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("evaluator panicked on %v: %v", cards, r)
+		}
+	}()
+
+	if len(cards) == 0 {
+		return nil
+	}
+
+	input := make([]models.Card, len(cards))
+	copy(input, cards)
+	got := getBestHand(input)
+	want := referenceBestHand(cards)
+	if got.category != want.category || got.beats(want) || want.beats(got) {
+		return fmt.Errorf("cards %v: evaluator returned %v, reference %v", cards, got, want)
+	}
+	return nil
+}
+
+func (h Hand) String() string {
+	values := h.values[:]
+	for len(values) > 0 && values[len(values)-1] == 0 {
+		values = values[:len(values)-1]
+	}
+	return fmt.Sprintf("%s %v", h.Name(), values)
+}
@@ -0,0 +1,84 @@
+package modes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"poker-bot/game"
+)
+
+// Variant is a table game the bot can deal. Each mode registers itself
+// from an init function, so $start, $rules and mixed games find it without
+// anything else having to list it.
+type Variant struct {
+	Name       string   // the game type, as $start takes it
+	Aliases    []string // other names $start accepts
+	Title      string   // the name players know it by
+	MinPlayers int      // players needed to deal a hand
+	MaxPlayers int      // seats at the table
+	New        func(channel string) game.Game
+	Rules      []string // a line each, in the order they happen in a hand
+	Commands   []string // every command a player can use on their turn
+}
+
+// BettingCommands are the commands of a betting round, in every variant.
+var BettingCommands = []string{"$bet", "$call", "$raise", "$fold", "$check", "$cheat"}
+
+// Phased is implemented by variants whose turns don't all take the same
+// commands, such as draw games with a draw between the betting rounds.
+type Phased interface {
+	TurnCommands() []string
+}
+
+// TurnCommands returns the commands the player to act in g can use: all
+// of its variant's, or those of the phase a Phased game is in.
+func TurnCommands(g game.Game) []string {
+	if phased, ok := g.(Phased); ok {
+		return phased.TurnCommands()
+	}
+	v, _ := Lookup(g.GetType())
+	return v.Commands
+}
+
+var variants = map[string]*Variant{} // name or alias, folded -> variant
+
+// Register adds a variant. It panics if the name or an alias is taken, as
+// that can only be a mistake in the modes package.
+func Register(v Variant) {
+	for _, name := range append([]string{v.Name}, v.Aliases...) {
+		key := variantKey(name)
+		if variants[key] != nil {
+			panic(fmt.Sprintf("modes: variant %q registered twice", name))
+		}
+		variants[key] = &v
+	}
+}
+
+// Lookup returns the variant called name or one of its aliases, ignoring
+// case and spaces, so "fivecarddraw" finds five card draw.
+func Lookup(name string) (Variant, bool) {
+	v := variants[variantKey(name)]
+	if v == nil {
+		return Variant{}, false
+	}
+	return *v, true
+}
+
+// Variants returns every registered variant, by name.
+func Variants() []Variant {
+	seen := make(map[*Variant]bool)
+	var list []Variant
+	for _, v := range variants {
+		if !seen[v] {
+			seen[v] = true
+			list = append(list, *v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func variantKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "")
+}
@@ -0,0 +1,27 @@
+package modes
+
+import "fmt"
+
+// handExamples is a hand of each category, from high card up.
+var handExamples = []string{
+	"AS JD 8C 6H 2S",
+	"QH QC 9D 5S 3C",
+	"KD KS 7H 7C 4D",
+	"8S 8H 8D KC 3S",
+	"9C 8D 7S 6H 5C",
+	"KH JH 9H 6H 3H",
+	"10D 10S 10C 4H 4S",
+	"JC JD JH JS 7D",
+	"9S 8S 7S 6S 5S",
+	"AD KD QD JD 10D",
+}
+
+// HandRankings lists the hands from best to worst, with an example of each.
+// Every variant the bot deals ranks them the same way.
+func HandRankings() []string {
+	rankings := make([]string, 0, len(handNames))
+	for category := len(handNames) - 1; category >= 0; category-- {
+		rankings = append(rankings, fmt.Sprintf("%d. %s: %s", len(handNames)-category, handNames[category], handExamples[category]))
+	}
+	return rankings
+}
@@ -0,0 +1,153 @@
+package modes
+
+import (
+	"testing"
+
+	"poker-bot/game"
+)
+
+// TestScriptedHands plays hands off stacked decks and checks the pot, the
+// stacks and the winner each ends with. Cards are dealt one to each player
+// in seat order per pass, then the board; with the button on the second
+// seat, the third posts the small blind.
+func TestScriptedHands(t *testing.T) {
+	tests := []struct {
+		name    string
+		game    func() game.Scriptable
+		seats   []game.Seat
+		deck    string
+		actions []game.Action
+		pot     int
+		stacks  map[string]int
+		winner  string
+	}{
+		{
+			name:  "everyone folds to the big blind",
+			game:  func() game.Scriptable { return NewHoldem("#test").(game.Scriptable) },
+			seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}, {Nick: "cat", Money: 1000}},
+			actions: []game.Action{
+				{Nick: "bob", Kind: "fold"},
+				{Nick: "cat", Kind: "fold"},
+			},
+			pot:    15,
+			stacks: map[string]int{"ann": 1005, "bob": 1000, "cat": 995},
+			winner: "ann",
+		},
+		{
+			name:  "the best hand at showdown takes the pot",
+			game:  func() game.Scriptable { return NewHoldem("#test").(game.Scriptable) },
+			seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}, {Nick: "cat", Money: 1000}, {Nick: "dan", Money: 1000}},
+			// Bob's king-high straight beats cat's queen-high one and ann's aces.
+			deck: "AS KD 7C 2H AH KC 8D 3S QS 9H 4C JD 10S",
+			actions: []game.Action{
+				{Nick: "ann", Kind: "call"},
+				{Nick: "bob", Kind: "call"},
+				{Nick: "cat", Kind: "call"},
+				{Nick: "dan", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "bet", Amount: 20},
+				{Nick: "dan", Kind: "fold"},
+				{Nick: "ann", Kind: "call"},
+				{Nick: "bob", Kind: "call"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+			},
+			pot:    100,
+			stacks: map[string]int{"ann": 970, "bob": 1070, "cat": 970, "dan": 990},
+			winner: "bob",
+		},
+		{
+			name:  "a board straight splits the pot",
+			game:  func() game.Scriptable { return NewHoldem("#test").(game.Scriptable) },
+			seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}, {Nick: "cat", Money: 1000}},
+			deck:  "2S 3D 2C 4H 3C 4D 10S JH QC KD AC",
+			actions: []game.Action{
+				{Nick: "bob", Kind: "call"},
+				{Nick: "cat", Kind: "call"},
+				{Nick: "ann", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+			},
+			pot:    30,
+			stacks: map[string]int{"ann": 1000, "bob": 1000, "cat": 1000},
+		},
+		{
+			name:  "a short stack calls all in and wins only the main pot",
+			game:  func() game.Scriptable { return NewHoldem("#test").(game.Scriptable) },
+			seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}, {Nick: "cat", Money: 100}},
+			// Cat's aces beat ann's kings, which beat bob's seven high.
+			deck: "KS 7C AS KH 2D AH QC 9D 4H 3S 8C",
+			actions: []game.Action{
+				{Nick: "bob", Kind: "raise", Amount: 290},
+				{Nick: "cat", Kind: "call"},
+				{Nick: "ann", Kind: "call"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "cat", Kind: "check"},
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+			},
+			pot:    700,
+			stacks: map[string]int{"ann": 1100, "bob": 700, "cat": 300},
+			winner: "ann",
+		},
+		{
+			name:  "the draw improves the hands that go to showdown",
+			game:  func() game.Scriptable { return NewFiveCardDraw("#test").(game.Scriptable) },
+			seats: []game.Seat{{Nick: "ann", Money: 1000}, {Nick: "bob", Money: 1000}},
+			// Ann draws to three aces, bob to kings full of nines.
+			deck: "AS KS AH KH 7C KD 4D 9C 2S 3H AC 8D 6H 9D 9H",
+			actions: []game.Action{
+				{Nick: "ann", Kind: "check"},
+				{Nick: "bob", Kind: "check"},
+				{Kind: "street"},
+				{Nick: "ann", Kind: "draw", Cards: []int{2, 3, 4}},
+				{Nick: "bob", Kind: "draw", Cards: []int{3, 4}},
+				{Kind: "street"},
+				{Nick: "ann", Kind: "bet", Amount: 20},
+				{Nick: "bob", Kind: "call"},
+			},
+			pot:    50,
+			stacks: map[string]int{"ann": 975, "bob": 1025},
+			winner: "bob",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := game.Run(test.game(), game.Script{
+				Seats:   test.seats,
+				Deck:    game.StackDeck(game.MustParseCards(test.deck)),
+				Actions: test.actions,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := result.Expect(test.pot, test.stacks, test.winner); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
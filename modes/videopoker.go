@@ -0,0 +1,99 @@
+package modes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"poker-bot/game"
+	"poker-bot/models"
+)
+
+// Paytable maps a video poker result to what it pays per unit bet, stake
+// included.
+type Paytable map[string]int
+
+// DefaultPaytable is full-pay 9/6 Jacks or Better, paying the five-coin
+// royal flush rate on every bet.
+var DefaultPaytable = Paytable{
+	"royal flush":     800,
+	"straight flush":  50,
+	"four of a kind":  25,
+	"full house":      9,
+	"flush":           6,
+	"straight":        4,
+	"three of a kind": 3,
+	"two pair":        2,
+	"jacks or better": 1,
+}
+
+// LoadPaytable reads a paytable from a JSON object of result names to
+// payouts. Results it leaves out pay nothing.
+func LoadPaytable(path string) (Paytable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	paytable := make(Paytable)
+	if err := json.Unmarshal(data, &paytable); err != nil {
+		return nil, fmt.Errorf("invalid paytable %s: %v", path, err)
+	}
+	for result := range paytable {
+		if _, ok := DefaultPaytable[result]; !ok {
+			return nil, fmt.Errorf("invalid paytable %s: unknown result %q", path, result)
+		}
+	}
+	return paytable, nil
+}
+
+// VideoPoker is one Jacks or Better hand: five cards are dealt, the player
+// holds any of them and the rest are replaced once.
+type VideoPoker struct {
+	Nick    string
+	Economy string // the economy the bet was taken from
+	Bet     int
+	Deck    game.Dealer
+	Hand    []models.Card
+	Done    bool
+}
+
+func NewVideoPoker(nick string, bet int) *VideoPoker {
+	deck := game.NewDealer()
+	deck.Shuffle()
+	return &VideoPoker{Nick: nick, Bet: bet, Deck: deck, Hand: deck.Draw(5)}
+}
+
+// Draw replaces every card not at one of the held 0-based positions.
+func (v *VideoPoker) Draw(held []int) error {
+	if v.Done {
+		return errors.New("the hand is over")
+	}
+	for _, index := range held {
+		if index < 0 || index >= len(v.Hand) {
+			return fmt.Errorf("card positions must be between 1 and %d", len(v.Hand))
+		}
+	}
+	for i := range v.Hand {
+		if !containsIndex(held, i) {
+			v.Hand[i] = v.Deck.Draw(1)[0]
+		}
+	}
+	v.Done = true
+	return nil
+}
+
+// Result names the hand the way the paytable does. Pairs below jacks, and
+// anything less, are "high card" or "pair" and pay nothing in the default
+// table.
+func (v *VideoPoker) Result() string {
+	hand := getBestHand(v.Hand)
+	if hand.category == 1 && hand.values[0] >= 11 {
+		return "jacks or better"
+	}
+	return handNames[hand.category]
+}
+
+// Payout is what the house pays back, stake included.
+func (v *VideoPoker) Payout(paytable Paytable) int {
+	return v.Bet * paytable[v.Result()]
+}
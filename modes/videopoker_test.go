@@ -0,0 +1,87 @@
+package modes
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"poker-bot/game"
+)
+
+func TestVideoPokerPayout(t *testing.T) {
+	tests := []struct {
+		hand   string
+		result string
+		payout int
+	}{
+		{"AS KS QS JS 10S", "royal flush", 4000},
+		{"9H 8H 7H 6H 5H", "straight flush", 250},
+		{"5H 4H 3H 2H AH", "straight flush", 250},
+		{"7C 7D 7H 7S 2C", "four of a kind", 125},
+		{"KC KD KH 4S 4C", "full house", 45},
+		{"2D 6D 9D JD KD", "flush", 30},
+		{"5C 4D 3H 2S AC", "straight", 20},
+		{"QC QD QH 4S 9C", "three of a kind", 15},
+		{"3C 3D 8H 8S KC", "two pair", 10},
+		{"JC JD 8H 4S 2C", "jacks or better", 5},
+		{"AC AD 8H 4S 2C", "jacks or better", 5},
+		{"10C 10D 8H 4S 2C", "pair", 0},
+		{"AC QD 8H 4S 2C", "high card", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.hand, func(t *testing.T) {
+			v := &VideoPoker{Bet: 5, Hand: game.MustParseCards(test.hand)}
+			if got := v.Result(); got != test.result {
+				t.Errorf("Result() = %q, want %q", got, test.result)
+			}
+			if got := v.Payout(DefaultPaytable); got != test.payout {
+				t.Errorf("Payout() = %d, want %d", got, test.payout)
+			}
+		})
+	}
+}
+
+func TestVideoPokerDrawKeepsTheHeldCards(t *testing.T) {
+	v := &VideoPoker{
+		Bet:  5,
+		Deck: game.NewStackedDealer(game.MustParseCards("JH 3D 7C 7S 2S 7D 7H 9C")),
+	}
+	v.Deck.Shuffle()
+	v.Hand = v.Deck.Draw(5)
+	if err := v.Draw([]int{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.Hand, game.MustParseCards("7D 7H 7C 7S 9C"); !slices.Equal(got, want) {
+		t.Errorf("drew to %v, want %v", got, want)
+	}
+	if got := v.Payout(DefaultPaytable); got != 125 {
+		t.Errorf("Payout() = %d, want 125", got)
+	}
+	if err := v.Draw(nil); err == nil {
+		t.Error("drew twice")
+	}
+}
+
+func TestLoadPaytable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "paytable.json")
+	if err := os.WriteFile(path, []byte(`{"royal flush": 250, "jacks or better": 1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	paytable, err := LoadPaytable(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &VideoPoker{Bet: 4, Hand: game.MustParseCards("QC QD QH 4S 9C")}
+	if got := v.Payout(paytable); got != 0 {
+		t.Errorf("three of a kind pays %d on a paytable without it, want 0", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"five of a kind": 1000}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPaytable(path); err == nil {
+		t.Error("loaded a paytable with an unknown result")
+	}
+}
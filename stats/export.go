@@ -0,0 +1,148 @@
+// Package stats exports a player's history: the hands they played, the
+// sessions those hands make up and their bankroll ledger.
+package stats
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"poker-bot/collusion"
+	"poker-bot/db"
+)
+
+// sessionGap is the longest break between two hands at a channel that
+// still counts as one session.
+const sessionGap = 30 * time.Minute
+
+// Hand is one hand a player was in, with the game and stakes it was dealt
+// at, which are empty for hands from before they were kept. Blinds and
+// antes aren't in the hand histories, so Invested and Net leave them out.
+type Hand struct {
+	Channel   string    `json:"channel"`
+	StartedAt time.Time `json:"started_at"`
+	Game      string    `json:"game,omitempty"`
+	Stakes    string    `json:"stakes,omitempty"`
+	Invested  int       `json:"invested"`
+	Won       int       `json:"won"`
+	Net       int       `json:"net"`
+}
+
+// Session is a run of hands of one game at the same stakes at one channel
+// without a long break.
+type Session struct {
+	Channel string    `json:"channel"`
+	Game    string    `json:"game,omitempty"`
+	Stakes  string    `json:"stakes,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Hands   int       `json:"hands"`
+	Net     int       `json:"net"`
+}
+
+type Export struct {
+	Nick         string           `json:"nick"`
+	Hands        []Hand           `json:"hands"`
+	Sessions     []Session        `json:"sessions"`
+	Transactions []db.Transaction `json:"transactions"`
+}
+
+// Build gathers nick's history from the database.
+func Build(nick string) (*Export, error) {
+	histories, err := db.PlayerHandHistories(nick)
+	if err != nil {
+		return nil, err
+	}
+	e := &Export{Nick: nick, Hands: []Hand{}, Sessions: []Session{}}
+	for _, history := range histories {
+		if !Played(nick, history.Lines) {
+			continue
+		}
+		parsed := collusion.ParseHand(history.Channel, history.Lines)
+		hand := Hand{
+			Channel:   history.Channel,
+			StartedAt: history.StartedAt,
+			Game:      history.Game,
+			Stakes:    history.Stakes,
+			Invested:  parsed.Invested[nick],
+			Won:       parsed.Won[nick],
+		}
+		hand.Net = hand.Won - hand.Invested
+		e.Hands = append(e.Hands, hand)
+	}
+	e.Sessions = sessions(e.Hands)
+	if e.Transactions, err = db.Transactions(nick); err != nil {
+		return nil, err
+	}
+	if e.Transactions == nil {
+		e.Transactions = []db.Transaction{}
+	}
+	return e, nil
+}
+
+// Played reports whether nick acted in or won the hand with these lines.
+func Played(nick string, lines []string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, nick+" ") || strings.HasPrefix(line, "Round over! "+nick+" ") ||
+			strings.HasPrefix(line, "Round over! ") && strings.Contains(line, ", "+nick+" wins ") {
+			return true
+		}
+	}
+	return false
+}
+
+func sessions(hands []Hand) []Session {
+	sessions := []Session{}
+	open := make(map[string]int) // channel -> index of its latest session
+	for _, hand := range hands {
+		i, ok := open[hand.Channel]
+		if !ok || hand.StartedAt.Sub(sessions[i].End) > sessionGap || hand.Game != sessions[i].Game || hand.Stakes != sessions[i].Stakes {
+			sessions = append(sessions, Session{Channel: hand.Channel, Game: hand.Game, Stakes: hand.Stakes, Start: hand.StartedAt})
+			i = len(sessions) - 1
+			open[hand.Channel] = i
+		}
+		sessions[i].End = hand.StartedAt
+		sessions[i].Hands++
+		sessions[i].Net += hand.Net
+	}
+	return sessions
+}
+
+func (e *Export) JSON() ([]byte, error) {
+	return json.MarshalIndent(e, "", "  ")
+}
+
+// CSV writes the export as one table, a row per hand, session and
+// transaction, told apart by the type column. Where is the channel of hands
+// and sessions and the economy of transactions; the detail of hands and
+// sessions starts with their game and stakes.
+func (e *Export) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"type", "time", "end", "where", "hands", "amount", "detail"})
+	for _, hand := range e.Hands {
+		w.Write([]string{"hand", hand.StartedAt.Format(time.RFC3339), "", hand.Channel, "1", strconv.Itoa(hand.Net),
+			stakesDetail(hand.Game, hand.Stakes) + "invested " + strconv.Itoa(hand.Invested) + ", won " + strconv.Itoa(hand.Won)})
+	}
+	for _, s := range e.Sessions {
+		w.Write([]string{"session", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339), s.Channel, strconv.Itoa(s.Hands), strconv.Itoa(s.Net),
+			strings.TrimSuffix(stakesDetail(s.Game, s.Stakes), ", ")})
+	}
+	for _, t := range e.Transactions {
+		w.Write([]string{"transaction", t.CreatedAt.Format(time.RFC3339), "", t.Economy, "", strconv.Itoa(t.Amount), t.Reason})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// stakesDetail starts a CSV detail with the game and stakes, such as
+// "holdem 5/10, ", or is empty if the hand didn't keep them.
+func stakesDetail(game, stakes string) string {
+	if game == "" {
+		return ""
+	}
+	return strings.TrimSpace(game+" "+stakes) + ", "
+}
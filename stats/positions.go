@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"poker-bot/collusion"
+	"poker-bot/db"
+)
+
+// Position is a player's record from one position at the table, over the
+// hands whose histories say where everyone sat.
+type Position struct {
+	Name  string `json:"name"`
+	Hands int    `json:"hands"`
+	// VPIP is how many hands they put chips in before the flop by
+	// calling, betting or raising.
+	VPIP int `json:"vpip"`
+	Won  int `json:"won"`
+	// Net leaves out blinds and antes, like a Hand's.
+	Net int `json:"net"`
+}
+
+// Positions breaks nick's hands down by position, in the order the
+// positions act before the flop.
+func Positions(nick string) ([]Position, error) {
+	histories, err := db.PlayerHandHistories(nick)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*Position)
+	for _, history := range histories {
+		hand := collusion.ParseHand(history.Channel, history.Lines)
+		name, ok := hand.Positions[nick]
+		if !ok {
+			continue
+		}
+		p := byName[name]
+		if p == nil {
+			p = &Position{Name: name}
+			byName[name] = p
+		}
+		p.Hands++
+		if hand.Voluntary[nick] {
+			p.VPIP++
+		}
+		p.Net -= hand.Invested[nick]
+		if won, ok := hand.Won[nick]; ok {
+			p.Won++
+			p.Net += won
+		}
+	}
+
+	positions := make([]Position, 0, len(byName))
+	for _, p := range byName {
+		positions = append(positions, *p)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positionOrder(positions[i].Name) < positionOrder(positions[j].Name)
+	})
+	return positions, nil
+}
+
+// positionOrder sorts positions as they act before the flop: under the
+// gun first, the big blind last.
+func positionOrder(name string) int {
+	switch name {
+	case "UTG":
+		return 0
+	case "LJ":
+		return 100
+	case "HJ":
+		return 101
+	case "CO":
+		return 102
+	case "BTN":
+		return 103
+	case "SB":
+		return 104
+	case "BB":
+		return 105
+	}
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "UTG+")); err == nil {
+		return n
+	}
+	return 200
+}
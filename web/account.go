@@ -0,0 +1,76 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"poker-bot/db"
+)
+
+// serveLink trades a $link code, posted as {"code": "..."}, for the
+// account's token.
+func (s *Server) serveLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST a link code", http.StatusMethodNotAllowed)
+		return
+	}
+	var request struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	nick, token, err := s.handler.RedeemLinkCode(strings.TrimSpace(request.Code))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	writeJSON(w, map[string]string{"nick": nick, "token": token})
+}
+
+// serveAccount returns the profile, bankrolls and stats of the account
+// whose token is in the Authorization header as "Bearer <token>".
+func (s *Server) serveAccount(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	nick, ok := s.handler.WebViewer(token)
+	if token == "" || !ok {
+		http.Error(w, "unknown token", http.StatusUnauthorized)
+		return
+	}
+	bankrolls, err := db.GetBankrolls(nick)
+	if err != nil {
+		log.Printf("Error getting bankrolls for %s: %v", nick, err)
+		http.Error(w, "error getting your bankrolls", http.StatusInternalServerError)
+		return
+	}
+	profile, err := db.GetProfile(nick)
+	if err != nil {
+		log.Printf("Error getting profile for %s: %v", nick, err)
+		http.Error(w, "error getting your profile", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, map[string]interface{}{"nick": nick, "profile": profile, "bankrolls": bankrolls})
+}
+
+// serveDownload hands out a file from an $export link.
+func (s *Server) serveDownload(w http.ResponseWriter, r *http.Request) {
+	name, contentType, data, ok := s.handler.Download(strings.TrimPrefix(r.URL.Path, "/download/"))
+	if !ok {
+		http.Error(w, "unknown or expired download link", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Write(data)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
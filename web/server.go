@@ -0,0 +1,133 @@
+// Package web serves the bot's games over HTTP for browsers and streaming
+// overlays.
+package web
+
+import (
+	"embed"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"poker-bot/irc"
+
+	"golang.org/x/net/websocket"
+)
+
+//go:embed index.html
+var static embed.FS
+
+// clientBuffer is how many events a slow client can fall behind before it
+// starts missing them.
+const clientBuffer = 64
+
+type client struct {
+	channel string // only events from this channel, or all if empty
+	events  chan irc.Event
+}
+
+// Server fans the handler's table events out to WebSocket clients.
+type Server struct {
+	handler *irc.Handler
+	mu      sync.Mutex
+	clients map[*client]bool
+}
+
+func NewServer(handler *irc.Handler) *Server {
+	s := &Server{handler: handler, clients: make(map[*client]bool)}
+	handler.Subscribe(s.publish)
+	return s
+}
+
+// ListenAndServe serves on addr until it fails.
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// Handler routes:
+//
+//	/events                       every table event as a JSON text message
+//	/events?channel=#poker        just one channel's events
+//	/table?channel=#poker         the table's state, then changes to it
+//	/table?channel=#poker&token=  the same with the token holder's hole cards,
+//	                              and they can act at the table through it
+//	/link                         POST a $link code to get an account token
+//	/account                      the bankrolls of the account in the
+//	                              "Authorization: Bearer <token>" header
+//	/                             the browser table view
+//
+// An account token works anywhere a $web token does.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(static)))
+	// Overlays are often local files with no useful Origin, so any origin
+	// may connect; the feeds only carry hole cards to their token holder.
+	mux.Handle("/events", websocket.Server{Handler: s.serveEvents})
+	mux.Handle("/table", websocket.Server{Handler: s.serveTable})
+	mux.HandleFunc("/link", s.serveLink)
+	mux.HandleFunc("/account", s.serveAccount)
+	mux.HandleFunc("/download/", s.serveDownload)
+	return mux
+}
+
+// publish runs on the handler's goroutine, so it never blocks: clients
+// whose buffer is full miss the event.
+func (s *Server) publish(event irc.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		if c.channel != "" && !strings.EqualFold(c.channel, event.Channel) {
+			continue
+		}
+		select {
+		case c.events <- event:
+		default:
+		}
+	}
+}
+
+// subscribe registers a client for the events at channel, or at every
+// table if channel is empty, until the returned func is called.
+func (s *Server) subscribe(channel string) (*client, func()) {
+	c := &client{channel: channel, events: make(chan irc.Event, clientBuffer)}
+	s.mu.Lock()
+	s.clients[c] = true
+	s.mu.Unlock()
+	return c, func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}
+}
+
+// closed returns a channel that is closed when the client goes away. The
+// feeds are one way, so anything the client sends is discarded.
+func closed(ws *websocket.Conn) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		var discard []byte
+		for websocket.Message.Receive(ws, &discard) == nil {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func (s *Server) serveEvents(ws *websocket.Conn) {
+	defer ws.Close()
+	c, unsubscribe := s.subscribe(ws.Request().URL.Query().Get("channel"))
+	defer unsubscribe()
+
+	done := closed(ws)
+	for {
+		select {
+		case event := <-c.events:
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				log.Printf("Event feed client %s dropped: %v", ws.Request().RemoteAddr, err)
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"poker-bot/irc"
+
+	"golang.org/x/net/websocket"
+)
+
+// tableMessage is what the table feed sends. The first message is the
+// whole state; after that each message carries only the top-level fields
+// of the state that changed. Closed is set when the game is over, and
+// Error when an action the viewer sent was refused.
+type tableMessage struct {
+	State   *irc.TableState            `json:"state,omitempty"`
+	Changes map[string]json.RawMessage `json:"changes,omitempty"`
+	Closed  bool                       `json:"closed,omitempty"`
+	Error   string                     `json:"error,omitempty"`
+}
+
+// action is what a viewer with a token sends to act at the table, such as
+// {"action": "raise", "amount": 100} or {"action": "draw", "cards": [1, 4]}.
+type action struct {
+	Action string `json:"action"`
+	Amount int    `json:"amount,omitempty"`
+	Cards  []int  `json:"cards,omitempty"`
+}
+
+func (a action) args() []string {
+	var args []string
+	if a.Amount != 0 {
+		args = append(args, strconv.Itoa(a.Amount))
+	}
+	for _, card := range a.Cards {
+		args = append(args, strconv.Itoa(card))
+	}
+	return args
+}
+
+func (s *Server) serveTable(ws *websocket.Conn) {
+	defer ws.Close()
+	query := ws.Request().URL.Query()
+	channel := query.Get("channel")
+	viewer := ""
+	if token := query.Get("token"); token != "" {
+		nick, ok := s.handler.WebViewer(token)
+		if !ok {
+			websocket.JSON.Send(ws, tableMessage{Error: "unknown token"})
+			return
+		}
+		viewer = nick
+	}
+
+	c, unsubscribe := s.subscribe(channel)
+	defer unsubscribe()
+
+	state := s.handler.TableState(channel, viewer)
+	if state == nil {
+		websocket.JSON.Send(ws, tableMessage{Closed: true})
+		return
+	}
+	if err := websocket.JSON.Send(ws, tableMessage{State: state}); err != nil {
+		return
+	}
+	last, _ := fields(state)
+
+	var done <-chan struct{}
+	refusals := make(chan string)
+	if viewer != "" {
+		done = s.readActions(ws, channel, viewer, refusals)
+	} else {
+		done = closed(ws)
+	}
+	for {
+		select {
+		case refusal := <-refusals:
+			if err := websocket.JSON.Send(ws, tableMessage{Error: refusal}); err != nil {
+				return
+			}
+		case event := <-c.events:
+			if event.Table == nil {
+				websocket.JSON.Send(ws, tableMessage{Closed: true})
+				return
+			}
+			next, err := fields(event.Table.For(viewer))
+			if err != nil {
+				log.Printf("Error encoding table state: %v", err)
+				return
+			}
+			changes := diff(last, next)
+			if len(changes) == 0 {
+				continue
+			}
+			if err := websocket.JSON.Send(ws, tableMessage{Changes: changes}); err != nil {
+				log.Printf("Table feed client %s dropped: %v", ws.Request().RemoteAddr, err)
+				return
+			}
+			last = next
+		case <-done:
+			return
+		}
+	}
+}
+
+// readActions runs the actions the viewer sends, passing back why any were
+// refused, until the client goes away.
+func (s *Server) readActions(ws *websocket.Conn, channel, viewer string, refusals chan<- string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var a action
+			if err := websocket.JSON.Receive(ws, &a); err != nil {
+				if _, ok := err.(*json.SyntaxError); ok {
+					continue
+				}
+				return
+			}
+			if err := s.handler.Act(channel, viewer, a.Action, a.args()...); err != nil {
+				select {
+				case refusals <- err.Error():
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// fields encodes a state as its top-level JSON fields.
+func fields(state *irc.TableState) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// diff returns the fields of next that differ from last. A field that was
+// dropped, such as the turn between hands, comes back as null.
+func diff(last, next map[string]json.RawMessage) map[string]json.RawMessage {
+	changes := make(map[string]json.RawMessage)
+	for key, value := range next {
+		if !bytes.Equal(last[key], value) {
+			changes[key] = value
+		}
+	}
+	for key := range last {
+		if _, ok := next[key]; !ok {
+			changes[key] = json.RawMessage("null")
+		}
+	}
+	return changes
+}